@@ -0,0 +1,158 @@
+package reveald
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// RedactedValue replaces the values of redacted fields
+const RedactedValue = "[REDACTED]"
+
+// AccessLogEntry is a sampled Elasticsearch request
+// and response pair
+type AccessLogEntry struct {
+	Time     time.Time
+	Indices  []string
+	Request  json.RawMessage
+	Response json.RawMessage
+	Error    string
+	Duration time.Duration
+}
+
+// AccessLogSink stores sampled entries, e.g. in a diagnostics
+// index or a bucket. It's called synchronously, and should
+// hand entries off rather than block
+type AccessLogSink func(context.Context, *AccessLogEntry)
+
+// AccessLogSampler passes at most a fixed number of
+// request/response pairs per minute to a sink
+type AccessLogSampler struct {
+	sink      AccessLogSink
+	perMinute int
+	redact    map[string]bool
+	now       func() time.Time
+
+	mu     sync.Mutex
+	window time.Time
+	count  int
+}
+
+// AccessLogOption configures an AccessLogSampler
+type AccessLogOption func(*AccessLogSampler)
+
+// WithRedactedFields replaces the values of the specified
+// keys, anywhere in the request or response, before they
+// reach the sink (e.g. "email" or "customer_id")
+func WithRedactedFields(keys ...string) AccessLogOption {
+	return func(s *AccessLogSampler) {
+		for _, key := range keys {
+			s.redact[key] = true
+		}
+	}
+}
+
+// NewAccessLogSampler creates a sampler passing at most
+// perMinute request/response pairs to the sink
+func NewAccessLogSampler(sink AccessLogSink, perMinute int, opts ...AccessLogOption) *AccessLogSampler {
+	s := &AccessLogSampler{
+		sink:      sink,
+		perMinute: perMinute,
+		redact:    make(map[string]bool),
+		now:       time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// WithAccessLogSampler samples raw requests and responses
+// for postmortem analysis, without full debug logging
+func WithAccessLogSampler(sampler *AccessLogSampler) ElasticBackendOption {
+	return func(b *ElasticBackend) {
+		b.sampler = sampler
+	}
+}
+
+// sample reports whether the current minute has room for
+// another entry
+func (s *AccessLogSampler) sample() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	window := s.now().Truncate(time.Minute)
+	if !window.Equal(s.window) {
+		s.window = window
+		s.count = 0
+	}
+
+	if s.count >= s.perMinute {
+		return false
+	}
+
+	s.count++
+	return true
+}
+
+func (s *AccessLogSampler) record(ctx context.Context, indices []string, request, response interface{}, err error, start time.Time) {
+	if s == nil || !s.sample() {
+		return
+	}
+
+	entry := &AccessLogEntry{
+		Time:     start,
+		Indices:  indices,
+		Request:  s.redacted(request),
+		Duration: s.now().Sub(start),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	} else {
+		entry.Response = s.redacted(response)
+	}
+
+	s.sink(ctx, entry)
+}
+
+func (s *AccessLogSampler) redacted(v interface{}) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil || len(s.redact) == 0 {
+		return data
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return data
+	}
+
+	data, err = json.Marshal(s.redactValue(doc))
+	if err != nil {
+		return nil
+	}
+
+	return data
+}
+
+func (s *AccessLogSampler) redactValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			if s.redact[k] {
+				t[k] = RedactedValue
+				continue
+			}
+
+			t[k] = s.redactValue(child)
+		}
+	case []interface{}:
+		for i, child := range t {
+			t[i] = s.redactValue(child)
+		}
+	}
+
+	return v
+}
@@ -0,0 +1,67 @@
+package reveald
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_AccessLogSampler_Rate(t *testing.T) {
+	var entries []*AccessLogEntry
+	s := NewAccessLogSampler(func(_ context.Context, e *AccessLogEntry) {
+		entries = append(entries, e)
+	}, 2)
+
+	now := time.Date(2024, 1, 1, 12, 0, 10, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	for i := 0; i < 5; i++ {
+		s.record(context.Background(), nil, map[string]interface{}{}, nil, nil, now)
+	}
+	assert.Len(t, entries, 2)
+
+	now = now.Add(time.Minute)
+	s.record(context.Background(), nil, map[string]interface{}{}, nil, nil, now)
+	assert.Len(t, entries, 3)
+}
+
+func Test_AccessLogSampler_Redaction(t *testing.T) {
+	var entry *AccessLogEntry
+	s := NewAccessLogSampler(func(_ context.Context, e *AccessLogEntry) {
+		entry = e
+	}, 1, WithRedactedFields("email"))
+
+	request := map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must": []interface{}{
+					map[string]interface{}{"term": map[string]interface{}{"email": "jane@example.com"}},
+				},
+			},
+		},
+	}
+	response := map[string]interface{}{
+		"hits": []interface{}{map[string]interface{}{"email": "jane@example.com", "name": "Jane"}},
+	}
+
+	s.record(context.Background(), []string{"users"}, request, response, nil, time.Now())
+
+	assert.JSONEq(t, `{"query":{"bool":{"must":[{"term":{"email":"[REDACTED]"}}]}}}`, string(entry.Request))
+	assert.JSONEq(t, `{"hits":[{"email":"[REDACTED]","name":"Jane"}]}`, string(entry.Response))
+	assert.Equal(t, []string{"users"}, entry.Indices)
+}
+
+func Test_AccessLogSampler_Error(t *testing.T) {
+	var entry *AccessLogEntry
+	s := NewAccessLogSampler(func(_ context.Context, e *AccessLogEntry) {
+		entry = e
+	}, 1)
+
+	s.record(context.Background(), nil, map[string]interface{}{}, nil, errors.New("timeout"), time.Now())
+
+	assert.Equal(t, "timeout", entry.Error)
+	assert.Nil(t, entry.Response)
+}
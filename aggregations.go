@@ -0,0 +1,273 @@
+package reveald
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// SubAggregationSeparator separates the levels of
+// a sub-aggregation path, e.g. "brand>model"
+const SubAggregationSeparator = ">"
+
+// bucketMetaKeys are the keys of a bucket which
+// don't hold sub-aggregations
+var bucketMetaKeys = map[string]bool{
+	"key":            true,
+	"key_as_string":  true,
+	"doc_count":      true,
+	"from":           true,
+	"from_as_string": true,
+	"to":             true,
+	"to_as_string":   true,
+	"bg_count":       true,
+	"score":          true,
+	"meta":           true,
+}
+
+// SubAggregation attaches a child aggregation to the aggregation
+// at the specified path, which is the name of an aggregation,
+// optionally followed by the names of previously attached
+// children (e.g. "brand>model"). Children are attached when the
+// query is built, so the parent may be added later on
+func (qb *QueryBuilder) SubAggregation(path, name string, agg elastic.Aggregation) {
+	if qb.subAggs == nil {
+		qb.subAggs = make(map[string]map[string]elastic.Aggregation)
+	}
+
+	if qb.subAggs[path] == nil {
+		qb.subAggs[path] = make(map[string]elastic.Aggregation)
+	}
+
+	qb.subAggs[path][name] = agg
+}
+
+// AttachedAggregation returns an aggregation by name, along
+// with the children attached to it, e.g. to add it to another
+// query builder
+func (qb *QueryBuilder) AttachedAggregation(name string) (elastic.Aggregation, bool) {
+	agg, ok := qb.aggs[name]
+	if !ok {
+		return nil, false
+	}
+
+	return qb.withSubAggregations(name, agg), true
+}
+
+// withSubAggregations attaches the children registered
+// for the path, recursively, to an aggregation
+func (qb *QueryBuilder) withSubAggregations(path string, agg elastic.Aggregation) elastic.Aggregation {
+	children := qb.subAggs[path]
+	if len(children) == 0 {
+		return agg
+	}
+
+	subs := make(map[string]elastic.Aggregation, len(children))
+	for name, child := range children {
+		subs[name] = qb.withSubAggregations(path+SubAggregationSeparator+name, child)
+	}
+
+	return &parentAggregation{agg, subs}
+}
+
+// parentAggregation adds sub-aggregations to
+// any bucket aggregation when rendered
+type parentAggregation struct {
+	agg  elastic.Aggregation
+	subs map[string]elastic.Aggregation
+}
+
+// Source returns the aggregation with its children
+func (pa *parentAggregation) Source() (interface{}, error) {
+	src, err := pa.agg.Source()
+	if err != nil {
+		return nil, err
+	}
+
+	m, ok := src.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("aggregation of type %T doesn't support sub-aggregations", pa.agg)
+	}
+
+	aggs, ok := m["aggregations"].(map[string]interface{})
+	if !ok {
+		aggs = make(map[string]interface{})
+	}
+
+	for name, sub := range pa.subs {
+		s, err := sub.Source()
+		if err != nil {
+			return nil, err
+		}
+
+		aggs[name] = s
+	}
+
+	m["aggregations"] = aggs
+	return m, nil
+}
+
+// MapBuckets maps a raw aggregation into result buckets, including
+// the sub-aggregations of each bucket. Bucket aggregations map to
+// their buckets, single bucket aggregations (e.g. filter or nested)
+// to one bucket, and single value metrics to one bucket holding
+// the value as Metric
+func MapBuckets(aggs elastic.Aggregations, name string) ([]*ResultBucket, bool) {
+	return mapBuckets(aggs, name, nil)
+}
+
+// MapSubAggregations maps the sub-aggregations held by
+// a bucket, returning nil if there are none
+func MapSubAggregations(aggs elastic.Aggregations) map[string][]*ResultBucket {
+	return mapSubAggregations(aggs, nil)
+}
+
+// SubAggregations maps the sub-aggregations held by a bucket,
+// decoding numeric keys with the backend's NumberDecoder
+func (r *Result) SubAggregations(aggs elastic.Aggregations) map[string][]*ResultBucket {
+	return mapSubAggregations(aggs, r.numbers)
+}
+
+func mapBuckets(aggs elastic.Aggregations, name string, numbers NumberDecoder) ([]*ResultBucket, bool) {
+	raw, ok := aggs[name]
+	if !ok || len(raw) == 0 {
+		return nil, false
+	}
+
+	var agg map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &agg); err != nil {
+		return nil, false
+	}
+
+	if buckets, ok := agg["buckets"]; ok {
+		return mapBucketList(buckets, numbers), true
+	}
+
+	if _, ok := agg["doc_count"]; ok {
+		b, ok := mapBucket(agg, numbers)
+		if !ok {
+			return nil, false
+		}
+
+		return []*ResultBucket{b}, true
+	}
+
+	if v, ok := agg["value"]; ok {
+		var metric *float64
+		if err := json.Unmarshal(v, &metric); err != nil {
+			return nil, false
+		}
+
+		b := &ResultBucket{}
+		if metric != nil {
+			b.Metric = *metric
+		}
+
+		return []*ResultBucket{b}, true
+	}
+
+	return nil, false
+}
+
+func mapSubAggregations(aggs elastic.Aggregations, numbers NumberDecoder) map[string][]*ResultBucket {
+	var subs map[string][]*ResultBucket
+	for name, raw := range aggs {
+		if bucketMetaKeys[name] || len(raw) == 0 || raw[0] != '{' {
+			continue
+		}
+
+		buckets, ok := mapBuckets(aggs, name, numbers)
+		if !ok {
+			continue
+		}
+
+		if subs == nil {
+			subs = make(map[string][]*ResultBucket)
+		}
+
+		subs[name] = buckets
+	}
+
+	return subs
+}
+
+// mapBucketList maps buckets returned either as
+// an array, or keyed by their keys
+func mapBucketList(raw json.RawMessage, numbers NumberDecoder) []*ResultBucket {
+	var list []map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &list); err != nil {
+		var keyed map[string]map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &keyed); err != nil {
+			return nil
+		}
+
+		for key, bucket := range keyed {
+			if _, ok := bucket["key"]; !ok {
+				bucket["key"], _ = json.Marshal(key)
+			}
+
+			list = append(list, bucket)
+		}
+	}
+
+	var buckets []*ResultBucket
+	for _, bucket := range list {
+		if b, ok := mapBucket(bucket, numbers); ok {
+			buckets = append(buckets, b)
+		}
+	}
+
+	return buckets
+}
+
+func mapBucket(bucket map[string]json.RawMessage, numbers NumberDecoder) (*ResultBucket, bool) {
+	b := &ResultBucket{}
+	if v, ok := bucket["key"]; ok {
+		if err := decodeJSON(v, &b.Value, numbers != nil); err != nil {
+			return nil, false
+		}
+
+		if numbers != nil {
+			value, err := numbers.convert(b.Value)
+			if err != nil {
+				return nil, false
+			}
+
+			b.Value = value
+		}
+	}
+
+	if v, ok := bucket["doc_count"]; ok {
+		if err := json.Unmarshal(v, &b.HitCount); err != nil {
+			return nil, false
+		}
+	}
+
+	b.SubAggregations = mapSubAggregations(elastic.Aggregations(bucket), numbers)
+	return b, true
+}
+
+// mapAttachedAggregations maps the top level aggregations that
+// have attached children, unless already mapped by a feature
+func mapAttachedAggregations(qb *QueryBuilder, result *Result) {
+	raw := result.RawResult()
+	if raw == nil || result.Aggregations == nil {
+		return
+	}
+
+	for path := range qb.subAggs {
+		if strings.Contains(path, SubAggregationSeparator) {
+			continue
+		}
+
+		if _, mapped := result.Aggregations[path]; mapped {
+			continue
+		}
+
+		if buckets, ok := mapBuckets(raw.Aggregations, path, result.numbers); ok {
+			result.Aggregations[path] = buckets
+		}
+	}
+}
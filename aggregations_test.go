@@ -0,0 +1,62 @@
+package reveald
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_QueryBuilder_SubAggregation(t *testing.T) {
+	qb := NewQueryBuilder(NewRequest())
+	qb.SubAggregation("brand>model", "price", elastic.NewAvgAggregation().Field("price"))
+	qb.SubAggregation("brand", "model", elastic.NewTermsAggregation().Field("model"))
+	qb.Aggregation("brand", elastic.NewTermsAggregation().Field("brand"))
+
+	src := sourceJSON(t, qb)
+	brand := src["aggregations"].(map[string]interface{})["brand"].(map[string]interface{})
+	model := brand["aggregations"].(map[string]interface{})["model"].(map[string]interface{})
+	price := model["aggregations"].(map[string]interface{})["price"].(map[string]interface{})
+
+	assert.Equal(t, map[string]interface{}{"field": "brand"}, brand["terms"])
+	assert.Equal(t, map[string]interface{}{"field": "model"}, model["terms"])
+	assert.Equal(t, map[string]interface{}{"field": "price"}, price["avg"])
+}
+
+func Test_MapBuckets(t *testing.T) {
+	aggs := elastic.Aggregations{
+		"brand": json.RawMessage(`{"buckets":[
+			{"key":"acme","doc_count":3,"model":{"buckets":[
+				{"key":"rocket","doc_count":2,"price":{"value":12.5}}
+			]}}
+		]}`),
+		"price_ranges": json.RawMessage(`{"buckets":{"cheap":{"to":10,"doc_count":4}}}`),
+		"in_stock":     json.RawMessage(`{"doc_count":7,"avg_price":{"value":null}}`),
+	}
+
+	brand, ok := MapBuckets(aggs, "brand")
+	assert.True(t, ok)
+	assert.Len(t, brand, 1)
+	assert.Equal(t, "acme", brand[0].Value)
+	assert.Equal(t, int64(3), brand[0].HitCount)
+
+	model := brand[0].SubAggregations["model"]
+	assert.Len(t, model, 1)
+	assert.Equal(t, "rocket", model[0].Value)
+	assert.Equal(t, 12.5, model[0].SubAggregations["price"][0].Metric)
+
+	ranges, ok := MapBuckets(aggs, "price_ranges")
+	assert.True(t, ok)
+	assert.Equal(t, "cheap", ranges[0].Value)
+	assert.Equal(t, int64(4), ranges[0].HitCount)
+	assert.Nil(t, ranges[0].SubAggregations)
+
+	inStock, ok := MapBuckets(aggs, "in_stock")
+	assert.True(t, ok)
+	assert.Equal(t, int64(7), inStock[0].HitCount)
+	assert.Equal(t, 0.0, inStock[0].SubAggregations["avg_price"][0].Metric)
+
+	_, ok = MapBuckets(aggs, "missing")
+	assert.False(t, ok)
+}
@@ -0,0 +1,30 @@
+package reveald
+
+// ResultFilter is a filter applied by a feature, where
+// Values are the selected values of a term filter, Excluded
+// are the values it filters out, and Min and Max are the
+// bounds of a range filter
+type ResultFilter struct {
+	Property string
+	Values   []string
+	Excluded []string
+	Min      *float64
+	Max      *float64
+}
+
+// ApplyFilter records a filter as applied, to be echoed in
+// Result.AppliedFilters, so that clients may render the active
+// filters without re-deriving them from the request
+func (qb *QueryBuilder) ApplyFilter(filter *ResultFilter) {
+	if filter == nil {
+		return
+	}
+
+	qb.appliedFilters = append(qb.appliedFilters, filter)
+}
+
+// AppliedFilters returns the filters recorded as applied,
+// in the order the features applied them
+func (qb *QueryBuilder) AppliedFilters() []*ResultFilter {
+	return qb.appliedFilters
+}
@@ -0,0 +1,51 @@
+package reveald
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type applyFilterFeature struct {
+	filters []*ResultFilter
+}
+
+func (f *applyFilterFeature) Process(qb *QueryBuilder, next FeatureFunc) (*Result, error) {
+	for _, filter := range f.filters {
+		qb.ApplyFilter(filter)
+	}
+
+	return next(qb)
+}
+
+func Test_Endpoint_AppliedFilters(t *testing.T) {
+	min := 10.0
+	filters := []*ResultFilter{
+		{Property: "brand", Values: []string{"acme"}},
+		{Property: "price", Min: &min},
+	}
+
+	e := NewEndpoint(&fakeBackend{}, WithIndices("-"))
+	assert.NoError(t, e.Register(&applyFilterFeature{filters: filters}))
+
+	r, err := e.Execute(context.Background(), NewRequest(
+		NewParameter("brand", "acme"),
+		NewParameter("price.min", "10"),
+		NewParameter("size", "10")))
+	assert.NoError(t, err)
+	assert.Equal(t, filters, r.AppliedFilters)
+
+	data, err := json.Marshal(NewEnvelope(r))
+	assert.NoError(t, err)
+
+	var env struct {
+		Filters map[string]interface{} `json:"filters"`
+	}
+	assert.NoError(t, json.Unmarshal(data, &env))
+	assert.Equal(t, map[string]interface{}{
+		"brand": map[string]interface{}{"values": []interface{}{"acme"}},
+		"price": map[string]interface{}{"min": float64(10)},
+	}, env.Filters)
+}
@@ -0,0 +1,72 @@
+package reveald
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// WithAPIKey authenticates requests to Elasticsearch with an
+// API key, in its base64 encoded form as returned when creating
+// the key, or as shown by the Elastic Cloud console
+func WithAPIKey(key string) ElasticBackendOption {
+	return func(b *ElasticBackend) {
+		b.authorization = "ApiKey " + key
+	}
+}
+
+// WithServiceToken authenticates requests to Elasticsearch
+// with a bearer token, such as a service account token
+func WithServiceToken(token string) ElasticBackendOption {
+	return func(b *ElasticBackend) {
+		b.authorization = "Bearer " + token
+	}
+}
+
+// WithCloudID targets the Elastic Cloud deployment identified by
+// id, overriding the nodes passed to NewElasticBackend. Sniffing
+// is disabled, as the nodes of a deployment are only reachable
+// through its proxy
+func WithCloudID(id string) ElasticBackendOption {
+	return func(b *ElasticBackend) {
+		b.cloudID = id
+	}
+}
+
+// cloudURL returns the Elasticsearch URL of a cloud id, which is
+// the deployment name and the base64 encoded host, Elasticsearch
+// id and Kibana id, as in name:base64(host$es-id$kibana-id)
+func cloudURL(id string) (string, error) {
+	_, encoded, ok := strings.Cut(id, ":")
+	if !ok {
+		encoded = id
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("invalid cloud id: %w", err)
+	}
+
+	parts := strings.Split(string(decoded), "$")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("invalid cloud id: expected host and elasticsearch id")
+	}
+
+	host, port, ok := strings.Cut(parts[0], ":")
+	if !ok {
+		port = "443"
+	}
+
+	return fmt.Sprintf("https://%s.%s:%s", parts[1], host, port), nil
+}
+
+// authHeaders returns the default headers
+// authenticating requests, if any
+func (b *ElasticBackend) authHeaders() http.Header {
+	if b.authorization == "" {
+		return nil
+	}
+
+	return http.Header{"Authorization": []string{b.authorization}}
+}
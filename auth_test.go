@@ -0,0 +1,73 @@
+package reveald
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CloudURL(t *testing.T) {
+	encode := func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	}
+
+	table := []struct {
+		name     string
+		id       string
+		expected string
+		err      bool
+	}{
+		{"default port", "prod:" + encode("eu-west-1.aws.found.io$abc123$def456"), "https://abc123.eu-west-1.aws.found.io:443", false},
+		{"explicit port", "prod:" + encode("eu-west-1.aws.found.io:9243$abc123$def456"), "https://abc123.eu-west-1.aws.found.io:9243", false},
+		{"without name", encode("eu-west-1.aws.found.io$abc123"), "https://abc123.eu-west-1.aws.found.io:443", false},
+		{"not base64", "prod:%%%", "", true},
+		{"missing elasticsearch id", "prod:" + encode("eu-west-1.aws.found.io"), "", true},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			url, err := cloudURL(tt.id)
+			assert.Equal(t, tt.err, err != nil)
+			assert.Equal(t, tt.expected, url)
+		})
+	}
+}
+
+func Test_ElasticBackend_Authorization(t *testing.T) {
+	table := []struct {
+		name     string
+		opt      ElasticBackendOption
+		expected string
+	}{
+		{"api key", WithAPIKey("a2V5OnNlY3JldA=="), "ApiKey a2V5OnNlY3JldA=="},
+		{"service token", WithServiceToken("token"), "Bearer token"},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := make(chan string, 1)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				auth <- r.Header.Get("Authorization")
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"hits": {"hits": []}}`))
+			}))
+			t.Cleanup(srv.Close)
+
+			b, err := NewElasticBackend([]string{srv.URL}, WithSniff(false), WithHealthCheck(false), tt.opt)
+			assert.NoError(t, err)
+
+			_, err = b.Execute(context.Background(), NewQueryBuilder(NewRequest(), "products"))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, <-auth)
+		})
+	}
+}
+
+func Test_ElasticBackend_Invalid_CloudID(t *testing.T) {
+	_, err := NewElasticBackend(nil, WithCloudID("prod:%%%"))
+	assert.ErrorContains(t, err, "invalid cloud id")
+}
@@ -0,0 +1,274 @@
+package reveald
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/olivere/elastic/v7"
+)
+
+const (
+	awsSigningAlgorithm = "AWS4-HMAC-SHA256"
+	awsDateFormat       = "20060102T150405Z"
+)
+
+// AWSCredentials are the credentials of an IAM identity,
+// where SessionToken is only set for temporary credentials
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// AWSCredentialsProvider retrieves the credentials used to sign
+// requests, on every request, so that providers may refresh
+// expiring credentials. The credential providers of the AWS SDK
+// are adapted with an AWSCredentialsFunc
+type AWSCredentialsProvider interface {
+	Retrieve(ctx context.Context) (AWSCredentials, error)
+}
+
+// AWSCredentialsFunc adapts a function to an AWSCredentialsProvider
+type AWSCredentialsFunc func(ctx context.Context) (AWSCredentials, error)
+
+// Retrieve returns the credentials returned by the function
+func (fn AWSCredentialsFunc) Retrieve(ctx context.Context) (AWSCredentials, error) {
+	return fn(ctx)
+}
+
+// StaticAWSCredentials returns a provider of fixed credentials
+func StaticAWSCredentials(accessKeyID, secretAccessKey, sessionToken string) AWSCredentialsProvider {
+	return AWSCredentialsFunc(func(context.Context) (AWSCredentials, error) {
+		return AWSCredentials{accessKeyID, secretAccessKey, sessionToken}, nil
+	})
+}
+
+type awsSigner struct {
+	region      string
+	service     string
+	credentials AWSCredentialsProvider
+	now         func() time.Time
+}
+
+// AWSSigV4Option is a functional option used
+// when signing requests with WithAWSSigV4
+type AWSSigV4Option func(*awsSigner)
+
+// WithAWSService defines the service name signed for,
+// "es" for Amazon OpenSearch Service domains (the default)
+// or "aoss" for Amazon OpenSearch Serverless collections
+func WithAWSService(service string) AWSSigV4Option {
+	return func(s *awsSigner) {
+		s.service = service
+	}
+}
+
+// WithAWSSigV4 signs every request with AWS Signature Version 4,
+// for Amazon OpenSearch Service domains requiring IAM-signed
+// requests. Sniffing is disabled, as the nodes of a domain are
+// only reachable through its endpoint, which should be passed
+// to NewElasticBackend along with WithScheme("https")
+func WithAWSSigV4(region string, credentials AWSCredentialsProvider, opts ...AWSSigV4Option) ElasticBackendOption {
+	return func(b *ElasticBackend) {
+		s := &awsSigner{
+			region:      region,
+			service:     "es",
+			credentials: credentials,
+			now:         time.Now,
+		}
+
+		for _, opt := range opts {
+			opt(s)
+		}
+
+		b.signer = s
+		b.opts = append(b.opts, elastic.SetSniff(false))
+	}
+}
+
+// wrap returns a doer signing requests before sending them
+func (s *awsSigner) wrap(doer elastic.Doer) elastic.Doer {
+	return &signingDoer{doer, s}
+}
+
+type signingDoer struct {
+	doer   elastic.Doer
+	signer *awsSigner
+}
+
+func (d *signingDoer) Do(req *http.Request) (*http.Response, error) {
+	creds, err := d.signer.credentials.Retrieve(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("failed retrieving aws credentials: %w", err)
+	}
+
+	if err := d.signer.sign(req, creds); err != nil {
+		return nil, err
+	}
+
+	return d.doer.Do(req)
+}
+
+// sign adds the signature headers to a request, buffering
+// its body to hash the payload
+func (s *awsSigner) sign(req *http.Request, creds AWSCredentials) error {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return fmt.Errorf("failed reading request body for signing: %w", err)
+		}
+		req.Body.Close()
+
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+
+	payloadHash := hashHex(body)
+	now := s.now().UTC()
+	amzDate := now.Format(awsDateFormat)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+	if s.service == "aoss" {
+		// serverless collections require the payload hash header
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	headers, signedHeaders := canonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalPath(req),
+		canonicalQuery(req),
+		headers,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{now.Format("20060102"), s.region, s.service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		awsSigningAlgorithm,
+		amzDate,
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), now.Format("20060102"))
+	for _, part := range []string{s.region, s.service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		awsSigningAlgorithm, creds.AccessKeyID, scope, signedHeaders, signature))
+	return nil
+}
+
+// canonicalHeaders returns the signed headers, which are the host
+// and the x-amz headers, in their canonical form and as a list
+func canonicalHeaders(req *http.Request) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	values := map[string]string{"host": host}
+	for name, v := range req.Header {
+		name = strings.ToLower(name)
+		if strings.HasPrefix(name, "x-amz-") {
+			values[name] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + ":" + values[name] + "\n")
+	}
+
+	return b.String(), strings.Join(names, ";")
+}
+
+// canonicalPath returns the escaped path, escaped once
+// more, as services other than S3 expect
+func canonicalPath(req *http.Request) string {
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	return awsEscape(path, false)
+}
+
+// canonicalQuery returns the query parameters, escaped
+// and sorted by name and then by value
+func canonicalQuery(req *http.Request) string {
+	var params [][2]string
+	for name, values := range req.URL.Query() {
+		for _, v := range values {
+			params = append(params, [2]string{awsEscape(name, true), awsEscape(v, true)})
+		}
+	}
+	sort.Slice(params, func(i, j int) bool {
+		if params[i][0] != params[j][0] {
+			return params[i][0] < params[j][0]
+		}
+		return params[i][1] < params[j][1]
+	})
+
+	encoded := make([]string, 0, len(params))
+	for _, p := range params {
+		encoded = append(encoded, p[0]+"="+p[1])
+	}
+
+	return strings.Join(encoded, "&")
+}
+
+// awsEscape percent-encodes everything but the unreserved
+// characters, and slashes unless escapeSlash is set
+func awsEscape(s string, escapeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !escapeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
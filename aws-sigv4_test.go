@@ -0,0 +1,67 @@
+package reveald
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_AWSSigner_Sign(t *testing.T) {
+	// the get-vanilla case of the AWS Signature Version 4 test suite
+	s := &awsSigner{
+		region:  "us-east-1",
+		service: "service",
+		now: func() time.Time {
+			return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+		},
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.sign(req, AWSCredentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}))
+	assert.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+		"SignedHeaders=host;x-amz-date, "+
+		"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		req.Header.Get("Authorization"))
+}
+
+func Test_CanonicalQuery(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/?b=2&a-b=1&a=3&a=1&q=red+shoes", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "a=1&a=3&a-b=1&b=2&q=red%20shoes", canonicalQuery(req))
+}
+
+func Test_ElasticBackend_AWSSigV4(t *testing.T) {
+	requests := make(chan *http.Request, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- r
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"hits": {"hits": []}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	b, err := NewElasticBackend([]string{srv.URL}, WithHealthCheck(false),
+		WithAWSSigV4("eu-west-1", StaticAWSCredentials("AKID", "secret", "token"), WithAWSService("aoss")))
+	assert.NoError(t, err)
+
+	_, err = b.Execute(context.Background(), NewQueryBuilder(NewRequest(), "products"))
+	assert.NoError(t, err)
+
+	r := <-requests
+	auth := r.Header.Get("Authorization")
+	assert.True(t, strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/"), auth)
+	assert.Contains(t, auth, "/eu-west-1/aoss/aws4_request")
+	assert.Contains(t, auth, "SignedHeaders=host;x-amz-content-sha256;x-amz-date;x-amz-security-token")
+	assert.Equal(t, "token", r.Header.Get("X-Amz-Security-Token"))
+	assert.NotEmpty(t, r.Header.Get("X-Amz-Content-Sha256"))
+}
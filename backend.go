@@ -2,7 +2,6 @@ package reveald
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -11,14 +10,30 @@ import (
 	"github.com/olivere/elastic/v7"
 )
 
+// HighlightsKey is the hit key holding highlighted fragments
+const HighlightsKey = "_highlights"
+
 // Retrier decides whether to retry a failed HTTP request with Elasticsearch.
 type Retrier elastic.Retrier
 
 // ElasticBackend defines an Elasticsearch backend
 // for Reveald
 type ElasticBackend struct {
-	client *elastic.Client
-	opts   []elastic.ClientOptionFunc
+	client  *elastic.Client
+	opts    []elastic.ClientOptionFunc
+	nodes   []string
+	sampler *AccessLogSampler
+	detect  bool
+	cluster *ClusterInfo
+	numbers NumberDecoder
+	http    elastic.Doer
+	signer  *awsSigner
+	breaker *CircuitBreaker
+
+	coalescer *coalescer
+
+	authorization string
+	cloudID       string
 }
 
 // ElasticBackendOption is a type for passing
@@ -65,11 +80,14 @@ func WithSniff(enabled bool) ElasticBackendOption {
 // WithHttpClient configures a http doer to use for the http requests to elastic backend.
 func WithHttpClient(httpClient *http.Client) ElasticBackendOption {
 	return func(b *ElasticBackend) {
-		b.opts = append(b.opts, elastic.SetHttpClient(httpClient))
+		b.http = httpClient
 	}
 }
 
 // WithRetrier configures a retry strategy to use when a http request to elastic backend fails.
+//
+// Deprecated: the retrier is only consulted on connection errors,
+// use WithRetryPolicy to also retry overloaded or unavailable nodes
 func WithRetrier(retrier Retrier) ElasticBackendOption {
 	return func(b *ElasticBackend) {
 		b.opts = append(b.opts, elastic.SetRetrier(retrier))
@@ -79,7 +97,7 @@ func WithRetrier(retrier Retrier) ElasticBackendOption {
 // NewElasticBackend creates a new backend for
 // Reveald, targeting Elasticsearch
 func NewElasticBackend(nodes []string, opts ...ElasticBackendOption) (*ElasticBackend, error) {
-	b := &ElasticBackend{}
+	b := &ElasticBackend{nodes: nodes}
 	b.opts = []elastic.ClientOptionFunc{
 		elastic.SetURL(nodes...),
 		elastic.SetScheme("http"),
@@ -92,20 +110,50 @@ func NewElasticBackend(nodes []string, opts ...ElasticBackendOption) (*ElasticBa
 		opt(b)
 	}
 
+	if b.cloudID != "" {
+		url, err := cloudURL(b.cloudID)
+		if err != nil {
+			return nil, err
+		}
+
+		b.nodes = []string{url}
+		b.opts = append(b.opts, elastic.SetURL(url), elastic.SetSniff(false))
+	}
+	if headers := b.authHeaders(); headers != nil {
+		b.opts = append(b.opts, elastic.SetHeaders(headers))
+	}
+
+	if b.signer != nil {
+		doer := b.http
+		if doer == nil {
+			doer = http.DefaultClient
+		}
+		b.http = b.signer.wrap(doer)
+	}
+	if b.http != nil {
+		b.opts = append(b.opts, elastic.SetHttpClient(b.http))
+	}
+
 	client, err := elastic.NewClient(b.opts...)
 	if err != nil {
 		return nil, err
 	}
 
 	b.client = client
+	if b.detect {
+		if _, err := b.DetectCluster(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
 	return b, nil
 }
 
-func mapSearchResult(result *elastic.SearchResult) (*Result, error) {
+func mapSearchResult(result *elastic.SearchResult, numbers NumberDecoder) (*Result, error) {
 	var hits []map[string]interface{}
 	for _, hit := range result.Hits.Hits {
 		var source map[string]interface{}
-		if err := json.Unmarshal(hit.Source, &source); err != nil {
+		if err := decodeJSON(hit.Source, &source, numbers != nil); err != nil {
 			continue
 		}
 
@@ -120,6 +168,36 @@ func mapSearchResult(result *elastic.SearchResult) (*Result, error) {
 			}
 		}
 
+		if numbers != nil {
+			if _, err := numbers.convert(source); err != nil {
+				return nil, fmt.Errorf("failed decoding number: %w", err)
+			}
+		}
+
+		if len(hit.Highlight) > 0 {
+			source[HighlightsKey] = hit.Highlight
+		}
+
+		if hit.Explanation != nil {
+			source[ExplanationKey] = mapExplanation(*hit.Explanation)
+		}
+
+		collapsed, err := collapsedHits(hit, numbers)
+		if err != nil {
+			return nil, err
+		}
+		if len(collapsed) > 0 {
+			source[CollapsedHitsKey] = collapsed
+		}
+
+		inner, err := innerHits(hit, numbers)
+		if err != nil {
+			return nil, err
+		}
+		if len(inner) > 0 {
+			source[InnerHitsKey] = inner
+		}
+
 		hits = append(hits, source)
 	}
 
@@ -128,36 +206,139 @@ func mapSearchResult(result *elastic.SearchResult) (*Result, error) {
 	}
 
 	return &Result{
-		result:        result,
-		TotalHitCount: result.TotalHits(),
-		Hits:          hits,
-		Pagination:    nil,
-		Sorting:       nil,
-		Aggregations:  make(map[string][]*ResultBucket),
+		result:            result,
+		numbers:           numbers,
+		TotalHitCount:     result.TotalHits(),
+		TotalHitsRelation: totalHitsRelation(result),
+		Hits:              hits,
+		Pagination:        nil,
+		Sorting:           nil,
+		Aggregations:      make(map[string][]*ResultBucket),
+		Suggestions:       make(map[string][]*ResultSuggestion),
+		Stats:             make(map[string]*ResultStats),
+		Cardinalities:     make(map[string]int64),
+		Related:           make(map[string][]*ResultBucket),
+		Facets:            make(map[string]*ResultFacet),
+		Intervals:         make(map[string]string),
+		PointInTimeID:     result.PitId,
+		Profile:           mapProfile(result.Profile),
+		TimedOut:          result.TimedOut,
+		TerminatedEarly:   result.TerminatedEarly,
 	}, nil
 }
 
+func totalHitsRelation(result *elastic.SearchResult) TotalHitsRelation {
+	if result.Hits == nil || result.Hits.TotalHits == nil {
+		return TotalHitsUntracked
+	}
+
+	if result.Hits.TotalHits.Relation == string(TotalHitsLowerBound) {
+		return TotalHitsLowerBound
+	}
+
+	return TotalHitsExact
+}
+
 // Execute an Elasticsearch query
 func (b *ElasticBackend) Execute(ctx context.Context, builder *QueryBuilder) (*Result, error) {
-	src := builder.Build()
-	svc := b.client.Search(builder.Indices()...)
-	result, err := svc.SearchSource(src).Do(ctx)
+	if err := b.checkCapabilities(builder); err != nil {
+		return nil, err
+	}
+
+	src, err := builder.BuildSource()
 	if err != nil {
-		return nil, fmt.Errorf("elasticsearch request failed: %w", err)
+		return nil, fmt.Errorf("failed building elasticsearch request: %w", err)
 	}
 
-	return mapSearchResult(result)
+	svc := b.client.Search(searchIndices(builder)...)
+	if id, ok := SearchIDFromContext(ctx); ok {
+		svc = svc.Header(SearchIDHeader, id)
+	}
+	if builder.Preference() != "" {
+		svc = svc.Preference(builder.Preference())
+	}
+	if len(builder.Routing()) > 0 {
+		svc = svc.Routing(builder.Routing()...)
+	}
+	if builder.IgnoreUnavailable() != nil {
+		svc = svc.IgnoreUnavailable(*builder.IgnoreUnavailable())
+	}
+	if builder.AllowNoIndices() != nil {
+		svc = svc.AllowNoIndices(*builder.AllowNoIndices())
+	}
+
+	search := func(ctx context.Context) (*elastic.SearchResult, error) {
+		if err := b.breaker.begin(); err != nil {
+			return nil, err
+		}
+
+		result, err := svc.Source(src).Do(ctx)
+		b.breaker.end(ctx, err)
+		return result, err
+	}
+
+	start := time.Now()
+	var result *elastic.SearchResult
+	if key, ok := b.coalescingKey(builder); ok {
+		result, err = b.coalescer.do(ctx, key, search)
+	} else {
+		result, err = search(ctx)
+	}
+	b.sampler.record(ctx, builder.Indices(), src, result, err, start)
+	if err != nil {
+		return nil, searchError(err)
+	}
+
+	return mapSearchResult(result, b.numbers)
 }
 
 func (b *ElasticBackend) ExecuteMultiple(ctx context.Context, builders []*QueryBuilder) ([]*Result, error) {
 	svc := b.client.MultiSearch()
+	if id, ok := SearchIDFromContext(ctx); ok {
+		svc = svc.Header(SearchIDHeader, id)
+	}
+	sources := make([]interface{}, 0, len(builders))
 	for _, builder := range builders {
-		svc = svc.Add(elastic.NewSearchRequest().SearchSource(builder.Build()).Index(builder.Indices()...))
+		if err := b.checkCapabilities(builder); err != nil {
+			return nil, err
+		}
+
+		src, err := builder.BuildSource()
+		if err != nil {
+			return nil, fmt.Errorf("failed building elasticsearch request: %w", err)
+		}
+		sources = append(sources, src)
+
+		req := elastic.NewSearchRequest().Source(src).Index(searchIndices(builder)...)
+		if builder.Preference() != "" {
+			req = req.Preference(builder.Preference())
+		}
+		if len(builder.Routing()) > 0 {
+			req = req.Routings(builder.Routing()...)
+		}
+		if builder.IgnoreUnavailable() != nil {
+			req = req.IgnoreUnavailable(*builder.IgnoreUnavailable())
+		}
+		if builder.AllowNoIndices() != nil {
+			req = req.AllowNoIndices(*builder.AllowNoIndices())
+		}
+
+		svc = svc.Add(req)
 	}
 
+	if err := b.breaker.begin(); err != nil {
+		return nil, searchError(err)
+	}
+
+	start := time.Now()
 	result, err := svc.Do(ctx)
+	b.breaker.end(ctx, err)
+	b.sampler.record(ctx, nil, sources, result, err, start)
+	if multiSearchUnsupported(err) {
+		return nil, fmt.Errorf("%w: %v", ErrMultiSearchUnsupported, err)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("elasticsearch request failed: %w", err)
+		return nil, searchError(err)
 	}
 
 	if len(result.Responses) != len(builders) {
@@ -166,7 +347,11 @@ func (b *ElasticBackend) ExecuteMultiple(ctx context.Context, builders []*QueryB
 
 	results := make([]*Result, 0, len(result.Responses))
 	for _, res := range result.Responses {
-		mres, err := mapSearchResult(res)
+		if inf := indexNotFound(res.Error, &elastic.Error{Status: res.Status, Details: res.Error}); inf != nil {
+			return nil, inf
+		}
+
+		mres, err := mapSearchResult(res, b.numbers)
 		if err != nil {
 			return nil, fmt.Errorf("elasticsearch request failed: %w", err)
 		}
@@ -176,3 +361,96 @@ func (b *ElasticBackend) ExecuteMultiple(ctx context.Context, builders []*QueryB
 
 	return results, nil
 }
+
+// OpenPointInTime opens a point in time snapshot of the specified
+// indices, kept alive for the specified duration (e.g. "1m")
+func (b *ElasticBackend) OpenPointInTime(ctx context.Context, keepAlive string, indices ...string) (string, error) {
+	if err := b.Require(CapabilityPointInTime); err != nil {
+		return "", err
+	}
+
+	res, err := b.client.OpenPointInTime(indices...).KeepAlive(keepAlive).Do(ctx)
+	if err != nil {
+		return "", fmt.Errorf("elasticsearch request failed: %w", err)
+	}
+
+	return res.Id, nil
+}
+
+// ClosePointInTime releases a point in time snapshot
+func (b *ElasticBackend) ClosePointInTime(ctx context.Context, id string) error {
+	_, err := b.client.ClosePointInTime(id).Do(ctx)
+	if err != nil {
+		return fmt.Errorf("elasticsearch request failed: %w", err)
+	}
+
+	return nil
+}
+
+// searchIndices returns the indices to target for a query, which
+// must be omitted when searching a point in time
+func searchIndices(builder *QueryBuilder) []string {
+	if builder.PointInTime() != nil {
+		return nil
+	}
+
+	return builder.Indices()
+}
+
+// FieldsOfType returns the fields of the specified
+// mapping types, across the specified indices
+func (b *ElasticBackend) FieldsOfType(ctx context.Context, indices []string, types ...string) ([]string, error) {
+	res, err := b.client.GetMapping().Index(indices...).Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("elasticsearch request failed: %w", err)
+	}
+
+	wanted := make(map[string]bool, len(types))
+	for _, t := range types {
+		wanted[t] = true
+	}
+
+	seen := make(map[string]bool)
+	var fields []string
+	for _, index := range res {
+		mapping, ok := index.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		mappings, ok := mapping["mappings"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		collectFieldsOfType(mappings, "", wanted, func(field string) {
+			if !seen[field] {
+				seen[field] = true
+				fields = append(fields, field)
+			}
+		})
+	}
+
+	return fields, nil
+}
+
+func collectFieldsOfType(mapping map[string]interface{}, prefix string, types map[string]bool, fn func(string)) {
+	properties, ok := mapping["properties"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	for name, p := range properties {
+		property, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		field := prefix + name
+		if t, ok := property["type"].(string); ok && types[t] {
+			fn(field)
+		}
+
+		collectFieldsOfType(property, field+".", types, fn)
+	}
+}
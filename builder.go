@@ -1,19 +1,57 @@
 package reveald
 
-import "github.com/olivere/elastic/v7"
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/olivere/elastic/v7"
+)
 
 // QueryBuilder is a construct to build a
 // dynamic Elasticsearch query
 type QueryBuilder struct {
+	ctx             context.Context
 	request         *Request
 	aggs            map[string]elastic.Aggregation
 	root            *elastic.BoolQuery
+	filter          *elastic.BoolQuery
+	filterClauses   int
 	postFilter      *elastic.BoolQuery
 	indices         []string
 	selection       *DocumentSelector
 	scriptedFields  []*elastic.ScriptField
 	runtimeMappings elastic.RuntimeMappings
 	docValueFields  []string
+	pointInTime     *elastic.PointInTime
+	pitResolver     PointInTimeResolver
+	pitKeepAlive    string
+	preference      string
+	routing         []string
+	ignoreUnavail   *bool
+	allowNoIndices  *bool
+	highlight       *elastic.Highlight
+	suggesters      []elastic.Suggester
+	knn             *KNNQuery
+	scoring         *elastic.FunctionScoreQuery
+	sourceFields    map[string]interface{}
+	counting        CountingStrategy
+	facetFilters    []facetFilter
+	aggFacets       map[string]string
+	subAggs         map[string]map[string]elastic.Aggregation
+	collapse        *elastic.CollapseBuilder
+	explain         bool
+	profile         bool
+	trackTotalHits  interface{}
+	queryTimeout    time.Duration
+	terminateAfter  int
+	indexSort       *indexSort
+	aggAliases      map[string]string
+	appliedFilters  []*ResultFilter
+	ids             []string
+	idOrder         bool
+	subsearch       backendFunc
+	noDebugResponse bool
 }
 
 // NewQueryBuilder returns a new base query for
@@ -23,6 +61,7 @@ func NewQueryBuilder(r *Request, indices ...string) *QueryBuilder {
 		request:         r,
 		aggs:            make(map[string]elastic.Aggregation),
 		root:            elastic.NewBoolQuery(),
+		filter:          elastic.NewBoolQuery(),
 		indices:         indices,
 		selection:       nil,
 		scriptedFields:  nil,
@@ -36,6 +75,21 @@ func (qb *QueryBuilder) Request() *Request {
 	return qb.request
 }
 
+// Context returns the context of the executing request,
+// or a background context if none has been set
+func (qb *QueryBuilder) Context() context.Context {
+	if qb.ctx == nil {
+		return context.Background()
+	}
+
+	return qb.ctx
+}
+
+// SetContext sets the context of the executing request
+func (qb *QueryBuilder) SetContext(ctx context.Context) {
+	qb.ctx = ctx
+}
+
 // Indices returns the targets for the Elasticsearch
 // query
 func (qb *QueryBuilder) Indices() []string {
@@ -51,12 +105,22 @@ func (qb *QueryBuilder) SetIndices(indices ...string) {
 // With filters documents based on the specified query
 func (qb *QueryBuilder) With(query elastic.Query) {
 	qb.root.Must(query)
+	qb.filter.Must(query)
+	qb.filterClauses++
 }
 
 // Without filters document based on an inverted
 // query
 func (qb *QueryBuilder) Without(query elastic.Query) {
 	qb.root.MustNot(query)
+	qb.filter.MustNot(query)
+	qb.filterClauses++
+}
+
+// Match filters documents based on a relevance query, which
+// unlike With doesn't restrict approximate kNN candidates
+func (qb *QueryBuilder) Match(query elastic.Query) {
+	qb.root.Must(query)
 }
 
 // Boost document based on specified query
@@ -105,11 +169,87 @@ func (qb *QueryBuilder) Aggregation(name string, agg elastic.Aggregation) {
 	qb.aggs[name] = agg
 }
 
+// Aggregations returns the aggregations added
+// to the Elasticsearch query, by name
+func (qb *QueryBuilder) Aggregations() map[string]elastic.Aggregation {
+	return qb.aggs
+}
+
+// dropAggregations removes all aggregations
+// from the Elasticsearch query
+func (qb *QueryBuilder) dropAggregations() {
+	qb.aggs = make(map[string]elastic.Aggregation)
+	qb.aggFacets = nil
+	qb.subAggs = nil
+}
+
+// Suggester adds a new suggester to the
+// Elasticsearch query
+func (qb *QueryBuilder) Suggester(suggester elastic.Suggester) {
+	qb.suggesters = append(qb.suggesters, suggester)
+}
+
+// ScoreFunction adjusts document scores using a function_score
+// function, applied to documents matching filter when not nil
+func (qb *QueryBuilder) ScoreFunction(filter elastic.Query, fn elastic.ScoreFunction) {
+	if filter != nil {
+		qb.functionScore().Add(filter, fn)
+		return
+	}
+
+	qb.functionScore().AddScoreFunc(fn)
+}
+
+// WithScoreMode defines how function_score function
+// scores are combined (e.g. "sum" or "multiply")
+func (qb *QueryBuilder) WithScoreMode(mode string) {
+	qb.functionScore().ScoreMode(mode)
+}
+
+// WithBoostMode defines how the combined function_score
+// score is combined with the query score
+func (qb *QueryBuilder) WithBoostMode(mode string) {
+	qb.functionScore().BoostMode(mode)
+}
+
+// WithMaxBoost caps the combined function_score score
+func (qb *QueryBuilder) WithMaxBoost(max float64) {
+	qb.functionScore().MaxBoost(max)
+}
+
+func (qb *QueryBuilder) functionScore() *elastic.FunctionScoreQuery {
+	if qb.scoring == nil {
+		qb.scoring = elastic.NewFunctionScoreQuery()
+	}
+
+	return qb.scoring
+}
+
 // RawQuery returns the current Elasticsearch query
 func (qb *QueryBuilder) RawQuery() elastic.Query {
 	return qb.root
 }
 
+// RawPostFilter returns the current post filter,
+// or nil when the hits aren't post filtered
+func (qb *QueryBuilder) RawPostFilter() elastic.Query {
+	if qb.postFilter == nil {
+		return nil
+	}
+
+	return qb.postFilter
+}
+
+// filterQuery returns the current query, including
+// any post filters, for requests without hits
+func (qb *QueryBuilder) filterQuery() elastic.Query {
+	if qb.postFilter == nil {
+		return qb.root
+	}
+
+	return elastic.NewBoolQuery().Must(qb.root).Filter(qb.postFilter)
+}
+
 // WithScriptedFields specifies scripted fields to add to query
 func (qb *QueryBuilder) WithScriptedField(scriptedField *elastic.ScriptField) {
 	qb.scriptedFields = append(qb.scriptedFields, scriptedField)
@@ -128,6 +268,209 @@ func (qb *QueryBuilder) DocvalueFields(docvalueFields ...string) {
 	qb.docValueFields = append(qb.docValueFields, docvalueFields...)
 }
 
+// WithPointInTime searches a point in time snapshot instead
+// of the live indices, extending its lifetime by keepAlive
+func (qb *QueryBuilder) WithPointInTime(id, keepAlive string) {
+	qb.pointInTime = elastic.NewPointInTimeWithKeepAlive(id, keepAlive)
+}
+
+// PointInTime returns the point in time to search, if any
+func (qb *QueryBuilder) PointInTime() *elastic.PointInTime {
+	return qb.pointInTime
+}
+
+// PointInTimeResolver returns the id of the point in time
+// to search for the indices a search is finally scoped to
+type PointInTimeResolver func(ctx context.Context, indices []string) (string, error)
+
+// WithPointInTimeResolver defers picking the point in time until
+// the endpoint has enforced its tenant, so the snapshot is taken
+// of the indices actually searched rather than the ones features
+// targeted, extending its lifetime by keepAlive
+func (qb *QueryBuilder) WithPointInTimeResolver(keepAlive string, resolve PointInTimeResolver) {
+	qb.pitResolver = resolve
+	qb.pitKeepAlive = keepAlive
+}
+
+// resolvePointInTime applies the deferred point in time, if any
+func (qb *QueryBuilder) resolvePointInTime(ctx context.Context) error {
+	if qb.pitResolver == nil {
+		return nil
+	}
+
+	id, err := qb.pitResolver(ctx, qb.Indices())
+	if err != nil {
+		return fmt.Errorf("failed resolving point in time: %w", err)
+	}
+
+	qb.WithPointInTime(id, qb.pitKeepAlive)
+	qb.pitResolver = nil
+	return nil
+}
+
+// WithPreference sets the shard copy preference for the
+// search, e.g. a session id to keep pagination consistent
+func (qb *QueryBuilder) WithPreference(preference string) {
+	qb.preference = preference
+}
+
+// Preference returns the shard copy preference for the search
+func (qb *QueryBuilder) Preference() string {
+	return qb.preference
+}
+
+// WithRouting restricts the search to the shards of the
+// routing values, for indices with custom routing
+func (qb *QueryBuilder) WithRouting(values ...string) {
+	qb.routing = values
+}
+
+// Routing returns the routing values of the search
+func (qb *QueryBuilder) Routing() []string {
+	return qb.routing
+}
+
+// WithIgnoreUnavailable defines whether missing or
+// closed indices are ignored by the search
+func (qb *QueryBuilder) WithIgnoreUnavailable(ignore bool) {
+	qb.ignoreUnavail = &ignore
+}
+
+// IgnoreUnavailable returns whether missing or closed
+// indices are ignored, or nil for the cluster default
+func (qb *QueryBuilder) IgnoreUnavailable() *bool {
+	return qb.ignoreUnavail
+}
+
+// WithAllowNoIndices defines whether a search is allowed when
+// wildcard expressions resolve to no concrete indices
+func (qb *QueryBuilder) WithAllowNoIndices(allow bool) {
+	qb.allowNoIndices = &allow
+}
+
+// AllowNoIndices returns whether wildcard expressions may resolve
+// to no concrete indices, or nil for the cluster default
+func (qb *QueryBuilder) AllowNoIndices() *bool {
+	return qb.allowNoIndices
+}
+
+// WithQueryTimeout bounds the time each shard spends on the
+// search, after which the hits collected so far are returned,
+// and the result is flagged as timed out
+func (qb *QueryBuilder) WithQueryTimeout(timeout time.Duration) {
+	qb.queryTimeout = timeout
+}
+
+// QueryTimeout returns the shard timeout of the search,
+// or zero when the search doesn't time out
+func (qb *QueryBuilder) QueryTimeout() time.Duration {
+	return qb.queryTimeout
+}
+
+// WithTerminateAfter stops each shard from collecting more than
+// n documents, after which the result is flagged as terminated
+// early, and its hit count and aggregations are partial
+func (qb *QueryBuilder) WithTerminateAfter(n int) {
+	qb.terminateAfter = n
+}
+
+// TerminateAfter returns the maximum number of documents collected
+// per shard, or zero when collection isn't terminated early
+func (qb *QueryBuilder) TerminateAfter() int {
+	return qb.terminateAfter
+}
+
+// HighlightOption is a functional option used
+// when highlighting a field
+type HighlightOption func(*elastic.HighlighterField)
+
+// WithHighlightTags defines the tags to wrap
+// highlighted terms with
+func WithHighlightTags(pre, post string) HighlightOption {
+	return func(f *elastic.HighlighterField) {
+		f.PreTags(pre).PostTags(post)
+	}
+}
+
+// WithHighlightFragmentSize defines the size of
+// highlighted fragments, in characters
+func WithHighlightFragmentSize(size int) HighlightOption {
+	return func(f *elastic.HighlighterField) {
+		f.FragmentSize(size)
+	}
+}
+
+// WithHighlightFragments defines the maximum number
+// of highlighted fragments to return
+func WithHighlightFragments(count int) HighlightOption {
+	return func(f *elastic.HighlighterField) {
+		f.NumOfFragments(count)
+	}
+}
+
+// WithHighlight adds highlighting of query matches in a field,
+// returned under the "_highlights" key of each hit
+func (qb *QueryBuilder) WithHighlight(field string, opts ...HighlightOption) {
+	if qb.highlight == nil {
+		qb.highlight = elastic.NewHighlight()
+	}
+
+	hf := elastic.NewHighlighterField(field)
+	for _, opt := range opts {
+		opt(hf)
+	}
+
+	qb.highlight.Fields(hf)
+}
+
+// WithSourceField sets a top-level field of the search
+// request body, for options not covered by the builder
+func (qb *QueryBuilder) WithSourceField(key string, value interface{}) {
+	if qb.sourceFields == nil {
+		qb.sourceFields = make(map[string]interface{})
+	}
+
+	qb.sourceFields[key] = value
+}
+
+// BuildSource renders the final Elasticsearch request body,
+// including the options Build can't express, such as kNN
+func (qb *QueryBuilder) BuildSource() (interface{}, error) {
+	src, err := qb.Build().Source()
+	if err != nil {
+		return nil, err
+	}
+
+	if qb.knn == nil && len(qb.sourceFields) == 0 {
+		return src, nil
+	}
+
+	body, ok := src.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected search source type %T", src)
+	}
+
+	if qb.knn != nil {
+		var filter elastic.Query
+		if qb.filterClauses > 0 {
+			filter = qb.filter
+		}
+
+		knn, err := qb.knn.source(filter)
+		if err != nil {
+			return nil, err
+		}
+
+		body["knn"] = knn
+	}
+
+	for k, v := range qb.sourceFields {
+		body[k] = v
+	}
+
+	return body, nil
+}
+
 // Build creates the final Elasticsearch query, containing
 // queries, aggregations, sort options, and pagination settings
 func (qb *QueryBuilder) Build() *elastic.SearchSource {
@@ -136,16 +479,57 @@ func (qb *QueryBuilder) Build() *elastic.SearchSource {
 	src = src.RuntimeMappings(qb.runtimeMappings)
 	src = src.DocvalueFields(qb.docValueFields...)
 
-	query := src.Query(qb.root).ScriptFields(qb.scriptedFields...)
+	var root elastic.Query = qb.root
+	if qb.scoring != nil {
+		root = qb.scoring.Query(qb.root)
+	}
+
+	query := src.Query(root).ScriptFields(qb.scriptedFields...)
 
 	if qb.postFilter != nil {
 		query.PostFilter(qb.postFilter)
 	}
 
-	for name, agg := range qb.aggs {
+	for name, agg := range qb.memoizedAggregations() {
 		query.Aggregation(name, agg)
 	}
 
+	if qb.pointInTime != nil {
+		src = src.PointInTime(qb.pointInTime)
+	}
+
+	if qb.highlight != nil {
+		src = src.Highlight(qb.highlight)
+	}
+
+	for _, suggester := range qb.suggesters {
+		src = src.Suggester(suggester)
+	}
+
+	if qb.collapse != nil {
+		src = src.Collapse(qb.collapse)
+	}
+
+	if qb.explain {
+		src = src.Explain(true)
+	}
+
+	if qb.profile {
+		src = src.Profile(true)
+	}
+
+	if track := qb.totalHitsTracking(); track != nil {
+		src = src.TrackTotalHits(track)
+	}
+
+	if qb.queryTimeout > 0 {
+		src = src.TimeoutInMillis(int(qb.queryTimeout.Milliseconds()))
+	}
+
+	if qb.terminateAfter > 0 {
+		src = src.TerminateAfter(qb.terminateAfter)
+	}
+
 	if qb.selection == nil {
 		return src
 	}
@@ -164,8 +548,12 @@ func (qb *QueryBuilder) Build() *elastic.SearchSource {
 		Size(qb.selection.pageSize).
 		From(qb.selection.offset)
 
-	if qb.selection.sort != nil {
-		src = src.SortBy(qb.selection.sort)
+	if sorters := qb.selection.Sorters(); len(sorters) > 0 {
+		src = src.SortBy(sorters...)
+	}
+
+	if len(qb.selection.searchAfter) > 0 {
+		src = src.SearchAfter(qb.selection.searchAfter...)
 	}
 
 	return src
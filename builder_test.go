@@ -1,6 +1,8 @@
 package reveald
 
 import (
+	"context"
+	"net/http"
 	"testing"
 
 	"github.com/olivere/elastic/v7"
@@ -84,3 +86,23 @@ func Test_That_PostFilter_Adds_To_Source(t *testing.T) {
 
 	assert.Equal(t, expected, actual)
 }
+
+func Test_ElasticBackend_Routing_And_Preference(t *testing.T) {
+	requests := make(chan *http.Request, 1)
+	b := newTestBackend(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- r
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"hits": {"total": {"value": 0, "relation": "eq"}, "hits": []}}`))
+	}))
+
+	qb := NewQueryBuilder(NewRequest(), "products")
+	qb.WithRouting("tenant-1", "tenant-2")
+	qb.WithPreference("session-1")
+
+	_, err := b.Execute(context.Background(), qb)
+	assert.NoError(t, err)
+
+	r := <-requests
+	assert.Equal(t, "tenant-1,tenant-2", r.URL.Query().Get("routing"))
+	assert.Equal(t, "session-1", r.URL.Query().Get("preference"))
+}
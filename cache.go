@@ -0,0 +1,279 @@
+package reveald
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// Cache stores encoded search responses by key, so that
+// it may be backed by a shared store, such as Redis
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte, ttl time.Duration)
+}
+
+// CachedBackend is a Backend decorator serving identical
+// searches from a cache, keyed by a hash of the built search
+// request. Searches using a point in time aren't cached, nor
+// are partial results, or results without a raw response
+type CachedBackend struct {
+	backend Backend
+	cache   Cache
+	ttl     time.Duration
+	numbers NumberDecoder
+}
+
+// NewCachedBackend returns a backend caching the
+// responses of backend in cache, for ttl
+func NewCachedBackend(backend Backend, cache Cache, ttl time.Duration) *CachedBackend {
+	cb := &CachedBackend{
+		backend: backend,
+		cache:   cache,
+		ttl:     ttl,
+	}
+
+	// cached hits are decoded the way the backend decodes them
+	if eb, ok := backend.(*ElasticBackend); ok {
+		cb.numbers = eb.numbers
+	}
+
+	return cb
+}
+
+// Execute returns the cached result of the search,
+// or executes it and caches its result
+func (cb *CachedBackend) Execute(ctx context.Context, builder *QueryBuilder) (*Result, error) {
+	key, ok := cacheKey(builder)
+	if ok {
+		if r, ok := cb.get(key); ok {
+			return r, nil
+		}
+	}
+
+	r, err := cb.backend.Execute(ctx, builder)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		cb.set(key, r)
+	}
+
+	return r, nil
+}
+
+// ExecuteMultiple serves the cached searches from the cache,
+// executing the remaining ones in a single multi search
+func (cb *CachedBackend) ExecuteMultiple(ctx context.Context, builders []*QueryBuilder) ([]*Result, error) {
+	results := make([]*Result, len(builders))
+	keys := make([]string, len(builders))
+
+	var missing []*QueryBuilder
+	var positions []int
+	for i, builder := range builders {
+		key, ok := cacheKey(builder)
+		if ok {
+			if r, ok := cb.get(key); ok {
+				results[i] = r
+				continue
+			}
+			keys[i] = key
+		}
+
+		missing = append(missing, builder)
+		positions = append(positions, i)
+	}
+
+	if len(missing) == 0 {
+		return results, nil
+	}
+
+	executed, err := cb.backend.ExecuteMultiple(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	for j, r := range executed {
+		if j >= len(positions) {
+			break
+		}
+
+		i := positions[j]
+		results[i] = r
+		if keys[i] != "" && r != nil {
+			cb.set(keys[i], r)
+		}
+	}
+
+	return results, nil
+}
+
+// FieldsOfType resolves fields by their mapping type
+// through the decorated backend, when it's able to
+func (cb *CachedBackend) FieldsOfType(ctx context.Context, indices []string, types ...string) ([]string, error) {
+	resolver, ok := cb.backend.(FieldTypeResolver)
+	if !ok {
+		return nil, fmt.Errorf("backend %T can't resolve fields by mapping type", cb.backend)
+	}
+
+	return resolver.FieldsOfType(ctx, indices, types...)
+}
+
+func (cb *CachedBackend) get(key string) (*Result, bool) {
+	data, ok := cb.cache.Get(key)
+	if !ok {
+		return nil, false
+	}
+
+	var raw elastic.SearchResult
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, false
+	}
+
+	// every hit maps a fresh result, as features
+	// modify the result they are handed
+	r, err := mapSearchResult(&raw, cb.numbers)
+	if err != nil {
+		return nil, false
+	}
+
+	return r, true
+}
+
+func (cb *CachedBackend) set(key string, r *Result) {
+	// partial results aren't worth repeating
+	if r.RawResult() == nil || r.TimedOut || r.TerminatedEarly {
+		return
+	}
+
+	raw, err := json.Marshal(r.RawResult())
+	if err != nil {
+		return
+	}
+
+	cb.cache.Set(key, raw, cb.ttl)
+}
+
+// cacheKey returns a hash of the search request built by
+// a query builder, and whether the search may be cached
+func cacheKey(builder *QueryBuilder) (string, bool) {
+	if builder.PointInTime() != nil {
+		return "", false
+	}
+
+	src, err := builder.BuildSource()
+	if err != nil {
+		return "", false
+	}
+
+	// maps are encoded with sorted keys, making
+	// the encoding independent of insertion order
+	data, err := json.Marshal(struct {
+		Indices           []string    `json:"indices"`
+		Source            interface{} `json:"source"`
+		Preference        string      `json:"preference,omitempty"`
+		Routing           []string    `json:"routing,omitempty"`
+		IgnoreUnavailable *bool       `json:"ignore_unavailable,omitempty"`
+		AllowNoIndices    *bool       `json:"allow_no_indices,omitempty"`
+	}{
+		builder.Indices(),
+		src,
+		builder.Preference(),
+		builder.Routing(),
+		builder.IgnoreUnavailable(),
+		builder.AllowNoIndices(),
+	})
+	if err != nil {
+		return "", false
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), true
+}
+
+// LRUCache is an in-memory Cache, evicting the least
+// recently used entries once it holds capacity entries
+type LRUCache struct {
+	capacity int
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewLRUCache returns an in-memory cache of up to capacity entries
+func NewLRUCache(capacity int) *LRUCache {
+	return &LRUCache{
+		capacity: capacity,
+		now:      time.Now,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get returns the value of a key, unless missing or expired
+func (c *LRUCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := el.Value.(*lruEntry)
+	if !c.now().Before(entry.expires) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	c.order.MoveToFront(el)
+	return entry.value, true
+}
+
+// Set stores the value of a key for ttl
+func (c *LRUCache) Set(key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := c.now().Add(ttl)
+	if el, ok := c.entries[key]; ok {
+		entry := el.Value.(*lruEntry)
+		entry.value = value
+		entry.expires = expires
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry{key, value, expires})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+}
+
+// Len returns the number of entries held, including expired
+// entries which haven't been evicted yet
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
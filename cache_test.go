@@ -0,0 +1,129 @@
+package reveald
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+const cacheTestResponse = `{"hits": {"total": {"value": 1, "relation": "eq"}, "hits": [{"_id": "1", "_source": {"name": "anvil"}}]}}`
+
+func newCachedTestBackend(t *testing.T, calls *int32) *CachedBackend {
+	b := newTestBackend(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "_msearch") {
+			var responses []string
+			scanner := bufio.NewScanner(r.Body)
+			for scanner.Scan() {
+				if scanner.Scan() {
+					atomic.AddInt32(calls, 1)
+					responses = append(responses, cacheTestResponse)
+				}
+			}
+			_, _ = fmt.Fprintf(w, `{"responses": [%s]}`, strings.Join(responses, ","))
+			return
+		}
+
+		atomic.AddInt32(calls, 1)
+		_, _ = w.Write([]byte(cacheTestResponse))
+	}))
+
+	return NewCachedBackend(b, NewLRUCache(10), time.Minute)
+}
+
+func Test_CachedBackend_Execute(t *testing.T) {
+	var calls int32
+	cb := newCachedTestBackend(t, &calls)
+
+	search := func(value string) *Result {
+		qb := NewQueryBuilder(NewRequest(), "products")
+		qb.With(elastic.NewTermQuery("brand", value))
+
+		r, err := cb.Execute(context.Background(), qb)
+		assert.NoError(t, err)
+		return r
+	}
+
+	first := search("acme")
+	first.Hits[0]["name"] = "modified"
+
+	second := search("acme")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Equal(t, "anvil", second.Hits[0]["name"])
+	assert.Equal(t, int64(1), second.TotalHitCount)
+
+	search("globex")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func Test_CachedBackend_PointInTime_Not_Cached(t *testing.T) {
+	var calls int32
+	cb := newCachedTestBackend(t, &calls)
+
+	for i := 0; i < 2; i++ {
+		qb := NewQueryBuilder(NewRequest())
+		qb.WithPointInTime("pit", "1m")
+
+		_, err := cb.Execute(context.Background(), qb)
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func Test_CachedBackend_ExecuteMultiple(t *testing.T) {
+	var calls int32
+	cb := newCachedTestBackend(t, &calls)
+
+	builder := func(value string) *QueryBuilder {
+		qb := NewQueryBuilder(NewRequest(), "products")
+		qb.With(elastic.NewTermQuery("brand", value))
+		return qb
+	}
+
+	_, err := cb.Execute(context.Background(), builder("acme"))
+	assert.NoError(t, err)
+
+	results, err := cb.ExecuteMultiple(context.Background(), []*QueryBuilder{builder("globex"), builder("acme"), builder("initech")})
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+	for _, r := range results {
+		assert.Equal(t, int64(1), r.TotalHitCount)
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	_, err = cb.ExecuteMultiple(context.Background(), []*QueryBuilder{builder("globex"), builder("initech")})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func Test_LRUCache(t *testing.T) {
+	now := time.Now()
+	c := NewLRUCache(2)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", []byte("1"), time.Minute)
+	c.Set("b", []byte("2"), time.Minute)
+	_, _ = c.Get("a")
+	c.Set("c", []byte("3"), time.Minute)
+
+	_, ok := c.Get("b")
+	assert.False(t, ok, "least recently used entry is evicted")
+
+	v, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), v)
+
+	now = now.Add(time.Minute)
+	_, ok = c.Get("c")
+	assert.False(t, ok, "expired entry is dropped")
+	assert.Equal(t, 1, c.Len())
+}
@@ -28,7 +28,7 @@ func (cc *callchain) add(f Feature) {
 
 func (cc *callchain) exec(qb *QueryBuilder, fn FeatureFunc) (*Result, error) {
 	n := cc.root
-	for n.fn != nil {
+	for n != nil && n.fn != nil {
 		fn = func(ff FeatureFunc, c *callchained) FeatureFunc {
 			return func(qb *QueryBuilder) (*Result, error) {
 				return c.fn(qb, ff)
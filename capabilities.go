@@ -0,0 +1,179 @@
+package reveald
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Capability is an Elasticsearch API which
+// isn't available on every cluster version
+type Capability string
+
+const (
+	// CapabilityPointInTime is the point in time API
+	CapabilityPointInTime Capability = "point-in-time"
+	// CapabilityRuntimeMappings is search time runtime fields
+	CapabilityRuntimeMappings Capability = "runtime-mappings"
+	// CapabilityCompositeMissingOrder is the missing_order
+	// option of composite aggregation sources
+	CapabilityCompositeMissingOrder Capability = "composite-missing-order"
+	// CapabilityKNN is approximate kNN search
+	CapabilityKNN Capability = "knn"
+	// CapabilityESQL is the ES|QL query API
+	CapabilityESQL Capability = "esql"
+)
+
+var capabilityVersions = map[Capability]string{
+	CapabilityPointInTime:           "7.10.0",
+	CapabilityRuntimeMappings:       "7.11.0",
+	CapabilityCompositeMissingOrder: "7.16.0",
+	CapabilityKNN:                   "8.0.0",
+	CapabilityESQL:                  "8.11.0",
+}
+
+// ClusterInfo describes the version and license
+// of an Elasticsearch cluster
+type ClusterInfo struct {
+	Version string
+	Flavor  string
+	License string
+}
+
+// Supports returns true when the cluster version provides
+// the capability. Unknown versions are assumed to support
+// everything, leaving Elasticsearch to reject requests
+func (ci *ClusterInfo) Supports(c Capability) bool {
+	if ci == nil || ci.Version == "" {
+		return true
+	}
+
+	required, ok := capabilityVersions[c]
+	if !ok {
+		return true
+	}
+
+	return compareVersions(ci.Version, required) >= 0
+}
+
+// UnsupportedCapabilityError is returned when a request
+// uses an API the cluster version doesn't provide
+type UnsupportedCapabilityError struct {
+	Capability Capability
+	Version    string
+	Required   string
+}
+
+func (e *UnsupportedCapabilityError) Error() string {
+	return fmt.Sprintf("%s requires elasticsearch %s or later, the cluster runs %s", e.Capability, e.Required, e.Version)
+}
+
+// WithVersionDetection detects the version and license of
+// the cluster when the backend is created, and rejects
+// requests using APIs the cluster doesn't support
+func WithVersionDetection() ElasticBackendOption {
+	return func(b *ElasticBackend) {
+		b.detect = true
+	}
+}
+
+// DetectCluster reads the version and license of the cluster,
+// gating requests on its capabilities from then on
+func (b *ElasticBackend) DetectCluster(ctx context.Context) (*ClusterInfo, error) {
+	if len(b.nodes) == 0 {
+		return nil, fmt.Errorf("failed detecting cluster version: no nodes configured")
+	}
+
+	res, _, err := b.client.Ping(b.nodes[0]).Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed detecting cluster version: %w", err)
+	}
+
+	info := &ClusterInfo{
+		Version: res.Version.Number,
+		Flavor:  res.Version.BuildFlavor,
+	}
+
+	// the license is unavailable on OSS distributions
+	if xpack, err := b.client.XPackInfo().Do(ctx); err == nil {
+		info.License = xpack.License.Type
+	}
+
+	b.cluster = info
+	return info, nil
+}
+
+// ClusterInfo returns the detected cluster version and
+// license, or nil when detection isn't enabled
+func (b *ElasticBackend) ClusterInfo() *ClusterInfo {
+	return b.cluster
+}
+
+// Require returns an UnsupportedCapabilityError if the
+// detected cluster doesn't provide the capability
+func (b *ElasticBackend) Require(c Capability) error {
+	if b.cluster.Supports(c) {
+		return nil
+	}
+
+	return &UnsupportedCapabilityError{
+		Capability: c,
+		Version:    b.cluster.Version,
+		Required:   capabilityVersions[c],
+	}
+}
+
+// checkCapabilities verifies that the cluster
+// supports the APIs used by a query
+func (b *ElasticBackend) checkCapabilities(builder *QueryBuilder) error {
+	var required []Capability
+	if builder.KNN() != nil {
+		required = append(required, CapabilityKNN)
+	}
+	if builder.PointInTime() != nil {
+		required = append(required, CapabilityPointInTime)
+	}
+	if len(builder.runtimeMappings) > 0 {
+		required = append(required, CapabilityRuntimeMappings)
+	}
+
+	for _, c := range required {
+		if err := b.Require(c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// compareVersions compares two dotted version numbers,
+// ignoring qualifiers such as "-SNAPSHOT"
+func compareVersions(a, b string) int {
+	pa, pb := parseVersion(a), parseVersion(b)
+	for i := range pa {
+		if pa[i] != pb[i] {
+			if pa[i] < pb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	return 0
+}
+
+func parseVersion(v string) [3]int {
+	var parts [3]int
+	v, _, _ = strings.Cut(v, "-")
+	for i, s := range strings.SplitN(v, ".", 3) {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			break
+		}
+
+		parts[i] = n
+	}
+
+	return parts
+}
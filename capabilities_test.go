@@ -0,0 +1,47 @@
+package reveald
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ClusterInfo_Supports(t *testing.T) {
+	table := []struct {
+		name       string
+		cluster    *ClusterInfo
+		capability Capability
+		expected   bool
+	}{
+		{"unknown cluster", nil, CapabilityKNN, true},
+		{"unknown version", &ClusterInfo{}, CapabilityKNN, true},
+		{"older version", &ClusterInfo{Version: "7.17.9"}, CapabilityKNN, false},
+		{"same version", &ClusterInfo{Version: "8.0.0"}, CapabilityKNN, true},
+		{"newer version", &ClusterInfo{Version: "8.11.1"}, CapabilityESQL, true},
+		{"older minor version", &ClusterInfo{Version: "8.9.0"}, CapabilityESQL, false},
+		{"snapshot version", &ClusterInfo{Version: "7.10.0-SNAPSHOT"}, CapabilityPointInTime, true},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.cluster.Supports(tt.capability))
+		})
+	}
+}
+
+func Test_ElasticBackend_CheckCapabilities(t *testing.T) {
+	b := &ElasticBackend{cluster: &ClusterInfo{Version: "7.9.3"}}
+
+	qb := NewQueryBuilder(NewRequest(), "products")
+	assert.NoError(t, b.checkCapabilities(qb))
+
+	qb.WithRuntimeMappings(elastic.RuntimeMappings{"day": map[string]interface{}{"type": "keyword"}})
+	err := b.checkCapabilities(qb)
+
+	var uce *UnsupportedCapabilityError
+	assert.True(t, errors.As(err, &uce))
+	assert.Equal(t, CapabilityRuntimeMappings, uce.Capability)
+	assert.Equal(t, "7.11.0", uce.Required)
+}
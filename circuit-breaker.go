@@ -0,0 +1,169 @@
+package reveald
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// ErrCircuitOpen is returned for requests to Elasticsearch
+// rejected by an open circuit breaker
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of a circuit breaker
+type CircuitState int
+
+const (
+	// CircuitClosed lets every request through
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects every request with ErrCircuitOpen
+	CircuitOpen
+	// CircuitHalfOpen lets a single trial request through,
+	// closing the circuit when it succeeds
+	CircuitHalfOpen
+)
+
+// CircuitBreaker stops sending requests to an Elasticsearch
+// cluster failing consecutively, failing them fast instead,
+// until the cluster has had time to recover
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+// NewCircuitBreaker returns a circuit breaker opening after
+// threshold consecutive failures, and letting a trial request
+// through once cooldown has passed
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// WithCircuitBreaker fails searches fast while the circuit
+// breaker is open. Searches failing with a connection error or
+// a 5xx response count as failures, while rejected (429) and
+// invalid searches don't, and neither do health checks, sniffing
+// and other requests of the client
+func WithCircuitBreaker(breaker *CircuitBreaker) ElasticBackendOption {
+	return func(b *ElasticBackend) {
+		b.breaker = breaker
+	}
+}
+
+// State returns the current state of the circuit breaker
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == CircuitOpen && cb.now().Sub(cb.openedAt) >= cb.cooldown {
+		return CircuitHalfOpen
+	}
+
+	return cb.state
+}
+
+// allow returns whether a request may be sent
+func (cb *CircuitBreaker) allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case CircuitOpen:
+		if cb.now().Sub(cb.openedAt) < cb.cooldown {
+			return false
+		}
+		cb.state = CircuitHalfOpen
+		cb.trial = true
+		return true
+	case CircuitHalfOpen:
+		if cb.trial {
+			return false
+		}
+		cb.trial = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record updates the circuit breaker with the outcome of a request
+func (cb *CircuitBreaker) record(failed bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.trial = false
+	if !failed {
+		cb.state = CircuitClosed
+		cb.failures = 0
+		return
+	}
+
+	cb.failures++
+	if cb.state == CircuitHalfOpen || cb.failures >= cb.threshold {
+		cb.state = CircuitOpen
+		cb.openedAt = cb.now()
+	}
+}
+
+// release lets another trial request through, without
+// recording the outcome of the current one
+func (cb *CircuitBreaker) release() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.trial = false
+}
+
+// begin returns ErrCircuitOpen when a search
+// may not be sent to Elasticsearch
+func (cb *CircuitBreaker) begin() error {
+	if cb == nil || cb.allow() {
+		return nil
+	}
+
+	return ErrCircuitOpen
+}
+
+// end records the outcome of a search let through by begin
+func (cb *CircuitBreaker) end(ctx context.Context, err error) {
+	if cb == nil {
+		return
+	}
+
+	if err != nil && ctx.Err() != nil {
+		// cancelled searches say nothing about the cluster
+		cb.release()
+		return
+	}
+
+	cb.record(clusterFailure(err))
+}
+
+// clusterFailure returns whether an error is caused by an
+// unreachable or failing cluster, rather than by the search
+func clusterFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var ee *elastic.Error
+	if errors.As(err, &ee) {
+		return ee.Status >= http.StatusInternalServerError
+	}
+
+	return true
+}
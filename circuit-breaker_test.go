@@ -0,0 +1,113 @@
+package reveald
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CircuitBreaker_States(t *testing.T) {
+	now := time.Now()
+	cb := NewCircuitBreaker(2, time.Minute)
+	cb.now = func() time.Time { return now }
+
+	assert.True(t, cb.allow())
+	cb.record(true)
+	assert.Equal(t, CircuitClosed, cb.State())
+
+	assert.True(t, cb.allow())
+	cb.record(true)
+	assert.Equal(t, CircuitOpen, cb.State())
+	assert.False(t, cb.allow())
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, CircuitHalfOpen, cb.State())
+	assert.True(t, cb.allow())
+	assert.False(t, cb.allow(), "only a single trial request is let through")
+
+	cb.record(true)
+	assert.Equal(t, CircuitOpen, cb.State())
+
+	now = now.Add(time.Minute)
+	assert.True(t, cb.allow())
+	cb.record(false)
+	assert.Equal(t, CircuitClosed, cb.State())
+	assert.True(t, cb.allow())
+}
+
+func Test_ElasticBackend_CircuitBreaker(t *testing.T) {
+	table := []struct {
+		name     string
+		status   int
+		multiple bool
+		opens    bool
+	}{
+		{"unavailable", http.StatusServiceUnavailable, false, true},
+		{"unavailable multi search", http.StatusServiceUnavailable, true, true},
+		{"rejected", http.StatusTooManyRequests, false, false},
+		{"invalid", http.StatusBadRequest, false, false},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				w.WriteHeader(tt.status)
+			}))
+			t.Cleanup(srv.Close)
+
+			b, err := NewElasticBackend([]string{srv.URL}, WithSniff(false), WithHealthCheck(false),
+				WithCircuitBreaker(NewCircuitBreaker(2, time.Minute)))
+			assert.NoError(t, err)
+
+			execute := func() error {
+				qb := NewQueryBuilder(NewRequest(), "products")
+				if tt.multiple {
+					_, err := b.ExecuteMultiple(context.Background(), []*QueryBuilder{qb})
+					return err
+				}
+				_, err := b.Execute(context.Background(), qb)
+				return err
+			}
+
+			for i := 0; i < 2; i++ {
+				err = execute()
+				assert.Error(t, err)
+				assert.NotErrorIs(t, err, ErrCircuitOpen)
+			}
+
+			err = execute()
+			if tt.opens {
+				assert.ErrorIs(t, err, ErrCircuitOpen)
+				assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+			} else {
+				assert.NotErrorIs(t, err, ErrCircuitOpen)
+				assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+			}
+		})
+	}
+}
+
+func Test_ElasticBackend_CircuitBreaker_Client(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+
+	cb := NewCircuitBreaker(1, time.Minute)
+	b, err := NewElasticBackend([]string{srv.URL}, WithSniff(false), WithHealthCheck(false),
+		WithCircuitBreaker(cb))
+	assert.NoError(t, err)
+
+	// requests of the client other than searches bypass the breaker
+	_, err = b.client.ClusterHealth().Do(context.Background())
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, CircuitClosed, cb.State())
+}
@@ -0,0 +1,113 @@
+package reveald
+
+import (
+	"context"
+	"sync"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// WithRequestCoalescing lets identical searches executed
+// concurrently share a single request to Elasticsearch, rather
+// than repeating it, e.g. for popular facet queries under bursty
+// traffic. Searches are identical when their built requests are,
+// and the shared request carries the search id of the first one.
+// Multi searches and searches using a point in time aren't
+// coalesced
+func WithRequestCoalescing() ElasticBackendOption {
+	return func(b *ElasticBackend) {
+		b.coalescer = &coalescer{flights: make(map[string]*flight)}
+	}
+}
+
+type coalescer struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// flight is a search in flight, shared by its waiters,
+// and cancelled once every waiter has given up on it
+type flight struct {
+	done    chan struct{}
+	result  *elastic.SearchResult
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// coalescingKey returns the key identifying searches
+// identical to a search, and whether it may be coalesced
+func (b *ElasticBackend) coalescingKey(builder *QueryBuilder) (string, bool) {
+	if b.coalescer == nil {
+		return "", false
+	}
+
+	return cacheKey(builder)
+}
+
+// do executes search, unless an identical search is already
+// in flight, in which case its response is shared
+func (c *coalescer) do(ctx context.Context, key string, search func(context.Context) (*elastic.SearchResult, error)) (*elastic.SearchResult, error) {
+	c.mu.Lock()
+	f, ok := c.flights[key]
+	if !ok {
+		// the search outlives the caller starting it,
+		// as long as other callers wait for it
+		sctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &flight{done: make(chan struct{}), cancel: cancel}
+		c.flights[key] = f
+
+		go func() {
+			f.result, f.err = search(sctx)
+
+			c.mu.Lock()
+			c.leave(key, f)
+			c.mu.Unlock()
+
+			cancel()
+			close(f.done)
+		}()
+	}
+	f.waiters++
+	c.mu.Unlock()
+
+	select {
+	case <-f.done:
+		if f.err != nil {
+			return nil, f.err
+		}
+		return copySearchResult(f.result), nil
+	case <-ctx.Done():
+		c.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			c.leave(key, f)
+			f.cancel()
+		}
+		c.mu.Unlock()
+
+		return nil, ctx.Err()
+	}
+}
+
+// leave stops sharing a flight with new callers
+func (c *coalescer) leave(key string, f *flight) {
+	if c.flights[key] == f {
+		delete(c.flights, key)
+	}
+}
+
+// copySearchResult returns a copy of a response, where the
+// aggregations may be replaced without affecting other waiters,
+// as done when unwrapping aggregations
+func copySearchResult(result *elastic.SearchResult) *elastic.SearchResult {
+	cp := *result
+	if result.Aggregations != nil {
+		cp.Aggregations = make(elastic.Aggregations, len(result.Aggregations))
+		for name, agg := range result.Aggregations {
+			cp.Aggregations[name] = agg
+		}
+	}
+
+	return &cp
+}
@@ -0,0 +1,100 @@
+package reveald
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newCoalescingBackend(t *testing.T, calls *int32, release chan struct{}) *ElasticBackend {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		<-release
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"hits": {"total": {"value": 3, "relation": "eq"}, "hits": []}, "aggregations": {"brand": {"buckets": []}}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	b, err := NewElasticBackend([]string{srv.URL}, WithSniff(false), WithHealthCheck(false), WithRequestCoalescing())
+	assert.NoError(t, err)
+	return b
+}
+
+// waitForWaiters blocks until n callers wait for the in-flight searches
+func waitForWaiters(t *testing.T, b *ElasticBackend, n int) {
+	assert.Eventually(t, func() bool {
+		b.coalescer.mu.Lock()
+		defer b.coalescer.mu.Unlock()
+
+		waiters := 0
+		for _, f := range b.coalescer.flights {
+			waiters += f.waiters
+		}
+		return waiters == n
+	}, time.Second, time.Millisecond)
+}
+
+func Test_ElasticBackend_RequestCoalescing(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	b := newCoalescingBackend(t, &calls, release)
+
+	results := make([]*Result, 5)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			r, err := b.Execute(context.Background(), NewQueryBuilder(NewRequest(), "products"))
+			assert.NoError(t, err)
+			results[i] = r
+		}(i)
+	}
+
+	waitForWaiters(t, b, len(results))
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	for _, r := range results {
+		assert.Equal(t, int64(3), r.TotalHitCount)
+	}
+
+	delete(results[0].RawResult().Aggregations, "brand")
+	assert.Contains(t, results[1].RawResult().Aggregations, "brand")
+}
+
+func Test_ElasticBackend_RequestCoalescing_Cancellation(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	b := newCoalescingBackend(t, &calls, release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error, 1)
+	go func() {
+		_, err := b.Execute(ctx, NewQueryBuilder(NewRequest(), "products"))
+		cancelled <- err
+	}()
+
+	completed := make(chan error, 1)
+	go func() {
+		_, err := b.Execute(context.Background(), NewQueryBuilder(NewRequest(), "products"))
+		completed <- err
+	}()
+
+	waitForWaiters(t, b, 2)
+	cancel()
+	assert.ErrorIs(t, <-cancelled, context.Canceled)
+
+	close(release)
+	assert.NoError(t, <-completed)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
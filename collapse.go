@@ -0,0 +1,32 @@
+package reveald
+
+import "github.com/olivere/elastic/v7"
+
+// CollapsedHitsKey is the hit key holding the other
+// documents of a collapsed group
+const CollapsedHitsKey = "_collapsed"
+
+// WithCollapse collapses hits sharing the same value of a
+// field (e.g. a product group id) into a single representative
+// hit, holding up to innerHitsSize other documents of its group
+// under CollapsedHitsKey
+func (qb *QueryBuilder) WithCollapse(field string, innerHitsSize int) {
+	collapse := elastic.NewCollapseBuilder(field)
+	if innerHitsSize > 0 {
+		collapse = collapse.InnerHit(elastic.NewInnerHit().
+			Name(CollapsedHitsKey).
+			Size(innerHitsSize))
+	}
+
+	qb.collapse = collapse
+}
+
+// Collapse returns the field collapsing of the search, if any
+func (qb *QueryBuilder) Collapse() *elastic.CollapseBuilder {
+	return qb.collapse
+}
+
+// collapsedHits maps the inner hits of a collapsed group
+func collapsedHits(hit *elastic.SearchHit, numbers NumberDecoder) ([]map[string]interface{}, error) {
+	return mapInnerHits(hit.InnerHits[CollapsedHitsKey], numbers)
+}
@@ -0,0 +1,29 @@
+package reveald
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MapSearchResult_Collapsed(t *testing.T) {
+	res := &elastic.SearchResult{
+		Hits: &elastic.SearchHits{
+			Hits: []*elastic.SearchHit{{
+				Source: json.RawMessage(`{"id":1}`),
+				InnerHits: map[string]*elastic.SearchHitInnerHits{
+					CollapsedHitsKey: {Hits: &elastic.SearchHits{Hits: []*elastic.SearchHit{
+						{Source: json.RawMessage(`{"id":2}`)},
+						{Source: json.RawMessage(`{"id":3}`)},
+					}}},
+				},
+			}},
+		},
+	}
+
+	r, err := mapSearchResult(res, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{{"id": 2.0}, {"id": 3.0}}, r.Hits[0][CollapsedHitsKey])
+}
@@ -0,0 +1,81 @@
+package reveald
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// HitCounter is implemented by backends able to count
+// the hits of a query without searching for them
+type HitCounter interface {
+	Count(context.Context, *QueryBuilder) (int64, error)
+}
+
+// Count returns the number of hits of a search query request,
+// as filtered by the endpoint's features, without fetching hits
+// or aggregations, e.g. for hit count badges
+func (e *Endpoint) Count(ctx context.Context, request *Request) (int64, error) {
+	builder, err := e.PrepareBuilder(ctx, request)
+	if err != nil {
+		return 0, err
+	}
+
+	if c, ok := e.backend.(HitCounter); ok {
+		return c.Count(ctx, builder)
+	}
+
+	return countBySearch(ctx, e.backend, builder)
+}
+
+// Count returns the number of documents matching the query of the
+// builder, including its post filter, using the count API. Queries
+// the count API doesn't support, such as kNN queries, are counted
+// with a search for no hits instead
+func (b *ElasticBackend) Count(ctx context.Context, builder *QueryBuilder) (int64, error) {
+	if builder.KNN() != nil || builder.PointInTime() != nil || len(builder.runtimeMappings) > 0 {
+		return countBySearch(ctx, b, builder)
+	}
+
+	svc := b.client.Count(builder.Indices()...).Query(builder.filterQuery())
+	if id, ok := SearchIDFromContext(ctx); ok {
+		svc = svc.Header(SearchIDHeader, id)
+	}
+	if builder.Preference() != "" {
+		svc = svc.Preference(builder.Preference())
+	}
+	if len(builder.Routing()) > 0 {
+		svc = svc.Routing(strings.Join(builder.Routing(), ","))
+	}
+	if builder.TerminateAfter() > 0 {
+		svc = svc.TerminateAfter(builder.TerminateAfter())
+	}
+	if builder.IgnoreUnavailable() != nil {
+		svc = svc.IgnoreUnavailable(*builder.IgnoreUnavailable())
+	}
+	if builder.AllowNoIndices() != nil {
+		svc = svc.AllowNoIndices(*builder.AllowNoIndices())
+	}
+
+	count, err := svc.Do(ctx)
+	if err != nil {
+		return 0, searchError(err)
+	}
+
+	return count, nil
+}
+
+// countBySearch counts the hits of a query with a search
+// for no hits, without aggregations, counting every hit
+func countBySearch(ctx context.Context, backend Backend, builder *QueryBuilder) (int64, error) {
+	builder.dropAggregations()
+	builder.Selection().Update(WithPageSize(0), WithOffset(0))
+	builder.WithTrackTotalHits(true)
+
+	r, err := backend.Execute(ctx, builder)
+	if err != nil {
+		return 0, fmt.Errorf("backend failed counting request: %w", err)
+	}
+
+	return r.TotalHitCount, nil
+}
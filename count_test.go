@@ -0,0 +1,60 @@
+package reveald
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Endpoint_Count_BySearch(t *testing.T) {
+	b := &fakeBackend{}
+	e := NewEndpoint(b, WithIndices("products"))
+	assert.NoError(t, e.Register(&fakePreparable{}, &offsetFeature{offset: 10}))
+
+	_, err := e.Count(context.Background(), NewRequest())
+	assert.NoError(t, err)
+	assert.Len(t, b.builders, 1)
+
+	src := sourceJSON(t, b.builders[0])
+	assert.NotContains(t, src, "aggregations")
+	assert.Equal(t, float64(0), src["size"])
+	assert.Equal(t, float64(0), src["from"])
+	assert.Equal(t, true, src["track_total_hits"])
+}
+
+type termFeature struct{}
+
+func (termFeature) Process(qb *QueryBuilder, next FeatureFunc) (*Result, error) {
+	qb.With(elastic.NewTermQuery("brand", "acme"))
+	qb.Aggregation("brand", elastic.NewTermsAggregation().Field("brand"))
+	return next(qb)
+}
+
+func Test_Endpoint_Count_API(t *testing.T) {
+	b := newTestBackend(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/products/_count", r.URL.Path)
+		assert.Equal(t, "count-1", r.Header.Get(SearchIDHeader))
+
+		var body map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must": map[string]interface{}{"term": map[string]interface{}{"brand": "acme"}},
+			},
+		}, body["query"])
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"count": 42}`))
+	}))
+
+	e := NewEndpoint(b, WithIndices("products"))
+	assert.NoError(t, e.Register(termFeature{}))
+
+	count, err := e.Count(ContextWithSearchID(context.Background(), "count-1"), NewRequest())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), count)
+}
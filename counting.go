@@ -0,0 +1,160 @@
+package reveald
+
+import (
+	"encoding/json"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// CountingStrategy decides, per facet, how a selection affects
+// hits and aggregation counts. A conjunctive facet filters the
+// query, narrowing both hits and the counts of every facet,
+// including its own. A disjunctive facet filters hits through
+// the post filter, and narrows the counts of every aggregation
+// except its own, giving multi-select facet behavior
+type CountingStrategy interface {
+	IsDisjunctive(facet string) bool
+}
+
+type countingStrategy func(string) bool
+
+func (cs countingStrategy) IsDisjunctive(facet string) bool {
+	return cs(facet)
+}
+
+// ConjunctiveCounting narrows all counts by every
+// selection, which is the default strategy
+var ConjunctiveCounting CountingStrategy = countingStrategy(func(string) bool {
+	return false
+})
+
+// DisjunctiveCounting counts the specified facets (or all
+// facets, when none are specified) disjunctively
+func DisjunctiveCounting(facets ...string) CountingStrategy {
+	if len(facets) == 0 {
+		return countingStrategy(func(string) bool {
+			return true
+		})
+	}
+
+	set := make(map[string]bool, len(facets))
+	for _, f := range facets {
+		set[f] = true
+	}
+
+	return countingStrategy(func(facet string) bool {
+		return set[facet]
+	})
+}
+
+// WithCountingStrategy defines how facet selections
+// affect hits and aggregation counts
+func WithCountingStrategy(strategy CountingStrategy) EndpointOption {
+	return func(e *Endpoint) {
+		e.counting = strategy
+	}
+}
+
+// WithDisjunctiveFacets gives multi-select facet behavior for the
+// specified facets, or all facets when none are specified: their
+// selections narrow the hits through the post filter, and every
+// aggregation is counted excluding its own facet's selection
+func WithDisjunctiveFacets(facets ...string) EndpointOption {
+	return WithCountingStrategy(DisjunctiveCounting(facets...))
+}
+
+type facetFilter struct {
+	facet string
+	query elastic.Query
+}
+
+// SetCountingStrategy defines how facet selections
+// affect hits and aggregation counts
+func (qb *QueryBuilder) SetCountingStrategy(strategy CountingStrategy) {
+	qb.counting = strategy
+}
+
+// FacetFilter filters documents on the selection of a facet,
+// where the facet name is the name of its aggregation. The
+// counting strategy decides whether the selection narrows
+// the query or the post filter
+func (qb *QueryBuilder) FacetFilter(facet string, query elastic.Query) {
+	if qb.counting == nil || !qb.counting.IsDisjunctive(facet) {
+		qb.With(query)
+		return
+	}
+
+	qb.facetFilters = append(qb.facetFilters, facetFilter{facet, query})
+	qb.PostFilterWith(query)
+}
+
+// FacetAggregation adds an aggregation counted as part of a
+// facet with a different name, e.g. the bounds of a range
+// slider, so that it isn't narrowed by the facet's own selection
+func (qb *QueryBuilder) FacetAggregation(facet, name string, agg elastic.Aggregation) {
+	if qb.aggFacets == nil {
+		qb.aggFacets = make(map[string]string)
+	}
+
+	qb.aggFacets[name] = facet
+	qb.Aggregation(name, agg)
+}
+
+// facetAggregation wraps an aggregation in a filter aggregation
+// holding the disjunctive selections of all other facets
+func (qb *QueryBuilder) facetAggregation(name string, agg elastic.Aggregation) (elastic.Aggregation, bool) {
+	facet := name
+	if f, ok := qb.aggFacets[name]; ok {
+		facet = f
+	}
+
+	var others []elastic.Query
+	for _, ff := range qb.facetFilters {
+		if ff.facet != facet {
+			others = append(others, ff.query)
+		}
+	}
+
+	if len(others) == 0 {
+		return agg, false
+	}
+
+	return elastic.NewFilterAggregation().
+		Filter(elastic.NewBoolQuery().Must(others...)).
+		SubAggregation(name, agg), true
+}
+
+// UnwrapAggregations restores the shape of aggregations wrapped
+// by the counting strategy, as well as aggregations only sent once
+// for being identical to another one, so that features can read
+// them by name
+func (qb *QueryBuilder) UnwrapAggregations(result *elastic.SearchResult) {
+	if result == nil {
+		return
+	}
+	defer qb.restoreAliases(result)
+
+	if len(qb.facetFilters) == 0 {
+		return
+	}
+
+	for name := range qb.aggs {
+		if _, wrapped := qb.facetAggregation(name, nil); !wrapped {
+			continue
+		}
+
+		raw, ok := result.Aggregations[name]
+		if !ok {
+			continue
+		}
+
+		var filter map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &filter); err != nil {
+			continue
+		}
+
+		if inner, ok := filter[name]; ok {
+			result.Aggregations[name] = inner
+		}
+	}
+}
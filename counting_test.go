@@ -0,0 +1,123 @@
+package reveald
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+func countingBuilder(strategy CountingStrategy) *QueryBuilder {
+	qb := NewQueryBuilder(NewRequest())
+	qb.SetCountingStrategy(strategy)
+	qb.Aggregation("color", elastic.NewTermsAggregation().Field("color"))
+	qb.Aggregation("size", elastic.NewTermsAggregation().Field("size"))
+	qb.Aggregation("price", elastic.NewStatsAggregation().Field("price"))
+	qb.FacetFilter("color", elastic.NewTermQuery("color", "red"))
+	return qb
+}
+
+func Test_Counting_Conjunctive(t *testing.T) {
+	for _, strategy := range []CountingStrategy{nil, ConjunctiveCounting, DisjunctiveCounting("size")} {
+		src := sourceJSON(t, countingBuilder(strategy))
+
+		assert.NotContains(t, src, "post_filter")
+		assert.Contains(t, src["query"].(map[string]interface{})["bool"], "must")
+
+		aggs := src["aggregations"].(map[string]interface{})
+		for _, name := range []string{"color", "size", "price"} {
+			assert.NotContains(t, aggs[name], "filter")
+		}
+	}
+}
+
+func Test_Counting_Disjunctive(t *testing.T) {
+	for _, strategy := range []CountingStrategy{DisjunctiveCounting(), DisjunctiveCounting("color")} {
+		src := sourceJSON(t, countingBuilder(strategy))
+
+		assert.Contains(t, src, "post_filter")
+		assert.NotContains(t, src["query"].(map[string]interface{})["bool"], "must")
+
+		aggs := src["aggregations"].(map[string]interface{})
+		assert.NotContains(t, aggs["color"], "filter", "own selection must not narrow its counts")
+		for _, name := range []string{"size", "price"} {
+			agg := aggs[name].(map[string]interface{})
+			assert.Contains(t, agg, "filter")
+			assert.Contains(t, agg["aggregations"], name)
+		}
+	}
+}
+
+func Test_Counting_Mixed(t *testing.T) {
+	qb := countingBuilder(DisjunctiveCounting("color"))
+	qb.FacetFilter("size", elastic.NewTermQuery("size", "M"))
+
+	src := sourceJSON(t, qb)
+
+	data, err := json.Marshal(src["query"])
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"size":"M"`)
+	assert.NotContains(t, string(data), `"color":"red"`)
+
+	aggs := src["aggregations"].(map[string]interface{})
+	assert.NotContains(t, aggs["color"], "filter")
+	assert.Contains(t, aggs["size"], "filter")
+}
+
+func Test_Counting_UnwrapAggregations(t *testing.T) {
+	qb := countingBuilder(DisjunctiveCounting())
+	result := &elastic.SearchResult{
+		Aggregations: elastic.Aggregations{
+			"color": json.RawMessage(`{"buckets":[{"key":"red","doc_count":3}]}`),
+			"size":  json.RawMessage(`{"doc_count":3,"size":{"buckets":[{"key":"M","doc_count":2}]}}`),
+			"price": json.RawMessage(`{"doc_count":3,"price":{"count":3,"min":1,"max":5}}`),
+		},
+	}
+
+	qb.UnwrapAggregations(result)
+
+	size, ok := result.Aggregations.Terms("size")
+	assert.True(t, ok)
+	assert.Len(t, size.Buckets, 1)
+	assert.Equal(t, int64(2), size.Buckets[0].DocCount)
+
+	price, ok := result.Aggregations.Stats("price")
+	assert.True(t, ok)
+	assert.Equal(t, int64(3), price.Count)
+
+	color, ok := result.Aggregations.Terms("color")
+	assert.True(t, ok)
+	assert.Len(t, color.Buckets, 1)
+}
+
+type facetFeature struct {
+	facet string
+	value string
+}
+
+func (f *facetFeature) Process(qb *QueryBuilder, next FeatureFunc) (*Result, error) {
+	qb.Aggregation(f.facet, elastic.NewTermsAggregation().Field(f.facet))
+	qb.FacetFilter(f.facet, elastic.NewTermQuery(f.facet, f.value))
+	return next(qb)
+}
+
+func Test_Endpoint_WithDisjunctiveFacets(t *testing.T) {
+	backend := &fakeBackend{}
+	e := NewEndpoint(backend, WithIndices("-"), WithDisjunctiveFacets())
+	assert.NoError(t, e.Register(&facetFeature{"color", "red"}, &facetFeature{"size", "M"}))
+
+	_, err := e.Execute(context.Background(), NewRequest())
+	assert.NoError(t, err)
+
+	src := sourceJSON(t, backend.builders[0])
+	assert.Contains(t, src, "post_filter")
+
+	aggs := src["aggregations"].(map[string]interface{})
+	for facet, other := range map[string]string{"color": `"size":"M"`, "size": `"color":"red"`} {
+		data, err := json.Marshal(aggs[facet].(map[string]interface{})["filter"])
+		assert.NoError(t, err)
+		assert.Contains(t, string(data), other)
+	}
+}
@@ -0,0 +1,89 @@
+package reveald
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// ResultDebug holds troubleshooting details of an executed
+// request, where Request is the rendered search request,
+// and Response the raw Elasticsearch response, unless left
+// out with WithoutDebugResponse
+type ResultDebug struct {
+	Request  json.RawMessage
+	Response json.RawMessage
+	Features []*ResultFeatureTiming
+}
+
+// ResultFeatureTiming holds the time spent in a
+// feature, excluding the features it calls
+type ResultFeatureTiming struct {
+	Feature  string
+	Duration time.Duration
+}
+
+// WithDebug attaches debug details to every result
+func WithDebug() EndpointOption {
+	return func(e *Endpoint) {
+		e.debug = true
+	}
+}
+
+// WithDebugParam attaches debug details to the results of
+// requests where the specified parameter is truthy
+func WithDebugParam(param string) EndpointOption {
+	return func(e *Endpoint) {
+		e.debugParam = param
+	}
+}
+
+// WithoutDebugResponse keeps the raw response out of the debug
+// details of the result, e.g. when features mask the hits
+func (qb *QueryBuilder) WithoutDebugResponse() {
+	qb.noDebugResponse = true
+}
+
+func (e *Endpoint) debugging(request *Request) bool {
+	if e.debug {
+		return true
+	}
+	if e.debugParam == "" {
+		return false
+	}
+
+	p, err := request.Get(e.debugParam)
+	return err == nil && p.IsTruthy()
+}
+
+// debugMetrics records feature timings for a single
+// request, while forwarding all measurements
+type debugMetrics struct {
+	Metrics
+	mu    sync.Mutex
+	debug *ResultDebug
+}
+
+func (dm *debugMetrics) FeatureProcessed(ctx context.Context, feature string, duration time.Duration) {
+	dm.mu.Lock()
+	dm.debug.Features = append(dm.debug.Features, &ResultFeatureTiming{
+		Feature:  feature,
+		Duration: duration,
+	})
+	dm.mu.Unlock()
+
+	dm.Metrics.FeatureProcessed(ctx, feature, duration)
+}
+
+// capture records the rendered request and raw response,
+// ignoring anything that can't be rendered
+func (dm *debugMetrics) capture(qb *QueryBuilder, r *Result) {
+	if src, err := qb.BuildSource(); err == nil {
+		dm.debug.Request, _ = json.Marshal(src)
+	}
+
+	if r != nil && r.RawResult() != nil && !qb.noDebugResponse {
+		dm.debug.Response, _ = json.Marshal(r.RawResult())
+	}
+}
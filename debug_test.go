@@ -0,0 +1,49 @@
+package reveald
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Endpoint_Debug(t *testing.T) {
+	table := []struct {
+		name     string
+		opts     []EndpointOption
+		request  *Request
+		expected bool
+	}{
+		{"disabled", nil, NewRequest(NewParameter("debug", "true")), false},
+		{"always", []EndpointOption{WithDebug()}, NewRequest(), true},
+		{"param missing", []EndpointOption{WithDebugParam("debug")}, NewRequest(), false},
+		{"param false", []EndpointOption{WithDebugParam("debug")}, NewRequest(NewParameter("debug", "false")), false},
+		{"param true", []EndpointOption{WithDebugParam("debug")}, NewRequest(NewParameter("debug", "true")), true},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEndpoint(&fakeBackend{}, WithIndices("-"), tt.opts...)
+			assert.NoError(t, e.Register(&offsetFeature{offset: 20}))
+
+			r, err := e.Execute(context.Background(), tt.request)
+			assert.NoError(t, err)
+
+			if !tt.expected {
+				assert.Nil(t, r.Debug)
+				return
+			}
+
+			assert.NotNil(t, r.Debug)
+			assert.Nil(t, r.Debug.Response)
+
+			var src map[string]interface{}
+			assert.NoError(t, json.Unmarshal(r.Debug.Request, &src))
+			assert.Equal(t, float64(20), src["from"])
+
+			assert.Len(t, r.Debug.Features, 1)
+			assert.Equal(t, "reveald.offsetFeature", r.Debug.Features[0].Feature)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package reveald
+
+import "fmt"
+
+// DeduplicateHits removes hits sharing the same value of a
+// property, keeping the highest-scored hit of each value at the
+// position of its first occurrence, and returns the number of hits
+// removed, which is also added to DuplicateHitCount. Hits without
+// the property are kept. When scores aren't available, the first
+// occurrence is kept
+func (r *Result) DeduplicateHits(property string) int {
+	scores := r.hitScores()
+
+	type kept struct {
+		index int
+		score float64
+	}
+
+	seen := make(map[string]*kept)
+	hits := make([]map[string]interface{}, 0, len(r.Hits))
+	for i, hit := range r.Hits {
+		v, ok := hit[property]
+		if !ok {
+			hits = append(hits, hit)
+			continue
+		}
+
+		key := fmt.Sprint(v)
+		if k, ok := seen[key]; ok {
+			if scores != nil && scores[i] > k.score {
+				hits[k.index] = hit
+				k.score = scores[i]
+			}
+			continue
+		}
+
+		var score float64
+		if scores != nil {
+			score = scores[i]
+		}
+
+		seen[key] = &kept{len(hits), score}
+		hits = append(hits, hit)
+	}
+
+	removed := len(r.Hits) - len(hits)
+	r.Hits = hits
+	r.DuplicateHitCount += int64(removed)
+	return removed
+}
+
+// hitScores returns the score of each hit, as long as
+// the hits still correspond to the raw result
+func (r *Result) hitScores() []float64 {
+	if r.result == nil || r.result.Hits == nil || len(r.result.Hits.Hits) != len(r.Hits) {
+		return nil
+	}
+
+	scores := make([]float64, len(r.Hits))
+	for i, hit := range r.result.Hits.Hits {
+		if hit.Score == nil {
+			return nil
+		}
+
+		scores[i] = *hit.Score
+	}
+
+	return scores
+}
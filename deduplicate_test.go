@@ -0,0 +1,38 @@
+package reveald
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Result_DeduplicateHits(t *testing.T) {
+	score := func(s float64) *float64 { return &s }
+	res := &elastic.SearchResult{
+		Hits: &elastic.SearchHits{
+			Hits: []*elastic.SearchHit{
+				{Score: score(1), Source: json.RawMessage(`{"id":1,"sku":"a"}`)},
+				{Score: score(2), Source: json.RawMessage(`{"id":2,"sku":"b"}`)},
+				{Score: score(3), Source: json.RawMessage(`{"id":3,"sku":"a"}`)},
+				{Score: score(4), Source: json.RawMessage(`{"id":4,"sku":"b"}`)},
+				{Score: score(5), Source: json.RawMessage(`{"id":5}`)},
+			},
+		},
+	}
+
+	r, err := mapSearchResult(res, nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, r.DeduplicateHits("sku"))
+	assert.Equal(t, []map[string]interface{}{
+		{"id": 3.0, "sku": "a"},
+		{"id": 4.0, "sku": "b"},
+		{"id": 5.0},
+	}, r.Hits)
+	assert.Equal(t, int64(2), r.DuplicateHitCount)
+
+	assert.Equal(t, 0, r.DeduplicateHits("sku"))
+	assert.Len(t, r.Hits, 3)
+}
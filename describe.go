@@ -0,0 +1,61 @@
+package reveald
+
+// ParameterKind is the value format of a request parameter
+type ParameterKind string
+
+const (
+	// ParameterValue is a parameter with a single value
+	ParameterValue ParameterKind = "value"
+	// ParameterMultiValue is a parameter accepting several values,
+	// as repeated parameters (e.g. brand=a&brand=b)
+	ParameterMultiValue ParameterKind = "multi-value"
+	// ParameterRange is a range parameter, with its bounds
+	// in .min and .max suffixed parameters
+	ParameterRange ParameterKind = "range"
+	// ParameterBoolean is a parameter with a true or false value
+	ParameterBoolean ParameterKind = "boolean"
+)
+
+// ParameterDescription describes a request parameter read by a
+// feature, where Values lists the accepted values when they are
+// known up front, such as sort options, and Examples holds example
+// values, being the lower and upper bound of range parameters
+type ParameterDescription struct {
+	Name        string
+	Kind        ParameterKind
+	Description string
+	Values      []string
+	Examples    []string
+}
+
+// Describable is implemented by features describing
+// the request parameters they read
+type Describable interface {
+	Describe() []ParameterDescription
+}
+
+// Parameters returns the request parameters of the endpoint's
+// registered features implementing Describable, in the order the
+// features were registered. A parameter read by several features
+// is described by the first of them
+func (e *Endpoint) Parameters() []ParameterDescription {
+	var params []ParameterDescription
+	seen := make(map[string]bool)
+	for _, f := range e.features {
+		d, ok := f.(Describable)
+		if !ok {
+			continue
+		}
+
+		for _, p := range d.Describe() {
+			if seen[p.Name] {
+				continue
+			}
+
+			seen[p.Name] = true
+			params = append(params, p)
+		}
+	}
+
+	return params
+}
@@ -0,0 +1,189 @@
+package reveald
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+const defaultDiffIDProperty = "id"
+
+// ResultDiff is a structured comparison of two results, listing
+// only the differences exceeding the configured tolerances
+type ResultDiff struct {
+	TotalHitCountDelta int64
+	Hits               []HitDiff
+	Buckets            []BucketDiff
+}
+
+// HitDiff is a hit which moved, or which is only present in
+// one of the results, in which case its missing position is -1
+type HitDiff struct {
+	ID     string
+	Before int
+	After  int
+}
+
+// BucketDiff is an aggregation bucket whose hit count changed
+type BucketDiff struct {
+	Aggregation string
+	Value       string
+	Before      int64
+	After       int64
+}
+
+// Empty returns true when the results are considered equal
+func (d *ResultDiff) Empty() bool {
+	return d.TotalHitCountDelta == 0 && len(d.Hits) == 0 && len(d.Buckets) == 0
+}
+
+type differ struct {
+	idProperty     string
+	rankTolerance  int
+	countThreshold float64
+}
+
+// DiffOption configures how results are compared
+type DiffOption func(*differ)
+
+// WithDiffIDProperty defines the hit property identifying
+// documents, defaulting to "id"
+func WithDiffIDProperty(property string) DiffOption {
+	return func(d *differ) {
+		d.idProperty = property
+	}
+}
+
+// WithRankTolerance ignores hits moving at most
+// the specified number of positions
+func WithRankTolerance(positions int) DiffOption {
+	return func(d *differ) {
+		d.rankTolerance = positions
+	}
+}
+
+// WithCountThreshold ignores bucket hit count changes up to
+// the specified fraction of the larger count (e.g. 0.05)
+func WithCountThreshold(threshold float64) DiffOption {
+	return func(d *differ) {
+		d.countThreshold = threshold
+	}
+}
+
+// DiffResults compares the hit ordering and aggregation
+// counts of two results, e.g. a production result and the
+// result of a candidate configuration for the same request,
+// to flag relevance regressions
+func DiffResults(before, after *Result, opts ...DiffOption) *ResultDiff {
+	d := &differ{
+		idProperty: defaultDiffIDProperty,
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return &ResultDiff{
+		TotalHitCountDelta: after.TotalHitCount - before.TotalHitCount,
+		Hits:               d.hits(before.Hits, after.Hits),
+		Buckets:            d.buckets(before.Aggregations, after.Aggregations),
+	}
+}
+
+func (d *differ) positions(hits []map[string]interface{}) (map[string]int, []string) {
+	positions := make(map[string]int, len(hits))
+	var ids []string
+	for i, hit := range hits {
+		v, ok := hit[d.idProperty]
+		if !ok {
+			continue
+		}
+
+		id := fmt.Sprint(v)
+		if _, seen := positions[id]; seen {
+			continue
+		}
+
+		positions[id] = i
+		ids = append(ids, id)
+	}
+
+	return positions, ids
+}
+
+func (d *differ) hits(before, after []map[string]interface{}) []HitDiff {
+	bp, bids := d.positions(before)
+	ap, aids := d.positions(after)
+
+	var diffs []HitDiff
+	for _, id := range bids {
+		a, ok := ap[id]
+		if !ok {
+			diffs = append(diffs, HitDiff{id, bp[id], -1})
+			continue
+		}
+
+		if delta := a - bp[id]; delta > d.rankTolerance || -delta > d.rankTolerance {
+			diffs = append(diffs, HitDiff{id, bp[id], a})
+		}
+	}
+
+	for _, id := range aids {
+		if _, ok := bp[id]; !ok {
+			diffs = append(diffs, HitDiff{id, -1, ap[id]})
+		}
+	}
+
+	return diffs
+}
+
+func (d *differ) buckets(before, after map[string][]*ResultBucket) []BucketDiff {
+	names := make(map[string]bool)
+	for name := range before {
+		names[name] = true
+	}
+	for name := range after {
+		names[name] = true
+	}
+
+	var diffs []BucketDiff
+	for name := range names {
+		counts := make(map[string][2]int64)
+		var values []string
+		collect := func(buckets []*ResultBucket, side int) {
+			for _, b := range buckets {
+				if b == nil {
+					continue
+				}
+
+				v := fmt.Sprint(b.Value)
+				c, ok := counts[v]
+				if !ok {
+					values = append(values, v)
+				}
+
+				c[side] = b.HitCount
+				counts[v] = c
+			}
+		}
+
+		collect(before[name], 0)
+		collect(after[name], 1)
+
+		for _, v := range values {
+			c := counts[v]
+			delta := math.Abs(float64(c[1] - c[0]))
+			if delta == 0 || delta <= d.countThreshold*math.Max(float64(c[0]), float64(c[1])) {
+				continue
+			}
+
+			diffs = append(diffs, BucketDiff{name, v, c[0], c[1]})
+		}
+	}
+
+	sort.SliceStable(diffs, func(i, j int) bool {
+		return diffs[i].Aggregation < diffs[j].Aggregation
+	})
+
+	return diffs
+}
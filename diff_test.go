@@ -0,0 +1,64 @@
+package reveald
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func diffHits(ids ...string) []map[string]interface{} {
+	var hits []map[string]interface{}
+	for _, id := range ids {
+		hits = append(hits, map[string]interface{}{"id": id})
+	}
+
+	return hits
+}
+
+func Test_DiffResults_Hits(t *testing.T) {
+	table := []struct {
+		name     string
+		after    []string
+		opts     []DiffOption
+		expected []HitDiff
+	}{
+		{"equal", []string{"a", "b", "c"}, nil, nil},
+		{"swapped", []string{"b", "a", "c"}, nil, []HitDiff{{"a", 0, 1}, {"b", 1, 0}}},
+		{"swapped within tolerance", []string{"b", "a", "c"}, []DiffOption{WithRankTolerance(1)}, nil},
+		{"replaced", []string{"a", "b", "d"}, nil, []HitDiff{{"c", 2, -1}, {"d", -1, 2}}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			before := &Result{Hits: diffHits("a", "b", "c")}
+			after := &Result{Hits: diffHits(tt.after...)}
+
+			assert.Equal(t, tt.expected, DiffResults(before, after, tt.opts...).Hits)
+		})
+	}
+}
+
+func Test_DiffResults_Buckets(t *testing.T) {
+	before := &Result{
+		TotalHitCount: 100,
+		Aggregations: map[string][]*ResultBucket{
+			"brand": {{Value: "acme", HitCount: 100}, {Value: "globex", HitCount: 10}},
+		},
+	}
+	after := &Result{
+		TotalHitCount: 97,
+		Aggregations: map[string][]*ResultBucket{
+			"brand": {{Value: "acme", HitCount: 97}, {Value: "initech", HitCount: 4}},
+		},
+	}
+
+	diff := DiffResults(before, after, WithCountThreshold(0.05))
+	assert.False(t, diff.Empty())
+	assert.Equal(t, int64(-3), diff.TotalHitCountDelta)
+	assert.Equal(t, []BucketDiff{
+		{"brand", "globex", 10, 0},
+		{"brand", "initech", 0, 4},
+	}, diff.Buckets)
+
+	assert.True(t, DiffResults(before, before).Empty())
+}
@@ -2,6 +2,7 @@ package reveald
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 )
@@ -29,6 +30,79 @@ type Endpoint struct {
 	backend  Backend
 	indices  []string
 	features []Feature
+	plan     *plan
+	budget   *sourceBudget
+	lint     LintHandler
+	counting CountingStrategy
+	metrics  Metrics
+
+	debug      bool
+	debugParam string
+
+	noAggregationFallback bool
+	fallbackConcurrency   int
+
+	ignoreUnavailable *bool
+	allowNoIndices    *bool
+	queryTimeout      time.Duration
+	terminateAfter    int
+	indexSort         *indexSort
+	tenants           TenantResolver
+	middleware        []EndpointMiddleware
+}
+
+// ExecuteFunc executes a search query request
+type ExecuteFunc func(context.Context, *Request) (*Result, error)
+
+// EndpointMiddleware wraps the execution of a search query
+// request, e.g. for logging, timing, injecting filters based
+// on the caller, or post-processing results
+type EndpointMiddleware func(next ExecuteFunc) ExecuteFunc
+
+// EndpointOption is a functional option used
+// when creating an Endpoint
+type EndpointOption func(*Endpoint)
+
+// WithIgnoreUnavailable defines whether missing or closed
+// indices are ignored, rather than failing the search
+func WithIgnoreUnavailable(ignore bool) EndpointOption {
+	return func(e *Endpoint) {
+		e.ignoreUnavailable = &ignore
+	}
+}
+
+// WithAllowNoIndices defines whether a search is allowed when
+// wildcard index patterns resolve to no concrete indices
+func WithAllowNoIndices(allow bool) EndpointOption {
+	return func(e *Endpoint) {
+		e.allowNoIndices = &allow
+	}
+}
+
+// WithQueryTimeout bounds the time each shard spends on a
+// search, returning partial results flagged as TimedOut once
+// the timeout expires, rather than exceeding a latency budget
+func WithQueryTimeout(timeout time.Duration) EndpointOption {
+	return func(e *Endpoint) {
+		e.queryTimeout = timeout
+	}
+}
+
+// WithTerminateAfter stops each shard from collecting more than
+// n documents, returning partial results flagged as TerminatedEarly
+func WithTerminateAfter(n int) EndpointOption {
+	return func(e *Endpoint) {
+		e.terminateAfter = n
+	}
+}
+
+// WithoutAggregationFallback fails searches exceeding the bucket or
+// memory limits of the cluster, instead of retrying them without
+// aggregations and returning the hits with a warning
+func WithoutAggregationFallback() EndpointOption {
+	return func(e *Endpoint) {
+		e.noAggregationFallback = true
+	}
 }
 
 // Indices is a type alias for a string slice
@@ -44,59 +118,252 @@ func WithIndices(index ...string) Indices {
 
 // NewEndpoint returns a new Endpoint for a specific
 // search query type
-func NewEndpoint(backend Backend, indices Indices) *Endpoint {
-	return &Endpoint{
+func NewEndpoint(backend Backend, indices Indices, opts ...EndpointOption) *Endpoint {
+	e := &Endpoint{
 		backend: backend,
 		indices: indices,
+		plan:    &plan{},
+		metrics: NopMetrics{},
+	}
+
+	for _, opt := range opts {
+		opt(e)
 	}
+
+	return e
+}
+
+// ValidatedFeature is implemented by features which can be
+// misconfigured, e.g. missing a required key, so Register
+// rejects them instead of failing, or leaking, per request
+type ValidatedFeature interface {
+	Validate() error
 }
 
 // Register a new set of features used when building
-// a search query
+// a search query. Features implementing PreparableFeature
+// are rendered once, here, rather than per request
 func (e *Endpoint) Register(features ...Feature) error {
+	for _, feature := range features {
+		if vf, ok := feature.(ValidatedFeature); ok {
+			if err := vf.Validate(); err != nil {
+				return fmt.Errorf("invalid feature %T: %w", feature, err)
+			}
+		}
+	}
+
+	p, err := newPlan(append(e.features, features...))
+	if err != nil {
+		return err
+	}
+
 	e.features = append(e.features, features...)
+	e.plan = p
 	return nil
 }
 
+// Use adds middleware wrapping Execute, where the
+// first middleware added is the outermost
+func (e *Endpoint) Use(middleware ...EndpointMiddleware) {
+	e.middleware = append(e.middleware, middleware...)
+}
+
+func (e *Endpoint) prepare(ctx context.Context, request *Request, metrics Metrics) (*QueryBuilder, *callchain, error) {
+	builder := NewQueryBuilder(request, e.indices...)
+	builder.SetContext(ctx)
+	builder.SetCountingStrategy(e.counting)
+	if e.ignoreUnavailable != nil {
+		builder.WithIgnoreUnavailable(*e.ignoreUnavailable)
+	}
+	if e.allowNoIndices != nil {
+		builder.WithAllowNoIndices(*e.allowNoIndices)
+	}
+	builder.WithQueryTimeout(e.queryTimeout)
+	builder.WithTerminateAfter(e.terminateAfter)
+	builder.indexSort = e.indexSort
+	builder.subsearch = e.subsearch
+
+	if e.budget != nil {
+		if err := e.budget.apply(ctx, e.backend, builder); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	cc := &callchain{}
+	for _, feature := range e.plan.features {
+		cc.add(newMeteredFeature(feature, metrics))
+	}
+
+	return builder, cc, nil
+}
+
+// errPrepared stops a feature chain once every
+// feature has contributed to the query builder
+var errPrepared = errors.New("query builder prepared")
+
+// PrepareBuilder returns the query builder for a request, as
+// built by the endpoint's features, without executing it. Each
+// call builds an independent query builder, so requests may be
+// prepared concurrently, as long as no features are registered
+// at the same time
+func (e *Endpoint) PrepareBuilder(ctx context.Context, request *Request) (*QueryBuilder, error) {
+	builder, cc, err := e.prepare(ctx, request, e.metrics)
+	if err != nil {
+		return nil, err
+	}
+
+	var prepared *QueryBuilder
+	_, err = cc.exec(builder, func(qb *QueryBuilder) (*Result, error) {
+		if err := e.enforceTenant(ctx, qb); err != nil {
+			return nil, err
+		}
+
+		prepared = qb
+		return nil, errPrepared
+	})
+	if prepared == nil {
+		if err == nil || errors.Is(err, errPrepared) {
+			return nil, errors.New("feature chain stopped before building the query")
+		}
+
+		return nil, err
+	}
+
+	return prepared, nil
+}
+
 // Execute a search query request
 func (e *Endpoint) Execute(ctx context.Context, request *Request) (*Result, error) {
+	exec := e.execute
+	for i := len(e.middleware) - 1; i >= 0; i-- {
+		exec = e.middleware[i](exec)
+	}
+
+	return exec(ctx, request)
+}
+
+// backendFunc executes the search built by a feature chain
+type backendFunc func(context.Context, *QueryBuilder) (*Result, error)
+
+func (e *Endpoint) execute(ctx context.Context, request *Request) (*Result, error) {
+	return e.run(ctx, request, e.backend.Execute)
+}
+
+// run executes the feature chain of a request,
+// where search executes the built query
+func (e *Endpoint) run(ctx context.Context, request *Request, search backendFunc) (*Result, error) {
 	start := time.Now()
-	builder := NewQueryBuilder(request, e.indices...)
+	var debug *debugMetrics
+	metrics := e.metrics
+	if e.debugging(request) {
+		debug = &debugMetrics{Metrics: e.metrics, debug: &ResultDebug{}}
+		metrics = debug
+	}
 
-	cc := &callchain{}
-	for _, feature := range e.features {
-		cc.add(feature)
+	builder, cc, err := e.prepare(ctx, request, metrics)
+	if err != nil {
+		return nil, err
 	}
 
 	result, err := cc.exec(builder, func(qb *QueryBuilder) (*Result, error) {
-		return e.backend.Execute(ctx, qb)
+		if err := e.enforceTenant(ctx, qb); err != nil {
+			return nil, err
+		}
+		if err := qb.resolvePointInTime(ctx); err != nil {
+			return nil, err
+		}
+
+		e.lintBuilder(ctx, qb)
+		r, err := search(ctx, qb)
+		if err != nil && !e.noAggregationFallback && len(qb.aggs) > 0 && exceedsAggregationLimits(err) {
+			r, err = e.executeWithoutAggregations(ctx, qb, search, err)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if debug != nil {
+			debug.capture(qb, r)
+		}
+
+		observeBackend(ctx, e.metrics, r)
+		qb.UnwrapAggregations(r.RawResult())
+		mapAttachedAggregations(qb, r)
+		orderByIDs(qb, r)
+		r.AppliedFilters = qb.AppliedFilters()
+		return r, nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("backend failed executing request: %w", err)
+		err = fmt.Errorf("backend failed executing request: %w", err)
+		e.metrics.RequestExecuted(ctx, time.Since(start), err)
+		return nil, err
 	}
 
 	result.request = request
+	result.Meta = request.Metadata()
 	result.Duration = time.Since(start)
+	if debug != nil {
+		result.Debug = debug.debug
+	}
+
+	e.metrics.RequestExecuted(ctx, result.Duration, nil)
 	return result, nil
 }
 
+// executeWithoutAggregations retries a search rejected for its
+// aggregations with the search of the request, e.g. a multi search,
+// returning only the hits
+func (e *Endpoint) executeWithoutAggregations(ctx context.Context, qb *QueryBuilder, search backendFunc, cause error) (*Result, error) {
+	qb.dropAggregations()
+	r, err := search(ctx, qb)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Warnings = append(r.Warnings, &ResultWarning{
+		Code:    WarningAggregationsDropped,
+		Message: fmt.Sprintf("aggregations were dropped after exceeding cluster limits: %v", cause),
+	})
+	return r, nil
+}
+
+// ExecuteMultiple executes several search query requests, running
+// the features of each request, with their searches sent in a single
+// multi search. It fails if any of the requests fails
 func (e *Endpoint) ExecuteMultiple(ctx context.Context, requests []*Request) ([]*Result, error) {
-	queryBuilders := make([]*QueryBuilder, 0, len(requests))
+	chains := make([]*suspendedChain, 0, len(requests))
 	for _, req := range requests {
-		builder := NewQueryBuilder(req, e.indices...)
+		chains = append(chains, suspend(e.backend, func(search backendFunc) (*Result, error) {
+			return e.run(ctx, req, search)
+		}))
+	}
+
+	outcomes, err := executeSuspended(ctx, e.backend, chains, e.fallbackConcurrency)
+	if err != nil {
+		return nil, err
+	}
 
-		cc := &callchain{}
-		for _, feature := range e.features {
-			cc.add(feature)
+	results := make([]*Result, 0, len(outcomes))
+	for _, o := range outcomes {
+		if o.err != nil {
+			return nil, o.err
 		}
 
-		queryBuilders = append(queryBuilders, builder)
+		results = append(results, o.result)
 	}
 
-	results, err := e.backend.ExecuteMultiple(ctx, queryBuilders)
-	if err != nil {
-		return nil, fmt.Errorf("backend failed executing requests: %w", err)
+	return results, nil
+}
+
+func (e *Endpoint) lintBuilder(ctx context.Context, qb *QueryBuilder) {
+	if e.lint == nil {
+		return
 	}
 
-	return results, nil
+	warnings, err := Lint(qb)
+	if err != nil || len(warnings) == 0 {
+		return
+	}
+
+	e.lint(ctx, qb, warnings)
 }
@@ -0,0 +1,156 @@
+package reveald
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Endpoint_Use(t *testing.T) {
+	var calls []string
+	trace := func(name string) EndpointMiddleware {
+		return func(next ExecuteFunc) ExecuteFunc {
+			return func(ctx context.Context, req *Request) (*Result, error) {
+				calls = append(calls, name+" before")
+				r, err := next(ctx, req)
+				calls = append(calls, name+" after")
+				return r, err
+			}
+		}
+	}
+
+	backend := &fakeBackend{}
+	e := NewEndpoint(backend, WithIndices("-"))
+	e.Use(trace("outer"), trace("inner"))
+	e.Use(func(next ExecuteFunc) ExecuteFunc {
+		return func(ctx context.Context, req *Request) (*Result, error) {
+			req.Set("tenant", "acme")
+			r, err := next(ctx, req)
+			if err == nil {
+				r.TotalHitCount = 42
+			}
+			return r, err
+		}
+	})
+
+	r, err := e.Execute(context.Background(), NewRequest())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"outer before", "inner before", "inner after", "outer after"}, calls)
+	assert.Equal(t, int64(42), r.TotalHitCount)
+	assert.True(t, backend.builders[0].Request().Has("tenant"))
+}
+
+func Test_Endpoint_Use_ShortCircuit(t *testing.T) {
+	backend := &fakeBackend{}
+	e := NewEndpoint(backend, WithIndices("-"))
+	e.Use(func(ExecuteFunc) ExecuteFunc {
+		return func(context.Context, *Request) (*Result, error) {
+			return nil, errors.New("unauthorized")
+		}
+	})
+
+	_, err := e.Execute(context.Background(), NewRequest())
+	assert.EqualError(t, err, "unauthorized")
+	assert.Empty(t, backend.builders)
+}
+
+func Test_Endpoint_PrepareBuilder(t *testing.T) {
+	backend := &fakeBackend{}
+	e := NewEndpoint(backend, WithIndices("-"))
+	assert.NoError(t, e.Register(&fakePreparable{}, &offsetFeature{offset: 10}))
+
+	qb, err := e.PrepareBuilder(context.Background(), NewRequest())
+	assert.NoError(t, err)
+	assert.Empty(t, backend.builders)
+	assert.Equal(t, 10, qb.Selection().offset)
+	assert.Contains(t, sourceJSON(t, qb), "aggregations")
+}
+
+func Test_Endpoint_PrepareBuilder_Concurrent(t *testing.T) {
+	e := NewEndpoint(&fakeBackend{}, WithIndices("-"))
+	assert.NoError(t, e.Register(&fakePreparable{}, &requestFeature{}))
+
+	const n = 64
+	var wg sync.WaitGroup
+	builders := make([]*QueryBuilder, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			qb, err := e.PrepareBuilder(context.Background(), NewRequest(NewParameter("id", strconv.Itoa(i))))
+			assert.NoError(t, err)
+			builders[i] = qb
+		}(i)
+	}
+	wg.Wait()
+
+	for i, qb := range builders {
+		expected := NewQueryBuilder(nil)
+		(&fakePreparable{}).Prepare(expected)
+		expected.With(elastic.NewTermQuery("id", strconv.Itoa(i)))
+
+		assert.Equal(t, sourceJSON(t, expected)["query"], sourceJSON(t, qb)["query"])
+	}
+}
+
+type requestFeature struct{}
+
+func (f *requestFeature) Process(qb *QueryBuilder, next FeatureFunc) (*Result, error) {
+	p, err := qb.Request().Get("id")
+	if err != nil {
+		return nil, err
+	}
+
+	qb.With(elastic.NewTermQuery("id", p.Value()))
+	return next(qb)
+}
+
+func Test_Endpoint_Timeout_And_TerminateAfter(t *testing.T) {
+	table := []struct {
+		name           string
+		opts           []EndpointOption
+		timeout        interface{}
+		terminateAfter interface{}
+	}{
+		{"defaults", nil, nil, nil},
+		{"timeout", []EndpointOption{WithQueryTimeout(1500 * time.Millisecond)}, "1500ms", nil},
+		{"terminate after", []EndpointOption{WithTerminateAfter(1000)}, nil, float64(1000)},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEndpoint(&fakeBackend{}, WithIndices("-"), tt.opts...)
+			qb, err := e.PrepareBuilder(context.Background(), NewRequest())
+			assert.NoError(t, err)
+
+			src := sourceJSON(t, qb)
+			assert.Equal(t, tt.timeout, src["timeout"])
+			assert.Equal(t, tt.terminateAfter, src["terminate_after"])
+		})
+	}
+}
+
+type validatedFeature struct{ err error }
+
+func (f validatedFeature) Process(qb *QueryBuilder, next FeatureFunc) (*Result, error) {
+	return next(qb)
+}
+
+func (f validatedFeature) Validate() error {
+	return f.err
+}
+
+func Test_Endpoint_Register_Validates(t *testing.T) {
+	invalid := errors.New("missing key")
+	e := NewEndpoint(&fakeBackend{}, WithIndices("-"))
+
+	assert.NoError(t, e.Register(validatedFeature{}))
+	assert.ErrorIs(t, e.Register(validatedFeature{invalid}), invalid)
+	assert.Len(t, e.features, 1)
+}
@@ -0,0 +1,220 @@
+package reveald
+
+import (
+	"fmt"
+	"sort"
+)
+
+// EnvelopeVersion is the version of the JSON envelope,
+// incremented on breaking changes to its shape
+const EnvelopeVersion = 1
+
+// Envelope is the stable JSON representation of a Result,
+// where every section is present even when the features
+// populating it aren't registered
+type Envelope struct {
+	Version        int                        `json:"version"`
+	TotalHitCount  int64                      `json:"total_hit_count"`
+	TotalHitsExact bool                       `json:"total_hits_exact"`
+	Hits           []map[string]interface{}   `json:"hits"`
+	Facets         map[string]*EnvelopeFacet  `json:"facets"`
+	Pagination     *EnvelopePagination        `json:"pagination"`
+	Sorting        *EnvelopeSorting           `json:"sorting"`
+	Filters        map[string]*EnvelopeFilter `json:"filters"`
+}
+
+// EnvelopeFacet is an aggregation, along with its metadata,
+// if any, where Interval is the bucket interval of histograms
+// whose interval is chosen at search time
+type EnvelopeFacet struct {
+	Buckets  []*EnvelopeBucket `json:"buckets"`
+	Coverage *float64          `json:"coverage,omitempty"`
+	Hidden   bool              `json:"hidden"`
+	Interval string            `json:"interval,omitempty"`
+}
+
+// EnvelopeBucket is a bucket of a facet, where Selected is set
+// when the bucket's value is part of the request, or the bucket
+// is within a selected range, Excluded is set when its value is
+// filtered out, and From and To bound range buckets
+type EnvelopeBucket struct {
+	Value    interface{}                  `json:"value"`
+	Label    string                       `json:"label,omitempty"`
+	HitCount int64                        `json:"hit_count"`
+	From     *float64                     `json:"from,omitempty"`
+	To       *float64                     `json:"to,omitempty"`
+	Selected bool                         `json:"selected"`
+	Excluded bool                         `json:"excluded,omitempty"`
+	Children map[string][]*EnvelopeBucket `json:"children,omitempty"`
+}
+
+// EnvelopePagination describes the current page, where
+// Page is 1-based and NextCursor is only set when using
+// cursor based pagination
+type EnvelopePagination struct {
+	Offset     int    `json:"offset"`
+	PageSize   int    `json:"page_size"`
+	Page       int    `json:"page"`
+	TotalPages int    `json:"total_pages"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// EnvelopeSorting lists the available sort options
+type EnvelopeSorting struct {
+	Param   string                   `json:"param"`
+	Options []*EnvelopeSortingOption `json:"options"`
+}
+
+// EnvelopeSortingOption is an available sort option
+type EnvelopeSortingOption struct {
+	Name      string `json:"name"`
+	Property  string `json:"property"`
+	Ascending bool   `json:"ascending"`
+	Selected  bool   `json:"selected"`
+	Priority  int    `json:"priority,omitempty"`
+}
+
+// EnvelopeFilter is a filter applied by a feature, or the
+// parameter of a facet when no feature recorded the filters
+// it applied
+type EnvelopeFilter struct {
+	Values   []string `json:"values,omitempty"`
+	Excluded []string `json:"excluded,omitempty"`
+	Min      *float64 `json:"min,omitempty"`
+	Max      *float64 `json:"max,omitempty"`
+}
+
+// NewEnvelope returns the stable JSON representation of a
+// result, leaving the encoding of Result itself unchanged
+func NewEnvelope(r *Result) *Envelope {
+	env := &Envelope{
+		Version:        EnvelopeVersion,
+		TotalHitCount:  r.TotalHitCount,
+		TotalHitsExact: r.TotalHitsRelation == TotalHitsExact,
+		Hits:           r.Hits,
+		Facets:         make(map[string]*EnvelopeFacet, len(r.Aggregations)),
+		Pagination:     &EnvelopePagination{},
+		Sorting:        &EnvelopeSorting{Options: []*EnvelopeSortingOption{}},
+		Filters:        make(map[string]*EnvelopeFilter),
+	}
+
+	if env.Hits == nil {
+		env.Hits = []map[string]interface{}{}
+	}
+
+	for name, buckets := range r.Aggregations {
+		facet := &EnvelopeFacet{Buckets: r.envelopeBuckets(name, buckets)}
+		if meta, ok := r.Facets[name]; ok && meta != nil {
+			coverage := meta.Coverage
+			facet.Coverage = &coverage
+			facet.Hidden = meta.Hidden
+		}
+		facet.Interval = r.Intervals[name]
+
+		env.Facets[name] = facet
+	}
+
+	if p := r.Pagination; p != nil {
+		env.Pagination = &EnvelopePagination{
+			Offset:     p.Offset,
+			PageSize:   p.PageSize,
+			NextCursor: p.NextCursor,
+		}
+
+		if p.PageSize > 0 {
+			env.Pagination.Page = p.Offset/p.PageSize + 1
+			env.Pagination.TotalPages = int((r.TotalHitCount + int64(p.PageSize) - 1) / int64(p.PageSize))
+		}
+	}
+
+	if s := r.Sorting; s != nil {
+		env.Sorting.Param = s.Param
+		for _, o := range s.Options {
+			env.Sorting.Options = append(env.Sorting.Options, &EnvelopeSortingOption{
+				Name:      o.Name,
+				Property:  o.Property,
+				Ascending: o.Ascending,
+				Selected:  o.Selected,
+				Priority:  o.Priority,
+			})
+		}
+
+		sort.Slice(env.Sorting.Options, func(i, j int) bool {
+			return env.Sorting.Options[i].Name < env.Sorting.Options[j].Name
+		})
+	}
+
+	for _, f := range r.AppliedFilters {
+		env.Filters[f.Property] = &EnvelopeFilter{
+			Values:   f.Values,
+			Excluded: f.Excluded,
+			Min:      f.Min,
+			Max:      f.Max,
+		}
+	}
+
+	if len(r.AppliedFilters) == 0 && r.request != nil {
+		for _, p := range r.request.Params() {
+			name := p.Name()
+			// only parameters of facets are filters, rather
+			// than e.g. pagination or sort parameters
+			if _, ok := r.Aggregations[name]; !ok {
+				continue
+			}
+
+			filter := &EnvelopeFilter{}
+			if min, ok := p.Min(); ok {
+				filter.Min = &min
+			}
+			if max, ok := p.Max(); ok {
+				filter.Max = &max
+			}
+			if !p.IsRangeValue() {
+				filter.Values = p.Values()
+			}
+
+			env.Filters[name] = filter
+		}
+	}
+
+	return env
+}
+
+func (r *Result) envelopeBuckets(name string, buckets []*ResultBucket) []*EnvelopeBucket {
+	selected := make(map[string]bool)
+	if r.request != nil {
+		if p, err := r.request.Get(name); err == nil {
+			for _, v := range p.Values() {
+				selected[v] = true
+			}
+		}
+	}
+
+	list := make([]*EnvelopeBucket, 0, len(buckets))
+	for _, b := range buckets {
+		if b == nil {
+			continue
+		}
+
+		eb := &EnvelopeBucket{
+			Value:    b.Value,
+			Label:    b.Label,
+			HitCount: b.HitCount,
+			From:     b.From,
+			To:       b.To,
+			Selected: b.Selected || selected[fmt.Sprint(b.Value)],
+			Excluded: b.Excluded,
+		}
+
+		for child, sub := range b.SubAggregations {
+			if eb.Children == nil {
+				eb.Children = make(map[string][]*EnvelopeBucket)
+			}
+			eb.Children[child] = r.envelopeBuckets(child, sub)
+		}
+
+		list = append(list, eb)
+	}
+
+	return list
+}
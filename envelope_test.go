@@ -0,0 +1,143 @@
+package reveald
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewEnvelope(t *testing.T) {
+	from, to := 100.0, 150.0
+	table := []struct {
+		name     string
+		result   *Result
+		expected string
+	}{
+		{"empty", &Result{}, `{
+			"version": 1,
+			"total_hit_count": 0,
+			"total_hits_exact": false,
+			"hits": [],
+			"facets": {},
+			"pagination": {"offset": 0, "page_size": 0, "page": 0, "total_pages": 0},
+			"sorting": {"param": "", "options": []},
+			"filters": {}
+		}`},
+		{"populated", &Result{
+			request: NewRequest(
+				NewParameter("brand", "acme"),
+				NewParameter("price.min", "10"),
+				NewParameter("offset", "24"),
+				NewParameter("sort", "price-asc")),
+			TotalHitCount:     50,
+			TotalHitsRelation: TotalHitsExact,
+			Hits:              []map[string]interface{}{{"id": "1"}},
+			Aggregations: map[string][]*ResultBucket{
+				"brand": {
+					{Value: "acme", HitCount: 30},
+					{Value: "globex", HitCount: 20},
+				},
+				"price": {},
+			},
+			Facets:     map[string]*ResultFacet{"brand": {Coverage: 1}},
+			Pagination: &ResultPagination{Offset: 24, PageSize: 12},
+			Sorting: &ResultSorting{Param: "sort", Options: []*ResultSortingOption{
+				{Name: "price-asc", Property: "price", Ascending: true, Selected: true, Priority: 1},
+			}},
+		}, `{
+			"version": 1,
+			"total_hit_count": 50,
+			"total_hits_exact": true,
+			"hits": [{"id": "1"}],
+			"facets": {"brand": {
+				"buckets": [
+					{"value": "acme", "hit_count": 30, "selected": true},
+					{"value": "globex", "hit_count": 20, "selected": false}
+				],
+				"coverage": 1,
+				"hidden": false
+			}, "price": {"buckets": [], "hidden": false}},
+			"pagination": {"offset": 24, "page_size": 12, "page": 3, "total_pages": 5},
+			"sorting": {"param": "sort", "options": [
+				{"name": "price-asc", "property": "price", "ascending": true, "selected": true, "priority": 1}
+			]},
+			"filters": {
+				"brand": {"values": ["acme"]},
+				"price": {"min": 10}
+			}
+		}`},
+		{"interval", &Result{
+			Aggregations: map[string][]*ResultBucket{
+				"published": {{Value: "2024-05-01", HitCount: 4}},
+			},
+			Intervals: map[string]string{"published": "7d"},
+		}, `{
+			"version": 1,
+			"total_hit_count": 0,
+			"total_hits_exact": false,
+			"hits": [],
+			"facets": {"published": {
+				"buckets": [{"value": "2024-05-01", "hit_count": 4, "selected": false}],
+				"hidden": false,
+				"interval": "7d"
+			}},
+			"pagination": {"offset": 0, "page_size": 0, "page": 0, "total_pages": 0},
+			"sorting": {"param": "", "options": []},
+			"filters": {}
+		}`},
+		{"range buckets", &Result{
+			Aggregations: map[string][]*ResultBucket{
+				"price": {{Value: "100", HitCount: 3, From: &from, To: &to, Selected: true}},
+			},
+		}, `{
+			"version": 1,
+			"total_hit_count": 0,
+			"total_hits_exact": false,
+			"hits": [],
+			"facets": {"price": {
+				"buckets": [{"value": "100", "hit_count": 3, "from": 100, "to": 150, "selected": true}],
+				"hidden": false
+			}},
+			"pagination": {"offset": 0, "page_size": 0, "page": 0, "total_pages": 0},
+			"sorting": {"param": "", "options": []},
+			"filters": {}
+		}`},
+		{"excluded values", &Result{
+			Aggregations: map[string][]*ResultBucket{
+				"brand": {{Value: "acme", HitCount: 3, Excluded: true}},
+			},
+			AppliedFilters: []*ResultFilter{{Property: "brand", Excluded: []string{"acme"}}},
+		}, `{
+			"version": 1,
+			"total_hit_count": 0,
+			"total_hits_exact": false,
+			"hits": [],
+			"facets": {"brand": {
+				"buckets": [{"value": "acme", "hit_count": 3, "selected": false, "excluded": true}],
+				"hidden": false
+			}},
+			"pagination": {"offset": 0, "page_size": 0, "page": 0, "total_pages": 0},
+			"sorting": {"param": "", "options": []},
+			"filters": {"brand": {"excluded": ["acme"]}}
+		}`},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(NewEnvelope(tt.result))
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(data))
+		})
+	}
+}
+
+func Test_Result_DefaultEncoding(t *testing.T) {
+	data, err := json.Marshal(&Result{TotalHitCount: 3})
+	assert.NoError(t, err)
+
+	var m map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &m))
+	assert.Equal(t, float64(3), m["TotalHitCount"])
+	assert.NotContains(t, m, "version")
+}
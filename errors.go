@@ -0,0 +1,129 @@
+package reveald
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/olivere/elastic/v7"
+)
+
+const (
+	indexNotFoundException   = "index_not_found_exception"
+	tooManyBucketsException  = "too_many_buckets_exception"
+	circuitBreakingException = "circuit_breaking_exception"
+)
+
+// IndexNotFoundError is returned when an index configured
+// on an endpoint doesn't exist
+type IndexNotFoundError struct {
+	Index string
+	err   error
+}
+
+func (e *IndexNotFoundError) Error() string {
+	return fmt.Sprintf("index %q does not exist; create it, or configure the endpoint using WithIgnoreUnavailable(true)", e.Index)
+}
+
+// Unwrap returns the underlying Elasticsearch error
+func (e *IndexNotFoundError) Unwrap() error {
+	return e.err
+}
+
+// searchError translates known Elasticsearch
+// failures into typed errors
+func searchError(err error) error {
+	var ee *elastic.Error
+	if errors.As(err, &ee) {
+		if inf := indexNotFound(ee.Details, err); inf != nil {
+			return inf
+		}
+	}
+
+	return fmt.Errorf("elasticsearch request failed: %w", err)
+}
+
+func indexNotFound(details *elastic.ErrorDetails, err error) *IndexNotFoundError {
+	if details == nil || details.Type != indexNotFoundException {
+		return nil
+	}
+
+	index := details.Index
+	if index == "" {
+		index = details.ResourceId
+	}
+
+	return &IndexNotFoundError{
+		Index: index,
+		err:   err,
+	}
+}
+
+// multiSearchUnsupported returns whether a multi search was
+// rejected for the multi search API not being available, e.g.
+// when blocked by a proxy in front of the cluster
+func multiSearchUnsupported(err error) bool {
+	var ee *elastic.Error
+	if !errors.As(err, &ee) {
+		return false
+	}
+
+	return ee.Status == http.StatusMethodNotAllowed ||
+		(ee.Status == http.StatusNotFound && ee.Details == nil)
+}
+
+// exceedsAggregationLimits returns whether a search was rejected
+// for creating too many buckets or tripping a circuit breaker,
+// either of which may be avoided by dropping its aggregations
+func exceedsAggregationLimits(err error) bool {
+	var ee *elastic.Error
+	if !errors.As(err, &ee) {
+		return false
+	}
+
+	return hasErrorType(ee.Details, tooManyBucketsException, circuitBreakingException)
+}
+
+func hasErrorType(details *elastic.ErrorDetails, types ...string) bool {
+	if details == nil {
+		return false
+	}
+
+	for _, t := range types {
+		if details.Type == t {
+			return true
+		}
+	}
+
+	for _, cause := range details.RootCause {
+		if hasErrorType(cause, types...) {
+			return true
+		}
+	}
+
+	if hasCauseType(details.CausedBy, types...) {
+		return true
+	}
+
+	for _, shard := range details.FailedShards {
+		if reason, ok := shard["reason"].(map[string]interface{}); ok && hasCauseType(reason, types...) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasCauseType(cause map[string]interface{}, types ...string) bool {
+	for cause != nil {
+		for _, t := range types {
+			if cause["type"] == t {
+				return true
+			}
+		}
+
+		cause, _ = cause["caused_by"].(map[string]interface{})
+	}
+
+	return false
+}
@@ -0,0 +1,151 @@
+package reveald
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SearchError(t *testing.T) {
+	table := []struct {
+		name  string
+		err   error
+		index string
+	}{
+		{"index not found", &elastic.Error{Status: http.StatusNotFound, Details: &elastic.ErrorDetails{
+			Type: indexNotFoundException, Index: "products"}}, "products"},
+		{"index not found by resource id", &elastic.Error{Status: http.StatusNotFound, Details: &elastic.ErrorDetails{
+			Type: indexNotFoundException, ResourceId: "products"}}, "products"},
+		{"other elasticsearch error", &elastic.Error{Status: http.StatusBadRequest, Details: &elastic.ErrorDetails{
+			Type: "parsing_exception"}}, ""},
+		{"other error", errors.New("connection refused"), ""},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			err := fmt.Errorf("backend failed executing request: %w", searchError(tt.err))
+
+			var inf *IndexNotFoundError
+			if tt.index == "" {
+				assert.False(t, errors.As(err, &inf))
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+
+			assert.True(t, errors.As(err, &inf))
+			assert.Equal(t, tt.index, inf.Index)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func Test_Endpoint_IndicesOptions(t *testing.T) {
+	backend := &fakeBackend{}
+	e := NewEndpoint(backend, WithIndices("products"), WithIgnoreUnavailable(true), WithAllowNoIndices(false))
+
+	_, err := e.Execute(context.Background(), NewRequest())
+	assert.NoError(t, err)
+
+	qb := backend.builders[0]
+	assert.Equal(t, true, *qb.IgnoreUnavailable())
+	assert.Equal(t, false, *qb.AllowNoIndices())
+}
+
+func Test_ExceedsAggregationLimits(t *testing.T) {
+	table := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"other error", errors.New("connection refused"), false},
+		{"other type", &elastic.Error{Status: 400, Details: &elastic.ErrorDetails{Type: "parsing_exception"}}, false},
+		{"too many buckets", &elastic.Error{Status: 400, Details: &elastic.ErrorDetails{Type: tooManyBucketsException}}, true},
+		{"root cause", &elastic.Error{Status: 503, Details: &elastic.ErrorDetails{
+			Type:      "search_phase_execution_exception",
+			RootCause: []*elastic.ErrorDetails{{Type: tooManyBucketsException}},
+		}}, true},
+		{"caused by", &elastic.Error{Status: 503, Details: &elastic.ErrorDetails{
+			Type: "search_phase_execution_exception",
+			CausedBy: map[string]interface{}{
+				"type":      "exception",
+				"caused_by": map[string]interface{}{"type": circuitBreakingException},
+			},
+		}}, true},
+		{"failed shard", &elastic.Error{Status: 503, Details: &elastic.ErrorDetails{
+			Type: "search_phase_execution_exception",
+			FailedShards: []map[string]interface{}{
+				{"reason": map[string]interface{}{"type": circuitBreakingException}},
+			},
+		}}, true},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, exceedsAggregationLimits(searchError(tt.err)))
+		})
+	}
+}
+
+type limitedBackend struct {
+	fakeBackend
+}
+
+func (b *limitedBackend) Execute(ctx context.Context, qb *QueryBuilder) (*Result, error) {
+	if len(qb.aggs) > 0 {
+		b.builders = append(b.builders, qb)
+		return nil, searchError(&elastic.Error{Status: 400, Details: &elastic.ErrorDetails{Type: tooManyBucketsException}})
+	}
+
+	return b.fakeBackend.Execute(ctx, qb)
+}
+
+func Test_Endpoint_AggregationFallback(t *testing.T) {
+	table := []struct {
+		name     string
+		opts     []EndpointOption
+		expected bool
+	}{
+		{"fallback", nil, true},
+		{"without fallback", []EndpointOption{WithoutAggregationFallback()}, false},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &limitedBackend{}
+			e := NewEndpoint(backend, WithIndices("products"), tt.opts...)
+			assert.NoError(t, e.Register(&fakePreparable{}))
+
+			r, err := e.Execute(context.Background(), NewRequest())
+			if !tt.expected {
+				assert.Error(t, err)
+				assert.Len(t, backend.builders, 1)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, backend.builders, 2)
+			assert.NotContains(t, sourceJSON(t, backend.builders[1]), "aggregations")
+			assert.Len(t, r.Warnings, 1)
+			assert.Equal(t, WarningAggregationsDropped, r.Warnings[0].Code)
+		})
+	}
+}
+
+func Test_Endpoint_AggregationFallback_Search(t *testing.T) {
+	backend := &limitedBackend{}
+	e := NewEndpoint(backend, WithIndices("products"))
+	assert.NoError(t, e.Register(&fakePreparable{}))
+
+	searched := 0
+	_, err := e.run(context.Background(), NewRequest(), func(ctx context.Context, qb *QueryBuilder) (*Result, error) {
+		searched++
+		return backend.Execute(ctx, qb)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, searched)
+}
@@ -0,0 +1,39 @@
+package reveald
+
+import "github.com/olivere/elastic/v7"
+
+// ExplanationKey is the hit key holding the explanation
+// of the hit's score, when explaining a search
+const ExplanationKey = "_explanation"
+
+// ResultExplanation is a node of the tree explaining how
+// the score of a hit was computed
+type ResultExplanation struct {
+	Value       float64
+	Description string
+	Details     []*ResultExplanation
+}
+
+// WithExplain includes an explanation of each hit's
+// score, under ExplanationKey
+func (qb *QueryBuilder) WithExplain() {
+	qb.explain = true
+}
+
+// Explain returns whether hit scores are explained
+func (qb *QueryBuilder) Explain() bool {
+	return qb.explain
+}
+
+func mapExplanation(e elastic.SearchExplanation) *ResultExplanation {
+	re := &ResultExplanation{
+		Value:       e.Value,
+		Description: e.Description,
+	}
+
+	for _, d := range e.Details {
+		re.Details = append(re.Details, mapExplanation(d))
+	}
+
+	return re
+}
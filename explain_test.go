@@ -0,0 +1,46 @@
+package reveald
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MapSearchResult_Explanation(t *testing.T) {
+	res := &elastic.SearchResult{
+		Hits: &elastic.SearchHits{
+			Hits: []*elastic.SearchHit{{
+				Source: json.RawMessage(`{"id":1}`),
+				Explanation: &elastic.SearchExplanation{
+					Value:       1.5,
+					Description: "sum of:",
+					Details: []elastic.SearchExplanation{
+						{Value: 1, Description: "weight(title:sound)"},
+						{Value: 0.5, Description: "weight(brand:acme)"},
+					},
+				},
+			}},
+		},
+	}
+
+	r, err := mapSearchResult(res, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &ResultExplanation{
+		Value:       1.5,
+		Description: "sum of:",
+		Details: []*ResultExplanation{
+			{Value: 1, Description: "weight(title:sound)"},
+			{Value: 0.5, Description: "weight(brand:acme)"},
+		},
+	}, r.Hits[0][ExplanationKey])
+}
+
+func Test_QueryBuilder_WithExplain(t *testing.T) {
+	qb := NewQueryBuilder(NewRequest(), "-")
+	assert.NotContains(t, sourceJSON(t, qb), "explain")
+
+	qb.WithExplain()
+	assert.Equal(t, true, sourceJSON(t, qb)["explain"])
+}
@@ -1,11 +1,53 @@
 package featureset
 
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+)
+
 const defaultAggregationSize = 10
 
 type AggregationFeature struct {
-	size int
+	size          int
+	missing       string
+	missingBucket bool
+	labels        map[string]string
+	order         []string
+	less          BucketComparator
+	zeroCount     bool
+	minDocCount   int
+	bucketOrder   BucketOrder
+	include       []string
+	exclude       []string
+	valueSearch   bool
+
+	hideBelowBuckets  int
+	hideBelowCoverage float64
 }
 
+// BucketOrder is the order in which Elasticsearch
+// returns the buckets of a terms aggregation
+type BucketOrder string
+
+const (
+	// BucketOrderByCount orders buckets by descending hit count
+	BucketOrderByCount BucketOrder = "count"
+	// BucketOrderByKeyAsc orders buckets alphabetically
+	BucketOrderByKeyAsc BucketOrder = "key_asc"
+	// BucketOrderByKeyDesc orders buckets reverse alphabetically
+	BucketOrderByKeyDesc BucketOrder = "key_desc"
+)
+
+// BucketComparator reports whether bucket a
+// should be presented before bucket b
+type BucketComparator func(a, b *reveald.ResultBucket) bool
+
 type AggregationOption func(*AggregationFeature)
 
 func WithAggregationSize(size int) AggregationOption {
@@ -14,6 +56,324 @@ func WithAggregationSize(size int) AggregationOption {
 	}
 }
 
+// WithMissingValueAs adds a bucket with the specified value
+// for documents without the property, and filters on documents
+// without the property when the value is requested
+func WithMissingValueAs(value string) AggregationOption {
+	return func(af *AggregationFeature) {
+		af.missing = value
+	}
+}
+
+// WithMissingBucket adds a bucket with the specified value for
+// documents without the property, counted by a missing aggregation
+// rather than the terms aggregation, so that it's neither cut by the
+// aggregation size nor required to match the type of the property.
+// Selecting the value filters on documents without the property
+func WithMissingBucket(value string) AggregationOption {
+	return func(af *AggregationFeature) {
+		af.missing = value
+		af.missingBucket = true
+	}
+}
+
+// WithBucketLabel defines a display label for
+// the bucket with the specified value
+func WithBucketLabel(value, label string) AggregationOption {
+	return func(af *AggregationFeature) {
+		if af.labels == nil {
+			af.labels = make(map[string]string)
+		}
+		af.labels[value] = label
+	}
+}
+
+// WithBucketValueOrder orders buckets with the specified
+// values first, in the specified order, pinning them to the
+// top of the facet (e.g. the house brand)
+func WithBucketValueOrder(values ...string) AggregationOption {
+	return func(af *AggregationFeature) {
+		af.order = values
+	}
+}
+
+// WithBucketComparator orders the buckets which aren't
+// pinned by WithBucketValueOrder, instead of keeping
+// the order returned by Elasticsearch
+func WithBucketComparator(less BucketComparator) AggregationOption {
+	return func(af *AggregationFeature) {
+		af.less = less
+	}
+}
+
+// WithZeroCountBuckets includes buckets without any
+// matching documents
+func WithZeroCountBuckets() AggregationOption {
+	return func(af *AggregationFeature) {
+		af.zeroCount = true
+	}
+}
+
+// WithValueSearch narrows the buckets to the values starting
+// with the text of a companion parameter suffixed .search
+// (e.g. brand.search=son), ignoring case, so that facets with
+// thousands of values may offer type-to-filter lists. Searching
+// values doesn't filter the hits
+func WithValueSearch() AggregationOption {
+	return func(af *AggregationFeature) {
+		af.valueSearch = true
+	}
+}
+
+// WithMinDocCount leaves out buckets with fewer than n
+// hits, such as rare values. A count of zero is the same as
+// WithZeroCountBuckets
+func WithMinDocCount(n int) AggregationOption {
+	return func(af *AggregationFeature) {
+		af.minDocCount = n
+		af.zeroCount = n == 0
+	}
+}
+
+// WithBucketOrder defines the order in which buckets are
+// returned, deciding which values are kept when there are more
+// than the aggregation size, e.g. alphabetical brands
+func WithBucketOrder(order BucketOrder) AggregationOption {
+	return func(af *AggregationFeature) {
+		af.bucketOrder = order
+	}
+}
+
+// WithInclude restricts the buckets to the specified values
+func WithInclude(values ...string) AggregationOption {
+	return func(af *AggregationFeature) {
+		af.include = append(af.include, values...)
+	}
+}
+
+// WithExclude leaves out the buckets with the specified values
+func WithExclude(values ...string) AggregationOption {
+	return func(af *AggregationFeature) {
+		af.exclude = append(af.exclude, values...)
+	}
+}
+
+// WithAutoHide flags the facet as Hidden in Result.Facets when
+// it has fewer than minBuckets buckets with hits, or when less
+// than minCoverage (0-1) of the hits have a value
+func WithAutoHide(minBuckets int, minCoverage float64) AggregationOption {
+	return func(af *AggregationFeature) {
+		af.hideBelowBuckets = minBuckets
+		af.hideBelowCoverage = minCoverage
+	}
+}
+
+func (af AggregationFeature) terms(field string) *elastic.TermsAggregation {
+	agg := elastic.NewTermsAggregation().Field(field).Size(af.size)
+	if af.missing != "" && !af.missingBucket {
+		agg = agg.Missing(af.missing)
+	}
+	if af.zeroCount {
+		agg = agg.MinDocCount(0)
+	} else if af.minDocCount > 0 {
+		agg = agg.MinDocCount(af.minDocCount)
+	}
+
+	switch af.bucketOrder {
+	case BucketOrderByKeyAsc:
+		agg = agg.OrderByKeyAsc()
+	case BucketOrderByKeyDesc:
+		agg = agg.OrderByKeyDesc()
+	}
+
+	if len(af.include) > 0 {
+		agg = agg.IncludeValues(toInterfaces(af.include)...)
+	}
+	if len(af.exclude) > 0 {
+		agg = agg.ExcludeValues(toInterfaces(af.exclude)...)
+	}
+
+	return agg
+}
+
+// searchTerms returns the terms aggregation of a field, narrowed
+// to the values searched for by a request, if value search is
+// enabled and requested. Searching falls back to patterns for the
+// included and excluded values, as Elasticsearch doesn't accept
+// patterns mixed with lists of values
+func (af AggregationFeature) searchTerms(request *reveald.Request, name, field string) *elastic.TermsAggregation {
+	agg := af.terms(field)
+	if !af.valueSearch {
+		return agg
+	}
+
+	p, err := request.Get(fmt.Sprintf("%s.search", name))
+	if err != nil || strings.TrimSpace(p.Value()) == "" {
+		return agg
+	}
+	text := strings.TrimSpace(p.Value())
+
+	if len(af.include) == 0 {
+		agg = agg.Include(prefixPattern(text))
+	} else {
+		var matching []string
+		for _, v := range af.include {
+			if strings.HasPrefix(strings.ToLower(v), strings.ToLower(text)) {
+				matching = append(matching, v)
+			}
+		}
+		agg = agg.Include(valuesPattern(matching))
+	}
+
+	if len(af.exclude) > 0 {
+		agg = agg.Exclude(valuesPattern(af.exclude))
+	}
+
+	return agg
+}
+
+// prefixPattern returns a Lucene regular expression matching
+// values starting with text, where letters match either case
+func prefixPattern(text string) string {
+	var sb strings.Builder
+	for _, r := range text {
+		lower, upper := unicode.ToLower(r), unicode.ToUpper(r)
+		if lower != upper {
+			sb.WriteString("[" + string(lower) + string(upper) + "]")
+			continue
+		}
+
+		sb.WriteString(escapePattern(string(r)))
+	}
+
+	return sb.String() + ".*"
+}
+
+// valuesPattern returns a Lucene regular expression matching
+// any of the values, or nothing when there are no values
+func valuesPattern(values []string) string {
+	if len(values) == 0 {
+		return "#"
+	}
+
+	escaped := make([]string, 0, len(values))
+	for _, v := range values {
+		escaped = append(escaped, escapePattern(v))
+	}
+
+	return strings.Join(escaped, "|")
+}
+
+// escapePattern escapes the reserved characters
+// of Lucene regular expressions in text
+func escapePattern(text string) string {
+	var sb strings.Builder
+	for _, r := range text {
+		if strings.ContainsRune(`.?+*|{}[]()"\#@&<>~`, r) {
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
+
+// missingAggregation returns the name and aggregation counting
+// documents without a field, when a missing bucket is requested
+func (af AggregationFeature) missingAggregation(name, field string) (string, *elastic.MissingAggregation, bool) {
+	if !af.missingBucket {
+		return "", nil, false
+	}
+
+	return fmt.Sprintf("%s_missing", name), elastic.NewMissingAggregation().Field(field), true
+}
+
+// withMissing appends the missing bucket, counted by the
+// missing aggregation, to the buckets of a terms aggregation
+func (af AggregationFeature) withMissing(aggs elastic.Aggregations, name string, buckets []*reveald.ResultBucket) []*reveald.ResultBucket {
+	if !af.missingBucket {
+		return buckets
+	}
+
+	missing, ok := aggs.Missing(fmt.Sprintf("%s_missing", name))
+	if !ok || (missing.DocCount == 0 && !af.zeroCount) {
+		return buckets
+	}
+
+	return append(buckets, &reveald.ResultBucket{
+		Value:    af.missing,
+		HitCount: missing.DocCount,
+	})
+}
+
+// present applies labels and value ordering to buckets
+func (af AggregationFeature) present(buckets []*reveald.ResultBucket) []*reveald.ResultBucket {
+	for _, b := range buckets {
+		if label, ok := af.labels[fmt.Sprint(b.Value)]; ok {
+			b.Label = label
+		}
+	}
+
+	if len(af.order) == 0 && af.less == nil {
+		return buckets
+	}
+
+	rank := make(map[string]int, len(af.order))
+	for i, v := range af.order {
+		rank[v] = i
+	}
+	position := func(b *reveald.ResultBucket) int {
+		if r, ok := rank[fmt.Sprint(b.Value)]; ok {
+			return r
+		}
+		return len(rank)
+	}
+
+	sort.SliceStable(buckets, func(i, j int) bool {
+		pi, pj := position(buckets[i]), position(buckets[j])
+		if pi != pj || af.less == nil {
+			return pi < pj
+		}
+
+		return af.less(buckets[i], buckets[j])
+	})
+
+	return buckets
+}
+
+// describe adds facet metadata to the result, computed from
+// its buckets, leaving out any bucket of missing values
+func (af AggregationFeature) describe(result *reveald.Result, name string, buckets []*reveald.ResultBucket) {
+	var count int
+	var hits int64
+	for _, b := range buckets {
+		if b.HitCount == 0 || af.isMissing(fmt.Sprint(b.Value)) {
+			continue
+		}
+
+		count++
+		hits += b.HitCount
+	}
+
+	coverage := 0.0
+	if result.TotalHitCount > 0 {
+		coverage = math.Min(float64(hits)/float64(result.TotalHitCount), 1)
+	}
+
+	if result.Facets == nil {
+		result.Facets = make(map[string]*reveald.ResultFacet)
+	}
+
+	result.Facets[name] = &reveald.ResultFacet{
+		Coverage: coverage,
+		Hidden:   count < af.hideBelowBuckets || coverage < af.hideBelowCoverage,
+	}
+}
+
+func (af AggregationFeature) isMissing(value string) bool {
+	return af.missing != "" && value == af.missing
+}
+
 func buildAggregationFeature(opts ...AggregationOption) AggregationFeature {
 	agg := AggregationFeature{
 		size: defaultAggregationSize,
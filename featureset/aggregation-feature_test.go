@@ -0,0 +1,156 @@
+package featureset
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/reveald/reveald"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_AggregationFeature_Present(t *testing.T) {
+	af := buildAggregationFeature(
+		WithBucketLabel("true", "In stock"),
+		WithBucketLabel("false", "Out of stock"),
+		WithBucketValueOrder("true", "false"))
+
+	buckets := af.present([]*reveald.ResultBucket{
+		{Value: "other", HitCount: 1},
+		{Value: "false", HitCount: 10},
+		{Value: "true", HitCount: 5},
+	})
+
+	assert.Equal(t, []*reveald.ResultBucket{
+		{Value: "true", Label: "In stock", HitCount: 5},
+		{Value: "false", Label: "Out of stock", HitCount: 10},
+		{Value: "other", HitCount: 1},
+	}, buckets)
+}
+
+func Test_AggregationFeature_ZeroCount(t *testing.T) {
+	src, err := buildAggregationFeature(WithZeroCountBuckets()).terms("property").Source()
+	assert.NoError(t, err)
+
+	terms := src.(map[string]interface{})["terms"].(map[string]interface{})
+	assert.Equal(t, 0, terms["min_doc_count"])
+}
+
+func Test_AggregationFeature_Terms(t *testing.T) {
+	table := []struct {
+		name     string
+		opts     []AggregationOption
+		expected string
+	}{
+		{"default", nil, `{"field":"brand","size":10}`},
+		{"alphabetical", []AggregationOption{WithBucketOrder(BucketOrderByKeyAsc)}, `{"field":"brand","size":10,"order":[{"_key":"asc"}]}`},
+		{"reverse alphabetical", []AggregationOption{WithBucketOrder(BucketOrderByKeyDesc)}, `{"field":"brand","size":10,"order":[{"_key":"desc"}]}`},
+		{"rare values hidden", []AggregationOption{WithMinDocCount(5)}, `{"field":"brand","size":10,"min_doc_count":5}`},
+		{"zero count", []AggregationOption{WithMinDocCount(0)}, `{"field":"brand","size":10,"min_doc_count":0}`},
+		{"included and excluded", []AggregationOption{WithInclude("Sony", "Sonos"), WithExclude("Sonos")}, `{"field":"brand","size":10,"include":["Sony","Sonos"],"exclude":["Sonos"]}`},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			src, err := buildAggregationFeature(tt.opts...).terms("brand").Source()
+			assert.NoError(t, err)
+
+			actual, err := json.Marshal(src.(map[string]interface{})["terms"])
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(actual))
+		})
+	}
+}
+
+func Test_AggregationFeature_SearchTerms(t *testing.T) {
+	request := reveald.NewRequest(reveald.NewParameter("brand.search", "so"))
+
+	table := []struct {
+		name     string
+		opts     []AggregationOption
+		expected string
+	}{
+		{"prefix", []AggregationOption{WithValueSearch()}, `{"field":"brand","size":10,"include":"[sS][oO].*"}`},
+		{"included values", []AggregationOption{WithValueSearch(), WithInclude("Sony", "Jabra")}, `{"field":"brand","size":10,"include":"Sony"}`},
+		{"no included value", []AggregationOption{WithValueSearch(), WithInclude("Jabra")}, `{"field":"brand","size":10,"include":"#"}`},
+		{"excluded values", []AggregationOption{WithValueSearch(), WithExclude("Sonos", "B&O")}, `{"field":"brand","size":10,"include":"[sS][oO].*","exclude":"Sonos|B\\&O"}`},
+		{"not enabled", []AggregationOption{WithInclude("Jabra")}, `{"field":"brand","size":10,"include":["Jabra"]}`},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			src, err := buildAggregationFeature(tt.opts...).searchTerms(request, "brand", "brand").Source()
+			assert.NoError(t, err)
+
+			actual, err := json.Marshal(src.(map[string]interface{})["terms"])
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(actual))
+		})
+	}
+}
+
+func Test_AggregationFeature_Comparator(t *testing.T) {
+	byValue := func(a, b *reveald.ResultBucket) bool {
+		return a.Value.(string) < b.Value.(string)
+	}
+
+	table := []struct {
+		name     string
+		opts     []AggregationOption
+		expected []string
+	}{
+		{"comparator", []AggregationOption{WithBucketComparator(byValue)}, []string{"acme", "globex", "house", "initech"}},
+		{"pinned and comparator", []AggregationOption{
+			WithBucketValueOrder("house"),
+			WithBucketComparator(byValue)}, []string{"house", "acme", "globex", "initech"}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			buckets := buildAggregationFeature(tt.opts...).present([]*reveald.ResultBucket{
+				{Value: "initech", HitCount: 9},
+				{Value: "globex", HitCount: 7},
+				{Value: "house", HitCount: 3},
+				{Value: "acme", HitCount: 1},
+			})
+
+			var values []string
+			for _, b := range buckets {
+				values = append(values, b.Value.(string))
+			}
+
+			assert.Equal(t, tt.expected, values)
+		})
+	}
+}
+
+func Test_AggregationFeature_AutoHide(t *testing.T) {
+	table := []struct {
+		name     string
+		opts     []AggregationOption
+		buckets  []*reveald.ResultBucket
+		coverage float64
+		hidden   bool
+	}{
+		{"disabled", nil, []*reveald.ResultBucket{{Value: "a", HitCount: 1}}, 0.01, false},
+		{"enough buckets and coverage", []AggregationOption{WithAutoHide(2, 0.5)}, []*reveald.ResultBucket{
+			{Value: "a", HitCount: 40}, {Value: "b", HitCount: 30}}, 0.7, false},
+		{"too few buckets", []AggregationOption{WithAutoHide(2, 0.5)}, []*reveald.ResultBucket{
+			{Value: "a", HitCount: 90}, {Value: "b", HitCount: 0}}, 0.9, true},
+		{"too low coverage", []AggregationOption{WithAutoHide(2, 0.5)}, []*reveald.ResultBucket{
+			{Value: "a", HitCount: 10}, {Value: "b", HitCount: 10}}, 0.2, true},
+		{"missing bucket excluded", []AggregationOption{WithAutoHide(2, 0.5), WithMissingValueAs("(none)")}, []*reveald.ResultBucket{
+			{Value: "a", HitCount: 10}, {Value: "b", HitCount: 10}, {Value: "(none)", HitCount: 80}}, 0.2, true},
+		{"multi-valued coverage capped", nil, []*reveald.ResultBucket{
+			{Value: "a", HitCount: 100}, {Value: "b", HitCount: 100}}, 1, false},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &reveald.Result{TotalHitCount: 100}
+			buildAggregationFeature(tt.opts...).describe(result, "brand", tt.buckets)
+
+			assert.InDelta(t, tt.coverage, result.Facets["brand"].Coverage, 1e-9)
+			assert.Equal(t, tt.hidden, result.Facets["brand"].Hidden)
+		})
+	}
+}
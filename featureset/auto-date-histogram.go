@@ -0,0 +1,145 @@
+package featureset
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+)
+
+// AutoDateHistogramFeature aggregates a date property into about
+// a target number of buckets, with an interval chosen by
+// Elasticsearch to fit the dates searched, e.g. for dashboards
+// where the selected time window varies. The chosen interval,
+// such as 1d or 7d, is returned in Result.Intervals. Filtering on
+// the buckets is left to a DateRangeFeature
+type AutoDateHistogramFeature struct {
+	property        string
+	buckets         int
+	minimumInterval string
+	format          string
+	timeZone        string
+	zerobucket      bool
+}
+
+type AutoDateHistogramOption func(*AutoDateHistogramFeature)
+
+// WithTargetBuckets sets the number of buckets
+// to aim for, defaulting to 10
+func WithTargetBuckets(buckets int) AutoDateHistogramOption {
+	return func(adhf *AutoDateHistogramFeature) {
+		adhf.buckets = buckets
+	}
+}
+
+// WithMinimumInterval sets the smallest interval to use,
+// one of second, minute, hour, day, month or year
+func WithMinimumInterval(interval string) AutoDateHistogramOption {
+	return func(adhf *AutoDateHistogramFeature) {
+		adhf.minimumInterval = interval
+	}
+}
+
+// WithAutoDateFormat sets the format of the bucket
+// keys, defaulting to the format of the property
+func WithAutoDateFormat(format string) AutoDateHistogramOption {
+	return func(adhf *AutoDateHistogramFeature) {
+		adhf.format = format
+	}
+}
+
+// WithAutoDateTimeZone sets the time zone the
+// buckets are rounded in, defaulting to UTC
+func WithAutoDateTimeZone(timeZone string) AutoDateHistogramOption {
+	return func(adhf *AutoDateHistogramFeature) {
+		adhf.timeZone = timeZone
+	}
+}
+
+func WithoutAutoDateHistogramZeroBucket() AutoDateHistogramOption {
+	return func(adhf *AutoDateHistogramFeature) {
+		adhf.zerobucket = false
+	}
+}
+
+func NewAutoDateHistogramFeature(property string, opts ...AutoDateHistogramOption) *AutoDateHistogramFeature {
+	adhf := &AutoDateHistogramFeature{
+		property:   property,
+		buckets:    10,
+		zerobucket: true,
+	}
+
+	for _, opt := range opts {
+		opt(adhf)
+	}
+
+	return adhf
+}
+
+func (adhf *AutoDateHistogramFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	adhf.build(builder)
+
+	r, err := next(builder)
+	if err != nil {
+		return nil, err
+	}
+
+	return adhf.handle(r)
+}
+
+func (adhf *AutoDateHistogramFeature) build(builder *reveald.QueryBuilder) {
+	agg := elastic.NewAutoDateHistogramAggregation().
+		Field(adhf.property).
+		Buckets(adhf.buckets)
+	if adhf.minimumInterval != "" {
+		agg = agg.MinimumInterval(adhf.minimumInterval)
+	}
+	if adhf.format != "" {
+		agg = agg.Format(adhf.format)
+	}
+	if adhf.timeZone != "" {
+		agg = agg.TimeZone(adhf.timeZone)
+	}
+
+	builder.Aggregation(adhf.property, agg)
+}
+
+func (adhf *AutoDateHistogramFeature) handle(result *reveald.Result) (*reveald.Result, error) {
+	agg, ok := result.RawResult().Aggregations.AutoDateHistogram(adhf.property)
+	if !ok {
+		return result, nil
+	}
+
+	var buckets []*reveald.ResultBucket
+	for _, bucket := range agg.Buckets {
+		if bucket.DocCount == 0 && !adhf.zerobucket {
+			continue
+		}
+
+		value := strconv.FormatFloat(bucket.Key, 'f', -1, 64)
+		if bucket.KeyAsString != nil {
+			value = *bucket.KeyAsString
+		}
+
+		buckets = append(buckets, &reveald.ResultBucket{
+			Value:    value,
+			HitCount: bucket.DocCount,
+		})
+	}
+
+	result.Aggregations[adhf.property] = buckets
+
+	// the interval isn't decoded by the client
+	var raw struct {
+		Interval string `json:"interval"`
+	}
+	if err := json.Unmarshal(result.RawResult().Aggregations[adhf.property], &raw); err == nil && raw.Interval != "" {
+		if result.Intervals == nil {
+			result.Intervals = make(map[string]string)
+		}
+		result.Intervals[adhf.property] = raw.Interval
+	}
+
+	return result, nil
+}
@@ -0,0 +1,40 @@
+package featureset
+
+import (
+	"testing"
+
+	"github.com/reveald/reveald"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_AutoDateHistogramFeature_Build(t *testing.T) {
+	table := []struct {
+		name     string
+		opts     []AutoDateHistogramOption
+		expected map[string]interface{}
+	}{
+		{"defaults", nil, map[string]interface{}{"field": "published", "buckets": float64(10)}},
+		{"options", []AutoDateHistogramOption{
+			WithTargetBuckets(20),
+			WithMinimumInterval("day"),
+			WithAutoDateFormat("yyyy-MM-dd"),
+			WithAutoDateTimeZone("Europe/Stockholm"),
+		}, map[string]interface{}{
+			"field":            "published",
+			"buckets":          float64(20),
+			"minimum_interval": "day",
+			"format":           "yyyy-MM-dd",
+			"time_zone":        "Europe/Stockholm",
+		}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			qb := reveald.NewQueryBuilder(reveald.NewRequest(), "articles")
+			NewAutoDateHistogramFeature("published", tt.opts...).build(qb)
+
+			aggs := sourceJSON(t, qb)["aggregations"].(map[string]interface{})
+			assert.Equal(t, tt.expected, aggs["published"].(map[string]interface{})["auto_date_histogram"])
+		})
+	}
+}
@@ -20,6 +20,15 @@ func NewBooleanFilterFeature(property string, opts ...AggregationOption) *Boolea
 	}
 }
 
+// Describe returns the parameter filtering on the property
+func (bff *BooleanFilterFeature) Describe() []reveald.ParameterDescription {
+	return []reveald.ParameterDescription{{
+		Name:        bff.property,
+		Kind:        reveald.ParameterBoolean,
+		Description: fmt.Sprintf("Filters on whether %s is set", bff.property),
+	}}
+}
+
 func (bff *BooleanFilterFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
 	bff.build(builder)
 
@@ -34,8 +43,10 @@ func (bff *BooleanFilterFeature) Process(builder *reveald.QueryBuilder, next rev
 func (bff *BooleanFilterFeature) build(builder *reveald.QueryBuilder) {
 	keyword := fmt.Sprintf("%s.keyword", bff.property)
 
-	builder.Aggregation(bff.property,
-		elastic.NewTermsAggregation().Field(keyword).Size(bff.agg.size))
+	builder.Aggregation(bff.property, bff.agg.terms(keyword))
+	if name, missing, ok := bff.agg.missingAggregation(bff.property, keyword); ok {
+		builder.FacetAggregation(bff.property, name, missing)
+	}
 
 	if !builder.Request().Has(bff.property) {
 		return
@@ -46,12 +57,19 @@ func (bff *BooleanFilterFeature) build(builder *reveald.QueryBuilder) {
 		return
 	}
 
+	if bff.agg.isMissing(v.Value()) {
+		builder.FacetFilter(bff.property, elastic.NewBoolQuery().MustNot(elastic.NewExistsQuery(keyword)))
+		builder.ApplyFilter(&reveald.ResultFilter{Property: bff.property, Values: []string{v.Value()}})
+		return
+	}
+
 	bl, err := strconv.ParseBool(v.Value())
 	if err != nil {
 		return
 	}
 
-	builder.With(elastic.NewTermQuery(bff.property, bl))
+	builder.FacetFilter(bff.property, elastic.NewTermQuery(bff.property, bl))
+	builder.ApplyFilter(&reveald.ResultFilter{Property: bff.property, Values: []string{strconv.FormatBool(bl)}})
 }
 
 func (bff *BooleanFilterFeature) handle(result *reveald.Result) (*reveald.Result, error) {
@@ -61,17 +79,38 @@ func (bff *BooleanFilterFeature) handle(result *reveald.Result) (*reveald.Result
 	}
 
 	var buckets []*reveald.ResultBucket
+	seen := make(map[string]bool)
 	for _, bucket := range agg.Buckets {
 		if bucket == nil {
 			continue
 		}
 
+		var value interface{} = bucket.Key
+		if bucket.KeyAsString != nil {
+			value = *bucket.KeyAsString
+		}
+		seen[fmt.Sprint(value)] = true
+
 		buckets = append(buckets, &reveald.ResultBucket{
-			Value:    bucket.Key,
+			Value:    value,
 			HitCount: bucket.DocCount,
 		})
 	}
 
-	result.Aggregations[bff.property] = buckets
+	if bff.agg.zeroCount {
+		for _, value := range []string{"true", "false"} {
+			if !seen[value] {
+				buckets = append(buckets, &reveald.ResultBucket{
+					Value:    value,
+					HitCount: 0,
+				})
+			}
+		}
+	}
+
+	buckets = bff.agg.withMissing(result.RawResult().Aggregations, bff.property, buckets)
+
+	result.Aggregations[bff.property] = bff.agg.present(buckets)
+	bff.agg.describe(result, bff.property, buckets)
 	return result, nil
 }
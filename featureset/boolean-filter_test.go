@@ -0,0 +1,18 @@
+package featureset
+
+import (
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BooleanFilterFeature_MissingValue(t *testing.T) {
+	qb := reveald.NewQueryBuilder(reveald.NewRequest(reveald.NewParameter("inStock", "(Unknown)")), "-")
+	NewBooleanFilterFeature("inStock", WithMissingValueAs("(Unknown)")).build(qb)
+
+	expected := elastic.NewBoolQuery().Must(
+		elastic.NewBoolQuery().MustNot(elastic.NewExistsQuery("inStock.keyword")))
+	assert.Equal(t, expected, qb.RawQuery())
+}
@@ -0,0 +1,186 @@
+package featureset
+
+import (
+	"fmt"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+)
+
+// Decay function types
+const (
+	DecayGauss       = "gauss"
+	DecayLinear      = "linear"
+	DecayExponential = "exp"
+)
+
+// BoostProfile declares how to adjust relevance scores
+// with a function_score query
+type BoostProfile struct {
+	// ScoreMode defines how function scores are combined
+	ScoreMode string
+	// BoostMode defines how the combined function score is
+	// combined with the query score
+	BoostMode string
+	// MaxBoost caps the combined function score, when positive
+	MaxBoost float64
+
+	FieldValueFactors []FieldValueFactorBoost
+	Decays            []DecayBoost
+	Filters           []FilterBoost
+	Scripts           []ScriptBoost
+}
+
+// FieldValueFactorBoost boosts documents by a numeric property,
+// e.g. popularity or rating
+type FieldValueFactorBoost struct {
+	Property string
+	Factor   float64
+	Modifier string
+	Missing  float64
+	Weight   float64
+}
+
+// DecayBoost boosts documents by their distance from an origin,
+// on a date, numeric, or geo property
+type DecayBoost struct {
+	Function string
+	Property string
+	Origin   interface{}
+	Scale    interface{}
+	Offset   interface{}
+	Decay    float64
+	Weight   float64
+}
+
+// FilterBoost boosts documents matching a filter
+type FilterBoost struct {
+	Filter elastic.Query
+	Weight float64
+}
+
+// ScriptBoost boosts documents by a painless script
+type ScriptBoost struct {
+	Script string
+	Params map[string]interface{}
+	Weight float64
+}
+
+type BoostProfileFeature struct {
+	profile BoostProfile
+}
+
+func NewBoostProfileFeature(profile BoostProfile) *BoostProfileFeature {
+	return &BoostProfileFeature{profile}
+}
+
+func (bpf *BoostProfileFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	if err := bpf.build(builder); err != nil {
+		return nil, err
+	}
+
+	return next(builder)
+}
+
+func (bpf *BoostProfileFeature) build(builder *reveald.QueryBuilder) error {
+	p := bpf.profile
+
+	for _, fvf := range p.FieldValueFactors {
+		fn := elastic.NewFieldValueFactorFunction().Field(fvf.Property)
+		if fvf.Factor != 0 {
+			fn = fn.Factor(fvf.Factor)
+		}
+		if fvf.Modifier != "" {
+			fn = fn.Modifier(fvf.Modifier)
+		}
+		if fvf.Missing != 0 {
+			fn = fn.Missing(fvf.Missing)
+		}
+		if fvf.Weight != 0 {
+			fn = fn.Weight(fvf.Weight)
+		}
+
+		builder.ScoreFunction(nil, fn)
+	}
+
+	for _, d := range p.Decays {
+		fn, err := decayFunction(d)
+		if err != nil {
+			return err
+		}
+
+		builder.ScoreFunction(nil, fn)
+	}
+
+	for _, f := range p.Filters {
+		builder.ScoreFunction(f.Filter, elastic.NewWeightFactorFunction(f.Weight))
+	}
+
+	for _, s := range p.Scripts {
+		script := elastic.NewScript(s.Script)
+		if len(s.Params) > 0 {
+			script = script.Params(s.Params)
+		}
+
+		fn := elastic.NewScriptFunction(script)
+		if s.Weight != 0 {
+			fn = fn.Weight(s.Weight)
+		}
+
+		builder.ScoreFunction(nil, fn)
+	}
+
+	if p.ScoreMode != "" {
+		builder.WithScoreMode(p.ScoreMode)
+	}
+	if p.BoostMode != "" {
+		builder.WithBoostMode(p.BoostMode)
+	}
+	if p.MaxBoost > 0 {
+		builder.WithMaxBoost(p.MaxBoost)
+	}
+
+	return nil
+}
+
+func decayFunction(d DecayBoost) (elastic.ScoreFunction, error) {
+	switch d.Function {
+	case DecayGauss, "":
+		return newDecayFunction(elastic.NewGaussDecayFunction, d), nil
+	case DecayLinear:
+		return newDecayFunction(elastic.NewLinearDecayFunction, d), nil
+	case DecayExponential:
+		return newDecayFunction(elastic.NewExponentialDecayFunction, d), nil
+	}
+
+	return nil, fmt.Errorf("invalid decay function: %s", d.Function)
+}
+
+// decayScoreFunction is implemented by the
+// gauss, linear and exponential decay functions
+type decayScoreFunction[T any] interface {
+	elastic.ScoreFunction
+	FieldName(fieldName string) T
+	Origin(origin interface{}) T
+	Scale(scale interface{}) T
+	Offset(offset interface{}) T
+	Decay(decay float64) T
+	Weight(weight float64) T
+}
+
+// newDecayFunction returns the decay function created by
+// the constructor, configured by a decay boost
+func newDecayFunction[T decayScoreFunction[T]](constructor func() T, d DecayBoost) elastic.ScoreFunction {
+	fn := constructor().FieldName(d.Property).Origin(d.Origin).Scale(d.Scale)
+	if d.Offset != nil {
+		fn = fn.Offset(d.Offset)
+	}
+	if d.Decay > 0 {
+		fn = fn.Decay(d.Decay)
+	}
+	if d.Weight != 0 {
+		fn = fn.Weight(d.Weight)
+	}
+
+	return fn
+}
@@ -0,0 +1,89 @@
+package featureset
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BoostProfileFeature_Build(t *testing.T) {
+	qb := reveald.NewQueryBuilder(reveald.NewRequest(), "-")
+	err := NewBoostProfileFeature(BoostProfile{
+		ScoreMode: "sum",
+		BoostMode: "multiply",
+		MaxBoost:  10,
+		FieldValueFactors: []FieldValueFactorBoost{
+			{Property: "popularity", Modifier: "log1p", Missing: 1},
+		},
+		Decays: []DecayBoost{
+			{Function: DecayExponential, Property: "published_at", Origin: "now", Scale: "7d"},
+		},
+		Filters: []FilterBoost{
+			{Filter: elastic.NewTermQuery("brand", "house"), Weight: 2},
+		},
+	}).build(qb)
+	assert.NoError(t, err)
+
+	src, err := qb.Build().Source()
+	assert.NoError(t, err)
+	data, _ := json.Marshal(src)
+
+	var m struct {
+		Query struct {
+			FunctionScore struct {
+				Functions []map[string]interface{} `json:"functions"`
+				ScoreMode string                   `json:"score_mode"`
+				BoostMode string                   `json:"boost_mode"`
+				MaxBoost  float64                  `json:"max_boost"`
+			} `json:"function_score"`
+		} `json:"query"`
+	}
+	assert.NoError(t, json.Unmarshal(data, &m))
+
+	fs := m.Query.FunctionScore
+	assert.Len(t, fs.Functions, 3)
+	assert.Contains(t, fs.Functions[0], "field_value_factor")
+	assert.Contains(t, fs.Functions[1], "exp")
+	assert.Contains(t, fs.Functions[2], "filter")
+	assert.Equal(t, "sum", fs.ScoreMode)
+	assert.Equal(t, "multiply", fs.BoostMode)
+	assert.Equal(t, 10.0, fs.MaxBoost)
+}
+
+func Test_BoostProfileFeature_InvalidDecay(t *testing.T) {
+	qb := reveald.NewQueryBuilder(reveald.NewRequest(), "-")
+	err := NewBoostProfileFeature(BoostProfile{
+		Decays: []DecayBoost{{Function: "cubic", Property: "published_at"}},
+	}).build(qb)
+	assert.Error(t, err)
+}
+
+func Test_DecayFunction(t *testing.T) {
+	table := []struct {
+		function string
+		name     string
+	}{
+		{"", "gauss"},
+		{DecayGauss, "gauss"},
+		{DecayLinear, "linear"},
+		{DecayExponential, "exp"},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			fn, err := decayFunction(DecayBoost{Function: tt.function, Property: "published_at", Origin: "now", Scale: "7d", Decay: 0.5, Weight: 2})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.name, fn.Name())
+
+			src, err := fn.Source()
+			assert.NoError(t, err)
+			assert.Equal(t, map[string]interface{}{
+				"published_at": map[string]interface{}{"origin": "now", "scale": "7d", "decay": 0.5},
+			}, src)
+			assert.Equal(t, 2.0, *fn.GetWeight())
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package featureset
+
+import (
+	"fmt"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+)
+
+const cardinalityAggregationSuffix = "_cardinality"
+
+type CardinalityFeature struct {
+	property  string
+	field     string
+	threshold int64
+}
+
+type CardinalityOption func(*CardinalityFeature)
+
+// WithPrecisionThreshold sets the count below which
+// the cardinality is expected to be close to exact
+func WithPrecisionThreshold(threshold int64) CardinalityOption {
+	return func(cf *CardinalityFeature) {
+		cf.threshold = threshold
+	}
+}
+
+// WithCardinalityField counts the values of the specified
+// field, instead of the keyword field of the property
+func WithCardinalityField(field string) CardinalityOption {
+	return func(cf *CardinalityFeature) {
+		cf.field = field
+	}
+}
+
+// NewCardinalityFeature returns the approximate number of
+// distinct values of a property in Result.Cardinalities, e.g.
+// "1,238 brands" next to a facet header. When counting facets
+// disjunctively, the count isn't narrowed by values selected
+// on the same property
+func NewCardinalityFeature(property string, opts ...CardinalityOption) *CardinalityFeature {
+	cf := &CardinalityFeature{
+		property: property,
+		field:    fmt.Sprintf("%s.keyword", property),
+	}
+
+	for _, opt := range opts {
+		opt(cf)
+	}
+
+	return cf
+}
+
+func (cf *CardinalityFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	cf.build(builder)
+
+	r, err := next(builder)
+	if err != nil {
+		return nil, err
+	}
+
+	return cf.handle(r)
+}
+
+func (cf *CardinalityFeature) name() string {
+	return cf.property + cardinalityAggregationSuffix
+}
+
+func (cf *CardinalityFeature) build(builder *reveald.QueryBuilder) {
+	agg := elastic.NewCardinalityAggregation().Field(cf.field)
+	if cf.threshold > 0 {
+		agg = agg.PrecisionThreshold(cf.threshold)
+	}
+
+	builder.FacetAggregation(cf.property, cf.name(), agg)
+}
+
+func (cf *CardinalityFeature) handle(result *reveald.Result) (*reveald.Result, error) {
+	agg, ok := result.RawResult().Aggregations.Cardinality(cf.name())
+	if !ok || agg.Value == nil {
+		return result, nil
+	}
+
+	if result.Cardinalities == nil {
+		result.Cardinalities = make(map[string]int64)
+	}
+
+	result.Cardinalities[cf.property] = int64(*agg.Value)
+	return result, nil
+}
@@ -0,0 +1,34 @@
+package featureset
+
+import (
+	"testing"
+
+	"github.com/reveald/reveald"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CardinalityFeature_Build(t *testing.T) {
+	table := []struct {
+		name     string
+		opts     []CardinalityOption
+		expected map[string]interface{}
+	}{
+		{"keyword field", nil, map[string]interface{}{"field": "brand.keyword"}},
+		{"custom field", []CardinalityOption{WithCardinalityField("brand_id")}, map[string]interface{}{"field": "brand_id"}},
+		{"precision threshold", []CardinalityOption{WithPrecisionThreshold(1000)}, map[string]interface{}{
+			"field": "brand.keyword", "precision_threshold": int64(1000)}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			qb := reveald.NewQueryBuilder(reveald.NewRequest(), "-")
+			NewCardinalityFeature("brand", tt.opts...).build(qb)
+
+			src, err := qb.Build().Source()
+			assert.NoError(t, err)
+
+			aggs := src.(map[string]interface{})["aggregations"].(map[string]interface{})
+			assert.Equal(t, tt.expected, aggs["brand_cardinality"].(map[string]interface{})["cardinality"])
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package featureset
+
+import "github.com/reveald/reveald"
+
+const defaultCollapsedHitsSize = 3
+
+type CollapseFeature struct {
+	field         string
+	innerHitsSize int
+}
+
+type CollapseOption func(*CollapseFeature)
+
+// WithCollapsedHitsSize defines the number of other documents
+// returned per group, where 0 returns the representative only
+func WithCollapsedHitsSize(size int) CollapseOption {
+	return func(cf *CollapseFeature) {
+		cf.innerHitsSize = size
+	}
+}
+
+// NewCollapseFeature returns a single hit per value of the field
+// (e.g. product_group_id), holding other documents of the group
+// under reveald.CollapsedHitsKey
+func NewCollapseFeature(field string, opts ...CollapseOption) *CollapseFeature {
+	cf := &CollapseFeature{
+		field:         field,
+		innerHitsSize: defaultCollapsedHitsSize,
+	}
+
+	for _, opt := range opts {
+		opt(cf)
+	}
+
+	return cf
+}
+
+func (cf *CollapseFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	cf.build(builder)
+	return next(builder)
+}
+
+func (cf *CollapseFeature) build(builder *reveald.QueryBuilder) {
+	builder.WithCollapse(cf.field, cf.innerHitsSize)
+}
@@ -0,0 +1,37 @@
+package featureset
+
+import (
+	"testing"
+
+	"github.com/reveald/reveald"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CollapseFeature_Build(t *testing.T) {
+	table := []struct {
+		name     string
+		opts     []CollapseOption
+		expected map[string]interface{}
+	}{
+		{"default inner hits", nil, map[string]interface{}{
+			"field": "product_group_id",
+			"inner_hits": []interface{}{
+				map[string]interface{}{"name": reveald.CollapsedHitsKey, "size": defaultCollapsedHitsSize},
+			},
+		}},
+		{"without inner hits", []CollapseOption{WithCollapsedHitsSize(0)}, map[string]interface{}{
+			"field": "product_group_id",
+		}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			qb := reveald.NewQueryBuilder(reveald.NewRequest(), "-")
+			NewCollapseFeature("product_group_id", tt.opts...).build(qb)
+
+			src, err := qb.Build().Source()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, src.(map[string]interface{})["collapse"])
+		})
+	}
+}
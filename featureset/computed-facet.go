@@ -0,0 +1,158 @@
+package featureset
+
+import (
+	"fmt"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+)
+
+const computedFacetValue = "value"
+
+type computedVariable struct {
+	name  string
+	field string
+}
+
+type ComputedFacetFeature struct {
+	name      string
+	groupBy   string
+	script    string
+	size      int
+	variables []computedVariable
+	ranges    []float64
+}
+
+type ComputedFacetOption func(*ComputedFacetFeature)
+
+// WithSumVariable exposes the sum of a field within each
+// group to the script, as params.<name>
+func WithSumVariable(name, field string) ComputedFacetOption {
+	return func(cff *ComputedFacetFeature) {
+		cff.variables = append(cff.variables, computedVariable{name, field})
+	}
+}
+
+// WithComputedRanges buckets groups into ranges of their computed
+// value, bounded by consecutive edges (e.g. 0, 10, 25, 50), instead
+// of returning one bucket per group
+func WithComputedRanges(edges ...float64) ComputedFacetOption {
+	return func(cff *ComputedFacetFeature) {
+		cff.ranges = edges
+	}
+}
+
+func WithComputedGroupSize(size int) ComputedFacetOption {
+	return func(cff *ComputedFacetFeature) {
+		cff.size = size
+	}
+}
+
+// NewComputedFacetFeature derives a facet from aggregated values
+// using a bucket_script, rather than a document level script. The
+// documents are grouped on the groupBy field, and the script is
+// evaluated once per group, e.g. a discount percentage with
+// "100 * (1 - params.price / params.list_price)"
+func NewComputedFacetFeature(name, groupBy, script string, opts ...ComputedFacetOption) *ComputedFacetFeature {
+	cff := &ComputedFacetFeature{
+		name:    name,
+		groupBy: groupBy,
+		script:  script,
+		size:    defaultAggregationSize,
+	}
+
+	for _, opt := range opts {
+		opt(cff)
+	}
+
+	return cff
+}
+
+func (cff *ComputedFacetFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	cff.build(builder)
+
+	r, err := next(builder)
+	if err != nil {
+		return nil, err
+	}
+
+	return cff.handle(r)
+}
+
+func (cff *ComputedFacetFeature) build(builder *reveald.QueryBuilder) {
+	agg := elastic.NewTermsAggregation().
+		Field(cff.groupBy).
+		Size(cff.size)
+
+	paths := make(map[string]string, len(cff.variables))
+	for _, v := range cff.variables {
+		agg = agg.SubAggregation(v.name, elastic.NewSumAggregation().Field(v.field))
+		paths[v.name] = v.name
+	}
+
+	agg = agg.SubAggregation(computedFacetValue,
+		elastic.NewBucketScriptAggregation().
+			BucketsPathsMap(paths).
+			Script(elastic.NewScript(cff.script)).
+			GapSkip())
+
+	builder.Aggregation(cff.name, agg)
+}
+
+func (cff *ComputedFacetFeature) handle(result *reveald.Result) (*reveald.Result, error) {
+	agg, ok := result.RawResult().Aggregations.Terms(cff.name)
+	if !ok {
+		return result, nil
+	}
+
+	var buckets []*reveald.ResultBucket
+	for _, bucket := range agg.Buckets {
+		if bucket == nil {
+			continue
+		}
+
+		v, ok := bucket.Aggregations.BucketScript(computedFacetValue)
+		if !ok || v.Value == nil {
+			continue
+		}
+
+		buckets = append(buckets, &reveald.ResultBucket{
+			Value:    *v.Value,
+			Label:    fmt.Sprint(bucket.Key),
+			HitCount: bucket.DocCount,
+		})
+	}
+
+	if len(cff.ranges) > 1 {
+		buckets = cff.rangeBuckets(buckets)
+	}
+
+	result.Aggregations[cff.name] = buckets
+	return result, nil
+}
+
+// rangeBuckets sums the hit counts of groups whose computed
+// value falls within each range, including the upper bound
+// of the last range
+func (cff *ComputedFacetFeature) rangeBuckets(groups []*reveald.ResultBucket) []*reveald.ResultBucket {
+	buckets := make([]*reveald.ResultBucket, len(cff.ranges)-1)
+	for i := range buckets {
+		buckets[i] = &reveald.ResultBucket{
+			Value: fmt.Sprintf("%g-%g", cff.ranges[i], cff.ranges[i+1]),
+		}
+	}
+
+	last := len(buckets) - 1
+	for _, g := range groups {
+		v := g.Value.(float64)
+		for i, b := range buckets {
+			from, to := cff.ranges[i], cff.ranges[i+1]
+			if v >= from && (v < to || (i == last && v == to)) {
+				b.HitCount += g.HitCount
+				break
+			}
+		}
+	}
+
+	return buckets
+}
@@ -0,0 +1,45 @@
+package featureset
+
+import (
+	"testing"
+
+	"github.com/reveald/reveald"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ComputedFacetFeature_Build(t *testing.T) {
+	qb := reveald.NewQueryBuilder(reveald.NewRequest(), "-")
+	NewComputedFacetFeature("discount", "brand.keyword", "100 * (1 - params.price / params.list_price)",
+		WithSumVariable("price", "price"),
+		WithSumVariable("list_price", "list_price")).build(qb)
+
+	src, err := qb.Build().Source()
+	assert.NoError(t, err)
+
+	agg := src.(map[string]interface{})["aggregations"].(map[string]interface{})["discount"].(map[string]interface{})
+	assert.Equal(t, "brand.keyword", agg["terms"].(map[string]interface{})["field"])
+
+	subs := agg["aggregations"].(map[string]interface{})
+	assert.Contains(t, subs, "price")
+	assert.Contains(t, subs, "list_price")
+
+	script := subs[computedFacetValue].(map[string]interface{})["bucket_script"].(map[string]interface{})
+	assert.Equal(t, map[string]string{"price": "price", "list_price": "list_price"}, script["buckets_path"])
+}
+
+func Test_ComputedFacetFeature_RangeBuckets(t *testing.T) {
+	cff := NewComputedFacetFeature("discount", "brand.keyword", "", WithComputedRanges(0, 10, 25, 50))
+	buckets := cff.rangeBuckets([]*reveald.ResultBucket{
+		{Value: 5.0, HitCount: 3},
+		{Value: 10.0, HitCount: 2},
+		{Value: 24.9, HitCount: 1},
+		{Value: 50.0, HitCount: 4},
+		{Value: 75.0, HitCount: 8},
+	})
+
+	assert.Len(t, buckets, 3)
+	assert.Equal(t, "0-10", buckets[0].Value)
+	assert.Equal(t, int64(3), buckets[0].HitCount)
+	assert.Equal(t, int64(3), buckets[1].HitCount)
+	assert.Equal(t, int64(4), buckets[2].HitCount)
+}
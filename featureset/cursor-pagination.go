@@ -0,0 +1,152 @@
+package featureset
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/reveald/reveald"
+)
+
+// ErrInvalidCursor is returned for cursors which weren't issued
+// as a Result.Pagination.NextCursor, so clients can tell a bad
+// cursor apart from the first page
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type CursorPaginationFeature struct {
+	param       string
+	pageSize    int
+	maxPageSize int
+}
+
+type CursorPaginationOption func(*CursorPaginationFeature)
+
+func WithCursorParam(param string) CursorPaginationOption {
+	return func(cpf *CursorPaginationFeature) {
+		cpf.param = param
+	}
+}
+
+func WithCursorPageSize(pageSize int) CursorPaginationOption {
+	return func(cpf *CursorPaginationFeature) {
+		cpf.pageSize = pageSize
+	}
+}
+
+func WithCursorMaxPageSize(maxPageSize int) CursorPaginationOption {
+	return func(cpf *CursorPaginationFeature) {
+		cpf.maxPageSize = maxPageSize
+	}
+}
+
+// NewCursorPaginationFeature pages through a result set using
+// search_after, which requires a deterministic sort, e.g. a
+// SortingFeature with WithTiebreaker on a unique property, which
+// also breaks ties of relevance when no sort is selected
+func NewCursorPaginationFeature(opts ...CursorPaginationOption) *CursorPaginationFeature {
+	cpf := &CursorPaginationFeature{
+		param:       "cursor",
+		pageSize:    defaultPageSize,
+		maxPageSize: defaultPageSize,
+	}
+
+	for _, opt := range opts {
+		opt(cpf)
+	}
+
+	return cpf
+}
+
+func (cpf *CursorPaginationFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	pageSize, err := cpf.build(builder)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := next(builder)
+	if err != nil {
+		return nil, err
+	}
+
+	return cpf.handle(pageSize, r)
+}
+
+func (cpf *CursorPaginationFeature) build(builder *reveald.QueryBuilder) (int, error) {
+	pageSize, err := toValue(builder.Request(), "size")
+	if err != nil || pageSize < 0 || pageSize > cpf.maxPageSize {
+		pageSize = cpf.pageSize
+	}
+
+	selectors := []reveald.Selector{
+		reveald.WithPageSize(pageSize),
+		reveald.WithOffset(0),
+	}
+
+	if p, err := builder.Request().Get(cpf.param); err == nil && p.Value() != "" {
+		values, err := decodeCursor(p.Value())
+		if err != nil {
+			return 0, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+		}
+		selectors = append(selectors, reveald.WithSearchAfter(values...))
+	}
+
+	builder.Selection().Update(selectors...)
+	return pageSize, nil
+}
+
+func (cpf *CursorPaginationFeature) handle(pageSize int, result *reveald.Result) (*reveald.Result, error) {
+	result.Pagination = &reveald.ResultPagination{
+		Offset:      0,
+		PageSize:    pageSize,
+		CursorParam: cpf.param,
+	}
+
+	raw := result.RawResult()
+	if raw == nil || raw.Hits == nil || len(raw.Hits.Hits) == 0 || len(raw.Hits.Hits) < pageSize {
+		return result, nil
+	}
+
+	last := raw.Hits.Hits[len(raw.Hits.Hits)-1]
+	if len(last.Sort) == 0 {
+		return result, nil
+	}
+
+	cursor, err := encodeCursor(last.Sort)
+	if err != nil {
+		return result, nil
+	}
+
+	result.Pagination.NextCursor = cursor
+	return result, nil
+}
+
+func encodeCursor(values []interface{}) (string, error) {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(cursor string) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&values); err != nil {
+		return nil, err
+	}
+
+	if len(values) == 0 {
+		return nil, errors.New("empty cursor")
+	}
+
+	return values, nil
+}
@@ -0,0 +1,70 @@
+package featureset
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/reveald/reveald"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Cursor_RoundTrip(t *testing.T) {
+	cursor, err := encodeCursor([]interface{}{1700000000123456789, "doc-1"})
+	assert.NoError(t, err)
+
+	values, err := decodeCursor(cursor)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{json.Number("1700000000123456789"), "doc-1"}, values)
+}
+
+func Test_Cursor_Invalid(t *testing.T) {
+	table := []string{"not base64!", "bnVsbA", "W10"}
+
+	for _, tt := range table {
+		t.Run(tt, func(t *testing.T) {
+			_, err := decodeCursor(tt)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func Test_CursorPaginationFeature_Build(t *testing.T) {
+	cursor, _ := encodeCursor([]interface{}{10, "doc-1"})
+
+	table := []struct {
+		name        string
+		req         *reveald.Request
+		searchAfter []interface{}
+	}{
+		{"without cursor", reveald.NewRequest(), nil},
+		{"with cursor", reveald.NewRequest(reveald.NewParameter("cursor", cursor)), []interface{}{json.Number("10"), "doc-1"}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			qb := reveald.NewQueryBuilder(tt.req, "-")
+			_, err := NewCursorPaginationFeature().build(qb)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.searchAfter, qb.Selection().SearchAfter())
+		})
+	}
+}
+
+func Test_CursorPaginationFeature_Malformed_Cursor(t *testing.T) {
+	table := []string{"garbage", "not base64!", "W10"}
+
+	for _, tt := range table {
+		t.Run(tt, func(t *testing.T) {
+			qb := reveald.NewQueryBuilder(reveald.NewRequest(reveald.NewParameter("cursor", tt)), "-")
+
+			called := false
+			_, err := NewCursorPaginationFeature().Process(qb, func(_ *reveald.QueryBuilder) (*reveald.Result, error) {
+				called = true
+				return &reveald.Result{}, nil
+			})
+			assert.ErrorIs(t, err, ErrInvalidCursor)
+			assert.False(t, called)
+		})
+	}
+}
@@ -106,6 +106,17 @@ func NewDateHistogramFeature(property string, opts ...DateHistogramOption) *Date
 	return dhf
 }
 
+// Describe returns the parameter selecting intervals of the property
+func (dhf *DateHistogramFeature) Describe() []reveald.ParameterDescription {
+	layout := intervalLayouts[dhf.interval]
+	return []reveald.ParameterDescription{{
+		Name:        dhf.property,
+		Kind:        reveald.ParameterMultiValue,
+		Description: fmt.Sprintf("Filters on intervals of %s, formatted as %s", dhf.property, layout),
+		Examples:    []string{time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC).Format(layout)},
+	}}
+}
+
 func (dhf *DateHistogramFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
 	dhf.build(builder)
 
@@ -151,7 +162,8 @@ func (dhf *DateHistogramFeature) build(builder *reveald.QueryBuilder) {
 
 	bq = bq.MinimumShouldMatch("1")
 
-	builder.With(bq)
+	builder.FacetFilter(dhf.property, bq)
+	builder.ApplyFilter(&reveald.ResultFilter{Property: dhf.property, Values: p.Values()})
 }
 
 func (dhf *DateHistogramFeature) handle(result *reveald.Result) (*reveald.Result, error) {
@@ -205,25 +217,24 @@ func IntervalEnd(t time.Time, interval string) time.Time {
 	return t
 }
 
+// intervalLayouts are the formats of date
+// parameters, by the interval of the histogram
+var intervalLayouts = map[string]string{
+	string(DateCalendarIntervalYearly):    "2006",
+	string(DateCalendarIntervalMonthly):   "2006-01",
+	string(DateCalendarIntervalDaily):     "2006-01-02",
+	string(DateFixedIntervalDaily):        "2006-01-02",
+	string(DateFixedIntervalHours):        "2006-01-02 15",
+	string(DateFixedIntervalMinutes):      "2006-01-02 15:04",
+	string(DateFixedIntervalSeconds):      "2006-01-02 15:04:05",
+	string(DateFixedIntervalMilliseconds): "2006-01-02 15:04:05.000",
+}
+
 func ParseTimeFrom(d string, interval string) (time.Time, error) {
-	switch interval {
-	case string(DateCalendarIntervalYearly):
-		return time.Parse("2006", d)
-	case string(DateCalendarIntervalMonthly):
-		return time.Parse("2006-01", d)
-	case string(DateCalendarIntervalDaily):
-		return time.Parse("2006-01-02", d)
-	case string(DateFixedIntervalDaily):
-		return time.Parse("2006-01-02", d)
-	case string(DateFixedIntervalHours):
-		return time.Parse("2006-01-02 15", d)
-	case string(DateFixedIntervalMinutes):
-		return time.Parse("2006-01-02 15:04", d)
-	case string(DateFixedIntervalSeconds):
-		return time.Parse("2006-01-02 15:04:05", d)
-	case string(DateFixedIntervalMilliseconds):
-		return time.Parse("2006-01-02 15:04:05.000", d)
+	layout, ok := intervalLayouts[interval]
+	if !ok {
+		return time.Time{}, errors.New("invalid date format")
 	}
 
-	return time.Time{}, errors.New("invalid date format")
+	return time.Parse(layout, d)
 }
@@ -67,3 +67,14 @@ func TestParseTimeFrom(t *testing.T) {
 		})
 	}
 }
+
+func TestDateHistogramFeatureDescribe(t *testing.T) {
+	params := NewDateHistogramFeature("released", WithCalendarInterval(DateCalendarIntervalMonthly)).Describe()
+	if len(params) != 1 || len(params[0].Examples) != 1 {
+		t.Fatalf("Describe() = %v, want a single parameter with an example", params)
+	}
+
+	if _, err := ParseTimeFrom(params[0].Examples[0], string(DateCalendarIntervalMonthly)); err != nil {
+		t.Errorf("ParseTimeFrom(%s) error = %v, want example to be parsable", params[0].Examples[0], err)
+	}
+}
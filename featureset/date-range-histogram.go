@@ -86,7 +86,7 @@ func (dhf *DateRangeHistogramFeature) build(builder *reveald.QueryBuilder) {
 		q.Lte(*dhf.rangeTo)
 	}
 
-	builder.With(q)
+	builder.FacetFilter(dhf.property, q)
 }
 
 func (dhf *DateRangeHistogramFeature) handle(result *reveald.Result) (*reveald.Result, error) {
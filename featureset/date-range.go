@@ -0,0 +1,215 @@
+package featureset
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+)
+
+const (
+	dateRangeFromSuffix = ".from"
+	dateRangeToSuffix   = ".to"
+)
+
+var (
+	dateMathPattern  = regexp.MustCompile(`^now([+-]\d+[yMwdhHms])*(/[yMwdhHms])?$`)
+	exactDatePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}(T\d{2}:\d{2}(:\d{2}(\.\d+)?)?(Z|[+-]\d{2}:?\d{2})?)?$`)
+)
+
+type dateRange struct {
+	from string
+	to   string
+}
+
+// relativeDateRanges are the named ranges understood by every
+// DateRangeFeature, where the upper bounds are exclusive
+var relativeDateRanges = map[string]dateRange{
+	"today":      {"now/d", "now+1d/d"},
+	"yesterday":  {"now-1d/d", "now/d"},
+	"this_week":  {"now/w", "now+1w/w"},
+	"last_week":  {"now-1w/w", "now/w"},
+	"this_month": {"now/M", "now+1M/M"},
+	"last_month": {"now-1M/M", "now/M"},
+	"this_year":  {"now/y", "now+1y/y"},
+	"last_year":  {"now-1y/y", "now/y"},
+}
+
+// DateRangeFeature filters on a date property by ranges, given
+// by named presets, relative names such as today or this_month,
+// or date math such as now-7d, matching the dates since. Bounds
+// may also be given by .from and .to suffixed parameters, as
+// date math or exact dates, where both bounds are inclusive
+type DateRangeFeature struct {
+	property string
+	param    string
+	timeZone string
+	presets  map[string]dateRange
+}
+
+type DateRangeOption func(*DateRangeFeature)
+
+// WithDateRangeParam sets the parameter selecting
+// ranges, defaulting to the property
+func WithDateRangeParam(name string) DateRangeOption {
+	return func(drf *DateRangeFeature) {
+		drf.param = name
+	}
+}
+
+// WithDatePreset names a range, from and to being date math
+// or exact dates, where to is exclusive. Empty bounds are
+// unbounded
+func WithDatePreset(name, from, to string) DateRangeOption {
+	return func(drf *DateRangeFeature) {
+		drf.presets[name] = dateRange{from, to}
+	}
+}
+
+// WithDateTimeZone sets the time zone used for rounding
+// date math, such as the start of today, defaulting to UTC
+func WithDateTimeZone(timeZone string) DateRangeOption {
+	return func(drf *DateRangeFeature) {
+		drf.timeZone = timeZone
+	}
+}
+
+func NewDateRangeFeature(property string, opts ...DateRangeOption) *DateRangeFeature {
+	drf := &DateRangeFeature{
+		property: property,
+		param:    property,
+		presets:  make(map[string]dateRange),
+	}
+
+	for _, opt := range opts {
+		opt(drf)
+	}
+
+	return drf
+}
+
+// Describe returns the range selecting parameter and its bounds
+func (drf *DateRangeFeature) Describe() []reveald.ParameterDescription {
+	names := make([]string, 0, len(drf.presets)+len(relativeDateRanges))
+	for name := range drf.presets {
+		names = append(names, name)
+	}
+	for name := range relativeDateRanges {
+		if _, ok := drf.presets[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return []reveald.ParameterDescription{{
+		Name:        drf.param,
+		Kind:        reveald.ParameterMultiValue,
+		Description: fmt.Sprintf("Filters on named ranges of %s, or the dates since date math such as now-7d", drf.property),
+		Values:      names,
+		Examples:    []string{"now-7d"},
+	}, {
+		Name:        drf.param + dateRangeFromSuffix,
+		Kind:        reveald.ParameterValue,
+		Description: fmt.Sprintf("Filters on %s from a date or date math, inclusive", drf.property),
+		Examples:    []string{"2006-01-02", "now-1M/d"},
+	}, {
+		Name:        drf.param + dateRangeToSuffix,
+		Kind:        reveald.ParameterValue,
+		Description: fmt.Sprintf("Filters on %s up to a date or date math, inclusive", drf.property),
+		Examples:    []string{"2006-01-02", "now"},
+	}}
+}
+
+func (drf *DateRangeFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	drf.build(builder)
+	return next(builder)
+}
+
+func (drf *DateRangeFeature) build(builder *reveald.QueryBuilder) {
+	bq := elastic.NewBoolQuery()
+	var applied []string
+
+	if p, err := builder.Request().Get(drf.param); err == nil {
+		for _, v := range p.Values() {
+			r, ok := drf.resolve(v)
+			if !ok {
+				continue
+			}
+
+			q := drf.query()
+			if r.from != "" {
+				q = q.Gte(r.from)
+			}
+			if r.to != "" {
+				q = q.Lt(r.to)
+			}
+
+			bq = bq.Should(q)
+			applied = append(applied, v)
+		}
+	}
+
+	from := drf.bound(builder.Request(), drf.param+dateRangeFromSuffix)
+	to := drf.bound(builder.Request(), drf.param+dateRangeToSuffix)
+	if from != "" || to != "" {
+		q := drf.query()
+		if from != "" {
+			q = q.Gte(from)
+		}
+		if to != "" {
+			q = q.Lte(to)
+		}
+
+		bq = bq.Should(q)
+		applied = append(applied, from+".."+to)
+	}
+
+	if len(applied) == 0 {
+		return
+	}
+
+	builder.FacetFilter(drf.param, bq.MinimumShouldMatch("1"))
+	builder.ApplyFilter(&reveald.ResultFilter{Property: drf.param, Values: applied})
+}
+
+func (drf *DateRangeFeature) query() *elastic.RangeQuery {
+	q := elastic.NewRangeQuery(drf.property)
+	if drf.timeZone != "" {
+		q = q.TimeZone(drf.timeZone)
+	}
+
+	return q
+}
+
+// resolve returns the range named by a value, or the
+// dates since a date math expression or exact date
+func (drf *DateRangeFeature) resolve(value string) (dateRange, bool) {
+	if r, ok := drf.presets[value]; ok {
+		return r, true
+	}
+	if r, ok := relativeDateRanges[value]; ok {
+		return r, true
+	}
+	if validDate(value) {
+		return dateRange{from: value}, true
+	}
+
+	return dateRange{}, false
+}
+
+func (drf *DateRangeFeature) bound(req *reveald.Request, name string) string {
+	p, err := req.Get(name)
+	if err != nil || !validDate(p.Value()) {
+		return ""
+	}
+
+	return p.Value()
+}
+
+// validDate returns whether a value is date math or an exact date,
+// so that other values don't fail the search
+func validDate(value string) bool {
+	return dateMathPattern.MatchString(value) || exactDatePattern.MatchString(value)
+}
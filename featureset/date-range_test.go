@@ -0,0 +1,74 @@
+package featureset
+
+import (
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DateRangeFeature_Build(t *testing.T) {
+	drf := NewDateRangeFeature("published",
+		WithDatePreset("last_7_days", "now-7d/d", ""),
+		WithDatePreset("today", "now-1d", "now"))
+
+	table := []struct {
+		name     string
+		params   []reveald.Parameter
+		expected []elastic.Query
+		applied  []string
+	}{
+		{"no params", nil, nil, nil},
+		{"preset", []reveald.Parameter{reveald.NewParameter("published", "last_7_days")},
+			[]elastic.Query{elastic.NewRangeQuery("published").Gte("now-7d/d")},
+			[]string{"last_7_days"}},
+		{"preset overriding relative", []reveald.Parameter{reveald.NewParameter("published", "today")},
+			[]elastic.Query{elastic.NewRangeQuery("published").Gte("now-1d").Lt("now")},
+			[]string{"today"}},
+		{"relative", []reveald.Parameter{reveald.NewParameter("published", "this_month", "yesterday")},
+			[]elastic.Query{
+				elastic.NewRangeQuery("published").Gte("now/M").Lt("now+1M/M"),
+				elastic.NewRangeQuery("published").Gte("now-1d/d").Lt("now/d"),
+			},
+			[]string{"this_month", "yesterday"}},
+		{"date math", []reveald.Parameter{reveald.NewParameter("published", "now-7d")},
+			[]elastic.Query{elastic.NewRangeQuery("published").Gte("now-7d")},
+			[]string{"now-7d"}},
+		{"invalid", []reveald.Parameter{reveald.NewParameter("published", "last_decade", "now-7x")}, nil, nil},
+		{"bounds", []reveald.Parameter{
+			reveald.NewParameter("published.from", "2024-01-01"),
+			reveald.NewParameter("published.to", "now/d"),
+		},
+			[]elastic.Query{elastic.NewRangeQuery("published").Gte("2024-01-01").Lte("now/d")},
+			[]string{"2024-01-01..now/d"}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			qb := reveald.NewQueryBuilder(reveald.NewRequest(tt.params...), "articles")
+			drf.build(qb)
+
+			if tt.expected == nil {
+				assert.Equal(t, elastic.NewBoolQuery(), qb.RawQuery())
+				assert.Empty(t, qb.AppliedFilters())
+				return
+			}
+
+			assert.Equal(t, elastic.NewBoolQuery().Must(
+				elastic.NewBoolQuery().Should(tt.expected...).MinimumShouldMatch("1")), qb.RawQuery())
+			assert.Equal(t, []*reveald.ResultFilter{{Property: "published", Values: tt.applied}}, qb.AppliedFilters())
+		})
+	}
+}
+
+func Test_DateRangeFeature_TimeZone(t *testing.T) {
+	drf := NewDateRangeFeature("published", WithDateRangeParam("when"), WithDateTimeZone("Europe/Stockholm"))
+	qb := reveald.NewQueryBuilder(reveald.NewRequest(reveald.NewParameter("when", "today")), "articles")
+	drf.build(qb)
+
+	assert.Equal(t, elastic.NewBoolQuery().Must(
+		elastic.NewBoolQuery().Should(
+			elastic.NewRangeQuery("published").TimeZone("Europe/Stockholm").Gte("now/d").Lt("now+1d/d"),
+		).MinimumShouldMatch("1")), qb.RawQuery())
+}
@@ -0,0 +1,31 @@
+package featureset
+
+import "github.com/reveald/reveald"
+
+// DeduplicationFeature removes hits sharing the same value of a
+// property, keeping the highest-scored one, for results where field
+// collapsing isn't available (e.g. results merged across indices).
+// The number of removed hits is set on Result.DuplicateHitCount
+type DeduplicationFeature struct {
+	property string
+}
+
+func NewDeduplicationFeature(property string) *DeduplicationFeature {
+	return &DeduplicationFeature{
+		property: property,
+	}
+}
+
+func (df *DeduplicationFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	r, err := next(builder)
+	if err != nil {
+		return nil, err
+	}
+
+	return df.handle(r)
+}
+
+func (df *DeduplicationFeature) handle(result *reveald.Result) (*reveald.Result, error) {
+	result.DeduplicateHits(df.property)
+	return result, nil
+}
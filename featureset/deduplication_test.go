@@ -0,0 +1,28 @@
+package featureset
+
+import (
+	"testing"
+
+	"github.com/reveald/reveald"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DeduplicationFeature_Handle(t *testing.T) {
+	result := &reveald.Result{
+		Hits: []map[string]interface{}{
+			{"id": 1, "group": "a"},
+			{"id": 2, "group": "b"},
+			{"id": 3, "group": "a"},
+			{"id": 4},
+		},
+	}
+
+	r, err := NewDeduplicationFeature("group").handle(result)
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{"id": 1, "group": "a"},
+		{"id": 2, "group": "b"},
+		{"id": 4},
+	}, r.Hits)
+	assert.Equal(t, int64(1), r.DuplicateHitCount)
+}
@@ -30,6 +30,30 @@ func NewNestedDocumentFilterFeature(property string, opts ...AggregationOption)
 	}
 }
 
+// Describe returns the parameters selecting and excluding
+// property values, and the parameter searching them when enabled
+func (dff *DynamicFilterFeature) Describe() []reveald.ParameterDescription {
+	params := []reveald.ParameterDescription{{
+		Name:        dff.property,
+		Kind:        reveald.ParameterMultiValue,
+		Description: fmt.Sprintf("Filters on values of %s, matching any of the values", dff.property),
+	}, {
+		Name:        dff.excludedParam(),
+		Kind:        reveald.ParameterMultiValue,
+		Description: fmt.Sprintf("Filters out values of %s, matching none of the values", dff.property),
+	}}
+
+	if dff.agg.valueSearch {
+		params = append(params, reveald.ParameterDescription{
+			Name:        fmt.Sprintf("%s.search", dff.property),
+			Kind:        reveald.ParameterValue,
+			Description: fmt.Sprintf("Narrows the values of %s to those starting with the text", dff.property),
+		})
+	}
+
+	return params
+}
+
 func (dff *DynamicFilterFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
 	dff.build(builder)
 
@@ -38,48 +62,87 @@ func (dff *DynamicFilterFeature) Process(builder *reveald.QueryBuilder, next rev
 		return nil, err
 	}
 
-	return dff.handle(r)
+	return dff.handle(r, builder.Request())
 }
 
 func (dff *DynamicFilterFeature) build(builder *reveald.QueryBuilder) {
 	keyword := fmt.Sprintf("%s.keyword", dff.property)
 
+	terms := dff.agg.searchTerms(builder.Request(), dff.property, keyword)
+
+	name, missing, hasMissing := dff.agg.missingAggregation(dff.property, keyword)
+
 	if !dff.nested {
-		builder.Aggregation(dff.property,
-			elastic.NewTermsAggregation().Field(keyword).Size(dff.agg.size))
+		builder.Aggregation(dff.property, terms)
+		if hasMissing {
+			builder.FacetAggregation(dff.property, name, missing)
+		}
 	} else {
 		path := strings.Split(dff.property, ".")[0]
-		builder.Aggregation(dff.property,
-			elastic.NewNestedAggregation().
-				Path(path).
-				SubAggregation(dff.property, elastic.NewTermsAggregation().Field(keyword).Size(dff.agg.size)))
+		nested := elastic.NewNestedAggregation().
+			Path(path).
+			SubAggregation(dff.property, terms)
+		if hasMissing {
+			nested = nested.SubAggregation(name, missing)
+		}
+		builder.Aggregation(dff.property, nested)
 	}
 
+	filter := &reveald.ResultFilter{Property: dff.property}
+
 	if builder.Request().Has(dff.property) {
 		p, err := builder.Request().Get(dff.property)
-		if err != nil {
-			return
+		if err == nil {
+			builder.FacetFilter(dff.property, dff.matchAny(keyword, p.Values()))
+			filter.Values = p.Values()
 		}
+	}
 
-		bq := elastic.NewBoolQuery()
-		for _, v := range p.Values() {
-			bq = bq.Should(elastic.NewTermQuery(keyword, v))
+	if builder.Request().Has(dff.excludedParam()) {
+		p, err := builder.Request().Get(dff.excludedParam())
+		if err == nil {
+			builder.FacetFilter(dff.property, elastic.NewBoolQuery().MustNot(dff.matchAny(keyword, p.Values())))
+			filter.Excluded = p.Values()
 		}
+	}
+
+	if len(filter.Values) > 0 || len(filter.Excluded) > 0 {
+		builder.ApplyFilter(filter)
+	}
+}
 
-		if !dff.nested {
-			builder.With(bq)
-		} else {
-			path := strings.Split(dff.property, ".")[0]
-			builder.With(elastic.NewNestedQuery(path, bq))
+// matchAny returns the query matching documents
+// with any of the values of the property
+func (dff *DynamicFilterFeature) matchAny(keyword string, values []string) elastic.Query {
+	bq := elastic.NewBoolQuery()
+	for _, v := range values {
+		if dff.agg.isMissing(v) {
+			bq = bq.Should(elastic.NewBoolQuery().MustNot(elastic.NewExistsQuery(keyword)))
+			continue
 		}
+
+		bq = bq.Should(elastic.NewTermQuery(keyword, v))
+	}
+
+	if !dff.nested {
+		return bq
 	}
+
+	path := strings.Split(dff.property, ".")[0]
+	return elastic.NewNestedQuery(path, bq)
 }
 
-func (dff *DynamicFilterFeature) handle(result *reveald.Result) (*reveald.Result, error) {
+// excludedParam returns the parameter excluding property values
+func (dff *DynamicFilterFeature) excludedParam() string {
+	return fmt.Sprintf("%s.not", dff.property)
+}
+
+func (dff *DynamicFilterFeature) handle(result *reveald.Result, req *reveald.Request) (*reveald.Result, error) {
 	var agg *elastic.AggregationBucketKeyItems
+	aggs := result.RawResult().Aggregations
 
 	if !dff.nested {
-		items, ok := result.RawResult().Aggregations.Terms(dff.property)
+		items, ok := aggs.Terms(dff.property)
 		if !ok {
 			return result, nil
 		}
@@ -97,6 +160,7 @@ func (dff *DynamicFilterFeature) handle(result *reveald.Result) (*reveald.Result
 		}
 
 		agg = items
+		aggs = bucket.Aggregations
 	}
 
 	var buckets []*reveald.ResultBucket
@@ -106,11 +170,25 @@ func (dff *DynamicFilterFeature) handle(result *reveald.Result) (*reveald.Result
 		}
 
 		buckets = append(buckets, &reveald.ResultBucket{
-			Value:    bucket.Key,
-			HitCount: bucket.DocCount,
+			Value:           result.BucketKey(bucket),
+			HitCount:        bucket.DocCount,
+			SubAggregations: result.SubAggregations(bucket.Aggregations),
 		})
 	}
 
-	result.Aggregations[dff.property] = buckets
+	buckets = dff.agg.withMissing(aggs, dff.property, buckets)
+
+	if p, err := req.Get(dff.excludedParam()); err == nil {
+		excluded := make(map[string]bool)
+		for _, v := range p.Values() {
+			excluded[v] = true
+		}
+		for _, b := range buckets {
+			b.Excluded = excluded[fmt.Sprint(b.Value)]
+		}
+	}
+
+	result.Aggregations[dff.property] = dff.agg.present(buckets)
+	dff.agg.describe(result, dff.property, buckets)
 	return result, nil
 }
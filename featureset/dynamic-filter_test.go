@@ -0,0 +1,140 @@
+package featureset
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+	"github.com/reveald/reveald/revealdtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DynamicFilterFeature_MissingValue(t *testing.T) {
+	qb := reveald.NewQueryBuilder(reveald.NewRequest(reveald.NewParameter("category", "(No category)", "shoes")), "-")
+	NewDynamicFilterFeature("category", WithMissingValueAs("(No category)")).build(qb)
+
+	src, err := qb.Build().Source()
+	assert.NoError(t, err)
+	aggs := src.(map[string]interface{})["aggregations"].(map[string]interface{})
+	terms := aggs["category"].(map[string]interface{})["terms"].(map[string]interface{})
+	assert.Equal(t, "(No category)", terms["missing"])
+
+	expected := elastic.NewBoolQuery().Must(
+		elastic.NewBoolQuery().
+			Should(elastic.NewBoolQuery().MustNot(elastic.NewExistsQuery("category.keyword"))).
+			Should(elastic.NewTermQuery("category.keyword", "shoes")))
+	assert.Equal(t, expected, qb.RawQuery())
+}
+
+func Test_DynamicFilterFeature_MissingBucket(t *testing.T) {
+	s := revealdtest.NewServer()
+	t.Cleanup(s.Close)
+
+	assert.NoError(t, s.Index("products",
+		map[string]interface{}{"name": "boot", "category": "shoes"},
+		map[string]interface{}{"name": "sneaker", "category": "shoes"},
+		map[string]interface{}{"name": "scarf"},
+	))
+
+	b, err := s.Backend()
+	assert.NoError(t, err)
+
+	e := reveald.NewEndpoint(b, reveald.WithIndices("products"), reveald.WithDisjunctiveFacets("category"))
+	assert.NoError(t, e.Register(NewDynamicFilterFeature("category", WithMissingBucket("(No category)"))))
+
+	r, err := e.Execute(context.Background(), reveald.NewRequest(reveald.NewParameter("category", "(No category)")))
+	assert.NoError(t, err)
+
+	assert.Equal(t, int64(1), r.TotalHitCount)
+	assert.Equal(t, "scarf", r.Hits[0]["name"])
+
+	var buckets []string
+	for _, bucket := range r.Aggregations["category"] {
+		buckets = append(buckets, fmt.Sprintf("%v:%d", bucket.Value, bucket.HitCount))
+	}
+	assert.Equal(t, []string{"shoes:2", "(No category):1"}, buckets)
+}
+
+func Test_DynamicFilterFeature_ValueSearch(t *testing.T) {
+	s := revealdtest.NewServer()
+	t.Cleanup(s.Close)
+
+	assert.NoError(t, s.Index("products",
+		map[string]interface{}{"brand": "Sony"},
+		map[string]interface{}{"brand": "Sonos"},
+		map[string]interface{}{"brand": "Samsung"},
+		map[string]interface{}{"brand": "Jabra"},
+	))
+
+	b, err := s.Backend()
+	assert.NoError(t, err)
+
+	e := reveald.NewEndpoint(b, reveald.WithIndices("products"))
+	assert.NoError(t, e.Register(NewDynamicFilterFeature("brand", WithValueSearch())))
+
+	r, err := e.Execute(context.Background(), reveald.NewRequest(reveald.NewParameter("brand.search", "son")))
+	assert.NoError(t, err)
+
+	assert.Equal(t, int64(4), r.TotalHitCount)
+
+	var values []string
+	for _, bucket := range r.Aggregations["brand"] {
+		values = append(values, fmt.Sprint(bucket.Value))
+	}
+	assert.ElementsMatch(t, []string{"Sony", "Sonos"}, values)
+}
+
+func Test_PrefixPattern(t *testing.T) {
+	table := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"letters", "So", "[sS][oO].*"},
+		{"digits", "4k", "4[kK].*"},
+		{"reserved", "a.b", `[aA]\.[bB].*`},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, prefixPattern(tt.text))
+		})
+	}
+}
+
+func Test_DynamicFilterFeature_ExcludedValues(t *testing.T) {
+	s := revealdtest.NewServer()
+	t.Cleanup(s.Close)
+
+	assert.NoError(t, s.Index("products",
+		map[string]interface{}{"name": "walkman", "brand": "Sony"},
+		map[string]interface{}{"name": "speaker", "brand": "Sonos"},
+		map[string]interface{}{"name": "headset", "brand": "Jabra"},
+		map[string]interface{}{"name": "cable"},
+	))
+
+	b, err := s.Backend()
+	assert.NoError(t, err)
+
+	e := reveald.NewEndpoint(b, reveald.WithIndices("products"), reveald.WithDisjunctiveFacets("brand"))
+	assert.NoError(t, e.Register(NewDynamicFilterFeature("brand", WithMissingBucket("(No brand)"))))
+
+	r, err := e.Execute(context.Background(), reveald.NewRequest(reveald.NewParameter("brand.not", "Sony", "(No brand)")))
+	assert.NoError(t, err)
+
+	var names []string
+	for _, hit := range r.Hits {
+		names = append(names, fmt.Sprint(hit["name"]))
+	}
+	assert.ElementsMatch(t, []string{"speaker", "headset"}, names)
+
+	excluded := make(map[string]bool)
+	for _, bucket := range r.Aggregations["brand"] {
+		excluded[fmt.Sprint(bucket.Value)] = bucket.Excluded
+	}
+	assert.Equal(t, map[string]bool{"Sony": true, "Sonos": false, "Jabra": false, "(No brand)": true}, excluded)
+
+	assert.Equal(t, []*reveald.ResultFilter{{Property: "brand", Excluded: []string{"Sony", "(No brand)"}}}, r.AppliedFilters)
+}
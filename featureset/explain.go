@@ -0,0 +1,46 @@
+package featureset
+
+import "github.com/reveald/reveald"
+
+type ExplainFeature struct {
+	param string
+}
+
+type ExplainOption func(*ExplainFeature)
+
+// WithExplainParam only explains scores when the
+// specified request parameter is truthy
+func WithExplainParam(param string) ExplainOption {
+	return func(ef *ExplainFeature) {
+		ef.param = param
+	}
+}
+
+// NewExplainFeature explains how the score of each hit was
+// computed, adding the explanation tree to each hit under
+// reveald.ExplanationKey
+func NewExplainFeature(opts ...ExplainOption) *ExplainFeature {
+	ef := &ExplainFeature{}
+
+	for _, opt := range opts {
+		opt(ef)
+	}
+
+	return ef
+}
+
+func (ef *ExplainFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	ef.build(builder)
+	return next(builder)
+}
+
+func (ef *ExplainFeature) build(builder *reveald.QueryBuilder) {
+	if ef.param != "" {
+		p, err := builder.Request().Get(ef.param)
+		if err != nil || !p.IsTruthy() {
+			return
+		}
+	}
+
+	builder.WithExplain()
+}
@@ -0,0 +1,31 @@
+package featureset
+
+import (
+	"testing"
+
+	"github.com/reveald/reveald"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ExplainFeature_Build(t *testing.T) {
+	table := []struct {
+		name     string
+		request  *reveald.Request
+		opts     []ExplainOption
+		expected bool
+	}{
+		{"always", reveald.NewRequest(), nil, true},
+		{"param missing", reveald.NewRequest(), []ExplainOption{WithExplainParam("explain")}, false},
+		{"param false", reveald.NewRequest(reveald.NewParameter("explain", "false")), []ExplainOption{WithExplainParam("explain")}, false},
+		{"param true", reveald.NewRequest(reveald.NewParameter("explain", "true")), []ExplainOption{WithExplainParam("explain")}, true},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			qb := reveald.NewQueryBuilder(tt.request, "-")
+			NewExplainFeature(tt.opts...).build(qb)
+
+			assert.Equal(t, tt.expected, qb.Explain())
+		})
+	}
+}
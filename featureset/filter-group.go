@@ -0,0 +1,114 @@
+package featureset
+
+import (
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+)
+
+// FilterGroupFeature combines the queries of the grouped features,
+// such as filters on several properties, so that documents must
+// match a minimum number of them, rather than all of them (e.g.
+// color=red OR tag=sale). Grouped features without a query, such
+// as filters without a selection, don't count towards the minimum.
+// The group is a facet of its own, named by the group, which the
+// aggregations of the grouped features count as part of, so that
+// counting the group disjunctively (see WithDisjunctiveFacets)
+// counts them regardless of the group's selection. Applied filters
+// of the grouped features are kept, while other changes they make
+// to the query builder, such as sorting, are ignored
+type FilterGroupFeature struct {
+	name     string
+	features []reveald.Feature
+	minimum  int
+}
+
+type FilterGroupOption func(*FilterGroupFeature)
+
+// WithGroupedFeatures adds features to the group
+func WithGroupedFeatures(features ...reveald.Feature) FilterGroupOption {
+	return func(fgf *FilterGroupFeature) {
+		fgf.features = append(fgf.features, features...)
+	}
+}
+
+// WithMinimumGroupMatches defines how many of the grouped
+// features documents must match, defaulting to one, and capped
+// at the number of grouped features with a query
+func WithMinimumGroupMatches(n int) FilterGroupOption {
+	return func(fgf *FilterGroupFeature) {
+		fgf.minimum = n
+	}
+}
+
+func NewFilterGroupFeature(name string, opts ...FilterGroupOption) *FilterGroupFeature {
+	fgf := &FilterGroupFeature{
+		name:    name,
+		minimum: 1,
+	}
+
+	for _, opt := range opts {
+		opt(fgf)
+	}
+
+	return fgf
+}
+
+func (fgf *FilterGroupFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	inners := make([]*reveald.QueryBuilder, len(fgf.features))
+	for i := range inners {
+		inners[i] = reveald.NewQueryBuilder(builder.Request(), builder.Indices()...)
+		inners[i].SetContext(builder.Context())
+	}
+
+	return fgf.process(0, inners, func() (*reveald.Result, error) {
+		fgf.build(builder, inners)
+		return next(builder)
+	})
+}
+
+// process runs the grouped features, from the feature
+// at index i, each on its own inner query builder
+func (fgf *FilterGroupFeature) process(i int, inners []*reveald.QueryBuilder, next func() (*reveald.Result, error)) (*reveald.Result, error) {
+	if i == len(fgf.features) {
+		return next()
+	}
+
+	return fgf.features[i].Process(inners[i], func(qb *reveald.QueryBuilder) (*reveald.Result, error) {
+		inners[i] = qb
+		return fgf.process(i+1, inners, next)
+	})
+}
+
+// build adds the queries of the inner query builders to the
+// builder as should clauses of the group's facet filter, along
+// with their aggregations, counted as part of the group
+func (fgf *FilterGroupFeature) build(builder *reveald.QueryBuilder, inners []*reveald.QueryBuilder) {
+	var queries []elastic.Query
+	for _, inner := range inners {
+		if query := inner.RawQuery(); !isEmptyQuery(query) {
+			queries = append(queries, query)
+		}
+
+		for _, filter := range inner.AppliedFilters() {
+			builder.ApplyFilter(filter)
+		}
+
+		for name := range inner.Aggregations() {
+			agg, _ := inner.AttachedAggregation(name)
+			builder.FacetAggregation(fgf.name, name, agg)
+		}
+	}
+
+	if len(queries) == 0 {
+		return
+	}
+
+	minimum := fgf.minimum
+	if minimum > len(queries) {
+		minimum = len(queries)
+	}
+
+	builder.FacetFilter(fgf.name, elastic.NewBoolQuery().
+		Should(queries...).
+		MinimumNumberShouldMatch(minimum))
+}
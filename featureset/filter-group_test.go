@@ -0,0 +1,128 @@
+package featureset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/reveald/reveald"
+	"github.com/reveald/reveald/revealdtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FilterGroupFeature(t *testing.T) {
+	s := revealdtest.NewServer()
+	t.Cleanup(s.Close)
+
+	assert.NoError(t, s.Index("products",
+		map[string]interface{}{"name": "red shirt", "color": "red", "tag": "new"},
+		map[string]interface{}{"name": "blue shirt", "color": "blue", "tag": "sale"},
+		map[string]interface{}{"name": "red hat", "color": "red", "tag": "sale"},
+		map[string]interface{}{"name": "green hat", "color": "green", "tag": "new"},
+	))
+
+	b, err := s.Backend()
+	assert.NoError(t, err)
+
+	table := []struct {
+		name     string
+		opts     []FilterGroupOption
+		params   []reveald.Parameter
+		expected []string
+	}{
+		{"any", nil, []reveald.Parameter{reveald.NewParameter("color", "red"), reveald.NewParameter("tag", "sale")}, []string{"red shirt", "blue shirt", "red hat"}},
+		{"all", []FilterGroupOption{WithMinimumGroupMatches(2)}, []reveald.Parameter{reveald.NewParameter("color", "red"), reveald.NewParameter("tag", "sale")}, []string{"red hat"}},
+		{"single selection", []FilterGroupOption{WithMinimumGroupMatches(2)}, []reveald.Parameter{reveald.NewParameter("color", "green")}, []string{"green hat"}},
+		{"no selection", nil, nil, []string{"red shirt", "blue shirt", "red hat", "green hat"}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			e := reveald.NewEndpoint(b, reveald.WithIndices("products"))
+			opts := append([]FilterGroupOption{WithGroupedFeatures(
+				NewDynamicFilterFeature("color"),
+				NewDynamicFilterFeature("tag"))}, tt.opts...)
+			assert.NoError(t, e.Register(NewFilterGroupFeature("style", opts...)))
+
+			r, err := e.Execute(context.Background(), reveald.NewRequest(tt.params...))
+			assert.NoError(t, err)
+
+			var names []string
+			for _, hit := range r.Hits {
+				names = append(names, fmt.Sprint(hit["name"]))
+			}
+			assert.ElementsMatch(t, tt.expected, names)
+			assert.NotEmpty(t, r.Aggregations["color"])
+			assert.NotEmpty(t, r.Aggregations["tag"])
+			assert.Len(t, r.AppliedFilters, len(tt.params))
+		})
+	}
+}
+
+func Test_FilterGroupFeature_Disjunctive(t *testing.T) {
+	s := revealdtest.NewServer()
+	t.Cleanup(s.Close)
+
+	assert.NoError(t, s.Index("products",
+		map[string]interface{}{"name": "red shirt", "color": "red", "tag": "new", "brand": "acme"},
+		map[string]interface{}{"name": "blue shirt", "color": "blue", "tag": "sale", "brand": "acme"},
+		map[string]interface{}{"name": "red hat", "color": "red", "tag": "sale", "brand": "globex"},
+		map[string]interface{}{"name": "green hat", "color": "green", "tag": "new", "brand": "globex"},
+	))
+
+	b, err := s.Backend()
+	assert.NoError(t, err)
+
+	e := reveald.NewEndpoint(b, reveald.WithIndices("products"), reveald.WithDisjunctiveFacets("style"))
+	assert.NoError(t, e.Register(
+		NewDynamicFilterFeature("brand"),
+		NewFilterGroupFeature("style", WithGroupedFeatures(
+			NewDynamicFilterFeature("color"),
+			NewDynamicFilterFeature("tag", WithMissingBucket("(No tag)"))))))
+
+	r, err := e.Execute(context.Background(), reveald.NewRequest(
+		reveald.NewParameter("color", "red"),
+		reveald.NewParameter("brand", "acme")))
+	assert.NoError(t, err)
+
+	var names []string
+	for _, hit := range r.Hits {
+		names = append(names, fmt.Sprint(hit["name"]))
+	}
+	assert.Equal(t, []string{"red shirt"}, names)
+
+	colors := make(map[interface{}]int64)
+	for _, bucket := range r.Aggregations["color"] {
+		colors[bucket.Value] = bucket.HitCount
+	}
+	assert.Equal(t, map[interface{}]int64{"red": 1, "blue": 1}, colors)
+
+	brands := make(map[interface{}]int64)
+	for _, bucket := range r.Aggregations["brand"] {
+		brands[bucket.Value] = bucket.HitCount
+	}
+	assert.Equal(t, map[interface{}]int64{"acme": 1}, brands)
+
+	tags := make(map[interface{}]int64)
+	for _, bucket := range r.Aggregations["tag"] {
+		tags[bucket.Value] = bucket.HitCount
+	}
+	assert.Equal(t, map[interface{}]int64{"new": 1, "sale": 1}, tags)
+}
+
+func Test_FilterGroupFeature_AttachedAggregations(t *testing.T) {
+	qb := reveald.NewQueryBuilder(reveald.NewRequest(), "-")
+	fgf := NewFilterGroupFeature("variant", WithGroupedFeatures(
+		NewNestedDocumentWrapper("variants", WithFeatures(NewDynamicFilterFeature("variants.color")))))
+
+	stop := errors.New("stop")
+	_, err := fgf.Process(qb, func(*reveald.QueryBuilder) (*reveald.Result, error) {
+		return nil, stop
+	})
+	assert.ErrorIs(t, err, stop)
+
+	agg := sourceJSON(t, qb)["aggregations"].(map[string]interface{})["variants.color"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"path": "variants"}, agg["nested"])
+	assert.Contains(t, agg["aggregations"], "variants.color")
+}
@@ -0,0 +1,100 @@
+package featureset
+
+import (
+	"github.com/reveald/reveald"
+)
+
+// FreshnessBoostFeature boosts newer documents with a decay
+// function on a date property, rather than filtering older
+// documents out. Documents within the offset of the origin get
+// the full score, and documents a scale away from it get the
+// decay fraction of it
+type FreshnessBoostFeature struct {
+	decay DecayBoost
+}
+
+type FreshnessBoostOption func(*FreshnessBoostFeature)
+
+// WithScale sets the distance from the origin, such as "7d",
+// where the score is decayed to the decay fraction
+func WithScale(scale string) FreshnessBoostOption {
+	return func(fbf *FreshnessBoostFeature) {
+		fbf.decay.Scale = scale
+	}
+}
+
+// WithDecay sets the fraction of the score left
+// a scale away from the origin, defaulting to 0.5
+func WithDecay(decay float64) FreshnessBoostOption {
+	return func(fbf *FreshnessBoostFeature) {
+		fbf.decay.Decay = decay
+	}
+}
+
+// WithOrigin sets the date documents are considered fresh
+// from, as a date or date math, defaulting to now
+func WithOrigin(origin string) FreshnessBoostOption {
+	return func(fbf *FreshnessBoostFeature) {
+		fbf.decay.Origin = origin
+	}
+}
+
+// WithDecayOffset sets the distance from the origin, such as
+// "1d", within which documents aren't decayed
+func WithDecayOffset(offset string) FreshnessBoostOption {
+	return func(fbf *FreshnessBoostFeature) {
+		fbf.decay.Offset = offset
+	}
+}
+
+// WithDecayFunction sets the shape of the decay,
+// DecayGauss by default, DecayLinear or DecayExponential
+func WithDecayFunction(function string) FreshnessBoostOption {
+	return func(fbf *FreshnessBoostFeature) {
+		fbf.decay.Function = function
+	}
+}
+
+// WithFreshnessWeight weighs the freshness
+// against other score functions
+func WithFreshnessWeight(weight float64) FreshnessBoostOption {
+	return func(fbf *FreshnessBoostFeature) {
+		fbf.decay.Weight = weight
+	}
+}
+
+func NewFreshnessBoostFeature(property string, opts ...FreshnessBoostOption) *FreshnessBoostFeature {
+	fbf := &FreshnessBoostFeature{
+		decay: DecayBoost{
+			Function: DecayGauss,
+			Property: property,
+			Origin:   "now",
+			Scale:    "7d",
+			Decay:    0.5,
+		},
+	}
+
+	for _, opt := range opts {
+		opt(fbf)
+	}
+
+	return fbf
+}
+
+func (fbf *FreshnessBoostFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	if err := fbf.build(builder); err != nil {
+		return nil, err
+	}
+
+	return next(builder)
+}
+
+func (fbf *FreshnessBoostFeature) build(builder *reveald.QueryBuilder) error {
+	fn, err := decayFunction(fbf.decay)
+	if err != nil {
+		return err
+	}
+
+	builder.ScoreFunction(nil, fn)
+	return nil
+}
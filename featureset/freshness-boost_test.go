@@ -0,0 +1,45 @@
+package featureset
+
+import (
+	"testing"
+
+	"github.com/reveald/reveald"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FreshnessBoostFeature_Build(t *testing.T) {
+	table := []struct {
+		name     string
+		opts     []FreshnessBoostOption
+		expected map[string]interface{}
+	}{
+		{"defaults", nil,
+			map[string]interface{}{"gauss": map[string]interface{}{
+				"published_at": map[string]interface{}{"origin": "now", "scale": "7d", "decay": 0.5},
+			}}},
+		{"options", []FreshnessBoostOption{WithScale("30d"), WithDecay(0.3), WithOrigin("2024-05-01"), WithDecayOffset("1d"), WithDecayFunction(DecayExponential), WithFreshnessWeight(2)},
+			map[string]interface{}{"exp": map[string]interface{}{
+				"published_at": map[string]interface{}{"origin": "2024-05-01", "scale": "30d", "offset": "1d", "decay": 0.3},
+			}, "weight": float64(2)}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			fbf := NewFreshnessBoostFeature("published_at", tt.opts...)
+			qb := reveald.NewQueryBuilder(reveald.NewRequest(), "articles")
+
+			assert.NoError(t, fbf.build(qb))
+
+			query := sourceJSON(t, qb)["query"].(map[string]interface{})
+			functions := query["function_score"].(map[string]interface{})["functions"].([]interface{})
+			assert.Equal(t, []interface{}{tt.expected}, functions)
+		})
+	}
+}
+
+func Test_FreshnessBoostFeature_InvalidFunction(t *testing.T) {
+	fbf := NewFreshnessBoostFeature("published_at", WithDecayFunction("cubic"))
+	qb := reveald.NewQueryBuilder(reveald.NewRequest(), "articles")
+
+	assert.Error(t, fbf.build(qb))
+}
@@ -0,0 +1,194 @@
+package featureset
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+)
+
+const (
+	geoGridCentroid         = "centroid"
+	defaultGeoHashPrecision = 5
+	defaultGeoTilePrecision = 7
+)
+
+type GeoGridFeature struct {
+	property  string
+	param     string
+	tile      bool
+	precision int
+	size      int
+}
+
+type GeoGridOption func(*GeoGridFeature)
+
+// WithGeoTileGrid buckets documents on map tiles
+// (geotile_grid) instead of geohash cells
+func WithGeoTileGrid() GeoGridOption {
+	return func(ggf *GeoGridFeature) {
+		ggf.tile = true
+	}
+}
+
+// WithGeoGridPrecision sets the geohash length, or the
+// zoom level when using WithGeoTileGrid
+func WithGeoGridPrecision(precision int) GeoGridOption {
+	return func(ggf *GeoGridFeature) {
+		ggf.precision = precision
+	}
+}
+
+func WithGeoGridSize(size int) GeoGridOption {
+	return func(ggf *GeoGridFeature) {
+		ggf.size = size
+	}
+}
+
+func WithBoundingBoxParam(param string) GeoGridOption {
+	return func(ggf *GeoGridFeature) {
+		ggf.param = param
+	}
+}
+
+// NewGeoGridFeature clusters documents on a geo_point property into
+// grid cells, each returned with its centroid. A bounding box,
+// passed as bbox=top,left,bottom,right, limits the search to
+// the visible area of a map
+func NewGeoGridFeature(property string, opts ...GeoGridOption) *GeoGridFeature {
+	ggf := &GeoGridFeature{
+		property: property,
+		param:    "bbox",
+	}
+
+	for _, opt := range opts {
+		opt(ggf)
+	}
+
+	if ggf.precision == 0 {
+		ggf.precision = defaultGeoHashPrecision
+		if ggf.tile {
+			ggf.precision = defaultGeoTilePrecision
+		}
+	}
+
+	return ggf
+}
+
+// Describe returns the bounding box parameter
+func (ggf *GeoGridFeature) Describe() []reveald.ParameterDescription {
+	return []reveald.ParameterDescription{{
+		Name:        ggf.param,
+		Kind:        reveald.ParameterValue,
+		Description: fmt.Sprintf("Filters on a bounding box of %s, formatted as top,left,bottom,right", ggf.property),
+		Examples:    []string{"59.4,17.8,59.2,18.2"},
+	}}
+}
+
+func (ggf *GeoGridFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	ggf.build(builder)
+
+	r, err := next(builder)
+	if err != nil {
+		return nil, err
+	}
+
+	return ggf.handle(r)
+}
+
+func (ggf *GeoGridFeature) build(builder *reveald.QueryBuilder) {
+	centroid := elastic.NewGeoCentroidAggregation().Field(ggf.property)
+
+	if ggf.tile {
+		agg := elastic.NewGeoTileGridAggregation().
+			Field(ggf.property).
+			Precision(ggf.precision).
+			SubAggregation(geoGridCentroid, centroid)
+		if ggf.size > 0 {
+			agg = agg.Size(ggf.size)
+		}
+
+		builder.Aggregation(ggf.property, agg)
+	} else {
+		agg := elastic.NewGeoHashGridAggregation().
+			Field(ggf.property).
+			Precision(ggf.precision).
+			SubAggregation(geoGridCentroid, centroid)
+		if ggf.size > 0 {
+			agg = agg.Size(ggf.size)
+		}
+
+		builder.Aggregation(ggf.property, agg)
+	}
+
+	p, err := builder.Request().Get(ggf.param)
+	if err != nil {
+		return
+	}
+
+	top, left, bottom, right, ok := parseBoundingBox(p.Value())
+	if !ok {
+		return
+	}
+
+	builder.With(elastic.NewGeoBoundingBoxQuery(ggf.property).
+		TopLeft(top, left).
+		BottomRight(bottom, right))
+}
+
+// parseBoundingBox reads a top,left,bottom,right coordinate list
+func parseBoundingBox(value string) (top, left, bottom, right float64, ok bool) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return
+	}
+
+	var coords [4]float64
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return
+		}
+
+		coords[i] = v
+	}
+
+	if coords[0] < coords[2] {
+		return
+	}
+
+	return coords[0], coords[1], coords[2], coords[3], true
+}
+
+func (ggf *GeoGridFeature) handle(result *reveald.Result) (*reveald.Result, error) {
+	agg, ok := result.RawResult().Aggregations.GeoHash(ggf.property)
+	if !ok {
+		return result, nil
+	}
+
+	var buckets []*reveald.ResultBucket
+	for _, bucket := range agg.Buckets {
+		if bucket == nil {
+			continue
+		}
+
+		rb := &reveald.ResultBucket{
+			Value:    bucket.Key,
+			HitCount: bucket.DocCount,
+		}
+
+		if c, ok := bucket.Aggregations.GeoCentroid(geoGridCentroid); ok {
+			rb.Centroid = &reveald.ResultGeoPoint{
+				Lat: c.Location.Latitude,
+				Lon: c.Location.Longitude,
+			}
+		}
+
+		buckets = append(buckets, rb)
+	}
+
+	result.Aggregations[ggf.property] = buckets
+	return result, nil
+}
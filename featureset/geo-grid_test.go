@@ -0,0 +1,60 @@
+package featureset
+
+import (
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GeoGridFeature_Aggregation(t *testing.T) {
+	table := []struct {
+		name     string
+		opts     []GeoGridOption
+		grid     string
+		expected interface{}
+	}{
+		{"geohash", nil, "geohash_grid", defaultGeoHashPrecision},
+		{"geotile", []GeoGridOption{WithGeoTileGrid()}, "geotile_grid", defaultGeoTilePrecision},
+		{"precision", []GeoGridOption{WithGeoGridPrecision(3)}, "geohash_grid", 3},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			qb := reveald.NewQueryBuilder(reveald.NewRequest(), "-")
+			NewGeoGridFeature("location", tt.opts...).build(qb)
+
+			src, err := qb.Build().Source()
+			assert.NoError(t, err)
+			aggs := src.(map[string]interface{})["aggregations"].(map[string]interface{})
+			agg := aggs["location"].(map[string]interface{})
+			assert.Contains(t, agg, tt.grid)
+			assert.Equal(t, tt.expected, agg[tt.grid].(map[string]interface{})["precision"])
+			assert.Contains(t, agg["aggregations"], geoGridCentroid)
+		})
+	}
+}
+
+func Test_GeoGridFeature_BoundingBox(t *testing.T) {
+	table := []struct {
+		name     string
+		value    string
+		expected elastic.Query
+	}{
+		{"valid", "59.5,17.8,59.1,18.3", elastic.NewBoolQuery().Must(
+			elastic.NewGeoBoundingBoxQuery("location").TopLeft(59.5, 17.8).BottomRight(59.1, 18.3))},
+		{"too few coordinates", "59.5,17.8,59.1", elastic.NewBoolQuery()},
+		{"not a number", "59.5,x,59.1,18.3", elastic.NewBoolQuery()},
+		{"inverted", "59.1,17.8,59.5,18.3", elastic.NewBoolQuery()},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			qb := reveald.NewQueryBuilder(reveald.NewRequest(reveald.NewParameter("bbox", tt.value)), "-")
+			NewGeoGridFeature("location").build(qb)
+
+			assert.Equal(t, tt.expected, qb.RawQuery())
+		})
+	}
+}
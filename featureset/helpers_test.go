@@ -0,0 +1,57 @@
+package featureset
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/reveald/reveald"
+	"github.com/stretchr/testify/assert"
+)
+
+// sourceJSON returns the search source built by a
+// query builder, decoded from its JSON encoding
+func sourceJSON(t *testing.T, qb *reveald.QueryBuilder) map[string]interface{} {
+	src, err := qb.BuildSource()
+	assert.NoError(t, err)
+
+	data, err := json.Marshal(src)
+	assert.NoError(t, err)
+
+	var m map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &m))
+	return m
+}
+
+// fixedBackend records the searched builders,
+// answering with a fixed result or error
+type fixedBackend struct {
+	builders []*reveald.QueryBuilder
+	result   *reveald.Result
+	err      error
+}
+
+func (b *fixedBackend) Execute(_ context.Context, qb *reveald.QueryBuilder) (*reveald.Result, error) {
+	b.builders = append(b.builders, qb)
+	if b.err != nil {
+		return nil, b.err
+	}
+	if b.result != nil {
+		return b.result, nil
+	}
+
+	return &reveald.Result{}, nil
+}
+
+func (b *fixedBackend) ExecuteMultiple(ctx context.Context, qbs []*reveald.QueryBuilder) ([]*reveald.Result, error) {
+	var results []*reveald.Result
+	for _, qb := range qbs {
+		r, err := b.Execute(ctx, qb)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+
+	return results, nil
+}
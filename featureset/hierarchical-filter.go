@@ -0,0 +1,202 @@
+package featureset
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+)
+
+const (
+	defaultHierarchySeparator = " > "
+	hierarchyChildren         = "children"
+)
+
+type HierarchicalFilterFeature struct {
+	param     string
+	levels    []string
+	separator string
+	size      int
+}
+
+type HierarchicalFilterOption func(*HierarchicalFilterFeature)
+
+// WithHierarchySeparator defines the separator between
+// the segments of a path, defaulting to " > "
+func WithHierarchySeparator(separator string) HierarchicalFilterOption {
+	return func(hff *HierarchicalFilterFeature) {
+		hff.separator = separator
+	}
+}
+
+func WithHierarchyLevelSize(size int) HierarchicalFilterOption {
+	return func(hff *HierarchicalFilterFeature) {
+		hff.size = size
+	}
+}
+
+// NewHierarchicalFilterFeature filters on a category path, such as
+// "Electronics > Audio > Headphones", passed as a breadcrumb in the
+// param. Each of the level fields holds the path prefix of its depth
+// (e.g. category.lvl0 holds "Electronics", and category.lvl1 holds
+// "Electronics > Audio"). The result is a tree, where the buckets of
+// each level along the selected path hold the next level as a
+// sub-aggregation named after the param. Bucket values are full
+// paths, and labels are the last segment of the path
+func NewHierarchicalFilterFeature(param string, levels []string, opts ...HierarchicalFilterOption) *HierarchicalFilterFeature {
+	hff := &HierarchicalFilterFeature{
+		param:     param,
+		levels:    levels,
+		separator: defaultHierarchySeparator,
+		size:      defaultAggregationSize,
+	}
+
+	for _, opt := range opts {
+		opt(hff)
+	}
+
+	return hff
+}
+
+// Describe returns the parameter selecting a path of the hierarchy
+func (hff *HierarchicalFilterFeature) Describe() []reveald.ParameterDescription {
+	return []reveald.ParameterDescription{{
+		Name:        hff.param,
+		Kind:        reveald.ParameterValue,
+		Description: fmt.Sprintf("Filters on a path of %s, with segments separated by %q", strings.Join(hff.levels, ", "), hff.separator),
+	}}
+}
+
+func (hff *HierarchicalFilterFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	hff.build(builder)
+
+	r, err := next(builder)
+	if err != nil {
+		return nil, err
+	}
+
+	return hff.handle(builder.Request(), r)
+}
+
+// selection returns the path prefixes of the selected
+// path, limited to the number of levels
+func (hff *HierarchicalFilterFeature) selection(req *reveald.Request) []string {
+	p, err := req.Get(hff.param)
+	if err != nil || p.Value() == "" {
+		return nil
+	}
+
+	var prefixes []string
+	var segments []string
+	for _, segment := range strings.Split(p.Value(), hff.separator) {
+		if segment = strings.TrimSpace(segment); segment == "" {
+			continue
+		}
+
+		segments = append(segments, segment)
+		prefixes = append(prefixes, strings.Join(segments, hff.separator))
+		if len(prefixes) == len(hff.levels) {
+			break
+		}
+	}
+
+	return prefixes
+}
+
+func (hff *HierarchicalFilterFeature) levelName(depth int) string {
+	return fmt.Sprintf("%s.level%d", hff.param, depth)
+}
+
+func (hff *HierarchicalFilterFeature) build(builder *reveald.QueryBuilder) {
+	if len(hff.levels) == 0 {
+		return
+	}
+
+	builder.FacetAggregation(hff.param, hff.param,
+		elastic.NewTermsAggregation().Field(hff.levels[0]).Size(hff.size))
+
+	prefixes := hff.selection(builder.Request())
+	for i, prefix := range prefixes {
+		if i+1 >= len(hff.levels) {
+			break
+		}
+
+		builder.FacetAggregation(hff.param, hff.levelName(i+1),
+			elastic.NewFilterAggregation().
+				Filter(elastic.NewTermQuery(hff.levels[i], prefix)).
+				SubAggregation(hierarchyChildren,
+					elastic.NewTermsAggregation().Field(hff.levels[i+1]).Size(hff.size)))
+	}
+
+	if len(prefixes) > 0 {
+		depth := len(prefixes) - 1
+		builder.FacetFilter(hff.param, elastic.NewTermQuery(hff.levels[depth], prefixes[depth]))
+		builder.ApplyFilter(&reveald.ResultFilter{Property: hff.param, Values: []string{prefixes[depth]}})
+	}
+}
+
+func (hff *HierarchicalFilterFeature) handle(req *reveald.Request, result *reveald.Result) (*reveald.Result, error) {
+	aggs := result.RawResult().Aggregations
+	root, ok := aggs.Terms(hff.param)
+	if !ok {
+		return result, nil
+	}
+
+	buckets := hff.buckets(root)
+	level := buckets
+	for i, prefix := range hff.selection(req) {
+		selected := findBucket(level, prefix)
+		if selected == nil {
+			break
+		}
+
+		filter, ok := aggs.Filter(hff.levelName(i + 1))
+		if !ok {
+			break
+		}
+
+		children, ok := filter.Aggregations.Terms(hierarchyChildren)
+		if !ok {
+			break
+		}
+
+		level = hff.buckets(children)
+		selected.SubAggregations = map[string][]*reveald.ResultBucket{
+			hff.param: level,
+		}
+	}
+
+	result.Aggregations[hff.param] = buckets
+	return result, nil
+}
+
+func (hff *HierarchicalFilterFeature) buckets(agg *elastic.AggregationBucketKeyItems) []*reveald.ResultBucket {
+	var buckets []*reveald.ResultBucket
+	for _, bucket := range agg.Buckets {
+		if bucket == nil {
+			continue
+		}
+
+		path := fmt.Sprint(bucket.Key)
+		segments := strings.Split(path, hff.separator)
+
+		buckets = append(buckets, &reveald.ResultBucket{
+			Value:    path,
+			Label:    segments[len(segments)-1],
+			HitCount: bucket.DocCount,
+		})
+	}
+
+	return buckets
+}
+
+func findBucket(buckets []*reveald.ResultBucket, value string) *reveald.ResultBucket {
+	for _, b := range buckets {
+		if fmt.Sprint(b.Value) == value {
+			return b
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package featureset
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+	"github.com/stretchr/testify/assert"
+)
+
+var categoryLevels = []string{"category.lvl0", "category.lvl1", "category.lvl2"}
+
+func Test_HierarchicalFilterFeature_Selection(t *testing.T) {
+	table := []struct {
+		name     string
+		value    string
+		expected []string
+	}{
+		{"empty", "", nil},
+		{"top level", "Electronics", []string{"Electronics"}},
+		{"nested", "Electronics > Audio", []string{"Electronics", "Electronics > Audio"}},
+		{"untrimmed", " Electronics  >  Audio ", []string{"Electronics", "Electronics > Audio"}},
+		{"deeper than levels", "A > B > C > D", []string{"A", "A > B", "A > B > C"}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			hff := NewHierarchicalFilterFeature("category", categoryLevels)
+			req := reveald.NewRequest(reveald.NewParameter("category", tt.value))
+
+			assert.Equal(t, tt.expected, hff.selection(req))
+		})
+	}
+}
+
+func Test_HierarchicalFilterFeature_Build(t *testing.T) {
+	qb := reveald.NewQueryBuilder(reveald.NewRequest(reveald.NewParameter("category", "Electronics > Audio")), "-")
+	NewHierarchicalFilterFeature("category", categoryLevels).build(qb)
+
+	assert.Equal(t, elastic.NewBoolQuery().Must(
+		elastic.NewTermQuery("category.lvl1", "Electronics > Audio")), qb.RawQuery())
+
+	src, err := qb.Build().Source()
+	assert.NoError(t, err)
+
+	aggs := src.(map[string]interface{})["aggregations"].(map[string]interface{})
+	assert.Len(t, aggs, 3)
+	assert.Contains(t, aggs, "category")
+
+	data, err := json.Marshal(aggs["category.level2"])
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"filter": {"term": {"category.lvl1": "Electronics > Audio"}},
+		"aggregations": {"children": {"terms": {"field": "category.lvl2", "size": 10}}}
+	}`, string(data))
+}
@@ -0,0 +1,77 @@
+package featureset
+
+import "github.com/reveald/reveald"
+
+const (
+	defaultHighlightPreTag  = "<em>"
+	defaultHighlightPostTag = "</em>"
+)
+
+type HighlightFeature struct {
+	fields       []string
+	preTag       string
+	postTag      string
+	fragmentSize int
+	fragments    int
+}
+
+type HighlightOption func(*HighlightFeature)
+
+func WithHighlightFields(fields ...string) HighlightOption {
+	return func(hf *HighlightFeature) {
+		hf.fields = append(hf.fields, fields...)
+	}
+}
+
+func WithHighlightTags(pre, post string) HighlightOption {
+	return func(hf *HighlightFeature) {
+		hf.preTag = pre
+		hf.postTag = post
+	}
+}
+
+func WithHighlightFragmentSize(size int) HighlightOption {
+	return func(hf *HighlightFeature) {
+		hf.fragmentSize = size
+	}
+}
+
+func WithHighlightFragments(count int) HighlightOption {
+	return func(hf *HighlightFeature) {
+		hf.fragments = count
+	}
+}
+
+func NewHighlightFeature(opts ...HighlightOption) *HighlightFeature {
+	hf := &HighlightFeature{
+		preTag:  defaultHighlightPreTag,
+		postTag: defaultHighlightPostTag,
+	}
+
+	for _, opt := range opts {
+		opt(hf)
+	}
+
+	return hf
+}
+
+func (hf *HighlightFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	hf.build(builder)
+	return next(builder)
+}
+
+func (hf *HighlightFeature) build(builder *reveald.QueryBuilder) {
+	opts := []reveald.HighlightOption{
+		reveald.WithHighlightTags(hf.preTag, hf.postTag),
+	}
+	if hf.fragmentSize > 0 {
+		opts = append(opts, reveald.WithHighlightFragmentSize(hf.fragmentSize))
+	}
+	if hf.fragments > 0 {
+		opts = append(opts, reveald.WithHighlightFragments(hf.fragments))
+	}
+
+	for _, field := range hf.fields {
+		builder.WithHighlight(field, opts...)
+	}
+}
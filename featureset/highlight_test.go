@@ -0,0 +1,30 @@
+package featureset
+
+import (
+	"testing"
+
+	"github.com/reveald/reveald"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HighlightFeature_Build(t *testing.T) {
+	qb := reveald.NewQueryBuilder(reveald.NewRequest(), "-")
+	NewHighlightFeature(
+		WithHighlightFields("title", "description"),
+		WithHighlightTags("<b>", "</b>"),
+		WithHighlightFragmentSize(80),
+		WithHighlightFragments(2)).build(qb)
+
+	src, err := qb.Build().Source()
+	assert.NoError(t, err)
+
+	highlight := src.(map[string]interface{})["highlight"].(map[string]interface{})
+	fields := highlight["fields"].(map[string]interface{})
+	assert.Len(t, fields, 2)
+
+	title := fields["title"].(map[string]interface{})
+	assert.Equal(t, []string{"<b>"}, title["pre_tags"])
+	assert.Equal(t, []string{"</b>"}, title["post_tags"])
+	assert.Equal(t, 80, title["fragment_size"])
+	assert.Equal(t, 2, title["number_of_fragments"])
+}
@@ -2,17 +2,26 @@ package featureset
 
 import (
 	"fmt"
+	"math"
+	"strconv"
 
 	"github.com/olivere/elastic/v7"
 	"github.com/reveald/reveald"
 )
 
+const (
+	histogramBoundsSuffix = "_bounds"
+	histogramStatsSuffix  = "_stats"
+)
+
 type HistogramFeature struct {
 	property    string
 	neg         bool
 	zeroBucket  bool
 	interval    float64
 	minDocCount int64
+	trim        []float64
+	autoBuckets int
 }
 
 type HistogramOption func(*HistogramFeature)
@@ -41,6 +50,30 @@ func WithMinimumDocumentCount(minDocCount int64) HistogramOption {
 	}
 }
 
+// WithTrimmedBounds drops buckets outside the lower and upper
+// percentiles of the property (e.g. 1 and 99), so a few outliers
+// don't stretch the range of a slider
+func WithTrimmedBounds(lower, upper float64) HistogramOption {
+	return func(hf *HistogramFeature) {
+		hf.trim = []float64{lower, upper}
+	}
+}
+
+// WithAutoInterval derives the interval from the bounds of the
+// property among the documents searched, aiming for about buckets
+// buckets, so that cheap and expensive categories get price
+// facets of similar resolution. The bounds are looked up by a
+// subsearch through the endpoint, filtered by the features
+// processed before the histogram and scoped to the tenant of the
+// request. Intervals are rounded to 1, 2 or 5 times a power of
+// ten, of at least 1, and the configured interval is used when
+// the property has no values
+func WithAutoInterval(buckets int) HistogramOption {
+	return func(hf *HistogramFeature) {
+		hf.autoBuckets = buckets
+	}
+}
+
 func NewHistogramFeature(property string, opts ...HistogramOption) *HistogramFeature {
 	hf := &HistogramFeature{
 		property:    property,
@@ -57,49 +90,108 @@ func NewHistogramFeature(property string, opts ...HistogramOption) *HistogramFea
 	return hf
 }
 
+// Describe returns the range parameter of the property
+func (hf *HistogramFeature) Describe() []reveald.ParameterDescription {
+	return []reveald.ParameterDescription{{
+		Name:        hf.property,
+		Kind:        reveald.ParameterRange,
+		Description: fmt.Sprintf("Filters on a range of %s, where both bounds are inclusive", hf.property),
+		Examples:    []string{"0", strconv.FormatFloat(hf.interval, 'f', -1, 64)},
+	}}
+}
+
 func (hf *HistogramFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
-	hf.build(builder)
+	interval, err := hf.resolveInterval(builder)
+	if err != nil {
+		return nil, err
+	}
+
+	hf.buildInterval(builder, interval)
 
 	r, err := next(builder)
 	if err != nil {
 		return nil, err
 	}
 
-	return hf.handle(r)
+	return hf.handleInterval(r, builder.Request(), interval)
 }
 
 func (hf *HistogramFeature) build(builder *reveald.QueryBuilder) {
+	hf.buildInterval(builder, hf.interval)
+}
+
+func (hf *HistogramFeature) buildInterval(builder *reveald.QueryBuilder, interval float64) {
 	builder.Aggregation(hf.property,
 		elastic.NewHistogramAggregation().
 			Field(hf.property).
-			Interval(hf.interval).
+			Interval(interval).
 			MinDocCount(hf.minDocCount))
 
-	p, err := builder.Request().Get(hf.property)
-	if err != nil || !p.IsRangeValue() {
+	if len(hf.trim) > 0 {
+		builder.FacetAggregation(hf.property, hf.property+histogramBoundsSuffix,
+			elastic.NewPercentilesAggregation().
+				Field(hf.property).
+				Percentiles(hf.trim...))
+	}
+
+	min, max, ok := hf.selection(builder.Request())
+	if !ok {
 		return
 	}
 
 	q := elastic.NewRangeQuery(hf.property)
+	if max != nil {
+		q.Lte(*max)
+	}
+	if min != nil {
+		q.Gte(*min)
+	}
+
+	builder.FacetFilter(hf.property, q)
+	if min != nil || max != nil {
+		builder.ApplyFilter(&reveald.ResultFilter{Property: hf.property, Min: min, Max: max})
+	}
+}
+
+// selection returns the selected bounds of the property, ignoring
+// negative bounds unless allowed, and whether a range is requested
+func (hf *HistogramFeature) selection(req *reveald.Request) (*float64, *float64, bool) {
+	if req == nil {
+		return nil, nil, false
+	}
+
+	p, err := req.Get(hf.property)
+	if err != nil || !p.IsRangeValue() {
+		return nil, nil, false
+	}
+
+	var selectedMin, selectedMax *float64
 	max, wmax := p.Max()
 	if wmax && (max >= 0 || hf.neg) {
-		q.Lte(max)
+		selectedMax = &max
 	}
 
 	min, wmin := p.Min()
 	if wmin && (!wmax || min <= max) && (min >= 0 || hf.neg) {
-		q.Gte(min)
+		selectedMin = &min
 	}
 
-	builder.With(q)
+	return selectedMin, selectedMax, true
 }
 
 func (hf *HistogramFeature) handle(result *reveald.Result) (*reveald.Result, error) {
+	return hf.handleInterval(result, result.Request(), hf.interval)
+}
+
+func (hf *HistogramFeature) handleInterval(result *reveald.Result, req *reveald.Request, interval float64) (*reveald.Result, error) {
 	agg, ok := result.RawResult().Aggregations.Histogram(hf.property)
 	if !ok {
 		return result, nil
 	}
 
+	lower, upper := hf.bounds(result)
+	min, max, _ := hf.selection(req)
+
 	var buckets []*reveald.ResultBucket
 	zeroOut := len(agg.Buckets) > 0
 	for _, bucket := range agg.Buckets {
@@ -107,6 +199,10 @@ func (hf *HistogramFeature) handle(result *reveald.Result) (*reveald.Result, err
 			continue
 		}
 
+		if bucket.Key+interval <= lower || bucket.Key > upper {
+			continue
+		}
+
 		if bucket.Key <= 0 {
 			zeroOut = false
 		}
@@ -115,22 +211,115 @@ func (hf *HistogramFeature) handle(result *reveald.Result) (*reveald.Result, err
 			continue
 		}
 
-		buckets = append(buckets, &reveald.ResultBucket{
-			Value:    fmt.Sprintf("%0.f", bucket.Key),
-			HitCount: bucket.DocCount,
-		})
+		buckets = append(buckets, rangeBucket(fmt.Sprintf("%0.f", bucket.Key), bucket.DocCount, bucket.Key, interval, min, max))
 	}
 
 	if hf.zeroBucket && zeroOut {
-		bucket := &reveald.ResultBucket{
-			Value:    0,
-			HitCount: 0,
-		}
+		bucket := rangeBucket(0, 0, 0, interval, min, max)
 		buckets = append(buckets, nil)
 		copy(buckets[1:], buckets)
 		buckets[0] = bucket
 	}
 
 	result.Aggregations[hf.property] = buckets
+	if hf.autoBuckets > 0 {
+		if result.Intervals == nil {
+			result.Intervals = make(map[string]string)
+		}
+		result.Intervals[hf.property] = strconv.FormatFloat(interval, 'f', -1, 64)
+	}
+
 	return result, nil
 }
+
+// rangeBucket returns a histogram bucket with its bounds, selected
+// when overlapping the selected bounds, if any
+func rangeBucket(value interface{}, count int64, key, interval float64, min, max *float64) *reveald.ResultBucket {
+	from, to := key, key+interval
+
+	return &reveald.ResultBucket{
+		Value:    value,
+		HitCount: count,
+		From:     &from,
+		To:       &to,
+		Selected: (min != nil || max != nil) &&
+			(min == nil || to > *min) &&
+			(max == nil || from <= *max),
+	}
+}
+
+// resolveInterval returns the interval of a search, looking
+// up the bounds of the property when deriving it
+func (hf *HistogramFeature) resolveInterval(builder *reveald.QueryBuilder) (float64, error) {
+	if hf.autoBuckets <= 0 {
+		return hf.interval, nil
+	}
+
+	qb := builder.Subsearch()
+	qb.With(builder.RawQuery())
+	qb.Selection().Update(reveald.WithPageSize(0))
+	qb.Aggregation(hf.property+histogramStatsSuffix,
+		elastic.NewStatsAggregation().Field(hf.property))
+
+	r, err := builder.ExecuteSubsearch(qb)
+	if err != nil {
+		return 0, fmt.Errorf("failed looking up bounds of %s: %w", hf.property, err)
+	}
+	if r.RawResult() == nil {
+		return hf.interval, nil
+	}
+
+	stats, ok := r.RawResult().Aggregations.Stats(hf.property + histogramStatsSuffix)
+	if !ok || stats.Min == nil || stats.Max == nil {
+		return hf.interval, nil
+	}
+
+	return niceInterval((*stats.Max - *stats.Min) / float64(hf.autoBuckets)), nil
+}
+
+// niceInterval rounds an interval up to 1, 2 or 5 times
+// a power of ten, of at least 1
+func niceInterval(interval float64) float64 {
+	if interval <= 1 {
+		return 1
+	}
+
+	magnitude := math.Pow(10, math.Floor(math.Log10(interval)))
+	for _, step := range []float64{1, 2, 5} {
+		if interval <= step*magnitude {
+			return step * magnitude
+		}
+	}
+
+	return 10 * magnitude
+}
+
+// bounds returns the trimmed bounds of the property, or
+// infinite bounds when not trimming
+func (hf *HistogramFeature) bounds(result *reveald.Result) (float64, float64) {
+	lower, upper := math.Inf(-1), math.Inf(1)
+	if len(hf.trim) == 0 {
+		return lower, upper
+	}
+
+	agg, ok := result.RawResult().Aggregations.Percentiles(hf.property + histogramBoundsSuffix)
+	if !ok {
+		return lower, upper
+	}
+
+	for key, value := range agg.Values {
+		percent, err := strconv.ParseFloat(key, 64)
+		if err != nil {
+			continue
+		}
+
+		switch percent {
+		case hf.trim[0]:
+			lower = value
+		case hf.trim[1]:
+			upper = value
+		}
+	}
+
+	return lower, upper
+}
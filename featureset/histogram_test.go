@@ -0,0 +1,164 @@
+package featureset
+
+import (
+	"context"
+	"testing"
+
+	"github.com/reveald/reveald"
+	"github.com/reveald/reveald/revealdtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HistogramFeature_Build(t *testing.T) {
+	table := []struct {
+		name     string
+		opts     []HistogramOption
+		expected map[string]interface{}
+	}{
+		{"untrimmed", nil, nil},
+		{"trimmed", []HistogramOption{WithTrimmedBounds(1, 99)}, map[string]interface{}{
+			"percentiles": map[string]interface{}{"field": "price", "percents": []interface{}{float64(1), float64(99)}},
+		}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			qb := reveald.NewQueryBuilder(reveald.NewRequest(), "-")
+			NewHistogramFeature("price", tt.opts...).build(qb)
+
+			aggs := sourceJSON(t, qb)["aggregations"].(map[string]interface{})
+			assert.Contains(t, aggs, "price")
+
+			if tt.expected == nil {
+				assert.NotContains(t, aggs, "price_bounds")
+				return
+			}
+
+			assert.Equal(t, tt.expected, aggs["price_bounds"])
+		})
+	}
+}
+
+func Test_HistogramFeature_AppliedFilter(t *testing.T) {
+	min, max := 10.0, 20.0
+	table := []struct {
+		name     string
+		params   []reveald.Parameter
+		expected []*reveald.ResultFilter
+	}{
+		{"none", nil, nil},
+		{"range", []reveald.Parameter{
+			reveald.NewParameter("price.min", "10"),
+			reveald.NewParameter("price.max", "20"),
+		}, []*reveald.ResultFilter{{Property: "price", Min: &min, Max: &max}}},
+		{"negative", []reveald.Parameter{
+			reveald.NewParameter("price.min", "-10"),
+		}, nil},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			qb := reveald.NewQueryBuilder(reveald.NewRequest(tt.params...), "-")
+			NewHistogramFeature("price").build(qb)
+
+			assert.Equal(t, tt.expected, qb.AppliedFilters())
+		})
+	}
+}
+
+func Test_HistogramFeature_AutoInterval(t *testing.T) {
+	s := revealdtest.NewServer()
+	t.Cleanup(s.Close)
+
+	assert.NoError(t, s.Index("products",
+		map[string]interface{}{"brand": "acme", "price": 80},
+		map[string]interface{}{"brand": "acme", "price": 95},
+		map[string]interface{}{"brand": "acme", "price": 120},
+		map[string]interface{}{"brand": "globex", "price": 15},
+	))
+
+	b, err := s.Backend()
+	assert.NoError(t, err)
+
+	tenant := reveald.TenantResolverFunc(func(context.Context, *reveald.Request) (*reveald.Tenant, error) {
+		return reveald.TermTenantFilter("brand", "acme"), nil
+	})
+
+	table := []struct {
+		name     string
+		opts     []reveald.EndpointOption
+		features []reveald.Feature
+		interval string
+		buckets  []interface{}
+	}{
+		{"all", nil, nil, "50", []interface{}{"0", "50", "100"}},
+		{"filtered", nil, []reveald.Feature{NewStaticFilterFeature(WithRequiredValue("brand", "acme"))},
+			"10", []interface{}{"80", "90", "100", "110", "120"}},
+		{"tenant", []reveald.EndpointOption{reveald.WithTenantResolver(tenant)}, nil,
+			"10", []interface{}{"80", "90", "100", "110", "120"}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			e := reveald.NewEndpoint(b, reveald.WithIndices("products"), tt.opts...)
+			assert.NoError(t, e.Register(append(tt.features,
+				NewHistogramFeature("price", WithAutoInterval(5), WithoutZeroBucket()))...))
+
+			r, err := e.Execute(context.Background(), reveald.NewRequest())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.interval, r.Intervals["price"])
+
+			var keys []interface{}
+			for _, bucket := range r.Aggregations["price"] {
+				keys = append(keys, bucket.Value)
+			}
+			assert.Equal(t, tt.buckets, keys)
+		})
+	}
+}
+
+func Test_NiceInterval(t *testing.T) {
+	table := []struct {
+		interval float64
+		expected float64
+	}{
+		{0.3, 1}, {1, 1}, {1.5, 2}, {3, 5}, {8, 10}, {21, 50}, {120, 200}, {600, 1000},
+	}
+
+	for _, tt := range table {
+		assert.Equal(t, tt.expected, niceInterval(tt.interval))
+	}
+}
+
+func Test_HistogramFeature_SelectedBuckets(t *testing.T) {
+	s := revealdtest.NewServer()
+	t.Cleanup(s.Close)
+
+	assert.NoError(t, s.Index("products",
+		map[string]interface{}{"price": 85},
+		map[string]interface{}{"price": 95},
+		map[string]interface{}{"price": 105},
+		map[string]interface{}{"price": 115},
+	))
+
+	b, err := s.Backend()
+	assert.NoError(t, err)
+
+	e := reveald.NewEndpoint(b, reveald.WithIndices("products"), reveald.WithDisjunctiveFacets("price"))
+	assert.NoError(t, e.Register(NewHistogramFeature("price", WithInterval(10), WithoutZeroBucket())))
+
+	r, err := e.Execute(context.Background(), reveald.NewRequest(
+		reveald.NewParameter("price.min", "90"),
+		reveald.NewParameter("price.max", "100")))
+	assert.NoError(t, err)
+
+	type bounds struct {
+		from, to float64
+		selected bool
+	}
+	var buckets []bounds
+	for _, bucket := range r.Aggregations["price"] {
+		buckets = append(buckets, bounds{*bucket.From, *bucket.To, bucket.Selected})
+	}
+	assert.Equal(t, []bounds{{80, 90, false}, {90, 100, true}, {100, 110, true}, {110, 120, false}}, buckets)
+}
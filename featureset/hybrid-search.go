@@ -0,0 +1,148 @@
+package featureset
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+)
+
+const (
+	defaultKNNK              = 10
+	defaultKNNCandidates     = 100
+	defaultRRFRankConstant   = 60
+	defaultRRFRankWindowSize = 100
+)
+
+// EmbeddingFunc converts a text query to a vector
+type EmbeddingFunc func(ctx context.Context, text string) ([]float64, error)
+
+type HybridSearchFeature struct {
+	param         string
+	fields        []string
+	vectorField   string
+	embed         EmbeddingFunc
+	textWeight    float64
+	vectorWeight  float64
+	k             int
+	numCandidates int
+	rrf           bool
+	rankConstant  int
+	windowSize    int
+}
+
+type HybridSearchOption func(*HybridSearchFeature)
+
+func WithHybridQueryParam(name string) HybridSearchOption {
+	return func(hsf *HybridSearchFeature) {
+		hsf.param = name
+	}
+}
+
+func WithHybridFields(fields ...string) HybridSearchOption {
+	return func(hsf *HybridSearchFeature) {
+		hsf.fields = fields
+	}
+}
+
+// WithTextWeight defines the boost of the lexical query
+// when combining scores linearly
+func WithTextWeight(weight float64) HybridSearchOption {
+	return func(hsf *HybridSearchFeature) {
+		hsf.textWeight = weight
+	}
+}
+
+// WithVectorWeight defines the boost of the kNN query
+// when combining scores linearly
+func WithVectorWeight(weight float64) HybridSearchOption {
+	return func(hsf *HybridSearchFeature) {
+		hsf.vectorWeight = weight
+	}
+}
+
+func WithKNNCandidates(k, numCandidates int) HybridSearchOption {
+	return func(hsf *HybridSearchFeature) {
+		hsf.k = k
+		hsf.numCandidates = numCandidates
+	}
+}
+
+// WithReciprocalRankFusion combines the lexical and kNN rankings
+// using reciprocal rank fusion instead of summing weighted scores
+func WithReciprocalRankFusion(rankConstant, windowSize int) HybridSearchOption {
+	return func(hsf *HybridSearchFeature) {
+		hsf.rrf = true
+		hsf.rankConstant = rankConstant
+		hsf.windowSize = windowSize
+	}
+}
+
+// NewHybridSearchFeature blends a lexical multi_match query with
+// a kNN search on the vector field, using embed to vectorize the
+// full-text parameter
+func NewHybridSearchFeature(vectorField string, embed EmbeddingFunc, opts ...HybridSearchOption) *HybridSearchFeature {
+	hsf := &HybridSearchFeature{
+		param:         "q",
+		fields:        []string{},
+		vectorField:   vectorField,
+		embed:         embed,
+		textWeight:    1,
+		vectorWeight:  1,
+		k:             defaultKNNK,
+		numCandidates: defaultKNNCandidates,
+		rankConstant:  defaultRRFRankConstant,
+		windowSize:    defaultRRFRankWindowSize,
+	}
+
+	for _, opt := range opts {
+		opt(hsf)
+	}
+
+	return hsf
+}
+
+func (hsf *HybridSearchFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	if err := hsf.build(builder); err != nil {
+		return nil, err
+	}
+
+	return next(builder)
+}
+
+func (hsf *HybridSearchFeature) build(builder *reveald.QueryBuilder) error {
+	p, err := builder.Request().Get(hsf.param)
+	if err != nil || p.Value() == "" {
+		return nil
+	}
+
+	vector, err := hsf.embed(builder.Context(), p.Value())
+	if err != nil {
+		return fmt.Errorf("failed embedding query: %w", err)
+	}
+
+	text := elastic.NewMultiMatchQuery(p.Value(), hsf.fields...)
+	knn := &reveald.KNNQuery{
+		Field:         hsf.vectorField,
+		QueryVector:   vector,
+		K:             hsf.k,
+		NumCandidates: hsf.numCandidates,
+	}
+
+	if hsf.rrf {
+		builder.WithSourceField("rank", map[string]interface{}{
+			"rrf": map[string]interface{}{
+				"rank_constant":    hsf.rankConstant,
+				"rank_window_size": hsf.windowSize,
+			},
+		})
+	} else {
+		text = text.Boost(hsf.textWeight)
+		knn.Boost = hsf.vectorWeight
+	}
+
+	builder.Match(text)
+	builder.WithKNN(knn)
+	return nil
+}
@@ -0,0 +1,69 @@
+package featureset
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+	"github.com/stretchr/testify/assert"
+)
+
+func fakeEmbedding(_ context.Context, _ string) ([]float64, error) {
+	return []float64{0.1, 0.2}, nil
+}
+
+func hybridSource(t *testing.T, qb *reveald.QueryBuilder) map[string]interface{} {
+	src, err := qb.BuildSource()
+	assert.NoError(t, err)
+
+	data, err := json.Marshal(src)
+	assert.NoError(t, err)
+
+	var m map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &m))
+	return m
+}
+
+func Test_HybridSearchFeature_Weighted(t *testing.T) {
+	qb := reveald.NewQueryBuilder(reveald.NewRequest(reveald.NewParameter("q", "red shoes")), "-")
+	qb.With(elastic.NewTermQuery("brand", "acme"))
+
+	err := NewHybridSearchFeature("embedding", fakeEmbedding, WithHybridFields("title"), WithVectorWeight(2)).build(qb)
+	assert.NoError(t, err)
+
+	m := hybridSource(t, qb)
+	knn := m["knn"].(map[string]interface{})
+	assert.Equal(t, "embedding", knn["field"])
+	assert.Equal(t, 2.0, knn["boost"])
+	assert.Equal(t, map[string]interface{}{
+		"bool": map[string]interface{}{
+			"must": map[string]interface{}{"term": map[string]interface{}{"brand": "acme"}},
+		},
+	}, knn["filter"])
+	assert.NotContains(t, m, "rank")
+}
+
+func Test_HybridSearchFeature_RRF(t *testing.T) {
+	qb := reveald.NewQueryBuilder(reveald.NewRequest(reveald.NewParameter("q", "red shoes")), "-")
+
+	err := NewHybridSearchFeature("embedding", fakeEmbedding, WithReciprocalRankFusion(20, 50)).build(qb)
+	assert.NoError(t, err)
+
+	m := hybridSource(t, qb)
+	knn := m["knn"].(map[string]interface{})
+	assert.NotContains(t, knn, "boost")
+	assert.NotContains(t, knn, "filter")
+	assert.Equal(t, map[string]interface{}{
+		"rrf": map[string]interface{}{"rank_constant": 20.0, "rank_window_size": 50.0},
+	}, m["rank"])
+}
+
+func Test_HybridSearchFeature_WithoutQuery(t *testing.T) {
+	qb := reveald.NewQueryBuilder(reveald.NewRequest(), "-")
+
+	err := NewHybridSearchFeature("embedding", fakeEmbedding).build(qb)
+	assert.NoError(t, err)
+	assert.Nil(t, qb.KNN())
+}
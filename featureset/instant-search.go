@@ -0,0 +1,114 @@
+package featureset
+
+import (
+	"fmt"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+)
+
+// InstantSearchFeature matches documents as the user types, with
+// a bool_prefix multi_match query on search_as_you_type fields and
+// their shingle subfields. It reads its own parameter, so that it
+// may be combined with a QueryFilterFeature on the same endpoint
+type InstantSearchFeature struct {
+	param      string
+	fields     []string
+	maxShingle int
+	operator   Operator
+	boost      float64
+}
+
+type InstantSearchOption func(*InstantSearchFeature)
+
+// WithPrefixParam sets the parameter holding the
+// text typed so far, defaulting to prefix
+func WithPrefixParam(name string) InstantSearchOption {
+	return func(isf *InstantSearchFeature) {
+		isf.param = name
+	}
+}
+
+// WithMaxShingleSize sets the max_shingle_size of the
+// fields, searching their _2gram up to _Ngram subfields.
+// The default is 3, as for search_as_you_type fields
+func WithMaxShingleSize(size int) InstantSearchOption {
+	return func(isf *InstantSearchFeature) {
+		isf.maxShingle = size
+	}
+}
+
+// WithPrefixOperator sets how the typed terms are
+// combined, matching any of them by default
+func WithPrefixOperator(operator Operator) InstantSearchOption {
+	return func(isf *InstantSearchFeature) {
+		isf.operator = operator
+	}
+}
+
+// WithPrefixBoost boosts the score of the prefix query,
+// relative to other queries of the search
+func WithPrefixBoost(boost float64) InstantSearchOption {
+	return func(isf *InstantSearchFeature) {
+		isf.boost = boost
+	}
+}
+
+func NewInstantSearchFeature(fields []string, opts ...InstantSearchOption) *InstantSearchFeature {
+	isf := &InstantSearchFeature{
+		param:      "prefix",
+		fields:     fields,
+		maxShingle: 3,
+	}
+
+	for _, opt := range opts {
+		opt(isf)
+	}
+
+	return isf
+}
+
+// Describe returns the prefix parameter
+func (isf *InstantSearchFeature) Describe() []reveald.ParameterDescription {
+	return []reveald.ParameterDescription{{
+		Name:        isf.param,
+		Kind:        reveald.ParameterValue,
+		Description: "Text typed so far, matched as a prefix",
+		Examples:    []string{"red sh"},
+	}}
+}
+
+func (isf *InstantSearchFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	isf.build(builder)
+	return next(builder)
+}
+
+func (isf *InstantSearchFeature) build(builder *reveald.QueryBuilder) {
+	p, err := builder.Request().Get(isf.param)
+	if err != nil || p.Value() == "" {
+		return
+	}
+
+	query := elastic.NewMultiMatchQuery(p.Value(), isf.subfields()...).Type("bool_prefix")
+	if isf.operator != "" {
+		query = query.Operator(string(isf.operator))
+	}
+	if isf.boost != 0 {
+		query = query.Boost(isf.boost)
+	}
+
+	builder.With(query)
+}
+
+// subfields returns the fields along with their shingle subfields
+func (isf *InstantSearchFeature) subfields() []string {
+	var fields []string
+	for _, field := range isf.fields {
+		fields = append(fields, field)
+		for n := 2; n <= isf.maxShingle; n++ {
+			fields = append(fields, fmt.Sprintf("%s._%dgram", field, n))
+		}
+	}
+
+	return fields
+}
@@ -0,0 +1,40 @@
+package featureset
+
+import (
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_InstantSearchFeature_Build(t *testing.T) {
+	table := []struct {
+		name   string
+		opts   []InstantSearchOption
+		params []reveald.Parameter
+		query  elastic.Query
+	}{
+		{"no prefix", nil,
+			[]reveald.Parameter{reveald.NewParameter("q", "red shoes")},
+			elastic.NewBoolQuery()},
+		{"prefix", nil,
+			[]reveald.Parameter{reveald.NewParameter("prefix", "red sh")},
+			elastic.NewBoolQuery().Must(elastic.NewMultiMatchQuery("red sh",
+				"title", "title._2gram", "title._3gram", "brand", "brand._2gram", "brand._3gram").Type("bool_prefix"))},
+		{"options", []InstantSearchOption{WithPrefixParam("typed"), WithMaxShingleSize(2), WithPrefixOperator(OperatorAnd), WithPrefixBoost(2)},
+			[]reveald.Parameter{reveald.NewParameter("typed", "red sh")},
+			elastic.NewBoolQuery().Must(elastic.NewMultiMatchQuery("red sh",
+				"title", "title._2gram", "brand", "brand._2gram").Type("bool_prefix").Operator("and").Boost(2))},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			isf := NewInstantSearchFeature([]string{"title", "brand"}, tt.opts...)
+			qb := reveald.NewQueryBuilder(reveald.NewRequest(tt.params...), "products")
+
+			isf.build(qb)
+			assert.Equal(t, tt.query, qb.RawQuery())
+		})
+	}
+}
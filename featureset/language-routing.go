@@ -0,0 +1,170 @@
+package featureset
+
+import (
+	"sort"
+
+	"github.com/reveald/reveald"
+)
+
+// Language configures searching documents in one language,
+// held in an index of their own and analyzed for the language
+type Language struct {
+	// Code is the value of the language parameter, such as "en"
+	Code string
+	// Index is the index holding the documents in the language,
+	// where an empty index keeps the endpoint indices
+	Index string
+	// Fields are the fields searched for the free text query,
+	// where no fields searches the index default fields
+	Fields []string
+	// Analyzer analyzes the free text query, where an empty
+	// analyzer uses the search analyzers of the fields
+	Analyzer string
+}
+
+// LanguageDetector returns the language code of a free
+// text query, and whether the language could be detected
+type LanguageDetector func(text string) (string, bool)
+
+// LanguageRoutingFeature searches the index of the requested
+// language, applying the free text query to the fields and with
+// the analyzer of the language. It replaces a QueryFilterFeature
+// for multilingual endpoints
+type LanguageRoutingFeature struct {
+	param           string
+	queryParam      string
+	languages       map[string]Language
+	defaultLanguage string
+	detector        LanguageDetector
+}
+
+type LanguageRoutingOption func(*LanguageRoutingFeature)
+
+// WithLanguageParam defines the request parameter
+// selecting the language, defaulting to "lang"
+func WithLanguageParam(name string) LanguageRoutingOption {
+	return func(lrf *LanguageRoutingFeature) {
+		lrf.param = name
+	}
+}
+
+// WithLanguageQueryParam defines the free text
+// query parameter, defaulting to "q"
+func WithLanguageQueryParam(name string) LanguageRoutingOption {
+	return func(lrf *LanguageRoutingFeature) {
+		lrf.queryParam = name
+	}
+}
+
+// WithLanguages adds languages to route to, replacing
+// any earlier language with the same code
+func WithLanguages(languages ...Language) LanguageRoutingOption {
+	return func(lrf *LanguageRoutingFeature) {
+		for _, l := range languages {
+			lrf.languages[l.Code] = l
+		}
+	}
+}
+
+// WithDefaultLanguage defines the language used when the request
+// neither selects a known language, nor has a detectable query
+func WithDefaultLanguage(code string) LanguageRoutingOption {
+	return func(lrf *LanguageRoutingFeature) {
+		lrf.defaultLanguage = code
+	}
+}
+
+// WithLanguageDetector detects the language of the free text
+// query, when the request doesn't select a known language
+func WithLanguageDetector(detector LanguageDetector) LanguageRoutingOption {
+	return func(lrf *LanguageRoutingFeature) {
+		lrf.detector = detector
+	}
+}
+
+func NewLanguageRoutingFeature(opts ...LanguageRoutingOption) *LanguageRoutingFeature {
+	lrf := &LanguageRoutingFeature{
+		param:      "lang",
+		queryParam: "q",
+		languages:  make(map[string]Language),
+	}
+
+	for _, opt := range opts {
+		opt(lrf)
+	}
+
+	return lrf
+}
+
+// Describe returns the language parameter, with the
+// configured languages, and the free text query parameter
+func (lrf *LanguageRoutingFeature) Describe() []reveald.ParameterDescription {
+	codes := make([]string, 0, len(lrf.languages))
+	for code := range lrf.languages {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	description := "Selects the language to search"
+	if lrf.defaultLanguage != "" {
+		description += " (default " + lrf.defaultLanguage + ")"
+	}
+
+	return append([]reveald.ParameterDescription{{
+		Name:        lrf.param,
+		Kind:        reveald.ParameterValue,
+		Description: description,
+		Values:      codes,
+	}}, NewQueryFilterFeature(WithQueryParam(lrf.queryParam)).Describe()...)
+}
+
+func (lrf *LanguageRoutingFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	qff := lrf.build(builder)
+	return qff.Process(builder, next)
+}
+
+// build routes the query builder to the index of the resolved
+// language, returning the query filter of the language
+func (lrf *LanguageRoutingFeature) build(builder *reveald.QueryBuilder) *QueryFilterFeature {
+	opts := []QueryFilterOption{WithQueryParam(lrf.queryParam)}
+
+	l, ok := lrf.resolve(builder.Request())
+	if !ok {
+		return NewQueryFilterFeature(opts...)
+	}
+
+	if l.Index != "" {
+		builder.SetIndices(l.Index)
+	}
+	if len(l.Fields) > 0 {
+		opts = append(opts, WithFields(l.Fields...))
+	}
+	if l.Analyzer != "" {
+		opts = append(opts, WithAnalyzer(l.Analyzer))
+	}
+
+	return NewQueryFilterFeature(opts...)
+}
+
+// resolve returns the language selected by the request, or
+// else the detected language, or else the default language
+func (lrf *LanguageRoutingFeature) resolve(request *reveald.Request) (Language, bool) {
+	if p, err := request.Get(lrf.param); err == nil {
+		if l, ok := lrf.languages[p.Value()]; ok {
+			return l, true
+		}
+	}
+
+	if lrf.detector != nil {
+		if p, err := request.Get(lrf.queryParam); err == nil && p.Value() != "" {
+			if code, ok := lrf.detector(p.Value()); ok {
+				if l, ok := lrf.languages[code]; ok {
+					return l, true
+				}
+			}
+		}
+	}
+
+	l, ok := lrf.languages[lrf.defaultLanguage]
+	return l, ok
+}
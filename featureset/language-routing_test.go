@@ -0,0 +1,71 @@
+package featureset
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LanguageRoutingFeature_Build(t *testing.T) {
+	detector := func(text string) (string, bool) {
+		if strings.Contains(text, "ö") {
+			return "sv", true
+		}
+		return "", false
+	}
+
+	lrf := NewLanguageRoutingFeature(
+		WithLanguages(
+			Language{Code: "en", Index: "products-en", Fields: []string{"name.en"}, Analyzer: "english"},
+			Language{Code: "sv", Index: "products-sv", Fields: []string{"name.sv"}, Analyzer: "swedish"}),
+		WithDefaultLanguage("en"),
+		WithLanguageDetector(detector))
+
+	table := []struct {
+		name    string
+		params  []reveald.Parameter
+		indices []string
+		query   elastic.Query
+	}{
+		{"default", []reveald.Parameter{reveald.NewParameter("q", "shoes")},
+			[]string{"products-en"},
+			elastic.NewQueryStringQuery("shoes").Lenient(true).Field("name.en").Analyzer("english")},
+		{"selected", []reveald.Parameter{reveald.NewParameter("lang", "sv"), reveald.NewParameter("q", "skor")},
+			[]string{"products-sv"},
+			elastic.NewQueryStringQuery("skor").Lenient(true).Field("name.sv").Analyzer("swedish")},
+		{"detected", []reveald.Parameter{reveald.NewParameter("q", "stövlar")},
+			[]string{"products-sv"},
+			elastic.NewQueryStringQuery("stövlar").Lenient(true).Field("name.sv").Analyzer("swedish")},
+		{"unknown language", []reveald.Parameter{reveald.NewParameter("lang", "fi"), reveald.NewParameter("q", "shoes")},
+			[]string{"products-en"},
+			elastic.NewQueryStringQuery("shoes").Lenient(true).Field("name.en").Analyzer("english")},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			qb := reveald.NewQueryBuilder(reveald.NewRequest(tt.params...), "products")
+
+			_, err := lrf.Process(qb, func(*reveald.QueryBuilder) (*reveald.Result, error) {
+				return nil, nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.indices, qb.Indices())
+			assert.Equal(t, elastic.NewBoolQuery().Must(tt.query), qb.RawQuery())
+		})
+	}
+}
+
+func Test_LanguageRoutingFeature_Without_Languages(t *testing.T) {
+	lrf := NewLanguageRoutingFeature()
+	qb := reveald.NewQueryBuilder(reveald.NewRequest(reveald.NewParameter("q", "shoes")), "products")
+
+	_, err := lrf.Process(qb, func(*reveald.QueryBuilder) (*reveald.Result, error) {
+		return nil, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"products"}, qb.Indices())
+	assert.Equal(t, elastic.NewBoolQuery().Must(elastic.NewQueryStringQuery("shoes").Lenient(true)), qb.RawQuery())
+}
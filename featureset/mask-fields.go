@@ -0,0 +1,192 @@
+package featureset
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+)
+
+// HitTransformer changes a hit before it's returned
+type HitTransformer func(hit map[string]interface{}) map[string]interface{}
+
+// MaskFieldsFeature redacts or hashes personal data, such as
+// emails or phone numbers, in the hits of a result, including
+// collapsed and inner hits, and drops the highlights of masked
+// fields, along with the raw response of debug details. Fields
+// are dotted paths, following objects and arrays
+type MaskFieldsFeature struct {
+	redacted     []string
+	hashed       []string
+	key          string
+	transformers []HitTransformer
+}
+
+type MaskFieldsOption func(*MaskFieldsFeature)
+
+// WithRedactedHitFields replaces the values
+// of fields with reveald.RedactedValue
+func WithRedactedHitFields(fields ...string) MaskFieldsOption {
+	return func(mff *MaskFieldsFeature) {
+		mff.redacted = append(mff.redacted, fields...)
+	}
+}
+
+// WithHashedFields replaces the values of fields with a hash of
+// them, so that hits may still be grouped or joined on them
+func WithHashedFields(fields ...string) MaskFieldsOption {
+	return func(mff *MaskFieldsFeature) {
+		mff.hashed = append(mff.hashed, fields...)
+	}
+}
+
+// WithHashKey keys the HMAC hashes of hashed fields, so that
+// common values can't be looked up by their hash. It's required
+// along with WithHashedFields
+func WithHashKey(key string) MaskFieldsOption {
+	return func(mff *MaskFieldsFeature) {
+		mff.key = key
+	}
+}
+
+// WithHitTransformer changes every hit, after the
+// configured fields have been masked
+func WithHitTransformer(fn HitTransformer) MaskFieldsOption {
+	return func(mff *MaskFieldsFeature) {
+		mff.transformers = append(mff.transformers, fn)
+	}
+}
+
+func NewMaskFieldsFeature(opts ...MaskFieldsOption) *MaskFieldsFeature {
+	mff := &MaskFieldsFeature{}
+
+	for _, opt := range opts {
+		opt(mff)
+	}
+
+	return mff
+}
+
+// Validate rejects hashed fields without a hash key, since
+// e.g. emails hashed without one are reversed by a dictionary
+func (mff *MaskFieldsFeature) Validate() error {
+	if len(mff.hashed) > 0 && mff.key == "" {
+		return errors.New("hashed fields require a hash key")
+	}
+
+	return nil
+}
+
+func (mff *MaskFieldsFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	builder.WithoutDebugResponse()
+
+	r, err := next(builder)
+	if err != nil {
+		return nil, err
+	}
+
+	return mff.handle(r)
+}
+
+func (mff *MaskFieldsFeature) handle(result *reveald.Result) (*reveald.Result, error) {
+	for i, hit := range result.Hits {
+		result.Hits[i] = mff.mask(hit)
+	}
+
+	return result, nil
+}
+
+// mask masks a hit, along with its collapsed and inner hits
+func (mff *MaskFieldsFeature) mask(hit map[string]interface{}) map[string]interface{} {
+	for _, field := range mff.redacted {
+		replaceValues(hit, strings.Split(field, "."), func(interface{}) interface{} {
+			return reveald.RedactedValue
+		})
+	}
+	for _, field := range mff.hashed {
+		replaceValues(hit, strings.Split(field, "."), mff.hash)
+	}
+
+	if highlights, ok := hit[reveald.HighlightsKey].(elastic.SearchHitHighlight); ok {
+		for name := range highlights {
+			if mff.masked(name) {
+				delete(highlights, name)
+			}
+		}
+	}
+
+	if collapsed, ok := hit[reveald.CollapsedHitsKey].([]map[string]interface{}); ok {
+		for i, h := range collapsed {
+			collapsed[i] = mff.mask(h)
+		}
+	}
+
+	if inner, ok := hit[reveald.InnerHitsKey].(map[string][]map[string]interface{}); ok {
+		for _, hits := range inner {
+			for i, h := range hits {
+				hits[i] = mff.mask(h)
+			}
+		}
+	}
+
+	for _, fn := range mff.transformers {
+		hit = fn(hit)
+	}
+
+	return hit
+}
+
+// masked returns whether a field, or one of its
+// sub-fields such as a keyword field, is masked
+func (mff *MaskFieldsFeature) masked(name string) bool {
+	for _, fields := range [][]string{mff.redacted, mff.hashed} {
+		for _, field := range fields {
+			if name == field || strings.HasPrefix(name, field+".") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (mff *MaskFieldsFeature) hash(v interface{}) interface{} {
+	mac := hmac.New(sha256.New, []byte(mff.key))
+	mac.Write([]byte(fmt.Sprint(v)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// replaceValues replaces the values at a path, following
+// objects and arrays of values or objects
+func replaceValues(value interface{}, path []string, fn func(interface{}) interface{}) {
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			replaceValues(item, path, fn)
+		}
+	case map[string]interface{}:
+		child, ok := v[path[0]]
+		if !ok || child == nil {
+			return
+		}
+
+		if len(path) > 1 {
+			replaceValues(child, path[1:], fn)
+			return
+		}
+
+		if list, ok := child.([]interface{}); ok {
+			for i, item := range list {
+				list[i] = fn(item)
+			}
+			return
+		}
+
+		v[path[0]] = fn(child)
+	}
+}
@@ -0,0 +1,101 @@
+package featureset
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+	"github.com/reveald/reveald/revealdtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MaskFieldsFeature_Handle(t *testing.T) {
+	mff := NewMaskFieldsFeature(
+		WithRedactedHitFields("email", "contacts.phone"),
+		WithHashedFields("customer"),
+		WithHashKey("pepper"),
+		WithHitTransformer(func(hit map[string]interface{}) map[string]interface{} {
+			delete(hit, "internal")
+			return hit
+		}))
+
+	result := &reveald.Result{Hits: []map[string]interface{}{{
+		"name":     "Anvil",
+		"email":    "wile@acme.com",
+		"customer": "wile",
+		"internal": true,
+		"contacts": []interface{}{
+			map[string]interface{}{"phone": "555-1234", "kind": "home"},
+			map[string]interface{}{"phone": []interface{}{"555-0000"}},
+		},
+		reveald.HighlightsKey: elastic.SearchHitHighlight{
+			"name":          {"<em>Anvil</em>"},
+			"email.keyword": {"<em>wile</em>@acme.com"},
+		},
+		reveald.InnerHitsKey: map[string][]map[string]interface{}{
+			"orders": {{"email": "road@runner.com"}},
+		},
+	}}}
+
+	r, err := mff.handle(result)
+	assert.NoError(t, err)
+
+	hit := r.Hits[0]
+	assert.Equal(t, "Anvil", hit["name"])
+	assert.Equal(t, reveald.RedactedValue, hit["email"])
+	mac := hmac.New(sha256.New, []byte("pepper"))
+	mac.Write([]byte("wile"))
+	assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), hit["customer"])
+	assert.NotEqual(t, NewMaskFieldsFeature().hash("wile"), hit["customer"], "hashes are keyed")
+	assert.NotContains(t, hit, "internal")
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"phone": reveald.RedactedValue, "kind": "home"},
+		map[string]interface{}{"phone": []interface{}{reveald.RedactedValue}},
+	}, hit["contacts"])
+	assert.Equal(t, elastic.SearchHitHighlight{"name": {"<em>Anvil</em>"}}, hit[reveald.HighlightsKey])
+	assert.Equal(t, reveald.RedactedValue, hit[reveald.InnerHitsKey].(map[string][]map[string]interface{})["orders"][0]["email"])
+}
+
+func Test_MaskFieldsFeature_Requires_Hash_Key(t *testing.T) {
+	table := []struct {
+		name  string
+		opts  []MaskFieldsOption
+		valid bool
+	}{
+		{"redacted only", []MaskFieldsOption{WithRedactedHitFields("email")}, true},
+		{"hashed with key", []MaskFieldsOption{WithHashedFields("email"), WithHashKey("pepper")}, true},
+		{"hashed without key", []MaskFieldsOption{WithHashedFields("email")}, false},
+		{"hashed with empty key", []MaskFieldsOption{WithHashedFields("email"), WithHashKey("")}, false},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			e := reveald.NewEndpoint(&fixedBackend{}, reveald.WithIndices("customers"))
+			err := e.Register(NewMaskFieldsFeature(tt.opts...))
+			assert.Equal(t, tt.valid, err == nil)
+		})
+	}
+}
+
+func Test_MaskFieldsFeature_DebugResponse(t *testing.T) {
+	s := revealdtest.NewServer()
+	t.Cleanup(s.Close)
+
+	assert.NoError(t, s.Index("customers", map[string]interface{}{"email": "wile@acme.com"}))
+
+	b, err := s.Backend()
+	assert.NoError(t, err)
+
+	e := reveald.NewEndpoint(b, reveald.WithIndices("customers"), reveald.WithDebug())
+	assert.NoError(t, e.Register(NewMaskFieldsFeature(WithRedactedHitFields("email"))))
+
+	r, err := e.Execute(context.Background(), reveald.NewRequest())
+	assert.NoError(t, err)
+	assert.Equal(t, reveald.RedactedValue, r.Hits[0]["email"])
+	assert.NotEmpty(t, r.Debug.Request)
+	assert.Empty(t, r.Debug.Response)
+}
@@ -0,0 +1,165 @@
+package featureset
+
+import (
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+)
+
+// MoreLikeThisFeature matches documents similar to documents
+// of the index, by id, or to free text of the request, e.g. for
+// "similar items" endpoints. The liked documents are excluded
+// from the hits
+type MoreLikeThisFeature struct {
+	documentParam string
+	textParam     string
+	index         string
+	fields        []string
+	minTermFreq   int
+	minDocFreq    int
+	maxQueryTerms int
+	minimumMatch  string
+}
+
+type MoreLikeThisOption func(*MoreLikeThisFeature)
+
+// WithLikeDocumentParam sets the parameter holding the ids
+// of the liked documents, defaulting to similar_to
+func WithLikeDocumentParam(name string) MoreLikeThisOption {
+	return func(mltf *MoreLikeThisFeature) {
+		mltf.documentParam = name
+	}
+}
+
+// WithLikeTextParam sets the parameter holding
+// the liked text, defaulting to like
+func WithLikeTextParam(name string) MoreLikeThisOption {
+	return func(mltf *MoreLikeThisFeature) {
+		mltf.textParam = name
+	}
+}
+
+// WithLikeIndex looks the liked documents up in an index,
+// instead of the index searched
+func WithLikeIndex(index string) MoreLikeThisOption {
+	return func(mltf *MoreLikeThisFeature) {
+		mltf.index = index
+	}
+}
+
+// WithLikeFields compares documents by the terms of fields,
+// rather than by the default fields of the index
+func WithLikeFields(fields ...string) MoreLikeThisOption {
+	return func(mltf *MoreLikeThisFeature) {
+		mltf.fields = fields
+	}
+}
+
+// WithMinTermFrequency ignores terms occurring
+// fewer times in the liked documents
+func WithMinTermFrequency(n int) MoreLikeThisOption {
+	return func(mltf *MoreLikeThisFeature) {
+		mltf.minTermFreq = n
+	}
+}
+
+// WithMinDocFrequency ignores terms occurring
+// in fewer documents of the index
+func WithMinDocFrequency(n int) MoreLikeThisOption {
+	return func(mltf *MoreLikeThisFeature) {
+		mltf.minDocFreq = n
+	}
+}
+
+// WithMaxQueryTerms limits the number of terms
+// selected from the liked documents
+func WithMaxQueryTerms(n int) MoreLikeThisOption {
+	return func(mltf *MoreLikeThisFeature) {
+		mltf.maxQueryTerms = n
+	}
+}
+
+// WithLikeMinimumShouldMatch requires a number of the
+// selected terms to match, such as "30%"
+func WithLikeMinimumShouldMatch(minimum string) MoreLikeThisOption {
+	return func(mltf *MoreLikeThisFeature) {
+		mltf.minimumMatch = minimum
+	}
+}
+
+func NewMoreLikeThisFeature(opts ...MoreLikeThisOption) *MoreLikeThisFeature {
+	mltf := &MoreLikeThisFeature{
+		documentParam: "similar_to",
+		textParam:     "like",
+	}
+
+	for _, opt := range opts {
+		opt(mltf)
+	}
+
+	return mltf
+}
+
+// Describe returns the liked document and text parameters
+func (mltf *MoreLikeThisFeature) Describe() []reveald.ParameterDescription {
+	return []reveald.ParameterDescription{{
+		Name:        mltf.documentParam,
+		Kind:        reveald.ParameterMultiValue,
+		Description: "Ids of documents to find similar documents to",
+		Examples:    []string{"product-123"},
+	}, {
+		Name:        mltf.textParam,
+		Kind:        reveald.ParameterValue,
+		Description: "Text to find similar documents to",
+		Examples:    []string{"waterproof hiking boots"},
+	}}
+}
+
+func (mltf *MoreLikeThisFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	mltf.build(builder)
+	return next(builder)
+}
+
+func (mltf *MoreLikeThisFeature) build(builder *reveald.QueryBuilder) {
+	var items []*elastic.MoreLikeThisQueryItem
+
+	if p, err := builder.Request().Get(mltf.documentParam); err == nil {
+		for _, id := range p.Values() {
+			if id == "" {
+				continue
+			}
+
+			item := elastic.NewMoreLikeThisQueryItem().Id(id)
+			if mltf.index != "" {
+				item = item.Index(mltf.index)
+			}
+			items = append(items, item)
+		}
+	}
+
+	if p, err := builder.Request().Get(mltf.textParam); err == nil && p.Value() != "" {
+		items = append(items, elastic.NewMoreLikeThisQueryItem().LikeText(p.Value()))
+	}
+
+	if len(items) == 0 {
+		return
+	}
+
+	query := elastic.NewMoreLikeThisQuery().LikeItems(items...)
+	if len(mltf.fields) > 0 {
+		query = query.Field(mltf.fields...)
+	}
+	if mltf.minTermFreq > 0 {
+		query = query.MinTermFreq(mltf.minTermFreq)
+	}
+	if mltf.minDocFreq > 0 {
+		query = query.MinDocFreq(mltf.minDocFreq)
+	}
+	if mltf.maxQueryTerms > 0 {
+		query = query.MaxQueryTerms(mltf.maxQueryTerms)
+	}
+	if mltf.minimumMatch != "" {
+		query = query.MinimumShouldMatch(mltf.minimumMatch)
+	}
+
+	builder.With(query)
+}
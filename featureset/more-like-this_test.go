@@ -0,0 +1,43 @@
+package featureset
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/reveald/reveald"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MoreLikeThisFeature_Build(t *testing.T) {
+	table := []struct {
+		name     string
+		opts     []MoreLikeThisOption
+		params   []reveald.Parameter
+		expected string
+	}{
+		{"nothing liked", nil,
+			[]reveald.Parameter{reveald.NewParameter("q", "boots")},
+			`{"bool":{}}`},
+		{"documents", []MoreLikeThisOption{WithLikeFields("title", "description")},
+			[]reveald.Parameter{reveald.NewParameter("similar_to", "p1", "p2")},
+			`{"bool":{"must":{"more_like_this":{"fields":["title","description"],"like":[{"_id":"p1"},{"_id":"p2"}]}}}}`},
+		{"text", []MoreLikeThisOption{WithMinTermFrequency(1), WithMaxQueryTerms(12)},
+			[]reveald.Parameter{reveald.NewParameter("like", "hiking boots")},
+			`{"bool":{"must":{"more_like_this":{"like":["hiking boots"],"max_query_terms":12,"min_term_freq":1}}}}`},
+		{"options", []MoreLikeThisOption{WithLikeDocumentParam("item"), WithLikeIndex("catalog"), WithMinDocFrequency(2), WithLikeMinimumShouldMatch("30%")},
+			[]reveald.Parameter{reveald.NewParameter("item", "p1"), reveald.NewParameter("like", "boots")},
+			`{"bool":{"must":{"more_like_this":{"like":[{"_id":"p1","_index":"catalog"},"boots"],"min_doc_freq":2,"minimum_should_match":"30%"}}}}`},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			mltf := NewMoreLikeThisFeature(tt.opts...)
+			qb := reveald.NewQueryBuilder(reveald.NewRequest(tt.params...), "products")
+
+			mltf.build(qb)
+			data, err := json.Marshal(sourceJSON(t, qb)["query"])
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(data))
+		})
+	}
+}
@@ -0,0 +1,337 @@
+package featureset
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+)
+
+const nestedDocumentCount = "documents"
+
+// NestedCountingMode defines what the bucket
+// counts of wrapped aggregations count
+type NestedCountingMode int
+
+const (
+	// NestedCountObjects counts the nested objects in each bucket
+	NestedCountObjects NestedCountingMode = iota
+	// NestedCountDocuments counts the documents having
+	// a nested object in each bucket
+	NestedCountDocuments
+)
+
+// NestedDocumentWrapper applies the queries and aggregations of
+// the wrapped features to nested objects at a path, such as the
+// variants of a product, so that filters on several properties
+// must match the same nested object. Sorts of the wrapped features,
+// such as sort options on nested properties, sort on the nested
+// objects matching the wrapped features' queries. Wrappers may wrap
+// wrappers of deeper nested objects (e.g. orders and orders.items),
+// chaining their queries, aggregations and sorts. Other changes the
+// wrapped features make to the query builder are ignored
+type NestedDocumentWrapper struct {
+	path          string
+	features      []reveald.Feature
+	innerHitsSize int
+	disjunctive   bool
+	counting      NestedCountingMode
+}
+
+type NestedDocumentOption func(*NestedDocumentWrapper)
+
+// WithFeatures adds features to apply to the nested objects
+func WithFeatures(features ...reveald.Feature) NestedDocumentOption {
+	return func(ndw *NestedDocumentWrapper) {
+		ndw.features = append(ndw.features, features...)
+	}
+}
+
+// WithInnerHits returns up to size of the nested objects matching
+// the wrapped features' queries, as inner hits under
+// reveald.InnerHitsKey and the nested path
+func WithInnerHits(size int) NestedDocumentOption {
+	return func(ndw *NestedDocumentWrapper) {
+		ndw.innerHitsSize = size
+	}
+}
+
+// WithDisjunctiveSelection filters the hits on the wrapped features'
+// queries using the post filter, so that the wrapped aggregations
+// count every nested object, regardless of the selection
+func WithDisjunctiveSelection() NestedDocumentOption {
+	return func(ndw *NestedDocumentWrapper) {
+		ndw.disjunctive = true
+	}
+}
+
+// WithNestedCounting defines what the bucket counts of the wrapped
+// aggregations count, defaulting to NestedCountObjects
+func WithNestedCounting(mode NestedCountingMode) NestedDocumentOption {
+	return func(ndw *NestedDocumentWrapper) {
+		ndw.counting = mode
+	}
+}
+
+func NewNestedDocumentWrapper(path string, opts ...NestedDocumentOption) *NestedDocumentWrapper {
+	ndw := &NestedDocumentWrapper{
+		path:     path,
+		counting: NestedCountObjects,
+	}
+
+	for _, opt := range opts {
+		opt(ndw)
+	}
+
+	return ndw
+}
+
+func (ndw *NestedDocumentWrapper) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	inner := reveald.NewQueryBuilder(builder.Request(), builder.Indices()...)
+	inner.SetContext(builder.Context())
+
+	return ndw.process(0, inner, func(inner *reveald.QueryBuilder) (*reveald.Result, error) {
+		aggs := ndw.build(builder, inner)
+
+		r, err := next(builder)
+		if err != nil {
+			return nil, err
+		}
+
+		return ndw.handle(r, aggs)
+	})
+}
+
+// process runs the wrapped features, from the
+// feature at index i, on the inner query builder
+func (ndw *NestedDocumentWrapper) process(i int, inner *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	if i == len(ndw.features) {
+		return next(inner)
+	}
+
+	return ndw.features[i].Process(inner, func(qb *reveald.QueryBuilder) (*reveald.Result, error) {
+		return ndw.process(i+1, qb, next)
+	})
+}
+
+// build applies the query and aggregations of the inner query
+// builder to the builder, returning the names of the aggregations
+func (ndw *NestedDocumentWrapper) build(builder, inner *reveald.QueryBuilder) []string {
+	if query := inner.RawQuery(); !isEmptyQuery(query) {
+		nested := elastic.NewNestedQuery(ndw.path, query)
+		if ndw.innerHitsSize > 0 {
+			nested = nested.InnerHit(elastic.NewInnerHit().Name(ndw.path).Size(ndw.innerHitsSize))
+		}
+
+		if ndw.disjunctive {
+			builder.PostFilterWith(nested)
+		} else {
+			builder.With(nested)
+		}
+	}
+
+	// post filters of wrapped wrappers, selecting deeper
+	// nested objects disjunctively, apply to these objects
+	if filter := inner.RawPostFilter(); filter != nil {
+		builder.PostFilterWith(elastic.NewNestedQuery(ndw.path, filter))
+	}
+
+	for _, filter := range inner.AppliedFilters() {
+		builder.ApplyFilter(filter)
+	}
+
+	if sorters := ndw.sorters(inner); len(sorters) > 0 {
+		builder.Selection().Update(reveald.WithSortBy(sorters...))
+	}
+
+	var names []string
+	for name := range inner.Aggregations() {
+		agg, _ := inner.AttachedAggregation(name)
+		builder.Aggregation(name, elastic.NewNestedAggregation().Path(ndw.path))
+		builder.SubAggregation(name, name, agg)
+		if ndw.counting == NestedCountDocuments {
+			builder.SubAggregation(name+reveald.SubAggregationSeparator+name, nestedDocumentCount,
+				elastic.NewReverseNestedAggregation())
+		}
+
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// sorters returns the sorts of the inner query builder, where
+// sorts on properties of the nested objects sort on the objects
+// matching the inner query, wrapping the nested sorts of deeper
+// nested objects
+func (ndw *NestedDocumentWrapper) sorters(inner *reveald.QueryBuilder) []elastic.Sorter {
+	var filter elastic.Query
+	if query := inner.RawQuery(); !isEmptyQuery(query) {
+		filter = query
+	}
+
+	var sorters []elastic.Sorter
+	for _, sorter := range inner.Selection().Sorters() {
+		if ndw.nests(sorter) {
+			sorter = &nestedSorter{sorter, ndw.path, filter}
+		}
+
+		sorters = append(sorters, sorter)
+	}
+
+	return sorters
+}
+
+// nests returns whether a sort is on a property of the nested
+// objects, without a nested sort, or with a nested sort of
+// deeper nested objects
+func (ndw *NestedDocumentWrapper) nests(sorter elastic.Sorter) bool {
+	field, params, ok := sortParams(sorter)
+	if !ok || !strings.HasPrefix(field, ndw.path+".") {
+		return false
+	}
+
+	nested, ok := params["nested"].(map[string]interface{})
+	if !ok {
+		return true
+	}
+
+	path, _ := nested["path"].(string)
+	return strings.HasPrefix(path, ndw.path+".")
+}
+
+// nestedSorter sorts on the nested objects at a path matching a
+// filter, wrapping the nested sort of deeper nested objects
+type nestedSorter struct {
+	sorter elastic.Sorter
+	path   string
+	filter elastic.Query
+}
+
+// Source returns the sort, with its nested sort
+func (ns *nestedSorter) Source() (interface{}, error) {
+	field, params, ok := sortParams(ns.sorter)
+	if !ok {
+		return ns.sorter.Source()
+	}
+
+	nested := map[string]interface{}{"path": ns.path}
+	if ns.filter != nil {
+		filter, err := ns.filter.Source()
+		if err != nil {
+			return nil, err
+		}
+		nested["filter"] = filter
+	}
+	if deeper, ok := params["nested"]; ok {
+		nested["nested"] = deeper
+	}
+	params["nested"] = nested
+
+	return map[string]interface{}{field: params}, nil
+}
+
+// sortParams returns the field and parameters of a field sort
+func sortParams(sorter elastic.Sorter) (string, map[string]interface{}, bool) {
+	src, err := sorter.Source()
+	if err != nil {
+		return "", nil, false
+	}
+
+	m, ok := src.(map[string]interface{})
+	if !ok || len(m) != 1 {
+		return "", nil, false
+	}
+
+	for field, params := range m {
+		p, ok := params.(map[string]interface{})
+		return field, p, ok
+	}
+
+	return "", nil, false
+}
+
+// handle replaces the nested aggregations with the wrapped
+// aggregations, so that the wrapped features find them by name
+func (ndw *NestedDocumentWrapper) handle(result *reveald.Result, names []string) (*reveald.Result, error) {
+	raw := result.RawResult()
+	if raw == nil {
+		return result, nil
+	}
+
+	for _, name := range names {
+		nested, ok := raw.Aggregations.Nested(name)
+		if !ok {
+			continue
+		}
+
+		agg, ok := nested.Aggregations[name]
+		if !ok {
+			continue
+		}
+
+		if ndw.counting == NestedCountDocuments {
+			agg = countDocuments(agg)
+		}
+
+		raw.Aggregations[name] = agg
+	}
+
+	return result, nil
+}
+
+// countDocuments replaces the bucket counts of an aggregation
+// with the count of their reverse nested aggregation
+func countDocuments(agg json.RawMessage) json.RawMessage {
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(agg, &body); err != nil {
+		return agg
+	}
+
+	var buckets []map[string]json.RawMessage
+	if err := json.Unmarshal(body["buckets"], &buckets); err != nil {
+		return agg
+	}
+
+	for _, bucket := range buckets {
+		var count struct {
+			DocCount json.RawMessage `json:"doc_count"`
+		}
+		if err := json.Unmarshal(bucket[nestedDocumentCount], &count); err != nil || count.DocCount == nil {
+			continue
+		}
+
+		bucket["doc_count"] = count.DocCount
+		delete(bucket, nestedDocumentCount)
+	}
+
+	data, err := json.Marshal(buckets)
+	if err != nil {
+		return agg
+	}
+	body["buckets"] = data
+
+	data, err = json.Marshal(body)
+	if err != nil {
+		return agg
+	}
+
+	return data
+}
+
+// isEmptyQuery returns whether a query is a bool query without clauses
+func isEmptyQuery(query elastic.Query) bool {
+	src, err := query.Source()
+	if err != nil {
+		return false
+	}
+
+	m, ok := src.(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	b, ok := m["bool"].(map[string]interface{})
+	return ok && len(b) == 0
+}
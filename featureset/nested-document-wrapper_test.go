@@ -0,0 +1,307 @@
+package featureset
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/reveald/reveald"
+	"github.com/reveald/reveald/revealdtest"
+	"github.com/stretchr/testify/assert"
+)
+
+type variant struct {
+	Color string `json:"color"`
+	Size  string `json:"size"`
+}
+
+type variantProduct struct {
+	Name     string    `json:"name"`
+	Variants []variant `json:"variants"`
+}
+
+func Test_NestedDocumentWrapper_Build(t *testing.T) {
+	table := []struct {
+		name       string
+		opts       []NestedDocumentOption
+		postFilter bool
+		innerHits  bool
+		counting   bool
+	}{
+		{"default", nil, false, false, false},
+		{"inner hits", []NestedDocumentOption{WithInnerHits(2)}, false, true, false},
+		{"disjunctive", []NestedDocumentOption{WithDisjunctiveSelection()}, true, false, false},
+		{"documents", []NestedDocumentOption{WithNestedCounting(NestedCountDocuments)}, false, false, true},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			qb := reveald.NewQueryBuilder(reveald.NewRequest(reveald.NewParameter("variants.color", "red")), "-")
+			ndw := NewNestedDocumentWrapper("variants",
+				append([]NestedDocumentOption{WithFeatures(NewDynamicFilterFeature("variants.color"))}, tt.opts...)...)
+
+			stop := errors.New("stop")
+			_, err := ndw.Process(qb, func(*reveald.QueryBuilder) (*reveald.Result, error) {
+				return nil, stop
+			})
+			assert.ErrorIs(t, err, stop)
+
+			src := sourceJSON(t, qb)
+			nested := src["query"].(map[string]interface{})["bool"].(map[string]interface{})["must"]
+			if tt.postFilter {
+				assert.Nil(t, nested)
+				nested = src["post_filter"].(map[string]interface{})["bool"].(map[string]interface{})["must"]
+			}
+
+			query := nested.(map[string]interface{})["nested"].(map[string]interface{})
+			assert.Equal(t, "variants", query["path"])
+			_, innerHits := query["inner_hits"]
+			assert.Equal(t, tt.innerHits, innerHits)
+
+			agg := src["aggregations"].(map[string]interface{})["variants.color"].(map[string]interface{})
+			assert.Equal(t, map[string]interface{}{"path": "variants"}, agg["nested"])
+
+			terms := agg["aggregations"].(map[string]interface{})["variants.color"].(map[string]interface{})
+			_, counting := terms["aggregations"]
+			assert.Equal(t, tt.counting, counting)
+		})
+	}
+}
+
+func Test_NestedDocumentWrapper_Execute(t *testing.T) {
+	s := revealdtest.NewServer()
+	t.Cleanup(s.Close)
+
+	assert.NoError(t, s.Index("products",
+		variantProduct{"Shirt", []variant{{"red", "S"}, {"red", "L"}, {"blue", "M"}}},
+		variantProduct{"Jacket", []variant{{"red", "M"}, {"black", "L"}}},
+		variantProduct{"Scarf", []variant{{"blue", "L"}}},
+	))
+
+	b, err := s.Backend()
+	assert.NoError(t, err)
+
+	table := []struct {
+		name     string
+		counting NestedCountingMode
+		params   []reveald.Parameter
+		hits     int64
+		colors   map[interface{}]int64
+	}{
+		{"objects", NestedCountObjects, nil, 3,
+			map[interface{}]int64{"red": 3, "blue": 2, "black": 1}},
+		{"documents", NestedCountDocuments, nil, 3,
+			map[interface{}]int64{"red": 2, "blue": 2, "black": 1}},
+		{"same object", NestedCountObjects, []reveald.Parameter{
+			reveald.NewParameter("variants.color", "red"),
+			reveald.NewParameter("variants.size", "L"),
+		}, 1, map[interface{}]int64{"red": 2, "blue": 1}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			e := reveald.NewEndpoint(b, reveald.WithIndices("products"))
+			assert.NoError(t, e.Register(NewNestedDocumentWrapper("variants",
+				WithFeatures(
+					NewDynamicFilterFeature("variants.color"),
+					NewDynamicFilterFeature("variants.size")),
+				WithNestedCounting(tt.counting))))
+
+			r, err := e.Execute(context.Background(), reveald.NewRequest(tt.params...))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.hits, r.TotalHitCount)
+
+			colors := make(map[interface{}]int64)
+			for _, bucket := range r.Aggregations["variants.color"] {
+				colors[bucket.Value] = bucket.HitCount
+			}
+			assert.Equal(t, tt.colors, colors)
+		})
+	}
+}
+
+type stockVariant struct {
+	Color   string  `json:"color"`
+	Price   float64 `json:"price"`
+	InStock bool    `json:"inStock"`
+}
+
+type stockProduct struct {
+	Name     string         `json:"name"`
+	Variants []stockVariant `json:"variants"`
+}
+
+func Test_NestedDocumentWrapper_BooleanFilterAndSorting(t *testing.T) {
+	s := revealdtest.NewServer()
+	t.Cleanup(s.Close)
+
+	assert.NoError(t, s.Index("products",
+		stockProduct{"Shirt", []stockVariant{{"red", 30, true}, {"blue", 10, false}}},
+		stockProduct{"Jacket", []stockVariant{{"red", 20, true}}},
+		stockProduct{"Scarf", []stockVariant{{"blue", 15, true}}},
+	))
+
+	b, err := s.Backend()
+	assert.NoError(t, err)
+
+	table := []struct {
+		name     string
+		params   []reveald.Parameter
+		expected []string
+	}{
+		{"any variant", []reveald.Parameter{reveald.NewParameter("sort", "price")}, []string{"Shirt", "Scarf", "Jacket"}},
+		{"variants in stock", []reveald.Parameter{reveald.NewParameter("sort", "price"), reveald.NewParameter("variants.inStock", "true")}, []string{"Scarf", "Jacket", "Shirt"}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			e := reveald.NewEndpoint(b, reveald.WithIndices("products"))
+			assert.NoError(t, e.Register(NewNestedDocumentWrapper("variants",
+				WithFeatures(
+					NewBooleanFilterFeature("variants.inStock"),
+					NewSortingFeature("sort", WithSortOption("price", "variants.price", true))))))
+
+			r, err := e.Execute(context.Background(), reveald.NewRequest(tt.params...))
+			assert.NoError(t, err)
+
+			var names []string
+			for _, hit := range r.Hits {
+				names = append(names, hit["name"].(string))
+			}
+			assert.Equal(t, tt.expected, names)
+			assert.NotEmpty(t, r.Aggregations["variants.inStock"])
+		})
+	}
+}
+
+func Test_NestedDocumentWrapper_QueryFilter(t *testing.T) {
+	qb := reveald.NewQueryBuilder(reveald.NewRequest(reveald.NewParameter("q", "red")), "-")
+	ndw := NewNestedDocumentWrapper("variants",
+		WithFeatures(NewQueryFilterFeature(WithFields("variants.color"))))
+
+	stop := errors.New("stop")
+	_, err := ndw.Process(qb, func(*reveald.QueryBuilder) (*reveald.Result, error) {
+		return nil, stop
+	})
+	assert.ErrorIs(t, err, stop)
+
+	src := sourceJSON(t, qb)
+	nested := src["query"].(map[string]interface{})["bool"].(map[string]interface{})["must"].(map[string]interface{})["nested"].(map[string]interface{})
+	assert.Equal(t, "variants", nested["path"])
+
+	query := nested["query"].(map[string]interface{})["bool"].(map[string]interface{})["must"].(map[string]interface{})["query_string"].(map[string]interface{})
+	assert.Equal(t, "red", query["query"])
+	assert.Equal(t, []interface{}{"variants.color"}, query["fields"])
+}
+
+type orderItem struct {
+	SKU      string `json:"sku"`
+	Quantity int    `json:"quantity"`
+}
+
+type order struct {
+	Status string      `json:"status"`
+	Items  []orderItem `json:"items"`
+}
+
+type customer struct {
+	Name   string  `json:"name"`
+	Orders []order `json:"orders"`
+}
+
+func newOrdersWrapper() *NestedDocumentWrapper {
+	return NewNestedDocumentWrapper("orders",
+		WithFeatures(
+			NewDynamicFilterFeature("orders.status"),
+			NewNestedDocumentWrapper("orders.items",
+				WithFeatures(
+					NewDynamicFilterFeature("orders.items.sku"),
+					NewSortingFeature("sort", WithSortOption("quantity", "orders.items.quantity", true))))))
+}
+
+func Test_NestedDocumentWrapper_MultiLevelBuild(t *testing.T) {
+	qb := reveald.NewQueryBuilder(reveald.NewRequest(
+		reveald.NewParameter("orders.status", "shipped"),
+		reveald.NewParameter("orders.items.sku", "C"),
+		reveald.NewParameter("sort", "quantity")), "-")
+
+	stop := errors.New("stop")
+	_, err := newOrdersWrapper().Process(qb, func(*reveald.QueryBuilder) (*reveald.Result, error) {
+		return nil, stop
+	})
+	assert.ErrorIs(t, err, stop)
+
+	src := sourceJSON(t, qb)
+
+	orders := src["query"].(map[string]interface{})["bool"].(map[string]interface{})["must"].(map[string]interface{})["nested"].(map[string]interface{})
+	assert.Equal(t, "orders", orders["path"])
+	clauses := orders["query"].(map[string]interface{})["bool"].(map[string]interface{})["must"].([]interface{})
+	assert.Len(t, clauses, 2)
+	var paths []interface{}
+	for _, clause := range clauses {
+		if nested, ok := clause.(map[string]interface{})["nested"].(map[string]interface{}); ok {
+			paths = append(paths, nested["path"])
+		}
+	}
+	assert.Equal(t, []interface{}{"orders.items"}, paths)
+
+	agg := src["aggregations"].(map[string]interface{})["orders.items.sku"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"path": "orders"}, agg["nested"])
+	items := agg["aggregations"].(map[string]interface{})["orders.items.sku"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"path": "orders.items"}, items["nested"])
+	assert.Contains(t, items["aggregations"].(map[string]interface{})["orders.items.sku"], "terms")
+
+	sort := src["sort"].([]interface{})[0].(map[string]interface{})["orders.items.quantity"].(map[string]interface{})
+	nested := sort["nested"].(map[string]interface{})
+	assert.Equal(t, "orders", nested["path"])
+	assert.NotNil(t, nested["filter"])
+	assert.Equal(t, "orders.items", nested["nested"].(map[string]interface{})["path"])
+}
+
+func Test_NestedDocumentWrapper_MultiLevelExecute(t *testing.T) {
+	s := revealdtest.NewServer()
+	t.Cleanup(s.Close)
+
+	assert.NoError(t, s.Index("customers",
+		customer{"Ann", []order{{"shipped", []orderItem{{"A", 3}, {"B", 1}}}, {"pending", []orderItem{{"C", 2}}}}},
+		customer{"Bob", []order{{"shipped", []orderItem{{"C", 5}}}, {"pending", []orderItem{{"A", 4}}}}},
+		customer{"Cid", []order{{"pending", []orderItem{{"B", 0}}}}},
+	))
+
+	b, err := s.Backend()
+	assert.NoError(t, err)
+
+	table := []struct {
+		name     string
+		params   []reveald.Parameter
+		expected []string
+		skus     map[interface{}]int64
+	}{
+		{"sorted", []reveald.Parameter{reveald.NewParameter("sort", "quantity")},
+			[]string{"Cid", "Ann", "Bob"}, map[interface{}]int64{"A": 2, "B": 2, "C": 2}},
+		{"same order", []reveald.Parameter{reveald.NewParameter("orders.status", "shipped"), reveald.NewParameter("orders.items.sku", "C")},
+			[]string{"Bob"}, map[interface{}]int64{"A": 1, "C": 1}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			e := reveald.NewEndpoint(b, reveald.WithIndices("customers"))
+			assert.NoError(t, e.Register(newOrdersWrapper()))
+
+			r, err := e.Execute(context.Background(), reveald.NewRequest(tt.params...))
+			assert.NoError(t, err)
+
+			var names []string
+			for _, hit := range r.Hits {
+				names = append(names, hit["name"].(string))
+			}
+			assert.Equal(t, tt.expected, names)
+
+			skus := make(map[interface{}]int64)
+			for _, bucket := range r.Aggregations["orders.items.sku"] {
+				skus[bucket.Value] = bucket.HitCount
+			}
+			assert.Equal(t, tt.skus, skus)
+		})
+	}
+}
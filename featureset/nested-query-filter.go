@@ -0,0 +1,88 @@
+package featureset
+
+import (
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+)
+
+const defaultNestedInnerHitsSize = 3
+
+// NestedQueryFilterFeature matches a free text query against
+// nested documents (e.g. product reviews), returning the
+// matching nested documents with highlights as inner hits,
+// under reveald.InnerHitsKey and the nested path
+type NestedQueryFilterFeature struct {
+	path          string
+	param         string
+	fields        []string
+	innerHitsSize int
+}
+
+type NestedQueryFilterOption func(*NestedQueryFilterFeature)
+
+// WithNestedQueryParam defines the query parameter, defaulting to "q"
+func WithNestedQueryParam(name string) NestedQueryFilterOption {
+	return func(nqf *NestedQueryFilterFeature) {
+		nqf.param = name
+	}
+}
+
+// WithNestedFields defines the nested fields to search and
+// highlight, defaulting to all fields of the nested path
+func WithNestedFields(fields ...string) NestedQueryFilterOption {
+	return func(nqf *NestedQueryFilterFeature) {
+		nqf.fields = append(nqf.fields, fields...)
+	}
+}
+
+// WithNestedInnerHitsSize defines the number of matching nested
+// documents returned per hit, defaulting to 3
+func WithNestedInnerHitsSize(size int) NestedQueryFilterOption {
+	return func(nqf *NestedQueryFilterFeature) {
+		nqf.innerHitsSize = size
+	}
+}
+
+func NewNestedQueryFilterFeature(path string, opts ...NestedQueryFilterOption) *NestedQueryFilterFeature {
+	nqf := &NestedQueryFilterFeature{
+		path:          path,
+		param:         "q",
+		innerHitsSize: defaultNestedInnerHitsSize,
+	}
+
+	for _, opt := range opts {
+		opt(nqf)
+	}
+
+	return nqf
+}
+
+func (nqf *NestedQueryFilterFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	nqf.build(builder)
+	return next(builder)
+}
+
+func (nqf *NestedQueryFilterFeature) build(builder *reveald.QueryBuilder) {
+	v, err := builder.Request().Get(nqf.param)
+	if err != nil || v.Value() == "" {
+		return
+	}
+
+	fields := nqf.fields
+	if len(fields) == 0 {
+		fields = []string{nqf.path + ".*"}
+	}
+
+	query := elastic.NewQueryStringQuery(v.Value()).Lenient(true)
+	highlight := elastic.NewHighlight()
+	for _, field := range fields {
+		query = query.Field(field)
+		highlight = highlight.Fields(elastic.NewHighlighterField(field))
+	}
+
+	builder.With(elastic.NewNestedQuery(nqf.path, query).
+		InnerHit(elastic.NewInnerHit().
+			Name(nqf.path).
+			Size(nqf.innerHitsSize).
+			Highlight(highlight)))
+}
@@ -0,0 +1,44 @@
+package featureset
+
+import (
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NestedQueryFilterFeature_Build(t *testing.T) {
+	table := []struct {
+		name     string
+		request  *reveald.Request
+		opts     []NestedQueryFilterOption
+		expected elastic.Query
+	}{
+		{"no query", reveald.NewRequest(), nil, elastic.NewBoolQuery()},
+		{"all nested fields", reveald.NewRequest(reveald.NewParameter("q", "sound")), nil,
+			elastic.NewBoolQuery().Must(elastic.NewNestedQuery("reviews",
+				elastic.NewQueryStringQuery("sound").Lenient(true).Field("reviews.*")).
+				InnerHit(elastic.NewInnerHit().Name("reviews").Size(defaultNestedInnerHitsSize).
+					Highlight(elastic.NewHighlight().Fields(elastic.NewHighlighterField("reviews.*")))))},
+		{"specified fields", reveald.NewRequest(reveald.NewParameter("search", "sound")),
+			[]NestedQueryFilterOption{WithNestedQueryParam("search"), WithNestedFields("reviews.text"), WithNestedInnerHitsSize(1)},
+			elastic.NewBoolQuery().Must(elastic.NewNestedQuery("reviews",
+				elastic.NewQueryStringQuery("sound").Lenient(true).Field("reviews.text")).
+				InnerHit(elastic.NewInnerHit().Name("reviews").Size(1).
+					Highlight(elastic.NewHighlight().Fields(elastic.NewHighlighterField("reviews.text")))))},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			qb := reveald.NewQueryBuilder(tt.request, "-")
+			NewNestedQueryFilterFeature("reviews", tt.opts...).build(qb)
+
+			expected, err := tt.expected.Source()
+			assert.NoError(t, err)
+			actual, err := qb.RawQuery().Source()
+			assert.NoError(t, err)
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package featureset
 
 import (
+	"fmt"
 	"strconv"
 
 	"github.com/reveald/reveald"
@@ -50,6 +51,21 @@ func NewPaginationFeature(opts ...PaginationOption) *PaginationFeature {
 	return pf
 }
 
+// Describe returns the offset and size parameters
+func (pf *PaginationFeature) Describe() []reveald.ParameterDescription {
+	return []reveald.ParameterDescription{{
+		Name:        "offset",
+		Kind:        reveald.ParameterValue,
+		Description: "Number of hits to skip",
+		Examples:    []string{strconv.Itoa(pf.pageSize)},
+	}, {
+		Name:        "size",
+		Kind:        reveald.ParameterValue,
+		Description: fmt.Sprintf("Number of hits per page, at most %d (default %d)", pf.maxPageSize, pf.pageSize),
+		Examples:    []string{strconv.Itoa(pf.pageSize)},
+	}}
+}
+
 func (pf *PaginationFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
 	pf.build(builder)
 
@@ -91,8 +107,9 @@ func (pf *PaginationFeature) handle(req *reveald.Request, result *reveald.Result
 	}
 
 	result.Pagination = &reveald.ResultPagination{
-		Offset:   offset,
-		PageSize: pageSize,
+		Offset:      offset,
+		PageSize:    pageSize,
+		OffsetParam: "offset",
 	}
 	return result, nil
 }
@@ -0,0 +1,107 @@
+package featureset
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+)
+
+type PercentilesFeature struct {
+	property string
+	percents []float64
+	ranks    []float64
+}
+
+type PercentilesOption func(*PercentilesFeature)
+
+// WithPercents defines the percentiles to calculate,
+// defaulting to those of Elasticsearch
+func WithPercents(percents ...float64) PercentilesOption {
+	return func(pf *PercentilesFeature) {
+		pf.percents = percents
+	}
+}
+
+// WithPercentileRanks calculates the percentage of values
+// below each of the specified values, instead of percentiles
+func WithPercentileRanks(values ...float64) PercentilesOption {
+	return func(pf *PercentilesFeature) {
+		pf.ranks = values
+	}
+}
+
+// NewPercentilesFeature returns the percentiles of a numeric
+// property, as buckets with the percent as Value and the
+// percentile as Metric, ordered by Value
+func NewPercentilesFeature(property string, opts ...PercentilesOption) *PercentilesFeature {
+	pf := &PercentilesFeature{
+		property: property,
+	}
+
+	for _, opt := range opts {
+		opt(pf)
+	}
+
+	return pf
+}
+
+func (pf *PercentilesFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	pf.build(builder)
+
+	r, err := next(builder)
+	if err != nil {
+		return nil, err
+	}
+
+	return pf.handle(r)
+}
+
+func (pf *PercentilesFeature) build(builder *reveald.QueryBuilder) {
+	if len(pf.ranks) > 0 {
+		builder.Aggregation(pf.property,
+			elastic.NewPercentileRanksAggregation().
+				Field(pf.property).
+				Values(pf.ranks...))
+		return
+	}
+
+	agg := elastic.NewPercentilesAggregation().Field(pf.property)
+	if len(pf.percents) > 0 {
+		agg = agg.Percentiles(pf.percents...)
+	}
+
+	builder.Aggregation(pf.property, agg)
+}
+
+func (pf *PercentilesFeature) handle(result *reveald.Result) (*reveald.Result, error) {
+	aggs := result.RawResult().Aggregations
+	agg, ok := aggs.Percentiles(pf.property)
+	if len(pf.ranks) > 0 {
+		agg, ok = aggs.PercentileRanks(pf.property)
+	}
+	if !ok {
+		return result, nil
+	}
+
+	var buckets []*reveald.ResultBucket
+	for key, value := range agg.Values {
+		k, err := strconv.ParseFloat(key, 64)
+		if err != nil {
+			continue
+		}
+
+		buckets = append(buckets, &reveald.ResultBucket{
+			Value:  k,
+			Metric: value,
+		})
+	}
+
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].Value.(float64) < buckets[j].Value.(float64)
+	})
+
+	result.Aggregations[pf.property] = buckets
+	return result, nil
+}
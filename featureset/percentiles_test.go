@@ -0,0 +1,36 @@
+package featureset
+
+import (
+	"testing"
+
+	"github.com/reveald/reveald"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PercentilesFeature_Build(t *testing.T) {
+	table := []struct {
+		name     string
+		opts     []PercentilesOption
+		expected map[string]interface{}
+	}{
+		{"default percents", nil, map[string]interface{}{
+			"percentiles": map[string]interface{}{"field": "latency"}}},
+		{"percents", []PercentilesOption{WithPercents(50, 95, 99)}, map[string]interface{}{
+			"percentiles": map[string]interface{}{"field": "latency", "percents": []float64{50, 95, 99}}}},
+		{"ranks", []PercentilesOption{WithPercentileRanks(100, 500)}, map[string]interface{}{
+			"percentile_ranks": map[string]interface{}{"field": "latency", "values": []float64{100, 500}}}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			qb := reveald.NewQueryBuilder(reveald.NewRequest(), "-")
+			NewPercentilesFeature("latency", tt.opts...).build(qb)
+
+			src, err := qb.Build().Source()
+			assert.NoError(t, err)
+
+			aggs := src.(map[string]interface{})["aggregations"].(map[string]interface{})
+			assert.Equal(t, tt.expected, aggs["latency"])
+		})
+	}
+}
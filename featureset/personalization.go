@@ -0,0 +1,123 @@
+package featureset
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+)
+
+const (
+	defaultPersonalizationBoost = 1.0
+	defaultMaxAffinities        = 10
+)
+
+// AffinityProvider returns the affinities of the current user,
+// keyed by property and value, e.g. {"brand": {"acme": 0.8}},
+// where scores are expected to be within 0-1
+type AffinityProvider func(ctx context.Context) map[string]map[string]float64
+
+type PersonalizationFeature struct {
+	provider      AffinityProvider
+	maxBoost      float64
+	maxAffinities int
+}
+
+type PersonalizationOption func(*PersonalizationFeature)
+
+// WithMaxAffinityBoost defines the boost of a value with
+// the highest possible affinity (1), defaulting to 1
+func WithMaxAffinityBoost(boost float64) PersonalizationOption {
+	return func(pf *PersonalizationFeature) {
+		pf.maxBoost = boost
+	}
+}
+
+// WithMaxAffinities limits the number of boosted values
+// to those with the highest affinity, defaulting to 10
+func WithMaxAffinities(count int) PersonalizationOption {
+	return func(pf *PersonalizationFeature) {
+		pf.maxAffinities = count
+	}
+}
+
+// NewPersonalizationFeature boosts documents matching the affinities
+// of the current user. Each affinity adds at most the max affinity
+// boost to the score of matching documents, so the total boost is
+// bounded by the number of affinities, and the boosts never
+// exclude documents or override explicit filters
+func NewPersonalizationFeature(provider AffinityProvider, opts ...PersonalizationOption) *PersonalizationFeature {
+	pf := &PersonalizationFeature{
+		provider:      provider,
+		maxBoost:      defaultPersonalizationBoost,
+		maxAffinities: defaultMaxAffinities,
+	}
+
+	for _, opt := range opts {
+		opt(pf)
+	}
+
+	return pf
+}
+
+func (pf *PersonalizationFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	pf.build(builder)
+	return next(builder)
+}
+
+type affinity struct {
+	property string
+	value    string
+	score    float64
+}
+
+// affinities returns the strongest positive affinities,
+// with scores clamped to 0-1
+func (pf *PersonalizationFeature) affinities(ctx context.Context) []affinity {
+	var affinities []affinity
+	for property, values := range pf.provider(ctx) {
+		for value, score := range values {
+			if score <= 0 || math.IsNaN(score) {
+				continue
+			}
+
+			affinities = append(affinities, affinity{property, value, math.Min(score, 1)})
+		}
+	}
+
+	sort.Slice(affinities, func(i, j int) bool {
+		a, b := affinities[i], affinities[j]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		if a.property != b.property {
+			return a.property < b.property
+		}
+		return a.value < b.value
+	})
+
+	if len(affinities) > pf.maxAffinities {
+		affinities = affinities[:pf.maxAffinities]
+	}
+
+	return affinities
+}
+
+func (pf *PersonalizationFeature) build(builder *reveald.QueryBuilder) {
+	affinities := pf.affinities(builder.Context())
+	if len(affinities) == 0 {
+		return
+	}
+
+	// the match_all filter keeps the boosts optional, even
+	// when the query has no other required clauses
+	bq := elastic.NewBoolQuery().Filter(elastic.NewMatchAllQuery())
+	for _, a := range affinities {
+		bq = bq.Should(elastic.NewConstantScoreQuery(elastic.NewTermQuery(a.property, a.value)).
+			Boost(a.score * pf.maxBoost))
+	}
+
+	builder.Boost(bq)
+}
@@ -0,0 +1,46 @@
+package featureset
+
+import (
+	"context"
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PersonalizationFeature_Build(t *testing.T) {
+	profile := map[string]map[string]float64{
+		"brand":    {"acme": 0.8, "globex": 3, "initech": -1},
+		"category": {"audio": 0.5, "video": 0.1},
+	}
+
+	table := []struct {
+		name     string
+		profile  map[string]map[string]float64
+		opts     []PersonalizationOption
+		expected elastic.Query
+	}{
+		{"no profile", nil, nil, elastic.NewBoolQuery()},
+		{"capped and limited", profile, []PersonalizationOption{WithMaxAffinityBoost(2), WithMaxAffinities(3)},
+			elastic.NewBoolQuery().Should(elastic.NewBoolQuery().
+				Filter(elastic.NewMatchAllQuery()).
+				Should(
+					elastic.NewConstantScoreQuery(elastic.NewTermQuery("brand", "globex")).Boost(2),
+					elastic.NewConstantScoreQuery(elastic.NewTermQuery("brand", "acme")).Boost(1.6),
+					elastic.NewConstantScoreQuery(elastic.NewTermQuery("category", "audio")).Boost(1)))},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := func(context.Context) map[string]map[string]float64 {
+				return tt.profile
+			}
+
+			qb := reveald.NewQueryBuilder(reveald.NewRequest(), "-")
+			NewPersonalizationFeature(provider, tt.opts...).build(qb)
+
+			assert.Equal(t, tt.expected, qb.RawQuery())
+		})
+	}
+}
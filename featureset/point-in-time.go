@@ -0,0 +1,204 @@
+package featureset
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/reveald/reveald"
+)
+
+const defaultPointInTimeKeepAlive = "1m"
+
+// ErrUnknownPointInTime is returned for requested point in time
+// ids which weren't issued for the indices being searched
+var ErrUnknownPointInTime = errors.New("point in time wasn't issued for the searched indices")
+
+// PointInTimeManager opens and closes point in time
+// snapshots, e.g. the reveald.ElasticBackend
+type PointInTimeManager interface {
+	OpenPointInTime(ctx context.Context, keepAlive string, indices ...string) (string, error)
+	ClosePointInTime(ctx context.Context, id string) error
+}
+
+type PointInTimeFeature struct {
+	manager   PointInTimeManager
+	param     string
+	keepAlive string
+	autoClose bool
+	key       string
+}
+
+type PointInTimeOption func(*PointInTimeFeature)
+
+func WithPointInTimeParam(param string) PointInTimeOption {
+	return func(pitf *PointInTimeFeature) {
+		pitf.param = param
+	}
+}
+
+func WithKeepAlive(keepAlive string) PointInTimeOption {
+	return func(pitf *PointInTimeFeature) {
+		pitf.keepAlive = keepAlive
+	}
+}
+
+// WithPointInTimeAutoClose closes the point in time once
+// a page holds fewer hits than the requested page size
+func WithPointInTimeAutoClose() PointInTimeOption {
+	return func(pitf *PointInTimeFeature) {
+		pitf.autoClose = true
+	}
+}
+
+// WithPointInTimeSigningKey keys the HMAC signing the returned
+// point in time ids to the indices they were opened on. Every
+// replica of a service must share the same key
+func WithPointInTimeSigningKey(key string) PointInTimeOption {
+	return func(pitf *PointInTimeFeature) {
+		pitf.key = key
+	}
+}
+
+// NewPointInTimeFeature searches a consistent snapshot of the
+// endpoint indices. The first request opens a point in time, and
+// subsequent requests pass the returned Result.PointInTimeID back
+// using the pit parameter, which refreshes its keep-alive. The point
+// in time is opened on the indices the endpoint searches once its
+// tenant is enforced, and the returned id is signed for those
+// indices, so ids issued for other indices are rejected. A signing
+// key is required, see WithPointInTimeSigningKey
+func NewPointInTimeFeature(manager PointInTimeManager, opts ...PointInTimeOption) *PointInTimeFeature {
+	pitf := &PointInTimeFeature{
+		manager:   manager,
+		param:     "pit",
+		keepAlive: defaultPointInTimeKeepAlive,
+	}
+
+	for _, opt := range opts {
+		opt(pitf)
+	}
+
+	return pitf
+}
+
+// Validate rejects features without a signing key, since
+// unsigned ids could be replayed against any indices
+func (pitf *PointInTimeFeature) Validate() error {
+	if pitf.key == "" {
+		return errors.New("point in time ids require a signing key")
+	}
+
+	return nil
+}
+
+func (pitf *PointInTimeFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	opened := pitf.build(builder)
+
+	r, err := next(builder)
+	if err != nil {
+		if *opened != "" {
+			_ = pitf.manager.ClosePointInTime(builder.Context(), *opened)
+		}
+		return nil, err
+	}
+
+	return pitf.handle(builder, r)
+}
+
+// build defers the point in time to the indices the endpoint
+// finally searches, returning the id of the point in time
+// opened for the search, if any
+func (pitf *PointInTimeFeature) build(builder *reveald.QueryBuilder) *string {
+	token := ""
+	if p, err := builder.Request().Get(pitf.param); err == nil {
+		token = p.Value()
+	}
+
+	opened := new(string)
+	builder.WithPointInTimeResolver(pitf.keepAlive, func(ctx context.Context, indices []string) (string, error) {
+		if token != "" {
+			id, ok := pitf.verify(token, indices)
+			if !ok {
+				return "", fmt.Errorf("point in time %q: %w", token, ErrUnknownPointInTime)
+			}
+			return id, nil
+		}
+
+		id, err := pitf.manager.OpenPointInTime(ctx, pitf.keepAlive, indices...)
+		if err != nil {
+			return "", fmt.Errorf("failed to open point in time: %w", err)
+		}
+
+		*opened = id
+		return id, nil
+	})
+
+	return opened
+}
+
+func (pitf *PointInTimeFeature) handle(builder *reveald.QueryBuilder, result *reveald.Result) (*reveald.Result, error) {
+	if builder.PointInTime() == nil {
+		return result, nil
+	}
+
+	if result.PointInTimeID == "" {
+		result.PointInTimeID = builder.PointInTime().Id
+	}
+
+	if !pitf.autoClose || len(result.Hits) >= builder.Selection().PageSize() {
+		result.PointInTimeID = pitf.sign(result.PointInTimeID, builder.Indices())
+		return result, nil
+	}
+
+	if err := pitf.manager.ClosePointInTime(builder.Context(), result.PointInTimeID); err != nil {
+		return nil, fmt.Errorf("failed to close point in time: %w", err)
+	}
+
+	result.PointInTimeID = ""
+	return result, nil
+}
+
+// sign binds a point in time id to a set of indices,
+// regardless of their order, as <id>.<signature>
+func (pitf *PointInTimeFeature) sign(id string, indices []string) string {
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(id))
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(pitf.mac(encoded, indices))
+}
+
+// verify returns the point in time id of a token
+// signed for the same set of indices
+func (pitf *PointInTimeFeature) verify(token string, indices []string) (string, bool) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", false
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, pitf.mac(encoded, indices)) {
+		return "", false
+	}
+
+	id, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", false
+	}
+
+	return string(id), true
+}
+
+func (pitf *PointInTimeFeature) mac(encoded string, indices []string) []byte {
+	sorted := append([]string(nil), indices...)
+	sort.Strings(sorted)
+
+	mac := hmac.New(sha256.New, []byte(pitf.key))
+	mac.Write([]byte(strings.Join(sorted, ",")))
+	mac.Write([]byte{0})
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,137 @@
+package featureset
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/reveald/reveald"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePointInTimeManager struct {
+	opened []string
+	closed []string
+}
+
+func (m *fakePointInTimeManager) OpenPointInTime(_ context.Context, _ string, indices ...string) (string, error) {
+	m.opened = append(m.opened, indices...)
+	return "new-pit", nil
+}
+
+func (m *fakePointInTimeManager) ClosePointInTime(_ context.Context, id string) error {
+	m.closed = append(m.closed, id)
+	return nil
+}
+
+// retargetFeature moves the search to other indices after
+// the point in time feature, like a tenant resolver would
+type retargetFeature struct{ indices []string }
+
+func (f retargetFeature) Process(qb *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	qb.SetIndices(f.indices...)
+	return next(qb)
+}
+
+const testPointInTimeKey = "secret"
+
+func Test_PointInTimeFeature_Requires_Key(t *testing.T) {
+	e := reveald.NewEndpoint(&fixedBackend{}, reveald.WithIndices("idx"))
+	assert.Error(t, e.Register(NewPointInTimeFeature(&fakePointInTimeManager{})))
+}
+
+func Test_PointInTimeFeature_Opens_When_Missing(t *testing.T) {
+	m := &fakePointInTimeManager{}
+	b := &fixedBackend{}
+	pitf := NewPointInTimeFeature(m, WithPointInTimeSigningKey(testPointInTimeKey))
+	e := reveald.NewEndpoint(b, reveald.WithIndices("idx"))
+	assert.NoError(t, e.Register(pitf, retargetFeature{[]string{"idx-acme"}}))
+
+	r, err := e.Execute(context.Background(), reveald.NewRequest())
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"idx-acme"}, m.opened)
+	assert.Len(t, b.builders, 1)
+	assert.Equal(t, "new-pit", b.builders[0].PointInTime().Id)
+	assert.Equal(t, defaultPointInTimeKeepAlive, b.builders[0].PointInTime().KeepAlive)
+	assert.Equal(t, pitf.sign("new-pit", []string{"idx-acme"}), r.PointInTimeID)
+}
+
+func Test_PointInTimeFeature_Reuses_Issued(t *testing.T) {
+	m := &fakePointInTimeManager{}
+	b := &fixedBackend{}
+	e := reveald.NewEndpoint(b, reveald.WithIndices("idx"))
+	assert.NoError(t, e.Register(NewPointInTimeFeature(m, WithKeepAlive("5m"), WithPointInTimeSigningKey(testPointInTimeKey))))
+
+	r, err := e.Execute(context.Background(), reveald.NewRequest())
+	assert.NoError(t, err)
+
+	// another replica sharing the key accepts the id
+	other := reveald.NewEndpoint(b, reveald.WithIndices("idx"))
+	assert.NoError(t, other.Register(NewPointInTimeFeature(m, WithKeepAlive("5m"), WithPointInTimeSigningKey(testPointInTimeKey))))
+
+	_, err = other.Execute(context.Background(), reveald.NewRequest(reveald.NewParameter("pit", r.PointInTimeID)))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"idx"}, m.opened)
+	assert.Len(t, b.builders, 2)
+	assert.Equal(t, "new-pit", b.builders[1].PointInTime().Id)
+	assert.Equal(t, "5m", b.builders[1].PointInTime().KeepAlive)
+}
+
+func Test_PointInTimeFeature_Rejects_Unknown(t *testing.T) {
+	signed := func(key string, indices ...string) string {
+		return NewPointInTimeFeature(nil, WithPointInTimeSigningKey(key)).sign("old-pit", indices)
+	}
+
+	table := []struct {
+		name  string
+		token string
+	}{
+		{"unsigned", "old-pit"},
+		{"malformed signature", "b2xkLXBpdA.!!"},
+		{"signed for other indices", signed(testPointInTimeKey, "other-idx")},
+		{"signed with other key", signed("other", "idx")},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &fakePointInTimeManager{}
+			b := &fixedBackend{}
+			e := reveald.NewEndpoint(b, reveald.WithIndices("idx"))
+			assert.NoError(t, e.Register(NewPointInTimeFeature(m, WithPointInTimeSigningKey(testPointInTimeKey))))
+
+			_, err := e.Execute(context.Background(), reveald.NewRequest(reveald.NewParameter("pit", tt.token)))
+			assert.ErrorIs(t, err, ErrUnknownPointInTime)
+			assert.Empty(t, b.builders)
+			assert.Empty(t, m.opened)
+		})
+	}
+}
+
+func Test_PointInTimeFeature_Closes_On_Failure(t *testing.T) {
+	m := &fakePointInTimeManager{}
+	b := &fixedBackend{err: errors.New("search failed")}
+	e := reveald.NewEndpoint(b, reveald.WithIndices("idx"))
+	assert.NoError(t, e.Register(NewPointInTimeFeature(m, WithPointInTimeSigningKey(testPointInTimeKey))))
+
+	_, err := e.Execute(context.Background(), reveald.NewRequest())
+	assert.Error(t, err)
+
+	assert.Equal(t, []string{"idx"}, m.opened)
+	assert.Equal(t, []string{"new-pit"}, m.closed)
+}
+
+func Test_PointInTimeFeature_AutoClose(t *testing.T) {
+	m := &fakePointInTimeManager{}
+	b := &fixedBackend{result: &reveald.Result{PointInTimeID: "refreshed-pit"}}
+	pitf := NewPointInTimeFeature(m, WithPointInTimeAutoClose(), WithPointInTimeSigningKey(testPointInTimeKey))
+	e := reveald.NewEndpoint(b, reveald.WithIndices("idx"))
+	assert.NoError(t, e.Register(pitf))
+
+	r, err := e.Execute(context.Background(), reveald.NewRequest(reveald.NewParameter("pit", pitf.sign("old-pit", []string{"idx"}))))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"refreshed-pit"}, m.closed)
+	assert.Empty(t, r.PointInTimeID)
+}
@@ -0,0 +1,124 @@
+package featureset
+
+import (
+	"context"
+	"strings"
+)
+
+// QueryExpander rewrites the query text of a search, e.g.
+// expanding synonyms, removing stopwords or normalizing
+// locale specific spellings
+type QueryExpander interface {
+	Expand(ctx context.Context, text string) (string, error)
+}
+
+// QueryExpanderFunc adapts a function to a QueryExpander
+type QueryExpanderFunc func(ctx context.Context, text string) (string, error)
+
+// Expand returns the text returned by the function
+func (fn QueryExpanderFunc) Expand(ctx context.Context, text string) (string, error) {
+	return fn(ctx, text)
+}
+
+// SynonymExpander is a QueryExpander expanding the terms of
+// a query with their synonyms from a static map, and removing
+// stopwords. Terms within quoted phrases are left as they are
+type SynonymExpander struct {
+	synonyms  map[string][]string
+	stopwords map[string]bool
+	simple    bool
+}
+
+type SynonymExpanderOption func(*SynonymExpander)
+
+// WithStopwords removes terms from the query, unless
+// every term of the query is a stopword
+func WithStopwords(words ...string) SynonymExpanderOption {
+	return func(se *SynonymExpander) {
+		for _, word := range words {
+			se.stopwords[strings.ToLower(word)] = true
+		}
+	}
+}
+
+// WithSimpleSyntaxExpansion writes expanded terms with the simple
+// query syntax, for a QueryFilterFeature using WithSimpleQuerySyntax
+// with the or and precedence operators enabled
+func WithSimpleSyntaxExpansion() SynonymExpanderOption {
+	return func(se *SynonymExpander) {
+		se.simple = true
+	}
+}
+
+// NewSynonymExpander returns an expander matching a term with any
+// of its synonyms, where terms are matched case insensitively.
+// Synonyms are one way, so equivalent terms are listed for each
+// other, and synonyms of several words are matched as phrases
+func NewSynonymExpander(synonyms map[string][]string, opts ...SynonymExpanderOption) *SynonymExpander {
+	se := &SynonymExpander{
+		synonyms:  make(map[string][]string, len(synonyms)),
+		stopwords: make(map[string]bool),
+	}
+
+	for term, alternatives := range synonyms {
+		term = strings.ToLower(term)
+		se.synonyms[term] = append(se.synonyms[term], alternatives...)
+	}
+
+	for _, opt := range opts {
+		opt(se)
+	}
+
+	return se
+}
+
+// Expand returns the text with stopwords removed,
+// and terms replaced by groups of their synonyms
+func (se *SynonymExpander) Expand(_ context.Context, text string) (string, error) {
+	terms := strings.Fields(text)
+
+	kept := make([]string, 0, len(terms))
+	quoted := false
+	for _, term := range terms {
+		phrase := quoted || strings.HasPrefix(term, "\"")
+		if strings.Count(term, "\"")%2 == 1 {
+			quoted = !quoted
+		}
+
+		if phrase || !se.stopwords[strings.ToLower(term)] {
+			kept = append(kept, se.expand(term, phrase))
+		}
+	}
+
+	if len(kept) == 0 {
+		return text, nil
+	}
+
+	return strings.Join(kept, " "), nil
+}
+
+func (se *SynonymExpander) expand(term string, phrase bool) string {
+	if phrase {
+		return term
+	}
+
+	synonyms, ok := se.synonyms[strings.ToLower(term)]
+	if !ok || len(synonyms) == 0 {
+		return term
+	}
+
+	group := []string{term}
+	for _, synonym := range synonyms {
+		if strings.Contains(synonym, " ") {
+			synonym = "\"" + synonym + "\""
+		}
+		group = append(group, synonym)
+	}
+
+	separator := " OR "
+	if se.simple {
+		separator = " | "
+	}
+
+	return "(" + strings.Join(group, separator) + ")"
+}
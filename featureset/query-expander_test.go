@@ -0,0 +1,73 @@
+package featureset
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SynonymExpander_Expand(t *testing.T) {
+	synonyms := map[string][]string{
+		"TV":    {"television"},
+		"couch": {"sofa", "love seat"},
+	}
+
+	table := []struct {
+		name     string
+		opts     []SynonymExpanderOption
+		text     string
+		expected string
+	}{
+		{"unchanged", nil, "red shoes", "red shoes"},
+		{"synonyms", nil, "tv couch", "(tv OR television) (couch OR sofa OR \"love seat\")"},
+		{"simple syntax", []SynonymExpanderOption{WithSimpleSyntaxExpansion()}, "big TV", "big (TV | television)"},
+		{"stopwords", []SynonymExpanderOption{WithStopwords("the", "a")}, "The couch", "(couch OR sofa OR \"love seat\")"},
+		{"only stopwords", []SynonymExpanderOption{WithStopwords("the", "who")}, "the who", "the who"},
+		{"phrases", []SynonymExpanderOption{WithStopwords("the")}, "\"the tv show\" tv", "\"the tv show\" (tv OR television)"},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			se := NewSynonymExpander(synonyms, tt.opts...)
+
+			text, err := se.Expand(context.Background(), tt.text)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, text)
+		})
+	}
+}
+
+func Test_QueryFilterFeature_WithQueryExpander(t *testing.T) {
+	suffix := QueryExpanderFunc(func(_ context.Context, text string) (string, error) {
+		return text + " shoe", nil
+	})
+
+	qff := NewQueryFilterFeature(WithQueryExpander(
+		NewSynonymExpander(map[string][]string{"sneakers": {"trainers"}}), suffix))
+	qb := reveald.NewQueryBuilder(reveald.NewRequest(reveald.NewParameter("q", "sneakers")), "products")
+
+	_, err := qff.Process(qb, func(*reveald.QueryBuilder) (*reveald.Result, error) {
+		return nil, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, elastic.NewBoolQuery().Must(
+		elastic.NewQueryStringQuery("(sneakers OR trainers) shoe").Lenient(true)), qb.RawQuery())
+}
+
+func Test_QueryFilterFeature_WithQueryExpander_Error(t *testing.T) {
+	failing := QueryExpanderFunc(func(context.Context, string) (string, error) {
+		return "", errors.New("unavailable")
+	})
+
+	qff := NewQueryFilterFeature(WithQueryExpander(failing))
+	qb := reveald.NewQueryBuilder(reveald.NewRequest(reveald.NewParameter("q", "sneakers")), "products")
+
+	_, err := qff.Process(qb, func(*reveald.QueryBuilder) (*reveald.Result, error) {
+		return nil, nil
+	})
+	assert.Error(t, err)
+}
@@ -1,13 +1,92 @@
 package featureset
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/olivere/elastic/v7"
 	"github.com/reveald/reveald"
 )
 
+// Operator combines the terms of a query
+type Operator string
+
+const (
+	// OperatorOr matches documents matching any term
+	OperatorOr Operator = "or"
+	// OperatorAnd matches documents matching every term
+	OperatorAnd Operator = "and"
+)
+
+// MatchType sets how a query matching several fields is scored
+type MatchType string
+
+const (
+	// MatchBestFields scores documents by their best matching field
+	MatchBestFields MatchType = "best_fields"
+	// MatchMostFields sums the scores of every matching field
+	MatchMostFields MatchType = "most_fields"
+	// MatchCrossFields matches the terms across the fields,
+	// as if they were a single field
+	MatchCrossFields MatchType = "cross_fields"
+	// MatchPhrase matches the query text as a phrase
+	MatchPhrase MatchType = "phrase"
+	// MatchPhrasePrefix matches the query text as a phrase, with
+	// the last term as a prefix, e.g. for instant search
+	MatchPhrasePrefix MatchType = "phrase_prefix"
+)
+
+// SyntaxFlag enables an operator of the simple query syntax
+type SyntaxFlag string
+
+const (
+	// SyntaxAll enables every operator
+	SyntaxAll SyntaxFlag = "ALL"
+	// SyntaxNone disables every operator
+	SyntaxNone SyntaxFlag = "NONE"
+	// SyntaxAnd enables + for AND
+	SyntaxAnd SyntaxFlag = "AND"
+	// SyntaxOr enables | for OR
+	SyntaxOr SyntaxFlag = "OR"
+	// SyntaxNot enables - for NOT
+	SyntaxNot SyntaxFlag = "NOT"
+	// SyntaxPhrase enables " for phrases
+	SyntaxPhrase SyntaxFlag = "PHRASE"
+	// SyntaxPrefix enables * for prefixes
+	SyntaxPrefix SyntaxFlag = "PREFIX"
+	// SyntaxPrecedence enables ( and ) for precedence
+	SyntaxPrecedence SyntaxFlag = "PRECEDENCE"
+	// SyntaxEscape enables \ for escaping
+	SyntaxEscape SyntaxFlag = "ESCAPE"
+	// SyntaxFuzzy enables ~N after a term for fuzziness
+	SyntaxFuzzy SyntaxFlag = "FUZZY"
+	// SyntaxSlop enables ~N after a phrase for slop
+	SyntaxSlop SyntaxFlag = "SLOP"
+	// SyntaxWhitespace enables whitespace as a split character
+	SyntaxWhitespace SyntaxFlag = "WHITESPACE"
+)
+
+type fieldBoost struct {
+	field string
+	boost float64
+}
+
+// QueryFilterFeature matches documents on the full-text query
+// parameter. The query is added as a filter with With, so with a
+// kNN search on the endpoint it also filters the kNN candidates,
+// use a HybridSearchFeature to blend lexical and kNN scores
 type QueryFilterFeature struct {
-	name   string
-	fields []string
+	name               string
+	fields             []string
+	analyzer           string
+	operator           Operator
+	fuzziness          string
+	minimumShouldMatch string
+	matchType          MatchType
+	boosts             []fieldBoost
+	simple             bool
+	syntax             []SyntaxFlag
+	expanders          []QueryExpander
 }
 
 type QueryFilterOption func(*QueryFilterFeature)
@@ -24,6 +103,86 @@ func WithFields(fields ...string) QueryFilterOption {
 	}
 }
 
+// WithAnalyzer analyzes the query text with a named
+// analyzer, instead of the search analyzers of the fields
+func WithAnalyzer(analyzer string) QueryFilterOption {
+	return func(qff *QueryFilterFeature) {
+		qff.analyzer = analyzer
+	}
+}
+
+// WithOperator sets how the terms of the query text are
+// combined, matching any of them by default
+func WithOperator(operator Operator) QueryFilterOption {
+	return func(qff *QueryFilterFeature) {
+		qff.operator = operator
+	}
+}
+
+// WithFuzziness matches terms within an edit distance, such
+// as "AUTO" or "1", tolerating misspelled query text. Since the
+// query syntax only applies fuzziness to terms suffixed with ~,
+// the query text is matched as is, without the query syntax,
+// using a multi_match query. Fuzziness doesn't apply to the
+// phrase and cross fields match types
+func WithFuzziness(fuzziness string) QueryFilterOption {
+	return func(qff *QueryFilterFeature) {
+		qff.fuzziness = fuzziness
+	}
+}
+
+// WithMinimumShouldMatch requires a number of the terms
+// to match, such as "2" or "2<75%", when combined with or
+func WithMinimumShouldMatch(minimum string) QueryFilterOption {
+	return func(qff *QueryFilterFeature) {
+		qff.minimumShouldMatch = minimum
+	}
+}
+
+// WithMatchPhrase matches the query text as a phrase,
+// requiring its terms in order and next to each other
+func WithMatchPhrase() QueryFilterOption {
+	return WithMatchType(MatchPhrase)
+}
+
+// WithMatchType sets how the fields matching the query text are
+// scored, or matched, scoring by the best matching field by default
+func WithMatchType(matchType MatchType) QueryFilterOption {
+	return func(qff *QueryFilterFeature) {
+		qff.matchType = matchType
+	}
+}
+
+// WithFieldBoost boosts the score of matches in a field,
+// e.g. to let title matches outrank description matches.
+// The field is searched, if not among the fields already
+func WithFieldBoost(field string, boost float64) QueryFilterOption {
+	return func(qff *QueryFilterFeature) {
+		qff.boosts = append(qff.boosts, fieldBoost{field, boost})
+	}
+}
+
+// WithSimpleQuerySyntax parses the query text with the simple
+// query syntax, limited to the operators enabled by flags, or
+// every operator if none. Unlike the default query syntax, it
+// neither fails on invalid syntax nor lets users query other
+// fields, so it's safe for power users. Fuzziness and match
+// types don't apply, but are expressed with the syntax
+func WithSimpleQuerySyntax(flags ...SyntaxFlag) QueryFilterOption {
+	return func(qff *QueryFilterFeature) {
+		qff.simple = true
+		qff.syntax = flags
+	}
+}
+
+// WithQueryExpander rewrites the query text with expanders,
+// in order, before the query is built
+func WithQueryExpander(expanders ...QueryExpander) QueryFilterOption {
+	return func(qff *QueryFilterFeature) {
+		qff.expanders = append(qff.expanders, expanders...)
+	}
+}
+
 func NewQueryFilterFeature(opts ...QueryFilterOption) *QueryFilterFeature {
 	qff := &QueryFilterFeature{
 		name:   "q",
@@ -37,6 +196,16 @@ func NewQueryFilterFeature(opts ...QueryFilterOption) *QueryFilterFeature {
 	return qff
 }
 
+// Describe returns the free text query parameter
+func (qff *QueryFilterFeature) Describe() []reveald.ParameterDescription {
+	return []reveald.ParameterDescription{{
+		Name:        qff.name,
+		Kind:        reveald.ParameterValue,
+		Description: "Free text query",
+		Examples:    []string{"red shoes"},
+	}}
+}
+
 func (qff *QueryFilterFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
 	if !builder.Request().Has(qff.name) {
 		return next(builder)
@@ -47,6 +216,136 @@ func (qff *QueryFilterFeature) Process(builder *reveald.QueryBuilder, next revea
 		return next(builder)
 	}
 
-	builder.With(elastic.NewQueryStringQuery(v.Value()).Lenient(true))
+	text := v.Value()
+	for _, expander := range qff.expanders {
+		text, err = expander.Expand(builder.Context(), text)
+		if err != nil {
+			return nil, fmt.Errorf("failed expanding query: %w", err)
+		}
+	}
+	if strings.TrimSpace(text) == "" {
+		return next(builder)
+	}
+
+	builder.With(qff.query(text))
 	return next(builder)
 }
+
+func (qff *QueryFilterFeature) query(text string) elastic.Query {
+	if qff.simple {
+		return qff.simpleQuery(text)
+	}
+
+	if qff.fuzzy() {
+		return qff.fuzzyQuery(text)
+	}
+
+	query := elastic.NewQueryStringQuery(text).Lenient(true)
+	qff.eachField(func(field string, boost float64, boosted bool) {
+		if boosted {
+			query = query.FieldWithBoost(field, boost)
+			return
+		}
+		query = query.Field(field)
+	})
+	if qff.analyzer != "" {
+		query = query.Analyzer(qff.analyzer)
+	}
+	if qff.operator != "" {
+		query = query.DefaultOperator(string(qff.operator))
+	}
+	if qff.minimumShouldMatch != "" {
+		query = query.MinimumShouldMatch(qff.minimumShouldMatch)
+	}
+	if qff.matchType != "" {
+		query = query.Type(string(qff.matchType))
+	}
+
+	return query
+}
+
+// fuzzy returns whether fuzziness applies to the match type
+func (qff *QueryFilterFeature) fuzzy() bool {
+	switch qff.matchType {
+	case MatchPhrase, MatchPhrasePrefix, MatchCrossFields:
+		return false
+	}
+
+	return qff.fuzziness != ""
+}
+
+func (qff *QueryFilterFeature) fuzzyQuery(text string) elastic.Query {
+	query := elastic.NewMultiMatchQuery(text).Lenient(true).Fuzziness(qff.fuzziness)
+	qff.eachField(func(field string, boost float64, boosted bool) {
+		if boosted {
+			query = query.FieldWithBoost(field, boost)
+			return
+		}
+		query = query.Field(field)
+	})
+	if qff.analyzer != "" {
+		query = query.Analyzer(qff.analyzer)
+	}
+	if qff.operator != "" {
+		query = query.Operator(string(qff.operator))
+	}
+	if qff.minimumShouldMatch != "" {
+		query = query.MinimumShouldMatch(qff.minimumShouldMatch)
+	}
+	if qff.matchType != "" {
+		query = query.Type(string(qff.matchType))
+	}
+
+	return query
+}
+
+func (qff *QueryFilterFeature) simpleQuery(text string) elastic.Query {
+	query := elastic.NewSimpleQueryStringQuery(text).Lenient(true)
+	qff.eachField(func(field string, boost float64, boosted bool) {
+		if boosted {
+			query = query.FieldWithBoost(field, boost)
+			return
+		}
+		query = query.Field(field)
+	})
+	if len(qff.syntax) > 0 {
+		flags := make([]string, 0, len(qff.syntax))
+		for _, flag := range qff.syntax {
+			flags = append(flags, string(flag))
+		}
+		query = query.Flags(strings.Join(flags, "|"))
+	}
+	if qff.analyzer != "" {
+		query = query.Analyzer(qff.analyzer)
+	}
+	if qff.operator != "" {
+		query = query.DefaultOperator(string(qff.operator))
+	}
+	if qff.minimumShouldMatch != "" {
+		query = query.MinimumShouldMatch(qff.minimumShouldMatch)
+	}
+
+	return query
+}
+
+// eachField calls fn with every field searched, and its boost
+// if boosted, starting with the fields set by WithFields
+func (qff *QueryFilterFeature) eachField(fn func(field string, boost float64, boosted bool)) {
+	boosts := make(map[string]float64, len(qff.boosts))
+	for _, fb := range qff.boosts {
+		boosts[fb.field] = fb.boost
+	}
+
+	searched := make(map[string]bool, len(qff.fields))
+	for _, field := range qff.fields {
+		searched[field] = true
+		boost, ok := boosts[field]
+		fn(field, boost, ok)
+	}
+	for _, fb := range qff.boosts {
+		if !searched[fb.field] {
+			searched[fb.field] = true
+			fn(fb.field, boosts[fb.field], true)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package featureset
+
+import (
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_QueryFilterFeature_Process(t *testing.T) {
+	table := []struct {
+		name  string
+		opts  []QueryFilterOption
+		query elastic.Query
+	}{
+		{"default", nil,
+			elastic.NewQueryStringQuery("red shoes").Lenient(true)},
+		{"operator", []QueryFilterOption{WithOperator(OperatorAnd)},
+			elastic.NewQueryStringQuery("red shoes").Lenient(true).DefaultOperator("and")},
+		{"fuzziness", []QueryFilterOption{WithFuzziness("AUTO")},
+			elastic.NewMultiMatchQuery("red shoes").Lenient(true).Fuzziness("AUTO")},
+		{"fuzziness with fields", []QueryFilterOption{WithFields("name"), WithFieldBoost("brand", 2), WithOperator(OperatorAnd), WithFuzziness("1"), WithMatchType(MatchMostFields)},
+			elastic.NewMultiMatchQuery("red shoes").Lenient(true).Fuzziness("1").Field("name").FieldWithBoost("brand", 2).Operator("and").Type("most_fields")},
+		{"fuzziness with phrase", []QueryFilterOption{WithFields("name"), WithFuzziness("AUTO"), WithMatchPhrase()},
+			elastic.NewQueryStringQuery("red shoes").Lenient(true).Field("name").Type("phrase")},
+		{"minimum should match", []QueryFilterOption{WithMinimumShouldMatch("2<75%")},
+			elastic.NewQueryStringQuery("red shoes").Lenient(true).MinimumShouldMatch("2<75%")},
+		{"match phrase", []QueryFilterOption{WithFields("name"), WithMatchPhrase()},
+			elastic.NewQueryStringQuery("red shoes").Lenient(true).Field("name").Type("phrase")},
+		{"match type", []QueryFilterOption{WithFields("name", "brand"), WithMatchType(MatchCrossFields)},
+			elastic.NewQueryStringQuery("red shoes").Lenient(true).Field("name").Field("brand").Type("cross_fields")},
+		{"field boosts", []QueryFilterOption{WithFields("title", "body"), WithFieldBoost("title", 3), WithFieldBoost("tags", 1.5)},
+			elastic.NewQueryStringQuery("red shoes").Lenient(true).FieldWithBoost("title", 3).Field("body").FieldWithBoost("tags", 1.5)},
+		{"simple query syntax", []QueryFilterOption{WithFields("title"), WithFieldBoost("title", 2), WithOperator(OperatorAnd), WithSimpleQuerySyntax()},
+			elastic.NewSimpleQueryStringQuery("red shoes").Lenient(true).FieldWithBoost("title", 2).DefaultOperator("and")},
+		{"simple query syntax flags", []QueryFilterOption{WithFuzziness("AUTO"), WithSimpleQuerySyntax(SyntaxAnd, SyntaxOr, SyntaxNot, SyntaxPhrase, SyntaxPrefix)},
+			elastic.NewSimpleQueryStringQuery("red shoes").Lenient(true).Flags("AND|OR|NOT|PHRASE|PREFIX")},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			qff := NewQueryFilterFeature(tt.opts...)
+			qb := reveald.NewQueryBuilder(reveald.NewRequest(reveald.NewParameter("q", "red shoes")), "products")
+
+			_, err := qff.Process(qb, func(*reveald.QueryBuilder) (*reveald.Result, error) {
+				return nil, nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, elastic.NewBoolQuery().Must(tt.query), qb.RawQuery())
+		})
+	}
+}
+
+func Test_QueryFilterFeature_Fuzziness_Source(t *testing.T) {
+	qff := NewQueryFilterFeature(WithFields("name"), WithFuzziness("AUTO"))
+	qb := reveald.NewQueryBuilder(reveald.NewRequest(reveald.NewParameter("q", "rde shoes")), "products")
+
+	_, err := qff.Process(qb, func(*reveald.QueryBuilder) (*reveald.Result, error) {
+		return nil, nil
+	})
+	assert.NoError(t, err)
+
+	query := sourceJSON(t, qb)["query"].(map[string]interface{})["bool"].(map[string]interface{})["must"]
+	assert.Equal(t, map[string]interface{}{
+		"multi_match": map[string]interface{}{
+			"query":     "rde shoes",
+			"fields":    []interface{}{"name"},
+			"fuzziness": "AUTO",
+			"lenient":   true,
+		},
+	}, query)
+}
+
+func Test_QueryFilterFeature_Process_Without_Query(t *testing.T) {
+	qff := NewQueryFilterFeature(WithOperator(OperatorAnd))
+	qb := reveald.NewQueryBuilder(reveald.NewRequest(), "products")
+
+	_, err := qff.Process(qb, func(*reveald.QueryBuilder) (*reveald.Result, error) {
+		return nil, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, elastic.NewBoolQuery(), qb.RawQuery())
+}
@@ -0,0 +1,127 @@
+package featureset
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"strconv"
+	"time"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+)
+
+// RandomSortFeature shuffles the hits with a random_score
+// function, e.g. to rotate the products of category pages
+// fairly. The shuffle is seeded by a seed parameter, or else
+// the session (see reveald.ContextWithSession), so that pages
+// of a session are consistent. Searches without a seed are
+// shuffled differently every time. The random score multiplies
+// the query score, and only applies when sorting by score
+type RandomSortFeature struct {
+	param        string
+	sessionParam string
+	field        string
+	rotation     time.Duration
+	now          func() time.Time
+}
+
+type RandomSortOption func(*RandomSortFeature)
+
+// WithSeedParam sets the parameter holding
+// the seed, defaulting to seed
+func WithSeedParam(name string) RandomSortOption {
+	return func(rsf *RandomSortFeature) {
+		rsf.param = name
+	}
+}
+
+// WithSeedSessionParam defines a request parameter to read the
+// session id from, when the context doesn't carry one
+func WithSeedSessionParam(name string) RandomSortOption {
+	return func(rsf *RandomSortFeature) {
+		rsf.sessionParam = name
+	}
+}
+
+// WithSeedField sets the field random scores are derived
+// from, along with the seed, defaulting to _seq_no. Documents
+// sharing a value for the field share their score
+func WithSeedField(field string) RandomSortOption {
+	return func(rsf *RandomSortFeature) {
+		rsf.field = field
+	}
+}
+
+// WithSeedRotation changes the seed every period, such as a day,
+// so that the order of a session, or seed, is rotated over time
+func WithSeedRotation(period time.Duration) RandomSortOption {
+	return func(rsf *RandomSortFeature) {
+		rsf.rotation = period
+	}
+}
+
+func NewRandomSortFeature(opts ...RandomSortOption) *RandomSortFeature {
+	rsf := &RandomSortFeature{
+		param: "seed",
+		field: "_seq_no",
+		now:   time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(rsf)
+	}
+
+	return rsf
+}
+
+// Describe returns the seed parameter
+func (rsf *RandomSortFeature) Describe() []reveald.ParameterDescription {
+	return []reveald.ParameterDescription{{
+		Name:        rsf.param,
+		Kind:        reveald.ParameterValue,
+		Description: "Seed of the random order",
+		Examples:    []string{"42"},
+	}}
+}
+
+func (rsf *RandomSortFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	rsf.build(builder)
+	return next(builder)
+}
+
+func (rsf *RandomSortFeature) build(builder *reveald.QueryBuilder) {
+	fn := elastic.NewRandomFunction()
+	if seed, ok := rsf.seed(builder); ok {
+		fn = fn.Seed(seed).Field(rsf.field)
+	}
+
+	builder.ScoreFunction(nil, fn)
+}
+
+// seed returns the seed of a search, hashed so that session
+// ids don't end up in slow logs, and whether it has one
+func (rsf *RandomSortFeature) seed(builder *reveald.QueryBuilder) (int64, bool) {
+	var value string
+	if p, err := builder.Request().Get(rsf.param); err == nil {
+		value = p.Value()
+	}
+	if value == "" {
+		value, _ = reveald.SessionFromContext(builder.Context())
+	}
+	if value == "" && rsf.sessionParam != "" {
+		if p, err := builder.Request().Get(rsf.sessionParam); err == nil {
+			value = p.Value()
+		}
+	}
+	if value == "" {
+		return 0, false
+	}
+
+	if rsf.rotation > 0 {
+		period := rsf.now().UnixNano() / int64(rsf.rotation)
+		value += ":" + strconv.FormatInt(period, 10)
+	}
+
+	sum := sha256.Sum256([]byte(value))
+	return int64(binary.BigEndian.Uint32(sum[:4])), true
+}
@@ -0,0 +1,65 @@
+package featureset
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/reveald/reveald"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RandomSortFeature_Seed(t *testing.T) {
+	rsf := NewRandomSortFeature(WithSeedSessionParam("sid"))
+
+	seed := func(ctx context.Context, params ...reveald.Parameter) (int64, bool) {
+		qb := reveald.NewQueryBuilder(reveald.NewRequest(params...), "products")
+		qb.SetContext(ctx)
+		return rsf.seed(qb)
+	}
+
+	_, ok := seed(context.Background())
+	assert.False(t, ok)
+
+	s1, ok := seed(context.Background(), reveald.NewParameter("seed", "42"))
+	assert.True(t, ok)
+	s2, _ := seed(context.Background(), reveald.NewParameter("seed", "42"))
+	assert.Equal(t, s1, s2, "seeds are stable")
+	s3, _ := seed(context.Background(), reveald.NewParameter("seed", "43"))
+	assert.NotEqual(t, s1, s3)
+
+	session, ok := seed(reveald.ContextWithSession(context.Background(), "abc"))
+	assert.True(t, ok)
+	param, _ := seed(context.Background(), reveald.NewParameter("sid", "abc"))
+	assert.Equal(t, session, param)
+}
+
+func Test_RandomSortFeature_Rotation(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	rsf := NewRandomSortFeature(WithSeedRotation(24 * time.Hour))
+	rsf.now = func() time.Time { return now }
+
+	qb := reveald.NewQueryBuilder(reveald.NewRequest(reveald.NewParameter("seed", "42")), "products")
+	morning, _ := rsf.seed(qb)
+
+	now = now.Add(6 * time.Hour)
+	evening, _ := rsf.seed(qb)
+	assert.Equal(t, morning, evening)
+
+	now = now.Add(24 * time.Hour)
+	tomorrow, _ := rsf.seed(qb)
+	assert.NotEqual(t, morning, tomorrow)
+}
+
+func Test_RandomSortFeature_Build(t *testing.T) {
+	rsf := NewRandomSortFeature()
+
+	qb := reveald.NewQueryBuilder(reveald.NewRequest(reveald.NewParameter("seed", "42")), "products")
+	rsf.build(qb)
+
+	seed, _ := rsf.seed(qb)
+	query := sourceJSON(t, qb)["query"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{
+		"random_score": map[string]interface{}{"seed": float64(seed), "field": "_seq_no"},
+	}, query["function_score"].(map[string]interface{})["functions"].([]interface{})[0])
+}
@@ -15,10 +15,10 @@ func NewScriptedFieldFeature(fieldName, script string) *ScriptedFieldFeature {
 }
 
 func (sff *ScriptedFieldFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
-	sff.build(builder)
+	sff.Prepare(builder)
 	return next(builder)
 }
 
-func (sff *ScriptedFieldFeature) build(builder *reveald.QueryBuilder) {
+func (sff *ScriptedFieldFeature) Prepare(builder *reveald.QueryBuilder) {
 	builder.WithScriptedField(elastic.NewScriptField(sff.fieldName, elastic.NewScript(sff.script)))
 }
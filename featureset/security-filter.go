@@ -0,0 +1,343 @@
+package featureset
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+)
+
+type roleFilter struct {
+	role  string
+	query elastic.Query
+}
+
+type allowedValues struct {
+	property  string
+	attribute string
+}
+
+type restrictedFields struct {
+	fields []string
+	roles  []string
+}
+
+// SecurityFilterFeature enforces document level security for
+// the principal carried by the context (see ContextWithPrincipal).
+// Documents are visible when granted by any of the role filters,
+// ownership or ACL terms configured, and when matching every
+// allowed values constraint. Searches without a principal fail
+// with reveald.ErrNoPrincipal
+type SecurityFilterFeature struct {
+	roleFilters []roleFilter
+	owner       string
+	acl         string
+	allowed     []allowedValues
+	restricted  []restrictedFields
+	bypass      []string
+}
+
+type SecurityFilterOption func(*SecurityFilterFeature)
+
+// WithRoleFilter grants principals with a role
+// access to the documents matching a query
+func WithRoleFilter(role string, query elastic.Query) SecurityFilterOption {
+	return func(sff *SecurityFilterFeature) {
+		sff.roleFilters = append(sff.roleFilters, roleFilter{role, query})
+	}
+}
+
+// WithOwnerProperty grants principals access to the
+// documents where a property holds their id
+func WithOwnerProperty(property string) SecurityFilterOption {
+	return func(sff *SecurityFilterFeature) {
+		sff.owner = property
+	}
+}
+
+// WithACLProperty grants principals access to the documents
+// where a property lists their id, one of their roles, or
+// one of their groups
+func WithACLProperty(property string) SecurityFilterOption {
+	return func(sff *SecurityFilterFeature) {
+		sff.acl = property
+	}
+}
+
+// WithAllowedValues restricts documents to those where a property
+// holds one of the values of an attribute of the principal, e.g.
+// the categories it's entitled to. Principals without values for
+// the attribute see no documents
+func WithAllowedValues(property, attribute string) SecurityFilterOption {
+	return func(sff *SecurityFilterFeature) {
+		sff.allowed = append(sff.allowed, allowedValues{property, attribute})
+	}
+}
+
+// WithRestrictedFields excludes fields from the hits, unless the
+// principal has any of the roles. Besides the source, the fields
+// and their sub-fields are stripped from docvalue fields, highlights,
+// and collapsed and inner hits, aggregations using them are dropped,
+// and so is the raw response of debug details
+func WithRestrictedFields(fields []string, roles ...string) SecurityFilterOption {
+	return func(sff *SecurityFilterFeature) {
+		sff.restricted = append(sff.restricted, restrictedFields{fields, roles})
+	}
+}
+
+// WithBypassRoles lets principals with any of the
+// roles search every document, with every field
+func WithBypassRoles(roles ...string) SecurityFilterOption {
+	return func(sff *SecurityFilterFeature) {
+		sff.bypass = append(sff.bypass, roles...)
+	}
+}
+
+func NewSecurityFilterFeature(opts ...SecurityFilterOption) *SecurityFilterFeature {
+	sff := &SecurityFilterFeature{}
+
+	for _, opt := range opts {
+		opt(sff)
+	}
+
+	return sff
+}
+
+func (sff *SecurityFilterFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	p, ok := reveald.PrincipalFromContext(builder.Context())
+	if !ok {
+		return nil, reveald.ErrNoPrincipal
+	}
+
+	excluded := sff.build(builder, p)
+
+	r, err := next(builder)
+	if err != nil {
+		return nil, err
+	}
+
+	return sff.handle(builder, excluded, r)
+}
+
+// build scopes the query to the documents of the principal,
+// returning the fields restricted from the principal
+func (sff *SecurityFilterFeature) build(builder *reveald.QueryBuilder, p *reveald.Principal) []string {
+	if len(sff.bypass) > 0 && p.HasRole(sff.bypass...) {
+		return nil
+	}
+
+	if grant, ok := sff.grant(p); ok {
+		builder.With(grant)
+	}
+
+	for _, a := range sff.allowed {
+		values := p.Attributes[a.attribute]
+		if len(values) == 0 {
+			builder.With(elastic.NewMatchNoneQuery())
+			continue
+		}
+
+		builder.With(elastic.NewTermsQuery(a.property, toInterfaces(values)...))
+	}
+
+	var excluded []string
+	for _, r := range sff.restricted {
+		if !p.HasRole(r.roles...) {
+			excluded = append(excluded, r.fields...)
+		}
+	}
+	if len(excluded) > 0 {
+		builder.Selection().Update(reveald.WithoutProperties(excluded...))
+		builder.WithoutDebugResponse()
+	}
+
+	return excluded
+}
+
+// handle strips the restricted fields from the parts of the
+// result the source exclusion doesn't cover, including those
+// added by features running after this one
+func (sff *SecurityFilterFeature) handle(builder *reveald.QueryBuilder, excluded []string, result *reveald.Result) (*reveald.Result, error) {
+	if result == nil || len(excluded) == 0 {
+		return result, nil
+	}
+
+	for _, hit := range result.Hits {
+		stripFields(hit, excluded)
+	}
+
+	for name := range builder.Aggregations() {
+		agg, _ := builder.AttachedAggregation(name)
+		if src, err := agg.Source(); err != nil || referencesFields(src, excluded) {
+			delete(result.Aggregations, name)
+		}
+	}
+
+	return result, nil
+}
+
+// grant returns the query matching the documents granted to
+// the principal, and whether any grants are configured
+func (sff *SecurityFilterFeature) grant(p *reveald.Principal) (elastic.Query, bool) {
+	if len(sff.roleFilters) == 0 && sff.owner == "" && sff.acl == "" {
+		return nil, false
+	}
+
+	var grants []elastic.Query
+	for _, rf := range sff.roleFilters {
+		if p.HasRole(rf.role) {
+			grants = append(grants, rf.query)
+		}
+	}
+
+	if sff.owner != "" && p.ID != "" {
+		grants = append(grants, elastic.NewTermQuery(sff.owner, p.ID))
+	}
+
+	if sff.acl != "" {
+		var terms []string
+		if p.ID != "" {
+			terms = append(terms, p.ID)
+		}
+		terms = append(terms, p.Roles...)
+		terms = append(terms, p.Groups...)
+
+		if len(terms) > 0 {
+			grants = append(grants, elastic.NewTermsQuery(sff.acl, toInterfaces(terms)...))
+		}
+	}
+
+	if len(grants) == 0 {
+		return elastic.NewMatchNoneQuery(), true
+	}
+
+	return elastic.NewBoolQuery().Should(grants...).MinimumNumberShouldMatch(1), true
+}
+
+// stripFields removes fields from a hit, along with their
+// highlights, and from its collapsed and inner hits
+func stripFields(hit map[string]interface{}, fields []string) {
+	for _, field := range fields {
+		deleteValues(hit, strings.Split(field, "."))
+	}
+
+	if highlights, ok := hit[reveald.HighlightsKey].(elastic.SearchHitHighlight); ok {
+		for name := range highlights {
+			if matchesField(name, fields) {
+				delete(highlights, name)
+			}
+		}
+	}
+
+	if collapsed, ok := hit[reveald.CollapsedHitsKey].([]map[string]interface{}); ok {
+		for _, h := range collapsed {
+			stripFields(h, fields)
+		}
+	}
+
+	if inner, ok := hit[reveald.InnerHitsKey].(map[string][]map[string]interface{}); ok {
+		for _, hits := range inner {
+			for _, h := range hits {
+				stripFields(h, fields)
+			}
+		}
+	}
+}
+
+// deleteValues deletes the values at a path, as a dotted
+// field name or following objects and arrays of objects
+func deleteValues(value interface{}, path []string) {
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			deleteValues(item, path)
+		}
+	case map[string]interface{}:
+		// docvalue fields are keyed by their dotted name,
+		// which may be a sub-field such as a keyword field
+		field := []string{strings.Join(path, ".")}
+		for name := range v {
+			if matchesField(name, field) {
+				delete(v, name)
+			}
+		}
+
+		if child, ok := v[path[0]]; ok && len(path) > 1 {
+			deleteValues(child, path[1:])
+		}
+	}
+}
+
+// matchesField returns whether a field name is one of the
+// fields, or one of their sub-fields such as a keyword field
+func matchesField(name string, fields []string) bool {
+	for _, field := range fields {
+		if name == field || strings.HasPrefix(name, field+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// referencesFields returns whether a rendered aggregation,
+// or any of its sub-aggregations or scripts, uses any of the fields
+func referencesFields(src interface{}, fields []string) bool {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return true
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return true
+	}
+
+	return walkFields(v, fields)
+}
+
+func walkFields(value interface{}, fields []string) bool {
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if walkFields(item, fields) {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		for k, child := range v {
+			if name, ok := child.(string); ok && (k == "field" || k == "path") && matchesField(name, fields) {
+				return true
+			}
+			if source, ok := child.(string); ok && k == "source" && mentionsField(source, fields) {
+				return true
+			}
+			if walkFields(child, fields) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// mentionsField returns whether a script mentions any of the fields
+func mentionsField(script string, fields []string) bool {
+	for _, field := range fields {
+		if strings.Contains(script, field) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func toInterfaces(values []string) []interface{} {
+	s := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		s = append(s, v)
+	}
+
+	return s
+}
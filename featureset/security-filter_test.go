@@ -0,0 +1,170 @@
+package featureset
+
+import (
+	"context"
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SecurityFilterFeature_Build(t *testing.T) {
+	sff := NewSecurityFilterFeature(
+		WithRoleFilter("editor", elastic.NewTermQuery("status", "draft")),
+		WithOwnerProperty("owner"),
+		WithACLProperty("acl"),
+		WithAllowedValues("category", "categories"),
+		WithRestrictedFields([]string{"cost", "supplier"}, "buyer"),
+		WithBypassRoles("admin"))
+
+	table := []struct {
+		name      string
+		principal *reveald.Principal
+		query     elastic.Query
+		excluded  bool
+	}{
+		{"owner and acl", &reveald.Principal{ID: "u1", Groups: []string{"g1"}, Attributes: map[string][]string{"categories": {"tools"}}},
+			elastic.NewBoolQuery().Must(
+				elastic.NewBoolQuery().Should(
+					elastic.NewTermQuery("owner", "u1"),
+					elastic.NewTermsQuery("acl", "u1", "g1")).MinimumNumberShouldMatch(1),
+				elastic.NewTermsQuery("category", "tools")),
+			true},
+		{"role filter", &reveald.Principal{Roles: []string{"editor", "buyer"}, Attributes: map[string][]string{"categories": {"tools", "toys"}}},
+			elastic.NewBoolQuery().Must(
+				elastic.NewBoolQuery().Should(
+					elastic.NewTermQuery("status", "draft"),
+					elastic.NewTermsQuery("acl", "editor", "buyer")).MinimumNumberShouldMatch(1),
+				elastic.NewTermsQuery("category", "tools", "toys")),
+			false},
+		{"no grants", &reveald.Principal{},
+			elastic.NewBoolQuery().Must(
+				elastic.NewMatchNoneQuery(),
+				elastic.NewMatchNoneQuery()),
+			true},
+		{"bypass", &reveald.Principal{ID: "root", Roles: []string{"admin"}},
+			elastic.NewBoolQuery(),
+			false},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			qb := reveald.NewQueryBuilder(reveald.NewRequest(), "-")
+			qb.SetContext(reveald.ContextWithPrincipal(context.Background(), tt.principal))
+
+			_, err := sff.Process(qb, func(*reveald.QueryBuilder) (*reveald.Result, error) {
+				return nil, nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.query, qb.RawQuery())
+
+			var excludes interface{}
+			if src, ok := sourceJSON(t, qb)["_source"].(map[string]interface{}); ok {
+				excludes = src["excludes"]
+			}
+			assert.Equal(t, tt.excluded, excludes != nil)
+		})
+	}
+}
+
+func Test_SecurityFilterFeature_Requires_Principal(t *testing.T) {
+	sff := NewSecurityFilterFeature(WithOwnerProperty("owner"))
+	qb := reveald.NewQueryBuilder(reveald.NewRequest(), "-")
+
+	_, err := sff.Process(qb, func(*reveald.QueryBuilder) (*reveald.Result, error) {
+		return nil, nil
+	})
+	assert.ErrorIs(t, err, reveald.ErrNoPrincipal)
+}
+
+// aggregationsFeature adds aggregations, like facet features would
+type aggregationsFeature map[string]elastic.Aggregation
+
+func (f aggregationsFeature) Process(qb *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	for name, agg := range f {
+		qb.Aggregation(name, agg)
+	}
+
+	return next(qb)
+}
+
+func Test_SecurityFilterFeature_Strips_Restricted_Fields(t *testing.T) {
+	hit := func() map[string]interface{} {
+		return map[string]interface{}{
+			"name":             "drill",
+			"cost":             12.5,
+			"supplier.keyword": []interface{}{"acme"},
+			"variants":         []interface{}{map[string]interface{}{"sku": "d-1", "supplier": "acme"}},
+			reveald.HighlightsKey: elastic.SearchHitHighlight{
+				"name":          {"<em>drill</em>"},
+				"cost":          {"<em>12.5</em>"},
+				"supplier.text": {"<em>acme</em>"},
+			},
+		}
+	}
+
+	table := []struct {
+		name       string
+		principal  *reveald.Principal
+		restricted bool
+	}{
+		{"restricted", &reveald.Principal{ID: "u1"}, true},
+		{"granted", &reveald.Principal{ID: "u1", Roles: []string{"buyer"}}, false},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			outer := hit()
+			outer[reveald.CollapsedHitsKey] = []map[string]interface{}{hit()}
+			outer[reveald.InnerHitsKey] = map[string][]map[string]interface{}{"offers": {hit()}}
+
+			b := &fixedBackend{result: &reveald.Result{
+				Hits: []map[string]interface{}{outer},
+				Aggregations: map[string][]*reveald.ResultBucket{
+					"brand":   {{Value: "acme"}},
+					"margin":  {{Value: "high"}},
+					"sources": {{Value: "acme"}},
+				},
+			}}
+
+			e := reveald.NewEndpoint(b, reveald.WithIndices("products"))
+			assert.NoError(t, e.Register(
+				NewSecurityFilterFeature(WithRestrictedFields([]string{"cost", "supplier", "variants.supplier"}, "buyer")),
+				NewHighlightFeature(WithHighlightFields("name", "cost", "supplier.text")),
+				aggregationsFeature{
+					"brand":   elastic.NewTermsAggregation().Field("brand"),
+					"margin":  elastic.NewTermsAggregation().Script(elastic.NewScript("doc['price'].value - doc['cost'].value")),
+					"sources": elastic.NewTermsAggregation().Field("supplier.keyword"),
+				}))
+
+			ctx := reveald.ContextWithPrincipal(context.Background(), tt.principal)
+			r, err := e.Execute(ctx, reveald.NewRequest())
+			assert.NoError(t, err)
+
+			hits := []map[string]interface{}{
+				r.Hits[0],
+				r.Hits[0][reveald.CollapsedHitsKey].([]map[string]interface{})[0],
+				r.Hits[0][reveald.InnerHitsKey].(map[string][]map[string]interface{})["offers"][0],
+			}
+			for _, h := range hits {
+				highlights := h[reveald.HighlightsKey].(elastic.SearchHitHighlight)
+				variant := h["variants"].([]interface{})[0].(map[string]interface{})
+
+				assert.Equal(t, "drill", h["name"])
+				assert.Contains(t, highlights, "name")
+				assert.Equal(t, "d-1", variant["sku"])
+
+				assert.Equal(t, !tt.restricted, h["cost"] != nil)
+				assert.Equal(t, !tt.restricted, h["supplier.keyword"] != nil)
+				assert.Equal(t, !tt.restricted, variant["supplier"] != nil)
+				assert.Equal(t, !tt.restricted, highlights["cost"] != nil)
+				assert.Equal(t, !tt.restricted, highlights["supplier.text"] != nil)
+			}
+
+			assert.Contains(t, r.Aggregations, "brand")
+			assert.Equal(t, !tt.restricted, r.Aggregations["margin"] != nil)
+			assert.Equal(t, !tt.restricted, r.Aggregations["sources"] != nil)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package featureset
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/reveald/reveald"
+)
+
+type SessionPreferenceFeature struct {
+	param string
+}
+
+type SessionPreferenceOption func(*SessionPreferenceFeature)
+
+// WithSessionParam defines a request parameter to read the
+// session id from, when the context doesn't carry one
+func WithSessionParam(param string) SessionPreferenceOption {
+	return func(spf *SessionPreferenceFeature) {
+		spf.param = param
+	}
+}
+
+// NewSessionPreferenceFeature routes every query from a session
+// to the same shard copies, using a preference derived from the
+// session id set with reveald.ContextWithSession
+func NewSessionPreferenceFeature(opts ...SessionPreferenceOption) *SessionPreferenceFeature {
+	spf := &SessionPreferenceFeature{}
+
+	for _, opt := range opts {
+		opt(spf)
+	}
+
+	return spf
+}
+
+func (spf *SessionPreferenceFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	spf.build(builder)
+	return next(builder)
+}
+
+func (spf *SessionPreferenceFeature) build(builder *reveald.QueryBuilder) {
+	id, ok := reveald.SessionFromContext(builder.Context())
+	if !ok && spf.param != "" {
+		if p, err := builder.Request().Get(spf.param); err == nil {
+			id = p.Value()
+		}
+	}
+
+	if id == "" {
+		return
+	}
+
+	builder.WithPreference(sessionPreference(id))
+}
+
+// sessionPreference hashes the session id, since preference
+// values starting with an underscore have special meaning and
+// raw ids shouldn't end up in slow logs
+func sessionPreference(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return "session-" + hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,34 @@
+package featureset
+
+import (
+	"context"
+	"testing"
+
+	"github.com/reveald/reveald"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SessionPreferenceFeature_Build(t *testing.T) {
+	table := []struct {
+		name       string
+		ctx        context.Context
+		feature    *SessionPreferenceFeature
+		req        *reveald.Request
+		preference string
+	}{
+		{"no session", context.Background(), NewSessionPreferenceFeature(), reveald.NewRequest(), ""},
+		{"session in context", reveald.ContextWithSession(context.Background(), "abc"), NewSessionPreferenceFeature(), reveald.NewRequest(), sessionPreference("abc")},
+		{"session in param", context.Background(), NewSessionPreferenceFeature(WithSessionParam("sid")), reveald.NewRequest(reveald.NewParameter("sid", "def")), sessionPreference("def")},
+		{"context before param", reveald.ContextWithSession(context.Background(), "abc"), NewSessionPreferenceFeature(WithSessionParam("sid")), reveald.NewRequest(reveald.NewParameter("sid", "def")), sessionPreference("abc")},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			qb := reveald.NewQueryBuilder(tt.req, "-")
+			qb.SetContext(tt.ctx)
+			tt.feature.build(qb)
+
+			assert.Equal(t, tt.preference, qb.Preference())
+		})
+	}
+}
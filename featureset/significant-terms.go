@@ -0,0 +1,120 @@
+package featureset
+
+import (
+	"fmt"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+)
+
+const significantTermsAggregationSuffix = "_significant"
+
+type SignificantTermsFeature struct {
+	property    string
+	field       string
+	size        int
+	minDocCount int
+}
+
+type SignificantTermsOption func(*SignificantTermsFeature)
+
+func WithSignificantTermsSize(size int) SignificantTermsOption {
+	return func(stf *SignificantTermsFeature) {
+		stf.size = size
+	}
+}
+
+func WithSignificantTermsMinDocCount(minDocCount int) SignificantTermsOption {
+	return func(stf *SignificantTermsFeature) {
+		stf.minDocCount = minDocCount
+	}
+}
+
+// WithSignificantTermsField aggregates the specified field,
+// instead of the keyword field of the property
+func WithSignificantTermsField(field string) SignificantTermsOption {
+	return func(stf *SignificantTermsFeature) {
+		stf.field = field
+	}
+}
+
+// NewSignificantTermsFeature returns the values of a property which
+// are unusually common among the matching documents, compared to
+// the whole index, in Result.Related (e.g. "users who searched
+// this also filter by"). Values already selected for the
+// property are left out, and each bucket's Metric is its score
+func NewSignificantTermsFeature(property string, opts ...SignificantTermsOption) *SignificantTermsFeature {
+	stf := &SignificantTermsFeature{
+		property: property,
+		field:    fmt.Sprintf("%s.keyword", property),
+		size:     defaultAggregationSize,
+	}
+
+	for _, opt := range opts {
+		opt(stf)
+	}
+
+	return stf
+}
+
+func (stf *SignificantTermsFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	stf.build(builder)
+
+	r, err := next(builder)
+	if err != nil {
+		return nil, err
+	}
+
+	return stf.handle(r)
+}
+
+func (stf *SignificantTermsFeature) name() string {
+	return stf.property + significantTermsAggregationSuffix
+}
+
+func (stf *SignificantTermsFeature) build(builder *reveald.QueryBuilder) {
+	agg := elastic.NewSignificantTermsAggregation().
+		Field(stf.field).
+		RequiredSize(stf.size)
+	if stf.minDocCount > 0 {
+		agg = agg.MinDocCount(stf.minDocCount)
+	}
+
+	if p, err := builder.Request().Get(stf.property); err == nil && len(p.Values()) > 0 {
+		var selected []interface{}
+		for _, v := range p.Values() {
+			selected = append(selected, v)
+		}
+
+		agg = agg.ExcludeValues(selected...)
+	}
+
+	builder.Aggregation(stf.name(), agg)
+}
+
+func (stf *SignificantTermsFeature) handle(result *reveald.Result) (*reveald.Result, error) {
+	agg, ok := result.RawResult().Aggregations.SignificantTerms(stf.name())
+	if !ok {
+		return result, nil
+	}
+
+	var buckets []*reveald.ResultBucket
+	for _, bucket := range agg.Buckets {
+		if bucket == nil {
+			continue
+		}
+
+		buckets = append(buckets, &reveald.ResultBucket{
+			Value:    bucket.Key,
+			HitCount: bucket.DocCount,
+			Metric:   bucket.Score,
+		})
+	}
+
+	if result.Related == nil {
+		result.Related = make(map[string][]*reveald.ResultBucket)
+	}
+
+	result.Related[stf.property] = buckets
+	return result, nil
+}
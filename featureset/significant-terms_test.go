@@ -0,0 +1,40 @@
+package featureset
+
+import (
+	"testing"
+
+	"github.com/reveald/reveald"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SignificantTermsFeature_Build(t *testing.T) {
+	table := []struct {
+		name     string
+		request  *reveald.Request
+		opts     []SignificantTermsOption
+		expected map[string]interface{}
+	}{
+		{"defaults", reveald.NewRequest(), nil, map[string]interface{}{
+			"field": "tags.keyword", "size": defaultAggregationSize}},
+		{"options", reveald.NewRequest(), []SignificantTermsOption{
+			WithSignificantTermsField("tag_ids"),
+			WithSignificantTermsSize(5),
+			WithSignificantTermsMinDocCount(3)}, map[string]interface{}{
+			"field": "tag_ids", "size": 5, "min_doc_count": 3}},
+		{"excludes selected values", reveald.NewRequest(reveald.NewParameter("tags", "red", "blue")), nil, map[string]interface{}{
+			"field": "tags.keyword", "size": defaultAggregationSize, "exclude": []interface{}{"red", "blue"}}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			qb := reveald.NewQueryBuilder(tt.request, "-")
+			NewSignificantTermsFeature("tags", tt.opts...).build(qb)
+
+			src, err := qb.Build().Source()
+			assert.NoError(t, err)
+
+			aggs := src.(map[string]interface{})["aggregations"].(map[string]interface{})
+			assert.Equal(t, tt.expected, aggs["tags_significant"].(map[string]interface{})["significant_terms"])
+		})
+	}
+}
@@ -1,6 +1,10 @@
 package featureset
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/olivere/elastic/v7"
 	"github.com/reveald/reveald"
 )
@@ -8,25 +12,175 @@ import (
 type sortingOption struct {
 	property  string
 	ascending bool
+	opts      []SortKeyOption
+	sorters   []elastic.Sorter
+	keys      []SortKey
+}
+
+// Missing values placements of sort keys
+const (
+	SortMissingFirst = "_first"
+	SortMissingLast  = "_last"
+)
+
+type nestedSort struct {
+	path   string
+	filter elastic.Query
+}
+
+// SortKey is a key of a composite sort option
+type SortKey struct {
+	property  string
+	ascending bool
+	mode      string
+	nested    []nestedSort
+	missing   interface{}
+	unmapped  string
+}
+
+type SortKeyOption func(*SortKey)
+
+// WithSortMode sets the value sorted on for properties with
+// several values, such as min, max, sum, avg or median
+func WithSortMode(mode string) SortKeyOption {
+	return func(sk *SortKey) {
+		sk.mode = mode
+	}
+}
+
+// WithNestedSort sorts on a property of the nested documents
+// at path, considering only the nested documents matching filter,
+// if not nil. Properties of nested documents within nested
+// documents are sorted on by a nested sort for each path, from
+// the outermost to the innermost
+func WithNestedSort(path string, filter elastic.Query) SortKeyOption {
+	return func(sk *SortKey) {
+		sk.nested = append(sk.nested, nestedSort{path, filter})
+	}
+}
+
+// WithMissing places documents without a value for the property
+// first, with SortMissingFirst, last, with SortMissingLast, or
+// as if they had a value. By default they are placed last
+func WithMissing(missing interface{}) SortKeyOption {
+	return func(sk *SortKey) {
+		sk.missing = missing
+	}
+}
+
+// WithUnmappedType sorts indices not mapping the property as
+// if it were of a type, such as keyword, rather than failing
+func WithUnmappedType(typ string) SortKeyOption {
+	return func(sk *SortKey) {
+		sk.unmapped = typ
+	}
+}
+
+// NewSortKey returns a sort key on a property, such as _score
+func NewSortKey(property string, ascending bool, opts ...SortKeyOption) SortKey {
+	sk := SortKey{property: property, ascending: ascending}
+
+	for _, opt := range opts {
+		opt(&sk)
+	}
+
+	return sk
 }
 
 type SortingFeature struct {
 	param         string
 	options       map[string]sortingOption
 	defaultOption string
+	tiebreaker    *SortKey
 }
 
 type SortingOption func(*SortingFeature)
 
-func WithSortOption(name, property string, ascending bool) SortingOption {
+func WithSortOption(name, property string, ascending bool, opts ...SortKeyOption) SortingOption {
+	return func(sf *SortingFeature) {
+		sf.options[name] = sortingOption{
+			property:  property,
+			ascending: ascending,
+			opts:      opts,
+		}
+	}
+}
+
+// WithBucketedSortOption sorts on a property grouped into bands of
+// the specified size, ordering documents within the same band by
+// relevance (e.g. "cheapest relevant first" with a price band)
+func WithBucketedSortOption(name, property string, bandSize float64, ascending bool) SortingOption {
+	return func(sf *SortingFeature) {
+		missing := "Double.MAX_VALUE"
+		if !ascending {
+			missing = "-Double.MAX_VALUE"
+		}
+
+		script := elastic.NewScript(fmt.Sprintf(
+			"doc[params.field].size() == 0 ? %s : Math.floor(doc[params.field].value / params.band)", missing)).
+			Param("field", property).
+			Param("band", bandSize)
+
+		sf.options[name] = sortingOption{
+			property:  property,
+			ascending: ascending,
+			sorters: []elastic.Sorter{
+				elastic.NewScriptSort(script, "number").Order(ascending),
+				elastic.NewScoreSort().Desc(),
+			},
+		}
+	}
+}
+
+// WithScriptSortOption sorts on the value computed by a painless
+// script, such as the margin per unit, where valueType is the
+// type of the value, number or string
+func WithScriptSortOption(name, script, valueType string, ascending bool) SortingOption {
 	return func(sf *SortingFeature) {
 		sf.options[name] = sortingOption{
-			property,
-			ascending,
+			property:  "_script",
+			ascending: ascending,
+			sorters: []elastic.Sorter{
+				elastic.NewScriptSort(elastic.NewScript(script), valueType).Order(ascending),
+			},
 		}
 	}
 }
 
+// WithCompositeSortOption sorts on several keys, where later
+// keys break ties of earlier ones, e.g. price ascending, then
+// score descending
+func WithCompositeSortOption(name string, keys ...SortKey) SortingOption {
+	return func(sf *SortingFeature) {
+		if len(keys) == 0 {
+			return
+		}
+
+		var sorters []elastic.Sorter
+		for _, key := range keys {
+			sorters = append(sorters, key.fieldSort())
+		}
+
+		sf.options[name] = sortingOption{
+			property:  keys[0].property,
+			ascending: keys[0].ascending,
+			sorters:   sorters,
+			keys:      keys,
+		}
+	}
+}
+
+// WithTiebreaker appends a sort on a unique property, such as an
+// id, to every selected sort not ending with it, and to relevance
+// when no sort is selected, so that the order of documents is
+// deterministic and pagination stable. It's required for cursor
+// pagination, which would otherwise skip or repeat tied hits
+func WithTiebreaker(property string, ascending bool) SortingOption {
+	return func(sf *SortingFeature) {
+		sf.tiebreaker = &SortKey{property: property, ascending: ascending}
+	}
+}
+
 func WithDefaultSortOption(name string) SortingOption {
 	return func(sf *SortingFeature) {
 		sf.defaultOption = name
@@ -46,6 +200,27 @@ func NewSortingFeature(param string, opts ...SortingOption) *SortingFeature {
 	return sf
 }
 
+// Describe returns the sort parameter, with its options
+func (sf *SortingFeature) Describe() []reveald.ParameterDescription {
+	names := make([]string, 0, len(sf.options))
+	for name := range sf.options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	description := fmt.Sprintf("Sorts the hits, where comma separated values, or %s2, %s3 and so on, add secondary sorts", sf.param, sf.param)
+	if sf.defaultOption != "" {
+		description += fmt.Sprintf(" (default %s)", sf.defaultOption)
+	}
+
+	return []reveald.ParameterDescription{{
+		Name:        sf.param,
+		Kind:        reveald.ParameterValue,
+		Description: description,
+		Values:      names,
+	}}
+}
+
 func (sf *SortingFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
 	sf.build(builder)
 
@@ -58,46 +233,135 @@ func (sf *SortingFeature) Process(builder *reveald.QueryBuilder, next reveald.Fe
 }
 
 func (sf *SortingFeature) build(builder *reveald.QueryBuilder) {
-	key := sf.defaultOption
+	keys := sf.selection(builder.Request())
+	if len(keys) == 0 {
+		if sf.tiebreaker != nil {
+			builder.Selection().Update(reveald.WithSortBy(elastic.NewScoreSort().Desc(), sf.tiebreaker.fieldSort()))
+		}
+		return
+	}
+
+	if option := sf.options[keys[0]]; len(keys) == 1 && len(option.sorters) == 0 && sf.tiebreaker == nil {
+		builder.Selection().Update(reveald.WithSort(option.fieldSort()))
+		return
+	}
+
+	var sorters []elastic.Sorter
+	for _, key := range keys {
+		sorters = append(sorters, sf.options[key].sortBy()...)
+	}
 
-	if builder.Request().Has(sf.param) {
-		v, err := builder.Request().Get(sf.param)
+	if sf.tiebreaker != nil && !sf.options[keys[len(keys)-1]].endsWith(sf.tiebreaker.property) {
+		sorters = append(sorters, sf.tiebreaker.fieldSort())
+	}
+
+	builder.Selection().Update(reveald.WithSortBy(sorters...))
+}
+
+// selection returns the requested, registered sort options in
+// priority order, read from repeated or comma separated values
+// of the sort parameter, followed by any numbered secondary
+// parameters (e.g. sort2, sort3)
+func (sf *SortingFeature) selection(req *reveald.Request) []string {
+	var keys []string
+	collect := func(name string) bool {
+		p, err := req.Get(name)
 		if err != nil {
-			return
+			return false
 		}
 
-		key = v.Value()
+		for _, v := range p.Values() {
+			for _, key := range strings.Split(v, ",") {
+				if key = strings.TrimSpace(key); key != "" {
+					keys = append(keys, key)
+				}
+			}
+		}
+
+		return true
 	}
 
-	if key == "" {
-		return
+	if collect(sf.param) {
+		for i := 2; ; i++ {
+			if !collect(fmt.Sprintf("%s%d", sf.param, i)) {
+				break
+			}
+		}
+	} else if sf.defaultOption != "" {
+		keys = append(keys, sf.defaultOption)
 	}
 
-	option, ok := sf.options[key]
-	if !ok {
-		return
+	var selected []string
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		if _, ok := sf.options[key]; !ok || seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		selected = append(selected, key)
 	}
 
-	sort := elastic.NewFieldSort(option.property)
-	if option.ascending {
-		sort = sort.Asc()
+	return selected
+}
+
+func (so sortingOption) fieldSort() *elastic.FieldSort {
+	return NewSortKey(so.property, so.ascending, so.opts...).fieldSort()
+}
+
+func (sk SortKey) fieldSort() *elastic.FieldSort {
+	sort := elastic.NewFieldSort(sk.property).Order(sk.ascending)
+	if sk.mode != "" {
+		sort = sort.SortMode(sk.mode)
 	}
-	if !option.ascending {
-		sort = sort.Desc()
+	if sk.missing != nil {
+		sort = sort.Missing(sk.missing)
+	}
+	if sk.unmapped != "" {
+		sort = sort.UnmappedType(sk.unmapped)
 	}
 
-	builder.Selection().Update(reveald.WithSort(sort))
+	var nested *elastic.NestedSort
+	for i := len(sk.nested) - 1; i >= 0; i-- {
+		n := elastic.NewNestedSort(sk.nested[i].path)
+		if sk.nested[i].filter != nil {
+			n = n.Filter(sk.nested[i].filter)
+		}
+		if nested != nil {
+			n = n.NestedSort(nested)
+		}
+		nested = n
+	}
+	if nested != nil {
+		sort = sort.Nested(nested)
+	}
+
+	return sort
+}
+
+// endsWith returns whether the last sort of an option is on a property
+func (so sortingOption) endsWith(property string) bool {
+	if len(so.keys) > 0 {
+		return so.keys[len(so.keys)-1].property == property
+	}
+
+	return len(so.sorters) == 0 && so.property == property
+}
+
+func (so sortingOption) sortBy() []elastic.Sorter {
+	if len(so.sorters) > 0 {
+		return so.sorters
+	}
+
+	return []elastic.Sorter{so.fieldSort()}
 }
 
 func (sf *SortingFeature) handle(req *reveald.Request, result *reveald.Result) (*reveald.Result, error) {
 	var options []*reveald.ResultSortingOption
 
-	selected := sf.defaultOption
-	if req.Has(sf.param) {
-		v, err := req.Get(sf.param)
-		if err == nil {
-			selected = v.Value()
-		}
+	priority := make(map[string]int)
+	for i, key := range sf.selection(req) {
+		priority[key] = i + 1
 	}
 
 	for k, v := range sf.options {
@@ -105,7 +369,8 @@ func (sf *SortingFeature) handle(req *reveald.Request, result *reveald.Result) (
 			Name:      k,
 			Property:  v.property,
 			Ascending: v.ascending,
-			Selected:  selected == k,
+			Selected:  priority[k] > 0,
+			Priority:  priority[k],
 		})
 	}
 
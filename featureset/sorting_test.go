@@ -1,8 +1,10 @@
 package featureset
 
 import (
+	"encoding/json"
 	"testing"
 
+	"github.com/olivere/elastic/v7"
 	"github.com/reveald/reveald"
 	"github.com/stretchr/testify/assert"
 )
@@ -16,8 +18,8 @@ func Test_NewSortingFeature(t *testing.T) {
 		result        map[string]sortingOption
 	}{
 		{"no options", "sort", []SortingOption{}, "", make(map[string]sortingOption)},
-		{"without default", "sort", []SortingOption{WithSortOption("opt", "prop", true)}, "", map[string]sortingOption{"opt": {"prop", true}}},
-		{"with default", "sort", []SortingOption{WithSortOption("opt", "prop", true), WithDefaultSortOption("opt")}, "opt", map[string]sortingOption{"opt": {"prop", true}}},
+		{"without default", "sort", []SortingOption{WithSortOption("opt", "prop", true)}, "", map[string]sortingOption{"opt": {property: "prop", ascending: true}}},
+		{"with default", "sort", []SortingOption{WithSortOption("opt", "prop", true), WithDefaultSortOption("opt")}, "opt", map[string]sortingOption{"opt": {property: "prop", ascending: true}}},
 	}
 
 	for _, tt := range table {
@@ -52,6 +54,162 @@ func Test_SortingFeature_Build(t *testing.T) {
 	}
 }
 
+func Test_SortingFeature_BucketedOption(t *testing.T) {
+	sf := NewSortingFeature("sort", WithBucketedSortOption("relevant-cheap", "price", 100, true))
+	qb := reveald.NewQueryBuilder(reveald.NewRequest(reveald.NewParameter("sort", "relevant-cheap")), "-")
+	sf.build(qb)
+
+	sorters := qb.Selection().Sorters()
+	assert.Len(t, sorters, 2)
+
+	src, err := sorters[0].Source()
+	assert.NoError(t, err)
+	script := src.(map[string]interface{})["_script"].(map[string]interface{})
+	assert.Equal(t, "asc", script["order"])
+	assert.Equal(t, "number", script["type"])
+
+	assert.Equal(t, elastic.NewScoreSort().Desc(), sorters[1])
+}
+
+func Test_SortingFeature_ScriptOption(t *testing.T) {
+	script := "doc['price'].value - doc['cost'].value"
+	sf := NewSortingFeature("sort",
+		WithSortOption("name", "name", true),
+		WithScriptSortOption("margin", script, "number", false))
+	qb := reveald.NewQueryBuilder(reveald.NewRequest(reveald.NewParameter("sort", "margin,name")), "-")
+	sf.build(qb)
+
+	assert.Equal(t, []elastic.Sorter{
+		elastic.NewScriptSort(elastic.NewScript(script), "number").Desc(),
+		elastic.NewFieldSort("name").Asc(),
+	}, qb.Selection().Sorters())
+}
+
+func Test_SortingFeature_CompositeOption(t *testing.T) {
+	table := []struct {
+		name     string
+		opts     []SortingOption
+		sort     string
+		expected []elastic.Sorter
+	}{
+		{"composite", []SortingOption{
+			WithCompositeSortOption("cheap", NewSortKey("price", true), NewSortKey("_score", false), NewSortKey("_id", true)),
+		}, "cheap", []elastic.Sorter{
+			elastic.NewFieldSort("price").Asc(),
+			elastic.NewFieldSort("_score").Desc(),
+			elastic.NewFieldSort("_id").Asc(),
+		}},
+		{"tiebreaker", []SortingOption{
+			WithSortOption("name", "name", true),
+			WithTiebreaker("_id", true),
+		}, "name", []elastic.Sorter{
+			elastic.NewFieldSort("name").Asc(),
+			elastic.NewFieldSort("_id").Asc(),
+		}},
+		{"tiebreaker without selection", []SortingOption{
+			WithSortOption("name", "name", true),
+			WithTiebreaker("_id", true),
+		}, "", []elastic.Sorter{
+			elastic.NewScoreSort().Desc(),
+			elastic.NewFieldSort("_id").Asc(),
+		}},
+		{"tiebreaker of unknown selection", []SortingOption{
+			WithSortOption("name", "name", true),
+			WithTiebreaker("_id", true),
+		}, "random", []elastic.Sorter{
+			elastic.NewScoreSort().Desc(),
+			elastic.NewFieldSort("_id").Asc(),
+		}},
+		{"tiebreaker already last", []SortingOption{
+			WithCompositeSortOption("cheap", NewSortKey("price", true), NewSortKey("_id", true)),
+			WithTiebreaker("_id", true),
+		}, "cheap", []elastic.Sorter{
+			elastic.NewFieldSort("price").Asc(),
+			elastic.NewFieldSort("_id").Asc(),
+		}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			sf := NewSortingFeature("sort", tt.opts...)
+			qb := reveald.NewQueryBuilder(reveald.NewRequest(reveald.NewParameter("sort", tt.sort)), "-")
+			sf.build(qb)
+
+			assert.Equal(t, tt.expected, qb.Selection().Sorters())
+		})
+	}
+}
+
+func Test_SortingFeature_NestedOption(t *testing.T) {
+	sf := NewSortingFeature("sort",
+		WithSortOption("cheapest", "offers.price", true,
+			WithSortMode("min"),
+			WithNestedSort("offers", elastic.NewTermQuery("offers.active", true))),
+		WithCompositeSortOption("warehouse",
+			NewSortKey("offers.stock.quantity", false,
+				WithSortMode("max"),
+				WithNestedSort("offers", nil),
+				WithNestedSort("offers.stock", elastic.NewTermQuery("offers.stock.region", "eu")))))
+
+	table := []struct {
+		name     string
+		sort     string
+		expected string
+	}{
+		{"nested", "cheapest",
+			`{"offers.price":{"order":"asc","mode":"min","nested":{"path":"offers","filter":{"term":{"offers.active":true}}}}}`},
+		{"multi level nested", "warehouse",
+			`{"offers.stock.quantity":{"order":"desc","mode":"max","nested":{"path":"offers","nested":{"path":"offers.stock","filter":{"term":{"offers.stock.region":"eu"}}}}}}`},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			qb := reveald.NewQueryBuilder(reveald.NewRequest(reveald.NewParameter("sort", tt.sort)), "-")
+			sf.build(qb)
+
+			sorters := qb.Selection().Sorters()
+			assert.Len(t, sorters, 1)
+
+			src, err := sorters[0].Source()
+			assert.NoError(t, err)
+			data, err := json.Marshal(src)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(data))
+		})
+	}
+}
+
+func Test_SortingFeature_MissingAndUnmapped(t *testing.T) {
+	sf := NewSortingFeature("sort",
+		WithSortOption("rating", "rating", false, WithMissing(SortMissingLast), WithUnmappedType("float")),
+		WithCompositeSortOption("release",
+			NewSortKey("released", false, WithMissing(SortMissingFirst)),
+			NewSortKey("rank", true, WithMissing(1000))))
+
+	table := []struct {
+		name     string
+		sort     string
+		expected []elastic.Sorter
+	}{
+		{"missing and unmapped", "rating", []elastic.Sorter{
+			elastic.NewFieldSort("rating").Desc().Missing("_last").UnmappedType("float"),
+		}},
+		{"composite", "release", []elastic.Sorter{
+			elastic.NewFieldSort("released").Desc().Missing("_first"),
+			elastic.NewFieldSort("rank").Asc().Missing(1000),
+		}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			qb := reveald.NewQueryBuilder(reveald.NewRequest(reveald.NewParameter("sort", tt.sort)), "-")
+			sf.build(qb)
+
+			assert.Equal(t, tt.expected, qb.Selection().Sorters())
+		})
+	}
+}
+
 func Test_SortingFeature_DefaultSelected(t *testing.T) {
 	table := []struct {
 		name         string
@@ -83,3 +241,66 @@ func Test_SortingFeature_DefaultSelected(t *testing.T) {
 		})
 	}
 }
+
+func Test_SortingFeature_MultiLevel(t *testing.T) {
+	sf := NewSortingFeature("sort",
+		WithSortOption("price-desc", "price", false),
+		WithSortOption("name-asc", "name", true),
+		WithSortOption("date-desc", "date", false))
+
+	table := []struct {
+		name     string
+		req      *reveald.Request
+		expected []elastic.Sorter
+	}{
+		{"secondary parameter", reveald.NewRequest(
+			reveald.NewParameter("sort", "price-desc"),
+			reveald.NewParameter("sort2", "name-asc")),
+			[]elastic.Sorter{elastic.NewFieldSort("price").Desc(), elastic.NewFieldSort("name").Asc()}},
+		{"comma list", reveald.NewRequest(
+			reveald.NewParameter("sort", "name-asc,date-desc")),
+			[]elastic.Sorter{elastic.NewFieldSort("name").Asc(), elastic.NewFieldSort("date").Desc()}},
+		{"unknown and duplicate options", reveald.NewRequest(
+			reveald.NewParameter("sort", "name-asc,unknown,name-asc"),
+			reveald.NewParameter("sort2", "price-desc")),
+			[]elastic.Sorter{elastic.NewFieldSort("name").Asc(), elastic.NewFieldSort("price").Desc()}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			qb := reveald.NewQueryBuilder(tt.req, "-")
+			sf.build(qb)
+			assert.Equal(t, tt.expected, qb.Selection().Sorters())
+		})
+	}
+}
+
+func Test_SortingFeature_Priority(t *testing.T) {
+	sf := NewSortingFeature("sort",
+		WithSortOption("price-desc", "price", false),
+		WithSortOption("name-asc", "name", true))
+	req := reveald.NewRequest(reveald.NewParameter("sort", "name-asc,price-desc"))
+
+	r, err := sf.handle(req, &reveald.Result{})
+	assert.NoError(t, err)
+
+	priorities := make(map[string]int)
+	for _, so := range r.Sorting.Options {
+		priorities[so.Name] = so.Priority
+	}
+	assert.Equal(t, map[string]int{"name-asc": 1, "price-desc": 2}, priorities)
+}
+
+func Test_SortingFeature_Describe(t *testing.T) {
+	sf := NewSortingFeature("sort",
+		WithSortOption("price-asc", "price", true),
+		WithSortOption("newest", "released", false),
+		WithDefaultSortOption("newest"))
+
+	params := sf.Describe()
+	assert.Len(t, params, 1)
+	assert.Equal(t, "sort", params[0].Name)
+	assert.Equal(t, reveald.ParameterValue, params[0].Kind)
+	assert.Equal(t, []string{"newest", "price-asc"}, params[0].Values)
+	assert.Contains(t, params[0].Description, "(default newest)")
+}
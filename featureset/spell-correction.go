@@ -0,0 +1,131 @@
+package featureset
+
+import (
+	"fmt"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+)
+
+const defaultSuggestionSize = 3
+
+type SpellCorrectionFeature struct {
+	field   string
+	param   string
+	size    int
+	collate bool
+	preTag  string
+	postTag string
+}
+
+type SpellCorrectionOption func(*SpellCorrectionFeature)
+
+func WithSpellCorrectionParam(name string) SpellCorrectionOption {
+	return func(scf *SpellCorrectionFeature) {
+		scf.param = name
+	}
+}
+
+func WithSuggestionSize(size int) SpellCorrectionOption {
+	return func(scf *SpellCorrectionFeature) {
+		scf.size = size
+	}
+}
+
+func WithSuggestionHighlightTags(pre, post string) SpellCorrectionOption {
+	return func(scf *SpellCorrectionFeature) {
+		scf.preTag = pre
+		scf.postTag = post
+	}
+}
+
+// WithoutCollate returns corrections regardless of
+// whether they match any documents
+func WithoutCollate() SpellCorrectionOption {
+	return func(scf *SpellCorrectionFeature) {
+		scf.collate = false
+	}
+}
+
+// NewSpellCorrectionFeature suggests "did you mean" corrections
+// of the full-text query, using a phrase suggester on the field
+func NewSpellCorrectionFeature(field string, opts ...SpellCorrectionOption) *SpellCorrectionFeature {
+	scf := &SpellCorrectionFeature{
+		field:   field,
+		param:   "q",
+		size:    defaultSuggestionSize,
+		collate: true,
+		preTag:  defaultHighlightPreTag,
+		postTag: defaultHighlightPostTag,
+	}
+
+	for _, opt := range opts {
+		opt(scf)
+	}
+
+	return scf
+}
+
+func (scf *SpellCorrectionFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	if !scf.build(builder) {
+		return next(builder)
+	}
+
+	r, err := next(builder)
+	if err != nil {
+		return nil, err
+	}
+
+	return scf.handle(r)
+}
+
+func (scf *SpellCorrectionFeature) build(builder *reveald.QueryBuilder) bool {
+	p, err := builder.Request().Get(scf.param)
+	if err != nil || p.Value() == "" {
+		return false
+	}
+
+	suggester := elastic.NewPhraseSuggester(scf.param).
+		Text(p.Value()).
+		Field(scf.field).
+		Size(scf.size).
+		Highlight(scf.preTag, scf.postTag)
+
+	if scf.collate {
+		suggester = suggester.
+			CollateQuery(elastic.NewScript(fmt.Sprintf(`{"match": {"%s": "{{suggestion}}"}}`, scf.field))).
+			CollatePrune(true)
+	}
+
+	builder.Suggester(suggester)
+	return true
+}
+
+func (scf *SpellCorrectionFeature) handle(result *reveald.Result) (*reveald.Result, error) {
+	suggestions, ok := result.RawResult().Suggest[scf.param]
+	if !ok {
+		return result, nil
+	}
+
+	var corrections []*reveald.ResultSuggestion
+	for _, suggestion := range suggestions {
+		for _, option := range suggestion.Options {
+			if scf.collate && !option.CollateMatch {
+				continue
+			}
+
+			corrections = append(corrections, &reveald.ResultSuggestion{
+				Text:        option.Text,
+				Highlighted: option.Highlighted,
+				Score:       option.Score,
+			})
+		}
+	}
+
+	if result.Suggestions == nil {
+		result.Suggestions = make(map[string][]*reveald.ResultSuggestion)
+	}
+
+	result.Suggestions[scf.param] = corrections
+	return result, nil
+}
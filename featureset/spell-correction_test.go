@@ -0,0 +1,34 @@
+package featureset
+
+import (
+	"testing"
+
+	"github.com/reveald/reveald"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SpellCorrectionFeature_Build(t *testing.T) {
+	table := []struct {
+		name    string
+		req     *reveald.Request
+		applied bool
+	}{
+		{"without query", reveald.NewRequest(), false},
+		{"with empty query", reveald.NewRequest(reveald.NewParameter("q", "")), false},
+		{"with query", reveald.NewRequest(reveald.NewParameter("q", "blu jeans")), true},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			qb := reveald.NewQueryBuilder(tt.req, "-")
+			applied := NewSpellCorrectionFeature("title").build(qb)
+			assert.Equal(t, tt.applied, applied)
+
+			src, err := qb.Build().Source()
+			assert.NoError(t, err)
+
+			_, ok := src.(map[string]interface{})["suggest"]
+			assert.Equal(t, tt.applied, ok)
+		})
+	}
+}
@@ -1,46 +1,116 @@
 package featureset
 
 import (
+	"context"
+
 	"github.com/olivere/elastic/v7"
 	"github.com/reveald/reveald"
 )
 
+// ValueFunc provides a filter value per request,
+// e.g. the current market or channel from context
+type ValueFunc func(ctx context.Context) interface{}
+
+// QueryFunc provides a filter query per request, e.g. the
+// tenants or entitlements of the caller, from context
+type QueryFunc func(ctx context.Context, r *reveald.Request) elastic.Query
+
+type valueProvider struct {
+	property string
+	value    ValueFunc
+}
+
 type StaticFilterFeature struct {
-	query elastic.Query
+	query     elastic.Query
+	providers []valueProvider
+	queries   []QueryFunc
 }
 
-type StaticFilterOption func(*elastic.BoolQuery)
+type StaticFilterOption func(*StaticFilterFeature)
 
 func WithRequiredProperty(property string) StaticFilterOption {
-	return func(query *elastic.BoolQuery) {
-		query.Must(elastic.NewExistsQuery(property))
+	return func(sff *StaticFilterFeature) {
+		sff.must(elastic.NewExistsQuery(property))
 	}
 }
 
 func WithRequiredValue(property string, value interface{}) StaticFilterOption {
-	return func(query *elastic.BoolQuery) {
-		query.Must(elastic.NewTermQuery(property, value))
+	return func(sff *StaticFilterFeature) {
+		sff.must(elastic.NewTermQuery(property, value))
 	}
 }
 
-func NewStaticFilterFeature(opts ...StaticFilterOption) *StaticFilterFeature {
-	if len(opts) == 0 {
-		return &StaticFilterFeature{nil}
+// WithRequiredValueFunc requires a property to match a value
+// evaluated for each request. A nil value leaves the property
+// unfiltered. Static filters using value functions are
+// processed per request rather than prepared once
+func WithRequiredValueFunc(property string, fn ValueFunc) StaticFilterOption {
+	return func(sff *StaticFilterFeature) {
+		sff.providers = append(sff.providers, valueProvider{property, fn})
+	}
+}
+
+// WithRequiredQueryFunc requires documents to match a query
+// built for each request. A nil query leaves the documents
+// unfiltered
+func WithRequiredQueryFunc(fn QueryFunc) StaticFilterOption {
+	return func(sff *StaticFilterFeature) {
+		sff.queries = append(sff.queries, fn)
 	}
+}
 
-	query := elastic.NewBoolQuery()
+func NewStaticFilterFeature(opts ...StaticFilterOption) *StaticFilterFeature {
+	sff := &StaticFilterFeature{}
 
 	for _, opt := range opts {
-		opt(query)
+		opt(sff)
 	}
 
-	return &StaticFilterFeature{query}
+	return sff
+}
+
+// NewContextualFilterFeature returns a static filter requiring
+// documents to match a query built for each request, from values
+// such as tenant ids or market codes carried by the context
+func NewContextualFilterFeature(fn QueryFunc, opts ...StaticFilterOption) *StaticFilterFeature {
+	return NewStaticFilterFeature(append([]StaticFilterOption{WithRequiredQueryFunc(fn)}, opts...)...)
+}
+
+func (sff *StaticFilterFeature) must(query elastic.Query) {
+	bq, ok := sff.query.(*elastic.BoolQuery)
+	if !ok {
+		bq = elastic.NewBoolQuery()
+		sff.query = bq
+	}
+
+	bq.Must(query)
 }
 
 func (sff *StaticFilterFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	sff.Prepare(builder)
+
+	for _, p := range sff.providers {
+		if v := p.value(builder.Context()); v != nil {
+			builder.With(elastic.NewTermQuery(p.property, v))
+		}
+	}
+
+	for _, fn := range sff.queries {
+		if q := fn(builder.Context(), builder.Request()); q != nil {
+			builder.With(q)
+		}
+	}
+
+	return next(builder)
+}
+
+func (sff *StaticFilterFeature) Prepare(builder *reveald.QueryBuilder) {
 	if sff.query != nil {
 		builder.With(sff.query)
 	}
+}
 
-	return next(builder)
+// Preparable reports whether the filter is request independent
+func (sff *StaticFilterFeature) Preparable() bool {
+	return len(sff.providers) == 0 && len(sff.queries) == 0
 }
@@ -1,6 +1,7 @@
 package featureset
 
 import (
+	"context"
 	"testing"
 
 	"github.com/olivere/elastic/v7"
@@ -52,3 +53,86 @@ func Test_StaticFilterFeature_Build(t *testing.T) {
 		})
 	}
 }
+
+type marketKey struct{}
+
+func Test_StaticFilterFeature_ValueFunc(t *testing.T) {
+	market := func(ctx context.Context) interface{} {
+		return ctx.Value(marketKey{})
+	}
+
+	table := []struct {
+		name   string
+		ctx    context.Context
+		result elastic.Query
+	}{
+		{"value from context", context.WithValue(context.Background(), marketKey{}, "se"), elastic.NewBoolQuery().Must(
+			elastic.NewBoolQuery().Must(elastic.NewExistsQuery("property")),
+			elastic.NewTermQuery("market", "se"))},
+		{"nil value", context.Background(), elastic.NewBoolQuery().Must(
+			elastic.NewBoolQuery().Must(elastic.NewExistsQuery("property")))},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			sff := NewStaticFilterFeature(WithRequiredProperty("property"), WithRequiredValueFunc("market", market))
+			assert.False(t, sff.Preparable())
+
+			qb := reveald.NewQueryBuilder(&reveald.Request{}, "-")
+			qb.SetContext(tt.ctx)
+
+			_, err := sff.Process(qb, func(_ *reveald.QueryBuilder) (*reveald.Result, error) {
+				return nil, nil
+			})
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.result, qb.RawQuery())
+		})
+	}
+}
+
+type tenantKey struct{}
+
+func Test_ContextualFilterFeature(t *testing.T) {
+	tenants := func(ctx context.Context, r *reveald.Request) elastic.Query {
+		ids, ok := ctx.Value(tenantKey{}).([]interface{})
+		if !ok {
+			return nil
+		}
+
+		if p, err := r.Get("shared"); err == nil && p.IsTruthy() {
+			ids = append(ids, "shared")
+		}
+		return elastic.NewTermsQuery("tenant", ids...)
+	}
+
+	table := []struct {
+		name   string
+		ctx    context.Context
+		req    *reveald.Request
+		result elastic.Query
+	}{
+		{"tenants from context", context.WithValue(context.Background(), tenantKey{}, []interface{}{"a", "b"}), reveald.NewRequest(),
+			elastic.NewBoolQuery().Must(elastic.NewTermsQuery("tenant", "a", "b"))},
+		{"request parameter", context.WithValue(context.Background(), tenantKey{}, []interface{}{"a"}), reveald.NewRequest(reveald.NewParameter("shared", "true")),
+			elastic.NewBoolQuery().Must(elastic.NewTermsQuery("tenant", "a", "shared"))},
+		{"nil query", context.Background(), reveald.NewRequest(), elastic.NewBoolQuery()},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			sff := NewContextualFilterFeature(tenants)
+			assert.False(t, sff.Preparable())
+
+			qb := reveald.NewQueryBuilder(tt.req, "-")
+			qb.SetContext(tt.ctx)
+
+			_, err := sff.Process(qb, func(_ *reveald.QueryBuilder) (*reveald.Result, error) {
+				return nil, nil
+			})
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.result, qb.RawQuery())
+		})
+	}
+}
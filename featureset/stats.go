@@ -0,0 +1,66 @@
+package featureset
+
+import (
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+)
+
+const statsAggregationSuffix = "_stats"
+
+type StatsFeature struct {
+	property string
+}
+
+// NewStatsFeature returns min, max, avg and sum of a numeric
+// property in Result.Stats, e.g. the bounds of a price slider.
+// When counting facets disjunctively, the statistics aren't
+// narrowed by a range selected on the same property
+func NewStatsFeature(property string) *StatsFeature {
+	return &StatsFeature{
+		property: property,
+	}
+}
+
+func (sf *StatsFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	sf.build(builder)
+
+	r, err := next(builder)
+	if err != nil {
+		return nil, err
+	}
+
+	return sf.handle(r)
+}
+
+func (sf *StatsFeature) name() string {
+	return sf.property + statsAggregationSuffix
+}
+
+func (sf *StatsFeature) build(builder *reveald.QueryBuilder) {
+	builder.FacetAggregation(sf.property, sf.name(),
+		elastic.NewStatsAggregation().Field(sf.property))
+}
+
+func (sf *StatsFeature) handle(result *reveald.Result) (*reveald.Result, error) {
+	agg, ok := result.RawResult().Aggregations.Stats(sf.name())
+	if !ok {
+		return result, nil
+	}
+
+	stats := &reveald.ResultStats{
+		Count: agg.Count,
+		Min:   agg.Min,
+		Max:   agg.Max,
+		Avg:   agg.Avg,
+	}
+	if agg.Sum != nil {
+		stats.Sum = *agg.Sum
+	}
+
+	if result.Stats == nil {
+		result.Stats = make(map[string]*reveald.ResultStats)
+	}
+
+	result.Stats[sf.property] = stats
+	return result, nil
+}
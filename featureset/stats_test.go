@@ -0,0 +1,42 @@
+package featureset
+
+import (
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_StatsFeature_Build(t *testing.T) {
+	table := []struct {
+		name     string
+		strategy reveald.CountingStrategy
+		filtered bool
+	}{
+		{"conjunctive", reveald.ConjunctiveCounting, false},
+		{"disjunctive on own property", reveald.DisjunctiveCounting("price"), false},
+		{"disjunctive on other property", reveald.DisjunctiveCounting("brand"), true},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			qb := reveald.NewQueryBuilder(reveald.NewRequest(), "-")
+			qb.SetCountingStrategy(tt.strategy)
+			qb.FacetFilter("price", elastic.NewRangeQuery("price").Gte(100))
+			qb.FacetFilter("brand", elastic.NewTermQuery("brand", "acme"))
+			NewStatsFeature("price").build(qb)
+
+			src, err := qb.Build().Source()
+			assert.NoError(t, err)
+
+			agg := src.(map[string]interface{})["aggregations"].(map[string]interface{})["price_stats"].(map[string]interface{})
+			if tt.filtered {
+				assert.Contains(t, agg, "filter")
+				assert.Contains(t, agg["aggregations"], "price_stats")
+			} else {
+				assert.Equal(t, map[string]interface{}{"field": "price"}, agg["stats"])
+			}
+		})
+	}
+}
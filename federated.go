@@ -0,0 +1,103 @@
+package reveald
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// FederatedEndpoint searches several sections at once, such as
+// products, articles and FAQ, where each section is an Endpoint
+// with its own indices and features. The searches of all sections
+// are sent in a single multi search
+type FederatedEndpoint struct {
+	backend  Backend
+	sections []*federatedSection
+}
+
+type federatedSection struct {
+	name     string
+	endpoint *Endpoint
+}
+
+// NewFederatedEndpoint returns a new FederatedEndpoint
+// without any sections
+func NewFederatedEndpoint(backend Backend) *FederatedEndpoint {
+	return &FederatedEndpoint{backend: backend}
+}
+
+// AddSection adds a named section targeting the specified indices,
+// returning its endpoint for registering the section's features.
+// Middleware used on the section endpoint isn't applied when
+// searching through the federated endpoint
+func (f *FederatedEndpoint) AddSection(name string, indices Indices, opts ...EndpointOption) (*Endpoint, error) {
+	for _, s := range f.sections {
+		if s.name == name {
+			return nil, fmt.Errorf("section %s is already added", name)
+		}
+	}
+
+	e := NewEndpoint(f.backend, indices, opts...)
+	f.sections = append(f.sections, &federatedSection{name, e})
+	return e, nil
+}
+
+// FederatedResult holds the results of a federated search,
+// with one section per section of the endpoint, in the order
+// the sections were added
+type FederatedResult struct {
+	Sections      []*ResultSection
+	TotalHitCount int64
+	Duration      time.Duration
+}
+
+// ResultSection is the result of a section
+// of a federated search
+type ResultSection struct {
+	Name   string
+	Result *Result
+}
+
+// Section returns the result of the section with the specified name
+func (r *FederatedResult) Section(name string) (*Result, bool) {
+	for _, s := range r.Sections {
+		if s.Name == name {
+			return s.Result, true
+		}
+	}
+
+	return nil, false
+}
+
+// Execute a search query request in every section, where each
+// section gets its own copy of the request. The search fails if
+// any of the sections fails
+func (f *FederatedEndpoint) Execute(ctx context.Context, request *Request) (*FederatedResult, error) {
+	start := time.Now()
+
+	chains := make([]*suspendedChain, 0, len(f.sections))
+	for _, s := range f.sections {
+		e, req := s.endpoint, request.clone()
+		chains = append(chains, suspend(e.backend, func(search backendFunc) (*Result, error) {
+			return e.run(ctx, req, search)
+		}))
+	}
+
+	results, err := executeSuspended(ctx, f.backend, chains, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	fr := &FederatedResult{}
+	for i, s := range f.sections {
+		if results[i].err != nil {
+			return nil, fmt.Errorf("section %s failed: %w", s.name, results[i].err)
+		}
+
+		fr.Sections = append(fr.Sections, &ResultSection{Name: s.name, Result: results[i].result})
+		fr.TotalHitCount += results[i].result.TotalHitCount
+	}
+
+	fr.Duration = time.Since(start)
+	return fr, nil
+}
@@ -0,0 +1,141 @@
+package reveald
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type multiBackend struct {
+	executed int
+	batches  [][]*QueryBuilder
+	err      error
+}
+
+func (b *multiBackend) Execute(_ context.Context, qb *QueryBuilder) (*Result, error) {
+	b.executed++
+	return &Result{}, nil
+}
+
+func (b *multiBackend) ExecuteMultiple(_ context.Context, qbs []*QueryBuilder) ([]*Result, error) {
+	b.batches = append(b.batches, qbs)
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	results := make([]*Result, 0, len(qbs))
+	for _, qb := range qbs {
+		results = append(results, &Result{TotalHitCount: int64(len(qb.Indices()[0]))})
+	}
+
+	return results, nil
+}
+
+type setParamFeature struct {
+	name  string
+	value string
+}
+
+func (f *setParamFeature) Process(qb *QueryBuilder, next FeatureFunc) (*Result, error) {
+	qb.Request().Set(f.name, f.value)
+	return next(qb)
+}
+
+type shortCircuitFeature struct {
+	err error
+}
+
+func (f *shortCircuitFeature) Process(qb *QueryBuilder, next FeatureFunc) (*Result, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return &Result{TotalHitCount: 100}, nil
+}
+
+func Test_FederatedEndpoint_Execute(t *testing.T) {
+	b := &multiBackend{}
+	f := NewFederatedEndpoint(b)
+
+	products, err := f.AddSection("products", WithIndices("products"))
+	assert.NoError(t, err)
+	assert.NoError(t, products.Register(&setParamFeature{"section", "products"}))
+
+	faq, err := f.AddSection("faq", WithIndices("faq"))
+	assert.NoError(t, err)
+	assert.NoError(t, faq.Register(&setParamFeature{"section", "faq"}))
+
+	_, err = f.AddSection("faq", WithIndices("faq"))
+	assert.Error(t, err)
+
+	req := NewRequest(NewParameter("q", "anvil"))
+	r, err := f.Execute(context.Background(), req)
+	assert.NoError(t, err)
+
+	assert.Len(t, b.batches, 1)
+	assert.Len(t, b.batches[0], 2)
+	assert.Equal(t, 0, b.executed)
+
+	assert.Len(t, r.Sections, 2)
+	assert.Equal(t, "products", r.Sections[0].Name)
+	assert.Equal(t, "faq", r.Sections[1].Name)
+	assert.Equal(t, int64(11), r.TotalHitCount)
+
+	section, ok := r.Section("faq")
+	assert.True(t, ok)
+	assert.Equal(t, int64(3), section.TotalHitCount)
+	p, err := section.Request().Get("section")
+	assert.NoError(t, err)
+	assert.Equal(t, "faq", p.Value())
+
+	assert.False(t, req.Has("section"))
+}
+
+func Test_FederatedEndpoint_ShortCircuit(t *testing.T) {
+	b := &multiBackend{}
+	f := NewFederatedEndpoint(b)
+
+	_, err := f.AddSection("products", WithIndices("products"))
+	assert.NoError(t, err)
+
+	cached, err := f.AddSection("cached", WithIndices("cached"))
+	assert.NoError(t, err)
+	assert.NoError(t, cached.Register(&shortCircuitFeature{}))
+
+	r, err := f.Execute(context.Background(), NewRequest())
+	assert.NoError(t, err)
+	assert.Len(t, b.batches, 1)
+	assert.Len(t, b.batches[0], 1)
+	assert.Equal(t, int64(108), r.TotalHitCount)
+}
+
+func Test_FederatedEndpoint_Errors(t *testing.T) {
+	failing := errors.New("failing")
+
+	table := []struct {
+		name    string
+		backend *multiBackend
+		feature Feature
+	}{
+		{"backend", &multiBackend{err: failing}, &setParamFeature{"a", "b"}},
+		{"feature", &multiBackend{}, &shortCircuitFeature{err: failing}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFederatedEndpoint(tt.backend)
+
+			_, err := f.AddSection("products", WithIndices("products"))
+			assert.NoError(t, err)
+
+			e, err := f.AddSection("articles", WithIndices("articles"))
+			assert.NoError(t, err)
+			assert.NoError(t, e.Register(tt.feature))
+
+			_, err = f.Execute(context.Background(), NewRequest())
+			assert.ErrorIs(t, err, failing)
+		})
+	}
+}
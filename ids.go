@@ -0,0 +1,71 @@
+package reveald
+
+import (
+	"sort"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// WithIDs filters on the documents with any of the ids, e.g.
+// to resolve a list of ids ranked by another system through
+// the features of an endpoint. The ids of the last call are
+// the ones hits are ordered by, with PreserveIDOrder
+func (qb *QueryBuilder) WithIDs(ids ...string) {
+	qb.ids = ids
+	qb.With(elastic.NewIdsQuery().Ids(ids...))
+}
+
+// IDs returns the ids filtered on by WithIDs
+func (qb *QueryBuilder) IDs() []string {
+	return qb.ids
+}
+
+// PreserveIDOrder returns hits in the order of the ids passed
+// to WithIDs, rather than by relevance or sorting. Hits are
+// reordered once returned, so only the hits of the requested
+// page are ordered, and the ids should fit in a single page
+func (qb *QueryBuilder) PreserveIDOrder() {
+	qb.idOrder = true
+}
+
+// orderByIDs reorders the hits of a result by the ids
+// of a query builder, if it preserves their order
+func orderByIDs(qb *QueryBuilder, r *Result) {
+	if !qb.idOrder || len(qb.ids) == 0 {
+		return
+	}
+
+	raw := r.RawResult()
+	if raw == nil || raw.Hits == nil || len(raw.Hits.Hits) != len(r.Hits) {
+		return
+	}
+
+	positions := make(map[string]int, len(qb.ids))
+	for i, id := range qb.ids {
+		if _, ok := positions[id]; !ok {
+			positions[id] = i
+		}
+	}
+
+	type positioned struct {
+		hit      map[string]interface{}
+		position int
+	}
+
+	hits := make([]positioned, len(r.Hits))
+	for i, hit := range raw.Hits.Hits {
+		position, ok := positions[hit.Id]
+		if !ok {
+			position = len(qb.ids)
+		}
+		hits[i] = positioned{r.Hits[i], position}
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].position < hits[j].position
+	})
+
+	for i, h := range hits {
+		r.Hits[i] = h.hit
+	}
+}
@@ -0,0 +1,45 @@
+package reveald
+
+import (
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_OrderByIDs(t *testing.T) {
+	table := []struct {
+		name     string
+		preserve bool
+		hits     []string
+		expected []string
+	}{
+		{"preserved", true, []string{"a", "b", "c"}, []string{"c", "a", "b"}},
+		{"unknown ids last", true, []string{"x", "b", "c"}, []string{"c", "b", "x"}},
+		{"not preserved", false, []string{"a", "b", "c"}, []string{"a", "b", "c"}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			qb := NewQueryBuilder(NewRequest(), "idx")
+			qb.WithIDs("c", "a", "b")
+			if tt.preserve {
+				qb.PreserveIDOrder()
+			}
+
+			r := &Result{result: &elastic.SearchResult{Hits: &elastic.SearchHits{}}}
+			for _, id := range tt.hits {
+				r.result.Hits.Hits = append(r.result.Hits.Hits, &elastic.SearchHit{Id: id})
+				r.Hits = append(r.Hits, map[string]interface{}{"id": id})
+			}
+
+			orderByIDs(qb, r)
+
+			var ids []string
+			for _, hit := range r.Hits {
+				ids = append(ids, hit["id"].(string))
+			}
+			assert.Equal(t, tt.expected, ids)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package reveald
+
+import "github.com/olivere/elastic/v7"
+
+// LintIndexSortMismatch flags sorted requests whose
+// sort doesn't match the sort of the index
+const LintIndexSortMismatch = "index-sort-mismatch"
+
+// IndexSortField is a field of an index sort,
+// as defined by the index.sort settings
+type IndexSortField struct {
+	Field     string
+	Ascending bool
+}
+
+type indexSort struct {
+	fields         []IndexSortField
+	trackTotalHits interface{}
+}
+
+// WithIndexSort declares that the indices of an endpoint are
+// sorted by the specified fields. Requests sorted by a prefix
+// of the index sort, without aggregations, track total hits
+// only up to trackTotalHitsUpTo (or not at all, when 0), which
+// lets Elasticsearch stop collecting once a page is filled.
+// Sorted requests not matching the index sort are flagged by
+// Lint, as they can't benefit from the index sort
+func WithIndexSort(trackTotalHitsUpTo int, fields ...IndexSortField) EndpointOption {
+	return func(e *Endpoint) {
+		var track interface{} = false
+		if trackTotalHitsUpTo > 0 {
+			track = trackTotalHitsUpTo
+		}
+
+		e.indexSort = &indexSort{
+			fields:         fields,
+			trackTotalHits: track,
+		}
+	}
+}
+
+// WithTrackTotalHits defines whether hits are counted, as
+// true, false, or the number of hits to count accurately up to
+func (qb *QueryBuilder) WithTrackTotalHits(track interface{}) {
+	qb.trackTotalHits = track
+}
+
+// totalHitsTracking returns the track_total_hits setting of
+// the request, or else of the selection, defaulting to early
+// termination for requests sorted by the index sort
+func (qb *QueryBuilder) totalHitsTracking() interface{} {
+	if qb.trackTotalHits != nil {
+		return qb.trackTotalHits
+	}
+
+	if qb.selection != nil && qb.selection.trackTotal != nil {
+		return qb.selection.trackTotal
+	}
+
+	if qb.indexSort == nil || len(qb.aggs) > 0 || qb.selection == nil {
+		return nil
+	}
+
+	if !qb.indexSort.matches(qb.selection.Sorters()) {
+		return nil
+	}
+
+	return qb.indexSort.trackTotalHits
+}
+
+// matches returns whether sorters are a prefix of the index sort,
+// in which case Elasticsearch can terminate collection early
+func (is *indexSort) matches(sorters []elastic.Sorter) bool {
+	if len(sorters) == 0 || len(sorters) > len(is.fields) {
+		return false
+	}
+
+	for i, sorter := range sorters {
+		src, err := sorter.Source()
+		if err != nil {
+			return false
+		}
+
+		sort := asMap(src)
+		options, ok := sort[is.fields[i].Field].(map[string]interface{})
+		if !ok || len(sort) != 1 {
+			return false
+		}
+
+		order := "desc"
+		if is.fields[i].Ascending {
+			order = "asc"
+		}
+		if len(options) != 1 || options["order"] != order {
+			return false
+		}
+	}
+
+	return true
+}
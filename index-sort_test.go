@@ -0,0 +1,90 @@
+package reveald
+
+import (
+	"context"
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_IndexSort_TrackTotalHits(t *testing.T) {
+	created := IndexSortField{Field: "created", Ascending: false}
+	id := IndexSortField{Field: "id", Ascending: true}
+
+	table := []struct {
+		name     string
+		opt      EndpointOption
+		sorters  []elastic.Sorter
+		aggs     bool
+		explicit interface{}
+		expected interface{}
+		lint     bool
+	}{
+		{"no index sort", func(*Endpoint) {}, []elastic.Sorter{elastic.NewFieldSort("created").Desc()}, false, nil, nil, false},
+		{"matching sort", WithIndexSort(0, created, id), []elastic.Sorter{elastic.NewFieldSort("created").Desc()}, false, nil, false, false},
+		{"matching sorts", WithIndexSort(1000, created, id), []elastic.Sorter{elastic.NewFieldSort("created").Desc(), elastic.NewFieldSort("id").Asc()}, false, nil, float64(1000), false},
+		{"reversed sort", WithIndexSort(0, created, id), []elastic.Sorter{elastic.NewFieldSort("created").Asc()}, false, nil, nil, true},
+		{"other field", WithIndexSort(0, created, id), []elastic.Sorter{elastic.NewFieldSort("id").Asc()}, false, nil, nil, true},
+		{"relevance", WithIndexSort(0, created, id), nil, false, nil, nil, false},
+		{"aggregations", WithIndexSort(0, created, id), []elastic.Sorter{elastic.NewFieldSort("created").Desc()}, true, nil, nil, false},
+		{"explicit", WithIndexSort(0, created, id), []elastic.Sorter{elastic.NewFieldSort("created").Desc()}, false, true, true, false},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEndpoint(&fakeBackend{}, WithIndices("-"), tt.opt)
+			qb, err := e.PrepareBuilder(context.Background(), NewRequest())
+			assert.NoError(t, err)
+
+			if tt.sorters != nil {
+				qb.Selection().Update(WithSortBy(tt.sorters...))
+			}
+			if tt.aggs {
+				qb.Aggregation("agg", elastic.NewTermsAggregation().Field("f"))
+			}
+			if tt.explicit != nil {
+				qb.WithTrackTotalHits(tt.explicit)
+			}
+
+			assert.Equal(t, tt.expected, sourceJSON(t, qb)["track_total_hits"])
+
+			warnings, err := Lint(qb)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.lint, len(warnings) > 0)
+		})
+	}
+}
+
+func Test_Selection_TrackTotalHits(t *testing.T) {
+	created := IndexSortField{Field: "created", Ascending: false}
+
+	table := []struct {
+		name     string
+		selected interface{}
+		explicit interface{}
+		expected interface{}
+	}{
+		{"index sort", nil, nil, false},
+		{"selection", float64(500), nil, float64(500)},
+		{"explicit before selection", float64(500), true, true},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEndpoint(&fakeBackend{}, WithIndices("-"), WithIndexSort(0, created))
+			qb, err := e.PrepareBuilder(context.Background(), NewRequest())
+			assert.NoError(t, err)
+
+			qb.Selection().Update(WithSortBy(elastic.NewFieldSort("created").Desc()))
+			if tt.selected != nil {
+				qb.Selection().Update(WithTrackTotalHits(tt.selected))
+			}
+			if tt.explicit != nil {
+				qb.WithTrackTotalHits(tt.explicit)
+			}
+
+			assert.Equal(t, tt.expected, sourceJSON(t, qb)["track_total_hits"])
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package reveald
+
+import (
+	"fmt"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// InnerHitsKey is the hit key holding named inner hits, such
+// as the matching nested documents of a nested query, keyed by
+// inner hit name
+const InnerHitsKey = "_inner_hits"
+
+// innerHits maps the named inner hits of a hit, other than
+// those of a collapsed group, including their highlights
+func innerHits(hit *elastic.SearchHit, numbers NumberDecoder) (map[string][]map[string]interface{}, error) {
+	var hits map[string][]map[string]interface{}
+	for name, inner := range hit.InnerHits {
+		if name == CollapsedHitsKey {
+			continue
+		}
+
+		mapped, err := mapInnerHits(inner, numbers)
+		if err != nil {
+			return nil, err
+		}
+
+		if hits == nil {
+			hits = make(map[string][]map[string]interface{})
+		}
+		hits[name] = mapped
+	}
+
+	return hits, nil
+}
+
+func mapInnerHits(inner *elastic.SearchHitInnerHits, numbers NumberDecoder) ([]map[string]interface{}, error) {
+	if inner == nil || inner.Hits == nil {
+		return nil, nil
+	}
+
+	hits := make([]map[string]interface{}, 0, len(inner.Hits.Hits))
+	for _, h := range inner.Hits.Hits {
+		var source map[string]interface{}
+		if err := decodeJSON(h.Source, &source, numbers != nil); err != nil {
+			continue
+		}
+
+		if numbers != nil {
+			if _, err := numbers.convert(source); err != nil {
+				return nil, fmt.Errorf("failed decoding number: %w", err)
+			}
+		}
+
+		if len(h.Highlight) > 0 {
+			source[HighlightsKey] = h.Highlight
+		}
+
+		hits = append(hits, source)
+	}
+
+	return hits, nil
+}
@@ -0,0 +1,40 @@
+package reveald
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MapSearchResult_InnerHits(t *testing.T) {
+	res := &elastic.SearchResult{
+		Hits: &elastic.SearchHits{
+			Hits: []*elastic.SearchHit{{
+				Source: json.RawMessage(`{"id":1}`),
+				InnerHits: map[string]*elastic.SearchHitInnerHits{
+					"reviews": {Hits: &elastic.SearchHits{Hits: []*elastic.SearchHit{{
+						Source:    json.RawMessage(`{"text":"great sound quality"}`),
+						Highlight: elastic.SearchHitHighlight{"reviews.text": {"great <em>sound</em> quality"}},
+					}}}},
+					CollapsedHitsKey: {Hits: &elastic.SearchHits{Hits: []*elastic.SearchHit{
+						{Source: json.RawMessage(`{"id":2}`)},
+					}}},
+				},
+			}, {
+				Source: json.RawMessage(`{"id":3}`),
+			}},
+		},
+	}
+
+	r, err := mapSearchResult(res, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]map[string]interface{}{
+		"reviews": {{
+			"text":        "great sound quality",
+			HighlightsKey: elastic.SearchHitHighlight{"reviews.text": {"great <em>sound</em> quality"}},
+		}},
+	}, r.Hits[0][InnerHitsKey])
+	assert.NotContains(t, r.Hits[1], InnerHitsKey)
+}
@@ -0,0 +1,52 @@
+package reveald
+
+import "github.com/olivere/elastic/v7"
+
+// KNNQuery is an approximate k-nearest neighbour
+// search on a dense vector field
+type KNNQuery struct {
+	Field         string
+	QueryVector   []float64
+	K             int
+	NumCandidates int
+	Boost         float64
+}
+
+// WithKNN adds an approximate kNN search, which is combined
+// with the query. Every clause added with With and Without
+// also becomes a filter of the kNN candidates, including
+// full-text queries such as the one of a QueryFilterFeature,
+// so add relevance queries meant to be blended with the kNN
+// scores with Match or Boost instead
+func (qb *QueryBuilder) WithKNN(knn *KNNQuery) {
+	qb.knn = knn
+}
+
+// KNN returns the approximate kNN search, if any
+func (qb *QueryBuilder) KNN() *KNNQuery {
+	return qb.knn
+}
+
+func (knn *KNNQuery) source(filter elastic.Query) (interface{}, error) {
+	src := map[string]interface{}{
+		"field":          knn.Field,
+		"query_vector":   knn.QueryVector,
+		"k":              knn.K,
+		"num_candidates": knn.NumCandidates,
+	}
+
+	if knn.Boost > 0 {
+		src["boost"] = knn.Boost
+	}
+
+	if filter != nil {
+		f, err := filter.Source()
+		if err != nil {
+			return nil, err
+		}
+
+		src["filter"] = f
+	}
+
+	return src, nil
+}
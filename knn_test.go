@@ -0,0 +1,86 @@
+package reveald
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+func knnSource(t *testing.T, qb *QueryBuilder) map[string]interface{} {
+	src, err := qb.BuildSource()
+	assert.NoError(t, err)
+
+	data, err := json.Marshal(src)
+	assert.NoError(t, err)
+
+	var m map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &m))
+	return m
+}
+
+func Test_KNN_Source(t *testing.T) {
+	qb := NewQueryBuilder(NewRequest())
+	assert.NotContains(t, knnSource(t, qb), "knn")
+
+	qb.WithKNN(&KNNQuery{
+		Field:         "embedding",
+		QueryVector:   []float64{0.1, 0.2},
+		K:             10,
+		NumCandidates: 100,
+		Boost:         2,
+	})
+
+	assert.Equal(t, map[string]interface{}{
+		"field":          "embedding",
+		"query_vector":   []interface{}{0.1, 0.2},
+		"k":              float64(10),
+		"num_candidates": float64(100),
+		"boost":          float64(2),
+	}, knnSource(t, qb)["knn"])
+}
+
+func Test_KNN_Filters(t *testing.T) {
+	table := []struct {
+		name   string
+		build  func(qb *QueryBuilder)
+		filter string
+	}{
+		{"no filters", func(qb *QueryBuilder) {}, ""},
+		{"match", func(qb *QueryBuilder) {
+			qb.Match(elastic.NewMatchQuery("title", "shoes"))
+		}, ""},
+		{"boost", func(qb *QueryBuilder) {
+			qb.Boost(elastic.NewTermQuery("brand", "acme"))
+		}, ""},
+		{"with", func(qb *QueryBuilder) {
+			qb.With(elastic.NewTermQuery("brand", "acme"))
+		}, `{"bool":{"must":{"term":{"brand":"acme"}}}}`},
+		{"without", func(qb *QueryBuilder) {
+			qb.Without(elastic.NewTermQuery("brand", "acme"))
+		}, `{"bool":{"must_not":{"term":{"brand":"acme"}}}}`},
+		{"lexical query added with with", func(qb *QueryBuilder) {
+			qb.With(elastic.NewMatchQuery("title", "shoes"))
+			qb.Match(elastic.NewMatchQuery("description", "shoes"))
+		}, `{"bool":{"must":{"match":{"title":{"query":"shoes"}}}}}`},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			qb := NewQueryBuilder(NewRequest())
+			qb.WithKNN(&KNNQuery{Field: "embedding", QueryVector: []float64{1}, K: 5, NumCandidates: 50})
+			tt.build(qb)
+
+			knn := knnSource(t, qb)["knn"].(map[string]interface{})
+			if tt.filter == "" {
+				assert.NotContains(t, knn, "filter")
+				return
+			}
+
+			data, err := json.Marshal(knn["filter"])
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.filter, string(data))
+		})
+	}
+}
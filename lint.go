@@ -0,0 +1,166 @@
+package reveald
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	// LintLeadingWildcard flags wildcard and query_string
+	// queries starting with a wildcard
+	LintLeadingWildcard = "leading-wildcard"
+	// LintDeepOffset flags offsets deep enough to be costly
+	LintDeepOffset = "deep-offset"
+	// LintScriptQuery flags script queries, which are
+	// evaluated per document
+	LintScriptQuery = "script-query"
+	// LintLargeTermsSize flags terms aggregations with
+	// a very large bucket size
+	LintLargeTermsSize = "large-terms-size"
+
+	lintMaxOffset    = 10000
+	lintMaxTermsSize = 1000
+)
+
+// LintWarning describes a potential performance
+// problem in a search request
+type LintWarning struct {
+	Rule    string
+	Message string
+}
+
+// LintHandler receives the warnings of a linted request
+type LintHandler func(context.Context, *QueryBuilder, []LintWarning)
+
+// WithLintHandler lints every request before it's sent to the
+// backend, passing any warnings to the handler (e.g. to log them)
+func WithLintHandler(handler LintHandler) EndpointOption {
+	return func(e *Endpoint) {
+		e.lint = handler
+	}
+}
+
+// Lint inspects the rendered request for leading wildcards,
+// deep offsets, script queries, unbounded terms sizes, and
+// sorts not matching the index sort
+func Lint(qb *QueryBuilder) ([]LintWarning, error) {
+	src, err := qb.BuildSource()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, err
+	}
+
+	var warnings []LintWarning
+	warn := func(rule, format string, args ...interface{}) {
+		warnings = append(warnings, LintWarning{rule, fmt.Sprintf(format, args...)})
+	}
+
+	if from, ok := body["from"].(float64); ok && from > lintMaxOffset {
+		warn(LintDeepOffset, "offset %0.f exceeds %d, consider search_after", from, lintMaxOffset)
+	}
+
+	for _, key := range []string{"query", "post_filter"} {
+		if q, ok := body[key]; ok {
+			lintQuery(q, true, warn)
+		}
+	}
+
+	if aggs, ok := body["aggregations"]; ok {
+		lintAggregations(aggs, warn)
+	}
+
+	if qb.indexSort != nil && qb.selection != nil {
+		if sorters := qb.selection.Sorters(); len(sorters) > 0 && !qb.indexSort.matches(sorters) {
+			warn(LintIndexSortMismatch, "sort doesn't match the index sort, preventing early termination")
+		}
+	}
+
+	return warnings, nil
+}
+
+// lintClauses are the keys holding query clauses, where
+// a script key is a script query rather than e.g. the
+// script of a function_score script_score function
+var lintClauses = map[string]bool{
+	"must":     true,
+	"must_not": true,
+	"should":   true,
+	"filter":   true,
+	"query":    true,
+	"queries":  true,
+	"positive": true,
+	"negative": true,
+}
+
+func lintQuery(node interface{}, clause bool, warn func(string, string, ...interface{})) {
+	switch n := node.(type) {
+	case []interface{}:
+		for _, v := range n {
+			lintQuery(v, clause, warn)
+		}
+	case map[string]interface{}:
+		for key, v := range n {
+			switch key {
+			case "wildcard":
+				for field, value := range asMap(v) {
+					pattern, ok := value.(string)
+					if !ok {
+						pattern, _ = asMap(value)["value"].(string)
+					}
+					if hasLeadingWildcard(pattern) {
+						warn(LintLeadingWildcard, "wildcard query on %s starts with a wildcard", field)
+					}
+				}
+				continue
+			case "query_string":
+				if query, ok := asMap(v)["query"].(string); ok && hasLeadingWildcard(query) {
+					warn(LintLeadingWildcard, "query_string query starts with a wildcard")
+				}
+				continue
+			case "script":
+				if clause {
+					warn(LintScriptQuery, "script query is evaluated for every document")
+					continue
+				}
+			}
+
+			lintQuery(v, lintClauses[key], warn)
+		}
+	}
+}
+
+func lintAggregations(node interface{}, warn func(string, string, ...interface{})) {
+	for name, agg := range asMap(node) {
+		a := asMap(agg)
+		if size, ok := asMap(a["terms"])["size"].(float64); ok && size > lintMaxTermsSize {
+			warn(LintLargeTermsSize, "terms aggregation %s has size %0.f, exceeding %d", name, size, lintMaxTermsSize)
+		}
+
+		for _, key := range []string{"aggregations", "aggs"} {
+			if sub, ok := a[key]; ok {
+				lintAggregations(sub, warn)
+			}
+		}
+	}
+}
+
+func hasLeadingWildcard(s string) bool {
+	s = strings.TrimSpace(s)
+	return strings.HasPrefix(s, "*") || strings.HasPrefix(s, "?")
+}
+
+func asMap(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
@@ -0,0 +1,93 @@
+package reveald
+
+import (
+	"context"
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+func lintRules(warnings []LintWarning) []string {
+	var rules []string
+	for _, w := range warnings {
+		rules = append(rules, w.Rule)
+	}
+	return rules
+}
+
+func Test_Lint(t *testing.T) {
+	table := []struct {
+		name  string
+		build func(*QueryBuilder)
+		rules []string
+	}{
+		{"clean", func(qb *QueryBuilder) {
+			qb.With(elastic.NewTermQuery("a", "b"))
+			qb.Aggregation("a", elastic.NewTermsAggregation().Field("a").Size(10))
+		}, nil},
+		{"leading wildcard", func(qb *QueryBuilder) {
+			qb.With(elastic.NewWildcardQuery("name", "*son"))
+		}, []string{LintLeadingWildcard}},
+		{"leading wildcard in query string", func(qb *QueryBuilder) {
+			qb.With(elastic.NewQueryStringQuery("*son"))
+		}, []string{LintLeadingWildcard}},
+		{"deep offset", func(qb *QueryBuilder) {
+			qb.Selection().Update(WithOffset(20000))
+		}, []string{LintDeepOffset}},
+		{"script query", func(qb *QueryBuilder) {
+			qb.With(elastic.NewBoolQuery().Filter(elastic.NewScriptQuery(elastic.NewScript("true"))))
+		}, []string{LintScriptQuery}},
+		{"must script query", func(qb *QueryBuilder) {
+			qb.With(elastic.NewScriptQuery(elastic.NewScript("true")))
+		}, []string{LintScriptQuery}},
+		{"script score function", func(qb *QueryBuilder) {
+			qb.Boost(elastic.NewFunctionScoreQuery().
+				Query(elastic.NewTermQuery("a", "b")).
+				AddScoreFunc(elastic.NewScriptFunction(elastic.NewScript("_score * 2"))))
+		}, nil},
+		{"script score function with script filter", func(qb *QueryBuilder) {
+			qb.Boost(elastic.NewFunctionScoreQuery().
+				Add(elastic.NewScriptQuery(elastic.NewScript("true")), elastic.NewWeightFactorFunction(2)))
+		}, []string{LintScriptQuery}},
+		{"large terms size", func(qb *QueryBuilder) {
+			qb.Aggregation("a", elastic.NewTermsAggregation().Field("a").Size(50000))
+		}, []string{LintLargeTermsSize}},
+		{"large nested terms size", func(qb *QueryBuilder) {
+			qb.Aggregation("a", elastic.NewNestedAggregation().Path("a").
+				SubAggregation("b", elastic.NewTermsAggregation().Field("a.b").Size(50000)))
+		}, []string{LintLargeTermsSize}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			qb := NewQueryBuilder(nil, "idx")
+			tt.build(qb)
+
+			warnings, err := Lint(qb)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.rules, lintRules(warnings))
+		})
+	}
+}
+
+func Test_LintHandler(t *testing.T) {
+	var warnings []LintWarning
+	e := NewEndpoint(&fakeBackend{}, WithIndices("idx"), WithLintHandler(func(_ context.Context, _ *QueryBuilder, w []LintWarning) {
+		warnings = w
+	}))
+	assert.NoError(t, e.Register(&offsetFeature{20000}))
+
+	_, err := e.Execute(context.Background(), NewRequest())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{LintDeepOffset}, lintRules(warnings))
+}
+
+type offsetFeature struct {
+	offset int
+}
+
+func (f *offsetFeature) Process(qb *QueryBuilder, next FeatureFunc) (*Result, error) {
+	qb.Selection().Update(WithOffset(f.offset))
+	return next(qb)
+}
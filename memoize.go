@@ -0,0 +1,89 @@
+package reveald
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// memoizedAggregations returns the aggregations to send, rendered
+// for the counting strategy, where aggregations identical to another
+// one (by canonical JSON) are only sent once, and recorded as aliases
+// to be restored from the response by UnwrapAggregations
+func (qb *QueryBuilder) memoizedAggregations() map[string]elastic.Aggregation {
+	names := make([]string, 0, len(qb.aggs))
+	for name := range qb.aggs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	aggs := make(map[string]elastic.Aggregation, len(names))
+	seen := make(map[string]string, len(names))
+	qb.aggAliases = nil
+	for _, name := range names {
+		agg, _ := qb.facetAggregation(name, qb.withSubAggregations(name, qb.aggs[name]))
+
+		key, ok := qb.aggregationKey(name)
+		if primary, duplicate := seen[key]; ok && duplicate {
+			if qb.aggAliases == nil {
+				qb.aggAliases = make(map[string]string)
+			}
+			qb.aggAliases[name] = primary
+			continue
+		}
+
+		if ok {
+			seen[key] = name
+		}
+		aggs[name] = agg
+	}
+
+	return aggs
+}
+
+// aggregationKey renders an aggregation as canonical JSON, independent
+// of its name, which only appears in the counting strategy's wrapper
+func (qb *QueryBuilder) aggregationKey(name string) (string, bool) {
+	var filters []interface{}
+	if _, wrapped := qb.facetAggregation(name, nil); wrapped {
+		facet := name
+		if f, ok := qb.aggFacets[name]; ok {
+			facet = f
+		}
+
+		for _, ff := range qb.facetFilters {
+			if ff.facet == facet {
+				continue
+			}
+
+			src, err := ff.query.Source()
+			if err != nil {
+				return "", false
+			}
+			filters = append(filters, src)
+		}
+	}
+
+	src, err := qb.withSubAggregations(name, qb.aggs[name]).Source()
+	if err != nil {
+		return "", false
+	}
+
+	data, err := json.Marshal([]interface{}{filters, src})
+	if err != nil {
+		return "", false
+	}
+
+	return string(data), true
+}
+
+// restoreAliases copies the response of memoized
+// aggregations to the names of their duplicates
+func (qb *QueryBuilder) restoreAliases(result *elastic.SearchResult) {
+	for name, primary := range qb.aggAliases {
+		if raw, ok := result.Aggregations[primary]; ok {
+			result.Aggregations[name] = raw
+		}
+	}
+}
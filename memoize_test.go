@@ -0,0 +1,52 @@
+package reveald
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_QueryBuilder_MemoizedAggregations(t *testing.T) {
+	qb := NewQueryBuilder(NewRequest(), "-")
+	qb.SetCountingStrategy(DisjunctiveCounting("brand", "color"))
+	qb.FacetFilter("brand", elastic.NewTermQuery("brand", "acme"))
+
+	qb.Aggregation("color", elastic.NewTermsAggregation().Field("color"))
+	qb.Aggregation("color_copy", elastic.NewTermsAggregation().Field("color"))
+	qb.Aggregation("brand", elastic.NewTermsAggregation().Field("color"))
+	qb.Aggregation("size", elastic.NewTermsAggregation().Field("size"))
+
+	aggs := sourceJSON(t, qb)["aggregations"].(map[string]interface{})
+	assert.ElementsMatch(t, []string{"brand", "color", "size"}, keys(aggs))
+
+	var result elastic.SearchResult
+	assert.NoError(t, json.Unmarshal([]byte(`{"aggregations": {
+		"brand": {"buckets": [{"key": "red", "doc_count": 3}]},
+		"color": {"doc_count": 2, "color": {"buckets": [{"key": "red", "doc_count": 2}]}},
+		"size": {"buckets": []}
+	}}`), &result))
+
+	qb.UnwrapAggregations(&result)
+
+	color, ok := result.Aggregations.Terms("color")
+	assert.True(t, ok)
+	assert.Equal(t, int64(2), color.Buckets[0].DocCount)
+
+	copied, ok := result.Aggregations.Terms("color_copy")
+	assert.True(t, ok)
+	assert.Equal(t, color, copied)
+
+	brand, ok := result.Aggregations.Terms("brand")
+	assert.True(t, ok)
+	assert.Equal(t, int64(3), brand.Buckets[0].DocCount)
+}
+
+func keys(m map[string]interface{}) []string {
+	var k []string
+	for key := range m {
+		k = append(k, key)
+	}
+	return k
+}
@@ -0,0 +1,79 @@
+package reveald
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Metrics receives measurements of executed search
+// requests, e.g. for exposing them to Prometheus
+type Metrics interface {
+	// RequestExecuted observes the total duration
+	// of a request, and whether it failed
+	RequestExecuted(ctx context.Context, duration time.Duration, err error)
+	// FeatureProcessed observes the time spent in a
+	// feature, excluding the features it calls
+	FeatureProcessed(ctx context.Context, feature string, duration time.Duration)
+	// BackendExecuted observes the time Elasticsearch
+	// reports having spent on a query
+	BackendExecuted(ctx context.Context, took time.Duration)
+}
+
+// NopMetrics discards all measurements
+type NopMetrics struct{}
+
+func (NopMetrics) RequestExecuted(context.Context, time.Duration, error)   {}
+func (NopMetrics) FeatureProcessed(context.Context, string, time.Duration) {}
+func (NopMetrics) BackendExecuted(context.Context, time.Duration)          {}
+
+// WithMetrics defines where an endpoint reports its measurements
+func WithMetrics(m Metrics) EndpointOption {
+	return func(e *Endpoint) {
+		e.metrics = m
+	}
+}
+
+// meteredFeature measures the processing time of
+// a feature, excluding the time spent in next
+type meteredFeature struct {
+	feature Feature
+	name    string
+	metrics Metrics
+}
+
+func newMeteredFeature(feature Feature, metrics Metrics) *meteredFeature {
+	named := feature
+	if pf, ok := feature.(*preparedFeature); ok {
+		named = pf.feature
+	}
+
+	return &meteredFeature{
+		feature: feature,
+		name:    strings.TrimPrefix(fmt.Sprintf("%T", named), "*"),
+		metrics: metrics,
+	}
+}
+
+func (mf *meteredFeature) Process(builder *QueryBuilder, next FeatureFunc) (*Result, error) {
+	var inner time.Duration
+	start := time.Now()
+	r, err := mf.feature.Process(builder, func(qb *QueryBuilder) (*Result, error) {
+		s := time.Now()
+		r, err := next(qb)
+		inner += time.Since(s)
+		return r, err
+	})
+
+	mf.metrics.FeatureProcessed(builder.Context(), mf.name, time.Since(start)-inner)
+	return r, err
+}
+
+func observeBackend(ctx context.Context, metrics Metrics, result *Result) {
+	if result == nil || result.RawResult() == nil {
+		return
+	}
+
+	metrics.BackendExecuted(ctx, time.Duration(result.RawResult().TookInMillis)*time.Millisecond)
+}
@@ -0,0 +1,53 @@
+package reveald
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeMetrics struct {
+	requests []error
+	features []string
+}
+
+func (m *fakeMetrics) RequestExecuted(_ context.Context, _ time.Duration, err error) {
+	m.requests = append(m.requests, err)
+}
+
+func (m *fakeMetrics) FeatureProcessed(_ context.Context, feature string, _ time.Duration) {
+	m.features = append(m.features, feature)
+}
+
+func (m *fakeMetrics) BackendExecuted(context.Context, time.Duration) {}
+
+type failingBackend struct{}
+
+func (failingBackend) Execute(context.Context, *QueryBuilder) (*Result, error) {
+	return nil, errors.New("unavailable")
+}
+
+func (failingBackend) ExecuteMultiple(context.Context, []*QueryBuilder) ([]*Result, error) {
+	return nil, errors.New("unavailable")
+}
+
+func Test_Endpoint_WithMetrics(t *testing.T) {
+	m := &fakeMetrics{}
+	e := NewEndpoint(&fakeBackend{}, WithIndices("-"), WithMetrics(m))
+	assert.NoError(t, e.Register(&offsetFeature{}))
+
+	_, err := e.Execute(context.Background(), NewRequest())
+	assert.NoError(t, err)
+	assert.Equal(t, []error{nil}, m.requests)
+	assert.Equal(t, []string{"reveald.offsetFeature"}, m.features)
+
+	m = &fakeMetrics{}
+	e = NewEndpoint(failingBackend{}, WithIndices("-"), WithMetrics(m))
+	_, err = e.Execute(context.Background(), NewRequest())
+	assert.Error(t, err)
+	assert.Len(t, m.requests, 1)
+	assert.ErrorContains(t, m.requests[0], "unavailable")
+}
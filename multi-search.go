@@ -0,0 +1,185 @@
+package reveald
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrMultiSearchUnsupported is returned by backends unable to
+// execute multi searches, in which case the searches are executed
+// individually instead
+var ErrMultiSearchUnsupported = errors.New("multi search is not supported")
+
+// WithFallbackConcurrency caps the number of concurrent searches
+// when falling back on individual searches, for backends unable to
+// execute multi searches. Zero, the default, doesn't cap them
+func WithFallbackConcurrency(concurrency int) EndpointOption {
+	return func(e *Endpoint) {
+		e.fallbackConcurrency = concurrency
+	}
+}
+
+type chainOutcome struct {
+	result *Result
+	err    error
+}
+
+// suspendedChain is a feature chain running up to its search,
+// where it waits for the search to be executed along with the
+// searches of other chains
+type suspendedChain struct {
+	builder chan *QueryBuilder
+	search  chan chainOutcome
+	done    chan chainOutcome
+
+	outcome *chainOutcome
+}
+
+// suspend starts a feature chain, where the first search
+// is suspended, and any retries, such as a search without
+// aggregations, are sent on their own as a multi search
+func suspend(backend Backend, run func(backendFunc) (*Result, error)) *suspendedChain {
+	c := &suspendedChain{
+		builder: make(chan *QueryBuilder, 1),
+		search:  make(chan chainOutcome, 1),
+		done:    make(chan chainOutcome, 1),
+	}
+
+	suspended := false
+	go func() {
+		r, err := run(func(ctx context.Context, qb *QueryBuilder) (*Result, error) {
+			if suspended {
+				outcomes, err := multiSearch(ctx, backend, []*QueryBuilder{qb}, 0)
+				if err != nil {
+					return nil, err
+				}
+				return outcomes[0].result, outcomes[0].err
+			}
+			suspended = true
+
+			c.builder <- qb
+			o := <-c.search
+			return o.result, o.err
+		})
+
+		c.done <- chainOutcome{r, err}
+	}()
+
+	return c
+}
+
+// wait returns the query builder of a suspended search, or
+// false when the chain completed without searching
+func (c *suspendedChain) wait() (*QueryBuilder, bool) {
+	select {
+	case qb := <-c.builder:
+		return qb, true
+	case o := <-c.done:
+		c.outcome = &o
+		return nil, false
+	}
+}
+
+// resume continues a suspended chain with the
+// outcome of its search, until the chain completes
+func (c *suspendedChain) resume(o chainOutcome) {
+	c.search <- o
+	done := <-c.done
+	c.outcome = &done
+}
+
+// executeSuspended executes the suspended searches of a set of
+// feature chains in a single multi search, and resumes the chains
+// with their results, returning the outcome of every chain
+func executeSuspended(ctx context.Context, backend Backend, chains []*suspendedChain, concurrency int) ([]chainOutcome, error) {
+	var pending []*suspendedChain
+	var builders []*QueryBuilder
+	for _, c := range chains {
+		if qb, ok := c.wait(); ok {
+			pending = append(pending, c)
+			builders = append(builders, qb)
+		}
+	}
+
+	searched, err := multiSearch(ctx, backend, builders, concurrency)
+	for i, c := range pending {
+		if err != nil {
+			c.resume(chainOutcome{err: err})
+			continue
+		}
+
+		c.resume(searched[i])
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("backend failed executing requests: %w", err)
+	}
+
+	outcomes := make([]chainOutcome, 0, len(chains))
+	for _, c := range chains {
+		outcomes = append(outcomes, *c.outcome)
+	}
+
+	return outcomes, nil
+}
+
+// multiSearch executes a set of searches in a single multi
+// search, or individually when multi search is unsupported
+func multiSearch(ctx context.Context, backend Backend, builders []*QueryBuilder, concurrency int) ([]chainOutcome, error) {
+	if len(builders) == 0 {
+		return nil, nil
+	}
+
+	results, err := backend.ExecuteMultiple(ctx, builders)
+	if errors.Is(err, ErrMultiSearchUnsupported) {
+		return searchEach(ctx, backend, builders, concurrency), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(results) != len(builders) {
+		return nil, errors.New("number of results does not match number of searches")
+	}
+
+	outcomes := make([]chainOutcome, 0, len(results))
+	for _, r := range results {
+		if r == nil {
+			outcomes = append(outcomes, chainOutcome{err: errors.New("missing result of search")})
+			continue
+		}
+
+		outcomes = append(outcomes, chainOutcome{result: r})
+	}
+
+	return outcomes, nil
+}
+
+// searchEach executes searches individually, at most
+// concurrency at a time, or all at once when zero
+func searchEach(ctx context.Context, backend Backend, builders []*QueryBuilder, concurrency int) []chainOutcome {
+	if concurrency <= 0 {
+		concurrency = len(builders)
+	}
+
+	outcomes := make([]chainOutcome, len(builders))
+	sem := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	for i, qb := range builders {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			r, err := backend.Execute(ctx, qb)
+			outcomes[i] = chainOutcome{r, err}
+		}()
+	}
+
+	wg.Wait()
+	return outcomes
+}
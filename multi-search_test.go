@@ -0,0 +1,130 @@
+package reveald
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type labelFeature struct{}
+
+func (labelFeature) Process(qb *QueryBuilder, next FeatureFunc) (*Result, error) {
+	r, err := next(qb)
+	if err != nil {
+		return nil, err
+	}
+
+	p, _ := qb.Request().Get("label")
+	r.Aggregations = map[string][]*ResultBucket{"label": {{Value: p.Value()}}}
+	return r, nil
+}
+
+func Test_Endpoint_ExecuteMultiple(t *testing.T) {
+	b := &multiBackend{}
+	e := NewEndpoint(b, WithIndices("products"))
+	assert.NoError(t, e.Register(labelFeature{}))
+
+	results, err := e.ExecuteMultiple(context.Background(), []*Request{
+		NewRequest(NewParameter("label", "a")),
+		NewRequest(NewParameter("label", "b")),
+	})
+	assert.NoError(t, err)
+
+	assert.Len(t, b.batches, 1)
+	assert.Len(t, b.batches[0], 2)
+	assert.Equal(t, 0, b.executed)
+
+	assert.Len(t, results, 2)
+	assert.Equal(t, "a", results[0].Aggregations["label"][0].Value)
+	assert.Equal(t, "b", results[1].Aggregations["label"][0].Value)
+	assert.True(t, results[1].Request().Has("label"))
+}
+
+type singleSearchBackend struct {
+	mu      sync.Mutex
+	active  int
+	maxSeen int
+}
+
+func (b *singleSearchBackend) Execute(_ context.Context, qb *QueryBuilder) (*Result, error) {
+	b.mu.Lock()
+	b.active++
+	if b.active > b.maxSeen {
+		b.maxSeen = b.active
+	}
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		b.active--
+		b.mu.Unlock()
+	}()
+
+	return &Result{TotalHitCount: 1}, nil
+}
+
+func (b *singleSearchBackend) ExecuteMultiple(context.Context, []*QueryBuilder) ([]*Result, error) {
+	return nil, fmt.Errorf("proxy rejected request: %w", ErrMultiSearchUnsupported)
+}
+
+func Test_Endpoint_ExecuteMultiple_Fallback(t *testing.T) {
+	b := &singleSearchBackend{}
+	e := NewEndpoint(b, WithIndices("products"), WithFallbackConcurrency(2))
+	assert.NoError(t, e.Register(labelFeature{}))
+
+	var requests []*Request
+	for i := 0; i < 8; i++ {
+		requests = append(requests, NewRequest(NewParameter("label", fmt.Sprint(i))))
+	}
+
+	results, err := e.ExecuteMultiple(context.Background(), requests)
+	assert.NoError(t, err)
+	assert.Len(t, results, 8)
+	for i, r := range results {
+		assert.Equal(t, int64(1), r.TotalHitCount)
+		assert.Equal(t, fmt.Sprint(i), r.Aggregations["label"][0].Value)
+	}
+
+	assert.LessOrEqual(t, b.maxSeen, 2)
+}
+
+// limitedMultiBackend rejects searches with aggregations, and
+// multi searches, so searches are executed individually
+type limitedMultiBackend struct {
+	limitedBackend
+	multi int
+}
+
+func (b *limitedMultiBackend) ExecuteMultiple(context.Context, []*QueryBuilder) ([]*Result, error) {
+	b.multi++
+	return nil, ErrMultiSearchUnsupported
+}
+
+func Test_Endpoint_ExecuteMultiple_AggregationFallback(t *testing.T) {
+	b := &limitedMultiBackend{}
+	e := NewEndpoint(b, WithIndices("products"), WithFallbackConcurrency(1))
+	assert.NoError(t, e.Register(&fakePreparable{}))
+
+	results, err := e.ExecuteMultiple(context.Background(), []*Request{NewRequest(), NewRequest()})
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	for _, r := range results {
+		assert.Equal(t, WarningAggregationsDropped, r.Warnings[0].Code)
+	}
+
+	// the batch, and then each retry, goes through the multi search path
+	assert.Equal(t, 3, b.multi)
+}
+
+func Test_ElasticBackend_ExecuteMultiple_Unsupported(t *testing.T) {
+	b := newTestBackend(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+
+	_, err := b.ExecuteMultiple(context.Background(), []*QueryBuilder{NewQueryBuilder(NewRequest(), "products")})
+	assert.ErrorIs(t, err, ErrMultiSearchUnsupported)
+}
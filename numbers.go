@@ -0,0 +1,92 @@
+package reveald
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// NumberDecoder converts numbers in hits, fields and
+// aggregation keys, e.g. into decimals for money values
+type NumberDecoder func(json.Number) (interface{}, error)
+
+// WithNumberDecoder decodes numbers in results using the
+// specified decoder, instead of into float64 which corrupts
+// large integers and decimal values
+func WithNumberDecoder(decoder NumberDecoder) ElasticBackendOption {
+	return func(b *ElasticBackend) {
+		b.numbers = decoder
+		b.opts = append(b.opts, elastic.SetDecoder(numberPreservingDecoder{}))
+	}
+}
+
+// WithJSONNumbers decodes numbers in results as json.Number
+func WithJSONNumbers() ElasticBackendOption {
+	return WithNumberDecoder(func(n json.Number) (interface{}, error) {
+		return n, nil
+	})
+}
+
+// numberPreservingDecoder decodes Elasticsearch
+// responses without losing numeric precision
+type numberPreservingDecoder struct{}
+
+// Decode decodes numbers into json.Number
+func (numberPreservingDecoder) Decode(data []byte, v interface{}) error {
+	return decodeJSON(data, v, true)
+}
+
+func decodeJSON(data []byte, v interface{}, useNumber bool) error {
+	if !useNumber {
+		return json.Unmarshal(data, v)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// convert replaces json.Number values,
+// recursively, using the decoder
+func (nd NumberDecoder) convert(v interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case json.Number:
+		return nd(t)
+	case map[string]interface{}:
+		for k, child := range t {
+			c, err := nd.convert(child)
+			if err != nil {
+				return nil, err
+			}
+
+			t[k] = c
+		}
+	case []interface{}:
+		for i, child := range t {
+			c, err := nd.convert(child)
+			if err != nil {
+				return nil, err
+			}
+
+			t[i] = c
+		}
+	}
+
+	return v, nil
+}
+
+// BucketKey returns the key of an aggregation bucket,
+// decoding numeric keys with the backend's NumberDecoder
+func (r *Result) BucketKey(bucket *elastic.AggregationBucketKeyItem) interface{} {
+	if _, numeric := bucket.Key.(float64); !numeric || r.numbers == nil || bucket.KeyNumber == "" {
+		return bucket.Key
+	}
+
+	key, err := r.numbers(bucket.KeyNumber)
+	if err != nil {
+		return bucket.Key
+	}
+
+	return key
+}
@@ -0,0 +1,74 @@
+package reveald
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MapSearchResult_Numbers(t *testing.T) {
+	res := &elastic.SearchResult{
+		Hits: &elastic.SearchHits{
+			Hits: []*elastic.SearchHit{{
+				Source: json.RawMessage(`{"id":9007199254740993,"price":19.99,"tags":[{"weight":0.1}]}`),
+				Fields: elastic.SearchHitFields{"total": []interface{}{json.Number("123456789012345678")}},
+			}},
+		},
+	}
+
+	table := []struct {
+		name     string
+		numbers  NumberDecoder
+		expected map[string]interface{}
+	}{
+		{"float64", nil, map[string]interface{}{
+			"id":    float64(9007199254740993),
+			"price": 19.99,
+			"tags":  []interface{}{map[string]interface{}{"weight": 0.1}},
+			"total": json.Number("123456789012345678"),
+		}},
+		{"json.Number", func(n json.Number) (interface{}, error) { return n, nil }, map[string]interface{}{
+			"id":    json.Number("9007199254740993"),
+			"price": json.Number("19.99"),
+			"tags":  []interface{}{map[string]interface{}{"weight": json.Number("0.1")}},
+			"total": json.Number("123456789012345678"),
+		}},
+		{"custom", func(n json.Number) (interface{}, error) { return n.String(), nil }, map[string]interface{}{
+			"id":    "9007199254740993",
+			"price": "19.99",
+			"tags":  []interface{}{map[string]interface{}{"weight": "0.1"}},
+			"total": "123456789012345678",
+		}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := mapSearchResult(res, tt.numbers)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, r.Hits[0])
+		})
+	}
+}
+
+func Test_Result_BucketKey(t *testing.T) {
+	var terms elastic.AggregationBucketKeyItems
+	assert.NoError(t, json.Unmarshal([]byte(`{"buckets":[{"key":9007199254740993,"doc_count":1},{"key":"acme","doc_count":1}]}`), &terms))
+
+	r := &Result{numbers: func(n json.Number) (interface{}, error) { return n, nil }}
+	assert.Equal(t, json.Number("9007199254740993"), r.BucketKey(terms.Buckets[0]))
+	assert.Equal(t, "acme", r.BucketKey(terms.Buckets[1]))
+
+	assert.Equal(t, float64(9007199254740993), (&Result{}).BucketKey(terms.Buckets[0]))
+}
+
+func Test_MapBuckets_Numbers(t *testing.T) {
+	aggs := elastic.Aggregations{
+		"ids": json.RawMessage(`{"buckets":[{"key":9007199254740993,"doc_count":1}]}`),
+	}
+
+	buckets, ok := mapBuckets(aggs, "ids", func(n json.Number) (interface{}, error) { return n, nil })
+	assert.True(t, ok)
+	assert.Equal(t, json.Number("9007199254740993"), buckets[0].Value)
+}
@@ -0,0 +1,121 @@
+package reveald
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"net/url"
+	"strings"
+	"text/template"
+)
+
+// parameterDoc is a parameter, as rendered in documentation
+type parameterDoc struct {
+	ParameterDescription
+	Format  string
+	Example string
+}
+
+var markdownDocs = template.Must(template.New("markdown").Parse(
+	`# Parameters of {{ .Path }}
+
+| Parameter | Format | Description | Values |
+| --- | --- | --- | --- |
+{{ range .Params -}}
+| ` + "`{{ .Name }}`" + ` | {{ .Format }} | {{ .Description }} | {{ range $i, $v := .Values }}{{ if $i }}, {{ end }}` + "`{{ $v }}`" + `{{ end }} |
+{{ end }}
+## Examples
+{{ range .Params }}
+- ` + "`{{ .Example }}`" + `{{ end }}
+`))
+
+var htmlDocs = htmltemplate.Must(htmltemplate.New("html").Parse(
+	`<h1>Parameters of <code>{{ .Path }}</code></h1>
+<table>
+<thead><tr><th>Parameter</th><th>Format</th><th>Description</th><th>Values</th><th>Example</th></tr></thead>
+<tbody>
+{{- range .Params }}
+<tr><td><code>{{ .Name }}</code></td><td>{{ .Format }}</td><td>{{ .Description }}</td><td>{{ range $i, $v := .Values }}{{ if $i }}, {{ end }}<code>{{ $v }}</code>{{ end }}</td><td><a href="{{ .Example }}"><code>{{ .Example }}</code></a></td></tr>
+{{- end }}
+</tbody>
+</table>
+`))
+
+// WriteParameterMarkdown writes Markdown documentation of the request
+// parameters of an endpoint served at path, with their value formats,
+// accepted values and example URLs
+func WriteParameterMarkdown(w io.Writer, path string, params []ParameterDescription) error {
+	return markdownDocs.Execute(w, parameterDocs(path, params))
+}
+
+// WriteParameterHTML writes HTML documentation of the request
+// parameters of an endpoint served at path, like WriteParameterMarkdown
+func WriteParameterHTML(w io.Writer, path string, params []ParameterDescription) error {
+	return htmlDocs.Execute(w, parameterDocs(path, params))
+}
+
+func parameterDocs(path string, params []ParameterDescription) interface{} {
+	docs := make([]parameterDoc, 0, len(params))
+	for _, p := range params {
+		docs = append(docs, parameterDoc{
+			ParameterDescription: p,
+			Format:               parameterFormat(p),
+			Example:              path + "?" + exampleQuery(p),
+		})
+	}
+
+	return struct {
+		Path   string
+		Params []parameterDoc
+	}{path, docs}
+}
+
+func parameterFormat(p ParameterDescription) string {
+	switch p.Kind {
+	case ParameterMultiValue:
+		return "repeatable"
+	case ParameterRange:
+		return fmt.Sprintf("range, as %s%s and %s%s", p.Name, rangeMinSuffix, p.Name, rangeMaxSuffix)
+	case ParameterBoolean:
+		return "true or false"
+	default:
+		return "single value"
+	}
+}
+
+// exampleQuery returns an example query string for a parameter,
+// using its examples, or else its accepted values
+func exampleQuery(p ParameterDescription) string {
+	examples := p.Examples
+	if len(examples) == 0 {
+		examples = p.Values
+	}
+
+	values := url.Values{}
+	switch p.Kind {
+	case ParameterRange:
+		if len(examples) > 0 {
+			values.Set(p.Name+rangeMinSuffix, examples[0])
+		}
+		if len(examples) > 1 {
+			values.Set(p.Name+rangeMaxSuffix, examples[1])
+		}
+	case ParameterMultiValue:
+		if len(examples) > 2 {
+			examples = examples[:2]
+		}
+		values[p.Name] = examples
+	case ParameterBoolean:
+		values.Set(p.Name, "true")
+	default:
+		if len(examples) > 0 {
+			values.Set(p.Name, examples[0])
+		}
+	}
+
+	if len(values) == 0 {
+		values.Set(p.Name, "")
+	}
+
+	return strings.ReplaceAll(values.Encode(), "+", "%20")
+}
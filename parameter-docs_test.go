@@ -0,0 +1,75 @@
+package reveald
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type describedFeature struct {
+	params []ParameterDescription
+}
+
+func (f *describedFeature) Describe() []ParameterDescription {
+	return f.params
+}
+
+func (f *describedFeature) Process(qb *QueryBuilder, next FeatureFunc) (*Result, error) {
+	return next(qb)
+}
+
+func describedEndpoint(t *testing.T) *Endpoint {
+	e := NewEndpoint(&fakeBackend{}, WithIndices("-"))
+	assert.NoError(t, e.Register(
+		&describedFeature{[]ParameterDescription{
+			{Name: "brand", Kind: ParameterMultiValue, Description: "Brands", Examples: []string{"acme", "globex", "initech"}},
+			{Name: "price", Kind: ParameterRange, Description: "Price", Examples: []string{"10", "100"}},
+		}},
+		&offsetFeature{},
+		&describedFeature{[]ParameterDescription{
+			{Name: "brand", Kind: ParameterValue, Description: "Shadowed"},
+			{Name: "sort", Kind: ParameterValue, Description: "Sorting", Values: []string{"newest", "price-asc"}},
+			{Name: "inStock", Kind: ParameterBoolean, Description: "In stock"},
+		}},
+	))
+
+	return e
+}
+
+func Test_Endpoint_Parameters(t *testing.T) {
+	params := describedEndpoint(t).Parameters()
+
+	var names []string
+	for _, p := range params {
+		names = append(names, p.Name)
+	}
+
+	assert.Equal(t, []string{"brand", "price", "sort", "inStock"}, names)
+	assert.Equal(t, "Brands", params[0].Description)
+}
+
+func Test_WriteParameterMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, WriteParameterMarkdown(&buf, "/search", describedEndpoint(t).Parameters()))
+
+	doc := buf.String()
+	assert.Contains(t, doc, "# Parameters of /search")
+	assert.Contains(t, doc, "| `brand` | repeatable | Brands |  |")
+	assert.Contains(t, doc, "| `price` | range, as price.min and price.max | Price |  |")
+	assert.Contains(t, doc, "| `sort` | single value | Sorting | `newest`, `price-asc` |")
+	assert.Contains(t, doc, "- `/search?brand=acme&brand=globex`")
+	assert.Contains(t, doc, "- `/search?price.max=100&price.min=10`")
+	assert.Contains(t, doc, "- `/search?sort=newest`")
+	assert.Contains(t, doc, "- `/search?inStock=true`")
+}
+
+func Test_WriteParameterHTML(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, WriteParameterHTML(&buf, "/search", describedEndpoint(t).Parameters()))
+
+	doc := buf.String()
+	assert.Contains(t, doc, "<h1>Parameters of <code>/search</code></h1>")
+	assert.Contains(t, doc, `<a href="/search?brand=acme&amp;brand=globex"><code>/search?brand=acme&amp;brand=globex</code></a>`)
+	assert.Contains(t, doc, "<td><code>newest</code>, <code>price-asc</code></td>")
+}
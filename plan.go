@@ -0,0 +1,279 @@
+package reveald
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// PreparableFeature is a Feature whose contributions to a query
+// don't depend on the request. When registered on an Endpoint,
+// Prepare is called once and its rendered output is reused by
+// every request, in place of calling Process. Features preparing
+// anything but query clauses, aggregations, script fields and
+// docvalue fields are processed per request instead
+type PreparableFeature interface {
+	Feature
+	Prepare(*QueryBuilder)
+}
+
+// conditionallyPreparable is implemented by preparable
+// features that may depend on the request after all,
+// in which case they are processed per request
+type conditionallyPreparable interface {
+	Preparable() bool
+}
+
+// rawAggregation is a pre-rendered aggregation
+type rawAggregation json.RawMessage
+
+// Source returns the pre-rendered aggregation
+func (ra rawAggregation) Source() (interface{}, error) {
+	return json.RawMessage(ra), nil
+}
+
+// plan holds the features of an Endpoint, in registration
+// order, where preparable features are replaced by their
+// pre-rendered query fragments
+type plan struct {
+	features []Feature
+}
+
+func newPlan(features []Feature) (*plan, error) {
+	p := &plan{}
+
+	for _, feature := range features {
+		pf, ok := feature.(PreparableFeature)
+		if cp, isConditional := feature.(conditionallyPreparable); isConditional && !cp.Preparable() {
+			ok = false
+		}
+
+		if !ok {
+			p.features = append(p.features, feature)
+			continue
+		}
+
+		// facet filters depend on the counting strategy, so
+		// they're kept apart from plain filters, and aren't cached
+		template := NewQueryBuilder(nil)
+		template.SetCountingStrategy(DisjunctiveCounting())
+		pf.Prepare(template)
+
+		// features preparing anything the plan can't replay,
+		// e.g. a post filter or highlighting, aren't cached
+		if !replayable(template) {
+			p.features = append(p.features, feature)
+			continue
+		}
+
+		prepared, err := newPreparedFeature(feature, template)
+		if err != nil {
+			return nil, err
+		}
+
+		p.features = append(p.features, prepared)
+	}
+
+	return p, nil
+}
+
+// replayable returns whether a query builder only holds state
+// a preparedFeature replays: query clauses, aggregations along
+// with their sub-aggregations, script fields and docvalue fields
+func replayable(template *QueryBuilder) bool {
+	clauses, err := renderClauses(template.root)
+	if err != nil {
+		return false
+	}
+	for occur := range clauses {
+		if occur != "must" && occur != "must_not" && occur != "should" {
+			return false
+		}
+	}
+
+	for path := range template.subAggs {
+		root, _, _ := strings.Cut(path, SubAggregationSeparator)
+		if _, ok := template.aggs[root]; !ok {
+			return false
+		}
+	}
+
+	rest := *template
+	fresh := NewQueryBuilder(nil)
+	rest.root = fresh.root
+	rest.filter = fresh.filter
+	rest.filterClauses = fresh.filterClauses
+	rest.aggs = fresh.aggs
+	rest.subAggs = fresh.subAggs
+	rest.scriptedFields = fresh.scriptedFields
+	rest.docValueFields = fresh.docValueFields
+	rest.counting = fresh.counting
+
+	return reflect.DeepEqual(&rest, fresh)
+}
+
+// preparedFeature replays the pre-rendered query
+// fragments of a preparable feature
+type preparedFeature struct {
+	feature        Feature
+	with           []elastic.Query
+	match          []elastic.Query
+	without        []elastic.Query
+	boost          []elastic.Query
+	aggs           map[string]elastic.Aggregation
+	scriptedFields []*elastic.ScriptField
+	docValueFields []string
+}
+
+func newPreparedFeature(feature Feature, template *QueryBuilder) (*preparedFeature, error) {
+	pf := &preparedFeature{
+		feature:        feature,
+		aggs:           make(map[string]elastic.Aggregation, len(template.aggs)),
+		scriptedFields: template.scriptedFields,
+		docValueFields: template.docValueFields,
+	}
+
+	if err := pf.renderQuery(template); err != nil {
+		return nil, err
+	}
+
+	for name, agg := range template.aggs {
+		data, err := render(template.withSubAggregations(name, agg))
+		if err != nil {
+			return nil, fmt.Errorf("failed preparing aggregation %s: %w", name, err)
+		}
+
+		pf.aggs[name] = rawAggregation(data)
+	}
+
+	return pf, nil
+}
+
+// renderQuery renders the query clauses of a template, where must
+// clauses also filtering kNN candidates were added with With,
+// rather than Match
+func (pf *preparedFeature) renderQuery(template *QueryBuilder) error {
+	root, err := renderClauses(template.root)
+	if err != nil {
+		return fmt.Errorf("failed preparing query: %w", err)
+	}
+
+	filter, err := renderClauses(template.filter)
+	if err != nil {
+		return fmt.Errorf("failed preparing query: %w", err)
+	}
+
+	filters := make(map[string]int)
+	for _, clause := range filter["must"] {
+		filters[string(clause)]++
+	}
+
+	for _, clause := range root["must"] {
+		q := elastic.NewRawStringQuery(string(clause))
+		if filters[string(clause)] > 0 {
+			filters[string(clause)]--
+			pf.with = append(pf.with, q)
+			continue
+		}
+
+		pf.match = append(pf.match, q)
+	}
+	for _, clause := range root["must_not"] {
+		pf.without = append(pf.without, elastic.NewRawStringQuery(string(clause)))
+	}
+	for _, clause := range root["should"] {
+		pf.boost = append(pf.boost, elastic.NewRawStringQuery(string(clause)))
+	}
+
+	return nil
+}
+
+// Process seeds the query builder with the pre-rendered fragments
+func (pf *preparedFeature) Process(qb *QueryBuilder, next FeatureFunc) (*Result, error) {
+	for _, q := range pf.with {
+		qb.With(q)
+	}
+	for _, q := range pf.match {
+		qb.Match(q)
+	}
+	for _, q := range pf.without {
+		qb.Without(q)
+	}
+	for _, q := range pf.boost {
+		qb.Boost(q)
+	}
+	for name, agg := range pf.aggs {
+		qb.Aggregation(name, agg)
+	}
+	for _, sf := range pf.scriptedFields {
+		qb.WithScriptedField(sf)
+	}
+	if len(pf.docValueFields) > 0 {
+		qb.DocvalueFields(pf.docValueFields...)
+	}
+
+	return next(qb)
+}
+
+// renderClauses renders the clauses of a bool query by occurrence
+func renderClauses(query *elastic.BoolQuery) (map[string][]json.RawMessage, error) {
+	src, err := query.Source()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var rendered struct {
+		Bool map[string]json.RawMessage `json:"bool"`
+	}
+	if err := json.Unmarshal(data, &rendered); err != nil {
+		return nil, err
+	}
+
+	clauses := make(map[string][]json.RawMessage, len(rendered.Bool))
+	for occur, data := range rendered.Bool {
+		split, err := splitClauses(data)
+		if err != nil {
+			return nil, err
+		}
+
+		clauses[occur] = split
+	}
+
+	return clauses, nil
+}
+
+// splitClauses handles bool query clauses being
+// rendered either as a single object or as an array
+func splitClauses(data json.RawMessage) ([]json.RawMessage, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	if data[0] != '[' {
+		return []json.RawMessage{data}, nil
+	}
+
+	var clauses []json.RawMessage
+	if err := json.Unmarshal(data, &clauses); err != nil {
+		return nil, err
+	}
+
+	return clauses, nil
+}
+
+func render(agg elastic.Aggregation) (json.RawMessage, error) {
+	src, err := agg.Source()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(src)
+}
@@ -0,0 +1,171 @@
+package reveald
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeBackend struct {
+	builders []*QueryBuilder
+}
+
+func (b *fakeBackend) Execute(_ context.Context, qb *QueryBuilder) (*Result, error) {
+	b.builders = append(b.builders, qb)
+	return &Result{}, nil
+}
+
+func (b *fakeBackend) ExecuteMultiple(_ context.Context, qbs []*QueryBuilder) ([]*Result, error) {
+	b.builders = append(b.builders, qbs...)
+	return make([]*Result, len(qbs)), nil
+}
+
+type fakePreparable struct {
+	prepared  int
+	processed int
+}
+
+func (f *fakePreparable) Prepare(qb *QueryBuilder) {
+	f.prepared++
+	qb.With(elastic.NewTermQuery("a", "b"))
+	qb.With(elastic.NewExistsQuery("c"))
+	qb.Boost(elastic.NewTermQuery("d", "e"))
+	qb.Aggregation("agg", elastic.NewTermsAggregation().Field("f"))
+}
+
+func (f *fakePreparable) Process(qb *QueryBuilder, next FeatureFunc) (*Result, error) {
+	f.processed++
+	f.Prepare(qb)
+	return next(qb)
+}
+
+func sourceJSON(t *testing.T, qb *QueryBuilder) map[string]interface{} {
+	src, err := qb.Build().Source()
+	assert.NoError(t, err)
+
+	data, err := json.Marshal(src)
+	assert.NoError(t, err)
+
+	var m map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &m))
+	return m
+}
+
+func Test_Plan_Prepares_Once(t *testing.T) {
+	f := &fakePreparable{}
+	b := &fakeBackend{}
+
+	e := NewEndpoint(b, WithIndices("idx"))
+	assert.NoError(t, e.Register(f, &fakeF{}))
+
+	for i := 0; i < 3; i++ {
+		_, err := e.Execute(context.Background(), NewRequest())
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, 1, f.prepared)
+	assert.Equal(t, 0, f.processed)
+	assert.Len(t, b.builders, 3)
+}
+
+func Test_Plan_Matches_Processed_Query(t *testing.T) {
+	b := &fakeBackend{}
+	e := NewEndpoint(b, WithIndices("idx"))
+	assert.NoError(t, e.Register(&fakePreparable{}))
+
+	_, err := e.Execute(context.Background(), NewRequest())
+	assert.NoError(t, err)
+
+	expected := NewQueryBuilder(nil, "idx")
+	_, err = (&fakePreparable{}).Process(expected, func(_ *QueryBuilder) (*Result, error) {
+		return nil, nil
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, sourceJSON(t, expected), sourceJSON(t, b.builders[0]))
+}
+
+type funcPreparable struct {
+	prepare   func(*QueryBuilder)
+	processed int
+}
+
+func (f *funcPreparable) Prepare(qb *QueryBuilder) {
+	f.prepare(qb)
+}
+
+func (f *funcPreparable) Process(qb *QueryBuilder, next FeatureFunc) (*Result, error) {
+	f.processed++
+	f.prepare(qb)
+	return next(qb)
+}
+
+func Test_Plan_Processes_Unreplayable_Features(t *testing.T) {
+	table := []struct {
+		name    string
+		prepare func(*QueryBuilder)
+		cached  bool
+	}{
+		{"query clauses", func(qb *QueryBuilder) {
+			qb.With(elastic.NewTermQuery("a", "b"))
+			qb.Match(elastic.NewMatchQuery("c", "d"))
+		}, true},
+		{"sub-aggregation", func(qb *QueryBuilder) {
+			qb.Aggregation("a", elastic.NewTermsAggregation().Field("a"))
+			qb.SubAggregation("a", "b", elastic.NewTermsAggregation().Field("b"))
+		}, true},
+		{"post filter", func(qb *QueryBuilder) {
+			qb.PostFilterWith(elastic.NewTermQuery("a", "b"))
+		}, false},
+		{"facet filter", func(qb *QueryBuilder) {
+			qb.FacetFilter("a", elastic.NewTermQuery("a", "b"))
+		}, false},
+		{"sub-aggregation of another feature", func(qb *QueryBuilder) {
+			qb.SubAggregation("a", "b", elastic.NewTermsAggregation().Field("b"))
+		}, false},
+		{"highlight", func(qb *QueryBuilder) {
+			qb.WithHighlight("a")
+		}, false},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &funcPreparable{prepare: tt.prepare}
+			b := &fakeBackend{}
+			e := NewEndpoint(b, WithIndices("idx"))
+			assert.NoError(t, e.Register(f))
+
+			_, err := e.Execute(context.Background(), NewRequest())
+			assert.NoError(t, err)
+			assert.Equal(t, !tt.cached, f.processed > 0)
+
+			expected := NewQueryBuilder(nil, "idx")
+			tt.prepare(expected)
+			assert.Equal(t, sourceJSON(t, expected), sourceJSON(t, b.builders[0]))
+			assert.Equal(t, expected.filterClauses, b.builders[0].filterClauses)
+		})
+	}
+}
+
+type orderFeature struct {
+	clauses *[]int
+}
+
+func (f *orderFeature) Process(qb *QueryBuilder, next FeatureFunc) (*Result, error) {
+	*f.clauses = append(*f.clauses, qb.filterClauses)
+	return next(qb)
+}
+
+func Test_Plan_Keeps_Registration_Order(t *testing.T) {
+	var clauses []int
+	b := &fakeBackend{}
+	e := NewEndpoint(b, WithIndices("idx"))
+	assert.NoError(t, e.Register(&orderFeature{&clauses}, &fakePreparable{}, &orderFeature{&clauses}))
+
+	_, err := e.Execute(context.Background(), NewRequest())
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 2}, clauses)
+}
@@ -0,0 +1,48 @@
+package reveald
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNoPrincipal is returned when a search requires
+// an authenticated principal, and the context has none
+var ErrNoPrincipal = errors.New("no principal in context")
+
+// Principal is the authenticated caller of a search, where
+// Attributes hold claims such as the categories or regions
+// the principal is entitled to
+type Principal struct {
+	ID         string
+	Roles      []string
+	Groups     []string
+	Attributes map[string][]string
+}
+
+type principalKey struct{}
+
+// ContextWithPrincipal returns a copy of the context
+// carrying the specified principal
+func ContextWithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the principal carried
+// by the context, if any
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// HasRole returns whether the principal has any of the roles
+func (p *Principal) HasRole(roles ...string) bool {
+	for _, have := range p.Roles {
+		for _, role := range roles {
+			if have == role {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,92 @@
+package reveald
+
+import (
+	"time"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// ResultProfile holds the timing breakdown of a
+// profiled search, per shard
+type ResultProfile struct {
+	Shards []*ResultShardProfile
+}
+
+// ResultShardProfile holds the timing breakdown of the query,
+// aggregation, and fetch phases of a profiled search on a shard
+type ResultShardProfile struct {
+	ID           string
+	Query        []*ResultProfileNode
+	RewriteTime  time.Duration
+	Aggregations []*ResultProfileNode
+	Fetch        *ResultProfileNode
+}
+
+// ResultProfileNode is a node of a profiled query or aggregation
+// tree, where Breakdown holds the time in nanoseconds spent on
+// each low level operation (e.g. "next_doc" or "build_scorer")
+type ResultProfileNode struct {
+	Type        string
+	Description string
+	Time        time.Duration
+	Breakdown   map[string]int64
+	Children    []*ResultProfileNode
+}
+
+// WithProfile profiles the execution of the search, adding
+// a timing breakdown of its phases to Result.Profile. Profiling
+// adds overhead, so it's meant for diagnosing slow queries
+func (qb *QueryBuilder) WithProfile() {
+	qb.profile = true
+}
+
+// Profile returns whether the search is profiled
+func (qb *QueryBuilder) Profile() bool {
+	return qb.profile
+}
+
+func mapProfile(p *elastic.SearchProfile) *ResultProfile {
+	if p == nil {
+		return nil
+	}
+
+	profile := &ResultProfile{}
+	for _, shard := range p.Shards {
+		sp := &ResultShardProfile{
+			ID:           shard.ID,
+			Aggregations: mapProfileNodes(shard.Aggregations),
+		}
+
+		for _, search := range shard.Searches {
+			sp.Query = append(sp.Query, mapProfileNodes(search.Query)...)
+			sp.RewriteTime += time.Duration(search.RewriteTime)
+		}
+
+		if shard.Fetch != nil {
+			sp.Fetch = mapProfileNode(*shard.Fetch)
+		}
+
+		profile.Shards = append(profile.Shards, sp)
+	}
+
+	return profile
+}
+
+func mapProfileNodes(results []elastic.ProfileResult) []*ResultProfileNode {
+	var nodes []*ResultProfileNode
+	for _, r := range results {
+		nodes = append(nodes, mapProfileNode(r))
+	}
+
+	return nodes
+}
+
+func mapProfileNode(r elastic.ProfileResult) *ResultProfileNode {
+	return &ResultProfileNode{
+		Type:        r.Type,
+		Description: r.Description,
+		Time:        time.Duration(r.NodeTimeNanos),
+		Breakdown:   r.Breakdown,
+		Children:    mapProfileNodes(r.Children),
+	}
+}
@@ -0,0 +1,55 @@
+package reveald
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MapSearchResult_Profile(t *testing.T) {
+	var res elastic.SearchResult
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"hits": {"hits": []},
+		"profile": {"shards": [{
+			"id": "[node][index][0]",
+			"searches": [{
+				"query": [{
+					"type": "BooleanQuery",
+					"description": "+brand:acme",
+					"time_in_nanos": 1500,
+					"breakdown": {"next_doc": 500},
+					"children": [{"type": "TermQuery", "description": "brand:acme", "time_in_nanos": 1000}]
+				}],
+				"rewrite_time": 200
+			}],
+			"aggregations": [{"type": "StringTermsAggregator", "description": "brand", "time_in_nanos": 3000}]
+		}]}
+	}`), &res))
+
+	r, err := mapSearchResult(&res, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &ResultProfile{Shards: []*ResultShardProfile{{
+		ID: "[node][index][0]",
+		Query: []*ResultProfileNode{{
+			Type:        "BooleanQuery",
+			Description: "+brand:acme",
+			Time:        1500 * time.Nanosecond,
+			Breakdown:   map[string]int64{"next_doc": 500},
+			Children:    []*ResultProfileNode{{Type: "TermQuery", Description: "brand:acme", Time: time.Microsecond}},
+		}},
+		RewriteTime:  200 * time.Nanosecond,
+		Aggregations: []*ResultProfileNode{{Type: "StringTermsAggregator", Description: "brand", Time: 3 * time.Microsecond}},
+	}}}, r.Profile)
+}
+
+func Test_QueryBuilder_WithProfile(t *testing.T) {
+	qb := NewQueryBuilder(NewRequest(), "-")
+	assert.NotContains(t, sourceJSON(t, qb), "profile")
+
+	qb.WithProfile()
+	assert.True(t, qb.Profile())
+	assert.Equal(t, true, sourceJSON(t, qb)["profile"])
+}
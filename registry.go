@@ -0,0 +1,101 @@
+package reveald
+
+import (
+	"fmt"
+	"sync"
+)
+
+// UnknownFeatureError is returned when a feature
+// isn't defined in a FeatureRegistry
+type UnknownFeatureError struct {
+	Name string
+}
+
+func (e *UnknownFeatureError) Error() string {
+	return fmt.Sprintf("feature %q is not defined", e.Name)
+}
+
+// FeatureRegistry holds named features, defined once and reused
+// by multiple endpoints, keeping their configuration consistent
+type FeatureRegistry struct {
+	mu       sync.RWMutex
+	parent   *FeatureRegistry
+	features map[string]Feature
+}
+
+// NewFeatureRegistry returns an empty feature registry
+func NewFeatureRegistry() *FeatureRegistry {
+	return &FeatureRegistry{
+		features: make(map[string]Feature),
+	}
+}
+
+// Define adds a named feature, failing if the
+// name is already defined in the registry
+func (r *FeatureRegistry) Define(name string, feature Feature) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.features[name]; ok {
+		return fmt.Errorf("feature %q is already defined", name)
+	}
+
+	r.features[name] = feature
+	return nil
+}
+
+// Override replaces a named feature, failing if the name isn't
+// defined in the registry or any registry it derives from
+func (r *FeatureRegistry) Override(name string, feature Feature) error {
+	if _, err := r.Get(name); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.features[name] = feature
+	return nil
+}
+
+// Derive returns a registry inheriting all features of this
+// registry, where overrides only apply to the derived registry
+// (e.g. for a country-specific set of endpoints)
+func (r *FeatureRegistry) Derive() *FeatureRegistry {
+	d := NewFeatureRegistry()
+	d.parent = r
+	return d
+}
+
+// Get returns a named feature
+func (r *FeatureRegistry) Get(name string) (Feature, error) {
+	r.mu.RLock()
+	feature, ok := r.features[name]
+	r.mu.RUnlock()
+
+	if ok {
+		return feature, nil
+	}
+
+	if r.parent != nil {
+		return r.parent.Get(name)
+	}
+
+	return nil, &UnknownFeatureError{Name: name}
+}
+
+// Features returns the named features in the
+// specified order, for registering on an endpoint
+func (r *FeatureRegistry) Features(names ...string) ([]Feature, error) {
+	features := make([]Feature, 0, len(names))
+	for _, name := range names {
+		feature, err := r.Get(name)
+		if err != nil {
+			return nil, err
+		}
+
+		features = append(features, feature)
+	}
+
+	return features, nil
+}
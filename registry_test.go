@@ -0,0 +1,35 @@
+package reveald
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FeatureRegistry(t *testing.T) {
+	brand, country := &fakePreparable{prepared: 1}, &fakePreparable{prepared: 2}
+	local := &fakePreparable{prepared: 3}
+
+	registry := NewFeatureRegistry()
+	assert.NoError(t, registry.Define("brand-facet", brand))
+	assert.NoError(t, registry.Define("country-facet", country))
+	assert.EqualError(t, registry.Define("brand-facet", brand), `feature "brand-facet" is already defined`)
+
+	derived := registry.Derive()
+	assert.NoError(t, derived.Override("country-facet", local))
+
+	var unknown *UnknownFeatureError
+	assert.ErrorAs(t, derived.Override("color-facet", local), &unknown)
+	assert.Equal(t, "color-facet", unknown.Name)
+
+	features, err := registry.Features("country-facet", "brand-facet")
+	assert.NoError(t, err)
+	assert.Equal(t, []Feature{country, brand}, features)
+
+	features, err = derived.Features("brand-facet", "country-facet")
+	assert.NoError(t, err)
+	assert.Equal(t, []Feature{brand, local}, features)
+
+	_, err = derived.Features("brand-facet", "color-facet")
+	assert.EqualError(t, err, `feature "color-facet" is not defined`)
+}
@@ -0,0 +1,130 @@
+package reveald
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// jsonRequest is the JSON search request schema, e.g.
+//
+//	{
+//	  "q": "shoes",
+//	  "filters": {"brand": ["acme", "globex"], "in_stock": true},
+//	  "range": {"price": {"min": 10, "max": 100}},
+//	  "sort": "price-asc",
+//	  "page": {"offset": 24, "size": 24}
+//	}
+type jsonRequest struct {
+	Query   *string                    `json:"q"`
+	Filters map[string]json.RawMessage `json:"filters"`
+	Range   map[string]jsonRange       `json:"range"`
+	Sort    json.RawMessage            `json:"sort"`
+	Page    *jsonPage                  `json:"page"`
+}
+
+type jsonRange struct {
+	Min *json.Number `json:"min"`
+	Max *json.Number `json:"max"`
+}
+
+type jsonPage struct {
+	Offset *int `json:"offset"`
+	Size   *int `json:"size"`
+}
+
+// ParseJSONRequest creates a Request from a JSON search request,
+// where q, sort, and page map to the q, sort, offset and size
+// parameters, each filter maps to a parameter with one or more
+// values, and each range maps to a range parameter, as if parsed
+// from price.min and price.max. Unknown fields are rejected
+func ParseJSONRequest(r io.Reader) (*Request, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	dec.DisallowUnknownFields()
+
+	var body jsonRequest
+	if err := dec.Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed parsing request: %w", err)
+	}
+
+	req := NewRequest()
+	if body.Query != nil {
+		req.Append(NewParameter("q", *body.Query))
+	}
+
+	for name, raw := range body.Filters {
+		values, err := jsonValues(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed parsing request: filter %s: %w", name, err)
+		}
+
+		if len(values) > 0 {
+			req.Append(NewParameter(name, values...))
+		}
+	}
+
+	for name, r := range body.Range {
+		if r.Min != nil {
+			req.Append(NewParameter(name+rangeMinSuffix, r.Min.String()))
+		}
+		if r.Max != nil {
+			req.Append(NewParameter(name+rangeMaxSuffix, r.Max.String()))
+		}
+	}
+
+	if len(body.Sort) > 0 {
+		values, err := jsonValues(body.Sort)
+		if err != nil {
+			return nil, fmt.Errorf("failed parsing request: sort: %w", err)
+		}
+
+		if len(values) > 0 {
+			req.Append(NewParameter("sort", values...))
+		}
+	}
+
+	if body.Page != nil {
+		if body.Page.Offset != nil {
+			req.Append(NewParameter("offset", strconv.Itoa(*body.Page.Offset)))
+		}
+		if body.Page.Size != nil {
+			req.Append(NewParameter("size", strconv.Itoa(*body.Page.Size)))
+		}
+	}
+
+	return req, nil
+}
+
+// jsonValues converts a scalar or an array of scalars into
+// parameter values
+func jsonValues(raw json.RawMessage) ([]string, error) {
+	var v interface{}
+	if err := decodeJSON(raw, &v, true); err != nil {
+		return nil, err
+	}
+
+	list, ok := v.([]interface{})
+	if !ok {
+		list = []interface{}{v}
+	}
+
+	values := make([]string, 0, len(list))
+	for _, item := range list {
+		switch value := item.(type) {
+		case nil:
+			continue
+		case string:
+			values = append(values, value)
+		case json.Number:
+			values = append(values, value.String())
+		case bool:
+			values = append(values, strconv.FormatBool(value))
+		default:
+			return nil, fmt.Errorf("unsupported value %v", value)
+		}
+	}
+
+	return values, nil
+}
@@ -0,0 +1,101 @@
+package reveald
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseJSONRequest(t *testing.T) {
+	r, err := ParseJSONRequest(strings.NewReader(`{
+		"q": "red shoes",
+		"filters": {"brand": ["acme", "globex"], "in_stock": true, "rating": 4, "color": null},
+		"range": {"price": {"min": 10, "max": 99.5}, "weight": {"max": 2}},
+		"sort": ["price-asc", "rating-desc"],
+		"page": {"offset": 24, "size": 12}
+	}`))
+	assert.NoError(t, err)
+
+	values := func(name string) []string {
+		p, err := r.Get(name)
+		assert.NoError(t, err)
+		return p.Values()
+	}
+
+	assert.Equal(t, []string{"red shoes"}, values("q"))
+	assert.Equal(t, []string{"acme", "globex"}, values("brand"))
+	assert.Equal(t, []string{"true"}, values("in_stock"))
+	assert.Equal(t, []string{"4"}, values("rating"))
+	assert.False(t, r.Has("color"))
+	assert.Equal(t, []string{"price-asc", "rating-desc"}, values("sort"))
+	assert.Equal(t, []string{"24"}, values("offset"))
+	assert.Equal(t, []string{"12"}, values("size"))
+
+	price, err := r.Get("price")
+	assert.NoError(t, err)
+	min, wmin := price.Min()
+	max, wmax := price.Max()
+	assert.True(t, wmin && wmax)
+	assert.Equal(t, 10.0, min)
+	assert.Equal(t, 99.5, max)
+
+	weight, err := r.Get("weight")
+	assert.NoError(t, err)
+	_, wmin = weight.Min()
+	max, wmax = weight.Max()
+	assert.False(t, wmin)
+	assert.True(t, wmax)
+	assert.Equal(t, 2.0, max)
+}
+
+func Test_ParseJSONRequest_Range_Bounds(t *testing.T) {
+	table := []struct {
+		name string
+		body string
+		min  float64
+		max  float64
+		wmin bool
+		wmax bool
+	}{
+		{"zero min", `{"range":{"price":{"min":0,"max":100}}}`, 0, 100, true, true},
+		{"zero max", `{"range":{"temp":{"min":-10,"max":0}}}`, -10, 0, true, true},
+		{"negative", `{"range":{"temp":{"min":-5,"max":-1}}}`, -5, -1, true, true},
+		{"only zero min", `{"range":{"price":{"min":0}}}`, 0, 0, true, false},
+		{"only negative max", `{"range":{"temp":{"max":-3.5}}}`, 0, -3.5, false, true},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := ParseJSONRequest(strings.NewReader(tt.body))
+			assert.NoError(t, err)
+			assert.Len(t, r.Params(), 1)
+
+			min, wmin := r.Params()[0].Min()
+			max, wmax := r.Params()[0].Max()
+			assert.Equal(t, tt.wmin, wmin)
+			assert.Equal(t, tt.wmax, wmax)
+			assert.Equal(t, tt.min, min)
+			assert.Equal(t, tt.max, max)
+		})
+	}
+}
+
+func Test_ParseJSONRequest_Invalid(t *testing.T) {
+	table := []struct {
+		name string
+		body string
+	}{
+		{"malformed", `{"q":`},
+		{"unknown field", `{"query": "shoes"}`},
+		{"object filter", `{"filters": {"brand": {"name": "acme"}}}`},
+		{"nested array filter", `{"filters": {"brand": [["acme"]]}}`},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseJSONRequest(strings.NewReader(tt.body))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -2,6 +2,8 @@ package reveald
 
 import (
 	"fmt"
+	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 )
@@ -11,6 +13,9 @@ const (
 	RangeMinParameterName string = "min"
 	// RangeMaxParameterName is the default prefix for a maximum range bound
 	RangeMaxParameterName string = "max"
+
+	rangeMinSuffix = "." + RangeMinParameterName
+	rangeMaxSuffix = "." + RangeMaxParameterName
 )
 
 // Parameter is used for filtering documents
@@ -30,23 +35,27 @@ type Parameter struct {
 // for handling special cases such as range query
 // parameters
 func NewParameter(name string, values ...string) Parameter {
-	pv := Parameter{}
-	pv.name = name
-	pv.values = values
+	pv := Parameter{
+		name:   name,
+		values: values,
+	}
 
-	var err error
+	if len(values) == 0 {
+		return pv
+	}
 
-	for _, v := range values {
-		if strings.HasSuffix(name, "."+RangeMinParameterName) {
-			pv.min, err = strconv.ParseFloat(v, 64)
-			pv.wmin = err == nil
-			pv.name = name[:len(name)-len("."+RangeMinParameterName)]
-		}
-		if strings.HasSuffix(name, "."+RangeMaxParameterName) {
-			pv.max, err = strconv.ParseFloat(v, 64)
-			pv.wmax = err == nil
-			pv.name = name[:len(name)-len("."+RangeMaxParameterName)]
-		}
+	var err error
+	last := values[len(values)-1]
+
+	switch {
+	case strings.HasSuffix(name, rangeMinSuffix):
+		pv.min, err = strconv.ParseFloat(last, 64)
+		pv.wmin = err == nil
+		pv.name = name[:len(name)-len(rangeMinSuffix)]
+	case strings.HasSuffix(name, rangeMaxSuffix):
+		pv.max, err = strconv.ParseFloat(last, 64)
+		pv.wmax = err == nil
+		pv.name = name[:len(name)-len(rangeMaxSuffix)]
 	}
 
 	return pv
@@ -84,15 +93,16 @@ func (pv Parameter) Max() (float64, bool) {
 	return pv.max, pv.wmax
 }
 
-// Merge a parameter with another parameter
+// Merge a parameter with another parameter, taking
+// the range bounds it doesn't set from the other one
 func (pv Parameter) Merge(m Parameter) Parameter {
 	pv.values = append(pv.values, m.values...)
 
-	if pv.min == 0 && m.min > 0 {
+	if !pv.wmin && m.wmin {
 		pv.min = m.min
 		pv.wmin = true
 	}
-	if pv.max == 0 && m.max > 0 {
+	if !pv.wmax && m.wmax {
 		pv.max = m.max
 		pv.wmax = true
 	}
@@ -119,16 +129,23 @@ func (pv Parameter) Values() []string {
 	return pv.values
 }
 
-// Request is a set of Parameter
+// Request is a set of Parameter, along with any
+// opaque metadata attached by the caller
+//
+// Parameters are kept in the order they were added, and
+// looked up by name, which for the handful of parameters
+// of a search request is cheaper than hashing into a map.
+// Range bounds of the same name are merged on Append
 type Request struct {
-	params map[string]Parameter
+	params []Parameter
+	meta   map[string]interface{}
 }
 
 // NewRequest create a new typed set of the
 // specified parameters
 func NewRequest(params ...Parameter) *Request {
 	q := &Request{
-		params: make(map[string]Parameter),
+		params: make([]Parameter, 0, len(params)),
 	}
 
 	for _, p := range params {
@@ -138,65 +155,222 @@ func NewRequest(params ...Parameter) *Request {
 	return q
 }
 
+// ParseQueryValues creates a Request from query string values
+// in a single pass, where keys ending with .min or .max are
+// merged into a range parameter. Values are used as is, see
+// ParseValues for normalizing them
+func ParseQueryValues(values url.Values) *Request {
+	q := &Request{
+		params: make([]Parameter, 0, len(values)),
+	}
+
+	for name, v := range values {
+		q.Append(NewParameter(name, v[:len(v):len(v)]...))
+	}
+
+	return q
+}
+
+// ParseValues creates a Request from query string or form
+// values, following these conventions:
+//
+//   - repeated keys are multiple values of a parameter
+//     (brand=a&brand=b), also when suffixed with [] (brand[]=a)
+//   - keys ending with .min or .max are merged into a range
+//     parameter (price.min=10&price.max=20)
+//   - values are trimmed, and empty values are dropped, along
+//     with parameters left without values
+//   - pagination and sort parameters (e.g. offset, size, and
+//     sort) are passed as is, for the features reading them
+func ParseValues(values url.Values) *Request {
+	q := &Request{
+		params: make([]Parameter, 0, len(values)),
+	}
+
+	for name, v := range values {
+		name = strings.TrimSuffix(strings.TrimSpace(name), "[]")
+		if name == "" {
+			continue
+		}
+
+		parsed := normalizeValues(v)
+		if len(parsed) == 0 {
+			continue
+		}
+
+		q.Append(NewParameter(name, parsed...))
+	}
+
+	return q
+}
+
+// normalizeValues trims the values and drops empty ones,
+// returning the values as is when they're already clean,
+// clipped so a later merge can't write into the caller's slice
+func normalizeValues(values []string) []string {
+	for i, value := range values {
+		if trimmed := strings.TrimSpace(value); trimmed != value || trimmed == "" {
+			parsed := append(make([]string, 0, len(values)), values[:i]...)
+			for _, value := range values[i:] {
+				if value = strings.TrimSpace(value); value != "" {
+					parsed = append(parsed, value)
+				}
+			}
+			return parsed
+		}
+	}
+
+	return values[:len(values):len(values)]
+}
+
+// ParseHTTPRequest creates a Request from the query string of an
+// HTTP request, and its form encoded body for POST, PUT and PATCH
+// requests, following the conventions of ParseValues
+func ParseHTTPRequest(r *http.Request) (*Request, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, fmt.Errorf("failed parsing request: %w", err)
+	}
+
+	return ParseValues(r.Form), nil
+}
+
+// clone returns a copy of the request, which can be
+// modified without affecting the original request
+func (q *Request) clone() *Request {
+	c := &Request{
+		params: make([]Parameter, len(q.params)),
+		meta:   q.Metadata(),
+	}
+
+	for i, p := range q.params {
+		p.values = append([]string(nil), p.values...)
+		c.params[i] = p
+	}
+
+	return c
+}
+
+// index returns the position of the parameter
+// with the specified name, or -1 if there is none
+func (q *Request) index(name string) int {
+	for i := range q.params {
+		if q.params[i].name == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
 // Append a parameter to the search request
 func (q *Request) Append(param Parameter) *Request {
-	if _, ok := q.params[param.name]; ok {
-		param = param.Merge(q.params[param.name])
+	if i := q.index(param.name); i >= 0 {
+		q.params[i] = param.Merge(q.params[i])
+		return q
 	}
 
-	q.params[param.name] = param
+	q.params = append(q.params, param)
 	return q
 }
 
 // Has returns truthy when a parameter with the
 // specified name exist on the request
 func (q *Request) Has(name string) bool {
-	_, ok := q.params[name]
-	return ok
+	return q.index(name) >= 0
 }
 
 // HasParam returns truthy when a parameter with
 // the same name as the specified parameter exist
 // on the request
 func (q *Request) HasParam(param Parameter) bool {
-	_, ok := q.params[param.name]
-	return ok
+	return q.index(param.name) >= 0
 }
 
 // Get returns the parameter with the specified name,
 // or an error if no such parameter exist
 func (q *Request) Get(name string) (Parameter, error) {
-	p, ok := q.params[name]
-	if !ok {
+	i := q.index(name)
+	if i < 0 {
 		return Parameter{}, fmt.Errorf("no such parameter: %s", name)
 	}
 
-	return p, nil
+	return q.params[i], nil
 }
 
-// GetAll returns all parameters as a map
+// GetAll returns all parameters as a map, built on
+// every call, see Params to iterate them without one
 func (q *Request) GetAll() map[string]Parameter {
+	all := make(map[string]Parameter, len(q.params))
+	for _, p := range q.params {
+		all[p.name] = p
+	}
+
+	return all
+}
+
+// Params returns all parameters, in the
+// order they were added to the request
+func (q *Request) Params() []Parameter {
 	return q.params
 }
 
 // Set creates or replaces an existing parameter,
 // with the specified name and values
 func (q *Request) Set(name string, values ...string) {
-	q.params[name] = NewParameter(name, values...)
+	q.SetParam(NewParameter(name, values...))
 }
 
 // SetParam creates or replaces an existing parameter
 func (q *Request) SetParam(param Parameter) {
-	q.params[param.name] = param
+	if i := q.index(param.name); i >= 0 {
+		q.params[i] = param
+		return
+	}
+
+	q.params = append(q.params, param)
 }
 
 // Del removes a parameter with the specified name,
 // if it exist
 func (q *Request) Del(name string) {
-	delete(q.params, name)
+	if i := q.index(name); i >= 0 {
+		q.params = append(q.params[:i], q.params[i+1:]...)
+	}
 }
 
 // DelParam removes a parameter if it exist
 func (q *Request) DelParam(param Parameter) {
-	delete(q.params, param.name)
+	q.Del(param.name)
+}
+
+// WithMeta attaches opaque metadata to the request, such as a
+// widget or trace id, which is echoed untouched in Result.Meta
+func (q *Request) WithMeta(key string, value interface{}) *Request {
+	if q.meta == nil {
+		q.meta = make(map[string]interface{})
+	}
+
+	q.meta[key] = value
+	return q
+}
+
+// Meta returns the metadata attached with the specified key
+func (q *Request) Meta(key string) (interface{}, bool) {
+	v, ok := q.meta[key]
+	return v, ok
+}
+
+// Metadata returns a copy of all attached metadata,
+// or nil if there is none
+func (q *Request) Metadata() map[string]interface{} {
+	if len(q.meta) == 0 {
+		return nil
+	}
+
+	meta := make(map[string]interface{}, len(q.meta))
+	for k, v := range q.meta {
+		meta[k] = v
+	}
+
+	return meta
 }
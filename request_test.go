@@ -1,7 +1,12 @@
 package reveald
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -137,18 +142,32 @@ func Test_Merge_Values(t *testing.T) {
 }
 
 func Test_Merge_Ranges(t *testing.T) {
-	min := 2.0
-	p1 := NewParameter("p1."+RangeMinParameterName, fmt.Sprintf("%f", min))
-
-	max := 10.0
-	p2 := NewParameter("p1."+RangeMaxParameterName, fmt.Sprintf("%f", max))
-
-	p := p1.Merge(p2)
+	table := []struct {
+		name string
+		min  string
+		max  string
+	}{
+		{"positive", "2", "10"},
+		{"zero min", "0", "100"},
+		{"zero max", "-10", "0"},
+		{"negative", "-5", "-1"},
+	}
 
-	v1, _ := p.Min()
-	v2, _ := p.Max()
-	assert.Equal(t, min, v1)
-	assert.Equal(t, max, v2)
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			pmin := NewParameter("p1."+RangeMinParameterName, tt.min)
+			pmax := NewParameter("p1."+RangeMaxParameterName, tt.max)
+
+			for _, p := range []Parameter{pmin.Merge(pmax), pmax.Merge(pmin)} {
+				min, wmin := p.Min()
+				max, wmax := p.Max()
+				assert.True(t, wmin)
+				assert.True(t, wmax)
+				assert.Equal(t, tt.min, strconv.FormatFloat(min, 'f', -1, 64))
+				assert.Equal(t, tt.max, strconv.FormatFloat(max, 'f', -1, 64))
+			}
+		})
+	}
 }
 
 func Test_NewRequest(t *testing.T) {
@@ -158,15 +177,15 @@ func Test_NewRequest(t *testing.T) {
 		validate   func(*Request) bool
 	}{
 		{"sets params", []Parameter{NewParameter("param", "value")}, func(r *Request) bool {
-			v, ok := r.params["param"]
-			if !ok {
+			v, err := r.Get("param")
+			if err != nil {
 				return false
 			}
 			return v.Value() == "value"
 		}},
 		{"merges params", []Parameter{NewParameter("param", "value1"), NewParameter("param", "value2")}, func(r *Request) bool {
-			v, ok := r.params["param"]
-			if !ok {
+			v, err := r.Get("param")
+			if err != nil {
 				return false
 			}
 			return assert.ElementsMatch(t, v.Values(), []string{"value1", "value2"})
@@ -187,8 +206,10 @@ func Test_Append(t *testing.T) {
 	r.Append(NewParameter("param1", "value2"))
 	r.Append(NewParameter("param2", "value3"))
 
-	assert.ElementsMatch(t, r.params["param1"].Values(), []string{"value1", "value2"})
-	assert.Equal(t, r.params["param2"].Value(), "value3")
+	p1, _ := r.Get("param1")
+	p2, _ := r.Get("param2")
+	assert.ElementsMatch(t, p1.Values(), []string{"value1", "value2"})
+	assert.Equal(t, p2.Value(), "value3")
 }
 
 func Test_Has(t *testing.T) {
@@ -390,3 +411,186 @@ func Test_DelParam(t *testing.T) {
 		})
 	}
 }
+
+func Test_ParseQueryValues(t *testing.T) {
+	values, err := url.ParseQuery("brand=a&brand=b&price.min=10&price.max=20&q=shoes")
+	assert.NoError(t, err)
+
+	r := ParseQueryValues(values)
+
+	brand, err := r.Get("brand")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a", "b"}, brand.Values())
+
+	price, err := r.Get("price")
+	assert.NoError(t, err)
+	min, wmin := price.Min()
+	max, wmax := price.Max()
+	assert.True(t, wmin)
+	assert.True(t, wmax)
+	assert.Equal(t, 10.0, min)
+	assert.Equal(t, 20.0, max)
+
+	assert.True(t, r.Has("q"))
+	assert.Len(t, r.Params(), 3)
+	assert.Len(t, r.GetAll(), 3)
+
+	// merging range bounds mustn't write into the values
+	assert.Equal(t, []string{"10"}, values["price.min"])
+	assert.Equal(t, []string{"20"}, values["price.max"])
+}
+
+func Test_Request_Params_Order(t *testing.T) {
+	r := NewRequest(NewParameter("b", "1"), NewParameter("a", "2"), NewParameter("c", "3"))
+	r.Del("a")
+	r.Set("d", "4")
+	r.Set("b", "5")
+
+	var names []string
+	for _, p := range r.Params() {
+		names = append(names, p.Name()+"="+p.Value())
+	}
+	assert.Equal(t, []string{"b=5", "c=3", "d=4"}, names)
+}
+
+func Test_ParseValues_Range_Bounds(t *testing.T) {
+	table := []struct {
+		query string
+		min   float64
+		max   float64
+	}{
+		{"price.min=0&price.max=100", 0, 100},
+		{"temp.min=-5&temp.max=5", -5, 5},
+		{"temp.min=-20&temp.max=0", -20, 0},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.query, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			assert.NoError(t, err)
+
+			// url.Values are iterated in random order, so
+			// either bound may be merged into the other
+			for i := 0; i < 20; i++ {
+				for _, r := range []*Request{ParseValues(values), ParseQueryValues(values)} {
+					assert.Len(t, r.Params(), 1)
+					p := r.Params()[0]
+
+					min, wmin := p.Min()
+					max, wmax := p.Max()
+					assert.True(t, wmin)
+					assert.True(t, wmax)
+					assert.Equal(t, tt.min, min)
+					assert.Equal(t, tt.max, max)
+				}
+			}
+		})
+	}
+}
+
+func Test_ParseValues(t *testing.T) {
+	values, err := url.ParseQuery("brand[]=a&brand[]=b&color=red&color=+&q=+shoes+&empty=&price.min=10&price.max=20&sort=price-asc&offset=24")
+	assert.NoError(t, err)
+
+	r := ParseValues(values)
+
+	brand, err := r.Get("brand")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a", "b"}, brand.Values())
+
+	color, err := r.Get("color")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"red"}, color.Values())
+
+	q, err := r.Get("q")
+	assert.NoError(t, err)
+	assert.Equal(t, "shoes", q.Value())
+
+	price, err := r.Get("price")
+	assert.NoError(t, err)
+	min, wmin := price.Min()
+	max, wmax := price.Max()
+	assert.True(t, wmin)
+	assert.True(t, wmax)
+	assert.Equal(t, 10.0, min)
+	assert.Equal(t, 20.0, max)
+
+	assert.False(t, r.Has("empty"))
+	assert.True(t, r.Has("sort"))
+	assert.True(t, r.Has("offset"))
+	assert.Len(t, r.GetAll(), 6)
+}
+
+func Test_ParseHTTPRequest(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/search?q=shoes", strings.NewReader("brand=a&brand=b"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	r, err := ParseHTTPRequest(req)
+	assert.NoError(t, err)
+	assert.True(t, r.Has("q"))
+
+	brand, err := r.Get("brand")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a", "b"}, brand.Values())
+
+	req = httptest.NewRequest(http.MethodGet, "/search?q=%zz", nil)
+	_, err = ParseHTTPRequest(req)
+	assert.Error(t, err)
+}
+
+func Benchmark_ParseQueryValues(b *testing.B) {
+	values, _ := url.ParseQuery("brand=a&brand=b&color=red&price.min=10&price.max=20&q=shoes&offset=24&size=24&sort=price-asc")
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ParseQueryValues(values)
+	}
+}
+
+func Benchmark_Request_Get(b *testing.B) {
+	values, _ := url.ParseQuery("brand=a&brand=b&color=red&price.min=10&price.max=20&q=shoes&offset=24&size=24&sort=price-asc")
+	r := ParseQueryValues(values)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = r.Get("sort")
+		_ = r.Has("missing")
+	}
+}
+
+func Benchmark_ParseValues(b *testing.B) {
+	values, _ := url.ParseQuery("brand=a&brand=b&color=red&price.min=10&price.max=20&q=shoes&offset=24&size=24&sort=price-asc")
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ParseValues(values)
+	}
+}
+
+func Test_Request_Meta(t *testing.T) {
+	req := NewRequest().WithMeta("widget", "sidebar").WithMeta("trace", 42)
+
+	v, ok := req.Meta("widget")
+	assert.True(t, ok)
+	assert.Equal(t, "sidebar", v)
+
+	_, ok = req.Meta("missing")
+	assert.False(t, ok)
+
+	meta := req.Metadata()
+	assert.Equal(t, map[string]interface{}{"widget": "sidebar", "trace": 42}, meta)
+
+	meta["widget"] = "changed"
+	v, _ = req.Meta("widget")
+	assert.Equal(t, "sidebar", v)
+
+	assert.Nil(t, NewRequest().Metadata())
+}
+
+func Test_Endpoint_EchoesMeta(t *testing.T) {
+	e := NewEndpoint(&fakeBackend{}, WithIndices("-"))
+
+	r, err := e.Execute(context.Background(), NewRequest().WithMeta("trace", "abc"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"trace": "abc"}, r.Meta)
+}
@@ -9,16 +9,48 @@ import (
 // Result is a construct containing the search result,
 // Elasticsearch aggregations, and meta data
 type Result struct {
-	result        *elastic.SearchResult
-	request       *Request
-	TotalHitCount int64
-	Hits          []map[string]interface{}
-	Aggregations  map[string][]*ResultBucket
-	Pagination    *ResultPagination
-	Sorting       *ResultSorting
-	Duration      time.Duration
+	result            *elastic.SearchResult
+	request           *Request
+	numbers           NumberDecoder
+	TotalHitCount     int64
+	TotalHitsRelation TotalHitsRelation
+	Hits              []map[string]interface{}
+	DuplicateHitCount int64
+	AppliedFilters    []*ResultFilter
+	Aggregations      map[string][]*ResultBucket
+	Suggestions       map[string][]*ResultSuggestion
+	Stats             map[string]*ResultStats
+	Cardinalities     map[string]int64
+	Related           map[string][]*ResultBucket
+	Facets            map[string]*ResultFacet
+	Intervals         map[string]string
+	Pagination        *ResultPagination
+	Sorting           *ResultSorting
+	PointInTimeID     string
+	Meta              map[string]interface{}
+	Debug             *ResultDebug
+	Profile           *ResultProfile
+	Warnings          []*ResultWarning
+	TimedOut          bool
+	TerminatedEarly   bool
+	Duration          time.Duration
 }
 
+// TotalHitsRelation tells how TotalHitCount
+// relates to the actual number of hits
+type TotalHitsRelation string
+
+const (
+	// TotalHitsExact is an accurate count of the hits
+	TotalHitsExact TotalHitsRelation = "eq"
+	// TotalHitsLowerBound is a lower bound of the hits, as
+	// counting stopped at the track_total_hits threshold
+	TotalHitsLowerBound TotalHitsRelation = "gte"
+	// TotalHitsUntracked is returned when hits weren't
+	// counted, and TotalHitCount is meaningless
+	TotalHitsUntracked TotalHitsRelation = ""
+)
+
 // RawResult returns the raw Elasticsearch response
 func (r *Result) RawResult() *elastic.SearchResult {
 	return r.result
@@ -29,19 +61,87 @@ func (r *Result) Request() *Request {
 	return r.request
 }
 
-// ResultBucket is a container for aggregations
+// ResultBucket is a container for aggregations, where
+// Metric holds the computed value of metric aggregations,
+// such as the latency of a percentile, and SubAggregations
+// holds the aggregations of the bucket's documents. From and
+// To are the bounds of range buckets, To being exclusive, and
+// Selected is set by features when the bucket is part of the
+// selection, such as a range bucket within the selected bounds,
+// and Excluded when the bucket's value is filtered out
 type ResultBucket struct {
-	Value            interface{}
-	HitCount         int64
+	Value           interface{}
+	Label           string
+	HitCount        int64
+	Metric          float64
+	From            *float64
+	To              *float64
+	Selected        bool
+	Excluded        bool
+	Centroid        *ResultGeoPoint
+	SubAggregations map[string][]*ResultBucket
+
+	// Deprecated: SubResultBuckets is never populated,
+	// use SubAggregations instead
 	SubResultBuckets map[string][]*ResultBucket
 }
 
+// ResultFacet holds metadata about a facet, where
+// Coverage is the share of hits having a value, and
+// Hidden flags facets too sparse to be useful
+type ResultFacet struct {
+	Coverage float64
+	Hidden   bool
+}
+
+// ResultGeoPoint is a geographical location,
+// such as the centroid of a geo grid bucket
+type ResultGeoPoint struct {
+	Lat float64
+	Lon float64
+}
+
+// ResultStats holds summary statistics of a numeric
+// property, such as the bounds of a range slider
+type ResultStats struct {
+	Count int64
+	Min   *float64
+	Max   *float64
+	Avg   *float64
+	Sum   float64
+}
+
+// WarningAggregationsDropped warns that a search was retried
+// without aggregations, after exceeding the bucket or memory
+// limits of the cluster
+const WarningAggregationsDropped = "aggregations-dropped"
+
+// ResultWarning describes a degraded result, which
+// is returned rather than failing the search
+type ResultWarning struct {
+	Code    string
+	Message string
+}
+
+// ResultSuggestion is a suggested correction
+// of a query, such as "did you mean"
+type ResultSuggestion struct {
+	Text        string
+	Highlighted string
+	Score       float64
+}
+
 // ResultPagination is a container for pagination
 // information, such as current offset and which
-// page size the result has
+// page size the result has, and a cursor for the
+// next page when using cursor based pagination,
+// along with the request parameters paging
 type ResultPagination struct {
-	Offset   int
-	PageSize int
+	Offset      int
+	PageSize    int
+	NextCursor  string
+	OffsetParam string
+	CursorParam string
 }
 
 // ResultSorting is a container for sort options
@@ -52,10 +152,13 @@ type ResultSorting struct {
 }
 
 // ResultSortingOption defines a possible
-// value to sort a result set on
+// value to sort a result set on, where Priority
+// is the 1-based position of a selected option
+// in a multi-level sort
 type ResultSortingOption struct {
 	Name      string
 	Property  string
 	Ascending bool
 	Selected  bool
+	Priority  int
 }
@@ -0,0 +1,154 @@
+package reveald
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// RetryPolicy retries requests to Elasticsearch failing
+// transiently, i.e. on dropped connections and on overloaded
+// or unavailable nodes, waiting an exponentially growing,
+// randomly jittered backoff between attempts
+type RetryPolicy struct {
+	maxRetries     int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	statusCodes    []int
+	jitter         func(time.Duration) time.Duration
+}
+
+// RetryOption is a functional option used
+// when creating a RetryPolicy
+type RetryOption func(*RetryPolicy)
+
+// WithMaxRetries defines the number of times a
+// request is retried, before failing (default 3)
+func WithMaxRetries(n int) RetryOption {
+	return func(p *RetryPolicy) {
+		p.maxRetries = n
+	}
+}
+
+// WithBackoff defines the backoff before the first retry, doubled
+// for every following retry up to max (default 100ms and 5s)
+func WithBackoff(initial, max time.Duration) RetryOption {
+	return func(p *RetryPolicy) {
+		p.initialBackoff = initial
+		p.maxBackoff = max
+	}
+}
+
+// WithRetryStatusCodes defines the response status codes retried
+// (default 429, 502, 503 and 504)
+func WithRetryStatusCodes(codes ...int) RetryOption {
+	return func(p *RetryPolicy) {
+		p.statusCodes = codes
+	}
+}
+
+// NewRetryPolicy returns a policy retrying transient failures
+func NewRetryPolicy(opts ...RetryOption) *RetryPolicy {
+	p := &RetryPolicy{
+		maxRetries:     3,
+		initialBackoff: 100 * time.Millisecond,
+		maxBackoff:     5 * time.Second,
+		statusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		jitter: func(d time.Duration) time.Duration {
+			return time.Duration(rand.Int63n(int64(d) + 1))
+		},
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// WithRetryPolicy retries requests failing transiently
+// according to the policy
+func WithRetryPolicy(policy *RetryPolicy) ElasticBackendOption {
+	return func(b *ElasticBackend) {
+		b.opts = append(b.opts,
+			elastic.SetRetrier(policy),
+			elastic.SetRetryStatusCodes(policy.statusCodes...))
+	}
+}
+
+// Retry implements elastic.Retrier, where retry is the
+// 1-based number of the retry about to be made
+func (p *RetryPolicy) Retry(ctx context.Context, retry int, _ *http.Request, resp *http.Response, err error) (time.Duration, bool, error) {
+	if retry > p.maxRetries || !p.transient(resp, err) {
+		return 0, false, nil
+	}
+
+	wait := p.backoff(retry)
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+		return 0, false, nil
+	}
+	if ctx.Err() != nil {
+		return 0, false, nil
+	}
+
+	// the response of a retried request is discarded
+	// without being closed by the client
+	if resp != nil && resp.Body != nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	return wait, true, nil
+}
+
+// backoff returns the jittered backoff before a retry
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	d := p.initialBackoff
+	for i := 1; i < retry && d < p.maxBackoff; i++ {
+		d *= 2
+	}
+	if d > p.maxBackoff {
+		d = p.maxBackoff
+	}
+
+	return p.jitter(d)
+}
+
+// transient returns whether a failed request may succeed when retried
+func (p *RetryPolicy) transient(resp *http.Response, err error) bool {
+	if resp != nil {
+		for _, code := range p.statusCodes {
+			if resp.StatusCode == code {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if err == nil || errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
@@ -0,0 +1,77 @@
+package reveald
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RetryPolicy_Retry(t *testing.T) {
+	p := NewRetryPolicy(WithMaxRetries(2), WithBackoff(100*time.Millisecond, 300*time.Millisecond))
+	p.jitter = func(d time.Duration) time.Duration { return d }
+
+	table := []struct {
+		name  string
+		retry int
+		resp  *http.Response
+		err   error
+		wait  time.Duration
+		ok    bool
+	}{
+		{"unavailable", 1, &http.Response{StatusCode: http.StatusServiceUnavailable}, nil, 100 * time.Millisecond, true},
+		{"too many requests", 2, &http.Response{StatusCode: http.StatusTooManyRequests}, nil, 200 * time.Millisecond, true},
+		{"bad request", 1, &http.Response{StatusCode: http.StatusBadRequest}, nil, 0, false},
+		{"connection reset", 1, nil, syscall.ECONNRESET, 100 * time.Millisecond, true},
+		{"unexpected eof", 2, nil, io.ErrUnexpectedEOF, 200 * time.Millisecond, true},
+		{"circuit open", 1, nil, ErrCircuitOpen, 0, false},
+		{"other error", 1, nil, errors.New("boom"), 0, false},
+		{"retries exhausted", 3, &http.Response{StatusCode: http.StatusServiceUnavailable}, nil, 0, false},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			wait, ok, err := p.Retry(context.Background(), tt.retry, nil, tt.resp, tt.err)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.wait, wait)
+		})
+	}
+}
+
+func Test_RetryPolicy_Backoff_Capped(t *testing.T) {
+	p := NewRetryPolicy(WithMaxRetries(10), WithBackoff(100*time.Millisecond, 300*time.Millisecond))
+	p.jitter = func(d time.Duration) time.Duration { return d }
+
+	assert.Equal(t, 300*time.Millisecond, p.backoff(8))
+}
+
+func Test_ElasticBackend_RetryPolicy(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"hits": {"total": {"value": 1, "relation": "eq"}, "hits": []}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	b, err := NewElasticBackend([]string{srv.URL}, WithSniff(false), WithHealthCheck(false),
+		WithRetryPolicy(NewRetryPolicy(WithBackoff(time.Millisecond, time.Millisecond))))
+	assert.NoError(t, err)
+
+	r, err := b.Execute(context.Background(), NewQueryBuilder(NewRequest(), "products"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), r.TotalHitCount)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
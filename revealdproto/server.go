@@ -0,0 +1,199 @@
+// Package revealdproto is a plain Go adapter serving a reveald
+// endpoint through flat request and response messages, with
+// streaming of paginated results, for services exposing search
+// to other services over a transport of their own, such as RPC.
+//
+// It doesn't define a wire format or depend on any transport:
+// hits are plain Go maps, and a transport handler converts its
+// own messages to and from the ones below
+package revealdproto
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/reveald/reveald"
+)
+
+type Parameter struct {
+	Name   string
+	Values []string
+}
+
+type SearchRequest struct {
+	Parameters []*Parameter
+}
+
+type SearchPagesRequest struct {
+	Parameters []*Parameter
+	MaxPages   int32
+}
+
+type Bucket struct {
+	Value    string
+	Label    string
+	HitCount int64
+}
+
+type BucketList struct {
+	Buckets []*Bucket
+}
+
+type Pagination struct {
+	Offset     int64
+	PageSize   int64
+	NextCursor string
+}
+
+type SearchResponse struct {
+	TotalHitCount int64
+	Hits          []map[string]interface{}
+	Aggregations  map[string]*BucketList
+	Pagination    *Pagination
+}
+
+// PageStream receives the pages of a SearchPages call,
+// e.g. a server stream of the transport
+type PageStream interface {
+	Context() context.Context
+	Send(*SearchResponse) error
+}
+
+// Server serves search requests using an endpoint
+type Server struct {
+	endpoint *reveald.Endpoint
+}
+
+// NewServer returns a server for the endpoint
+func NewServer(endpoint *reveald.Endpoint) *Server {
+	return &Server{
+		endpoint: endpoint,
+	}
+}
+
+// Search executes a single search request
+func (s *Server) Search(ctx context.Context, req *SearchRequest) (*SearchResponse, error) {
+	r, err := s.endpoint.Execute(ctx, toRequest(req.Parameters))
+	if err != nil {
+		return nil, err
+	}
+
+	return toResponse(r), nil
+}
+
+// SearchPages streams consecutive pages of a search request,
+// following the next cursor of cursor paginated endpoints, and
+// the offset of offset paginated ones, using the parameters named
+// by the endpoint's pagination. It stops when the hits run out,
+// or when a page doesn't advance past the previous one
+func (s *Server) SearchPages(req *SearchPagesRequest, stream PageStream) error {
+	request := toRequest(req.Parameters)
+
+	var previous *reveald.ResultPagination
+	for page := int32(0); req.MaxPages <= 0 || page < req.MaxPages; page++ {
+		r, err := s.endpoint.Execute(stream.Context(), request)
+		if err != nil {
+			return err
+		}
+
+		if previous != nil && !advanced(previous, r.Pagination) {
+			return nil
+		}
+
+		if err := stream.Send(toResponse(r)); err != nil {
+			return err
+		}
+
+		if !nextPage(request, r) {
+			return nil
+		}
+		previous = r.Pagination
+	}
+
+	return nil
+}
+
+// nextPage updates the request to fetch the page
+// following the result, if there is one
+func nextPage(request *reveald.Request, r *reveald.Result) bool {
+	p := r.Pagination
+	if p == nil || len(r.Hits) == 0 {
+		return false
+	}
+
+	if p.NextCursor != "" && p.CursorParam != "" {
+		request.Set(p.CursorParam, p.NextCursor)
+		return true
+	}
+
+	offset := p.Offset + len(r.Hits)
+	if p.OffsetParam == "" || p.PageSize <= 0 || int64(offset) >= r.TotalHitCount {
+		return false
+	}
+
+	request.Set(p.OffsetParam, strconv.Itoa(offset))
+	return true
+}
+
+// advanced returns whether a page follows the previous page,
+// rather than repeating it, e.g. when the offset is capped
+func advanced(previous, p *reveald.ResultPagination) bool {
+	if p == nil {
+		return false
+	}
+
+	if previous.NextCursor != "" {
+		return p.NextCursor != previous.NextCursor
+	}
+
+	return p.Offset > previous.Offset
+}
+
+func toRequest(params []*Parameter) *reveald.Request {
+	req := reveald.NewRequest()
+	for _, p := range params {
+		if p == nil || p.Name == "" {
+			continue
+		}
+
+		req.Append(reveald.NewParameter(p.Name, p.Values...))
+	}
+
+	return req
+}
+
+func toResponse(r *reveald.Result) *SearchResponse {
+	res := &SearchResponse{
+		TotalHitCount: r.TotalHitCount,
+		Hits:          r.Hits,
+		Aggregations:  make(map[string]*BucketList, len(r.Aggregations)),
+	}
+
+	for name, buckets := range r.Aggregations {
+		list := &BucketList{}
+		for _, b := range buckets {
+			if b == nil {
+				continue
+			}
+
+			list.Buckets = append(list.Buckets, &Bucket{
+				Value:    fmt.Sprint(b.Value),
+				Label:    b.Label,
+				HitCount: b.HitCount,
+			})
+		}
+
+		res.Aggregations[name] = list
+	}
+
+	if r.Pagination != nil {
+		res.Pagination = &Pagination{
+			Offset:     int64(r.Pagination.Offset),
+			PageSize:   int64(r.Pagination.PageSize),
+			NextCursor: r.Pagination.NextCursor,
+		}
+	}
+
+	return res
+}
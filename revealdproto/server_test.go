@@ -0,0 +1,132 @@
+package revealdproto
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/reveald/reveald"
+	"github.com/reveald/reveald/featureset"
+	"github.com/stretchr/testify/assert"
+)
+
+// pagedBackend returns pages of a fixed number of hits
+type pagedBackend struct {
+	total int
+}
+
+func (b *pagedBackend) Execute(_ context.Context, qb *reveald.QueryBuilder) (*reveald.Result, error) {
+	offset := 0
+	if p, err := qb.Request().Get("offset"); err == nil {
+		offset, _ = strconv.Atoi(p.Value())
+	}
+
+	var hits []map[string]interface{}
+	for i := offset; i < offset+qb.Selection().PageSize() && i < b.total; i++ {
+		hits = append(hits, map[string]interface{}{"id": i})
+	}
+
+	return &reveald.Result{
+		TotalHitCount: int64(b.total),
+		Hits:          hits,
+		Aggregations: map[string][]*reveald.ResultBucket{
+			"brand": {{Value: "acme", HitCount: int64(b.total)}},
+		},
+	}, nil
+}
+
+func (b *pagedBackend) ExecuteMultiple(context.Context, []*reveald.QueryBuilder) ([]*reveald.Result, error) {
+	return nil, nil
+}
+
+type fakeStream struct {
+	pages []*SearchResponse
+}
+
+func (s *fakeStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *fakeStream) Send(res *SearchResponse) error {
+	s.pages = append(s.pages, res)
+	return nil
+}
+
+func newTestServer(t *testing.T, total int, opts ...featureset.PaginationOption) *Server {
+	e := reveald.NewEndpoint(&pagedBackend{total: total}, reveald.WithIndices("-"))
+	assert.NoError(t, e.Register(featureset.NewPaginationFeature(append(opts, featureset.WithPageSize(2))...)))
+	return NewServer(e)
+}
+
+func Test_Server_Search(t *testing.T) {
+	res, err := newTestServer(t, 5).Search(context.Background(), &SearchRequest{
+		Parameters: []*Parameter{{Name: "offset", Values: []string{"2"}}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &SearchResponse{
+		TotalHitCount: 5,
+		Hits:          []map[string]interface{}{{"id": 2}, {"id": 3}},
+		Aggregations: map[string]*BucketList{
+			"brand": {Buckets: []*Bucket{{Value: "acme", HitCount: 5}}},
+		},
+		Pagination: &Pagination{Offset: 2, PageSize: 2},
+	}, res)
+}
+
+func Test_NextPage(t *testing.T) {
+	hits := []map[string]interface{}{{"id": 1}}
+
+	table := []struct {
+		name       string
+		pagination *reveald.ResultPagination
+		param      string
+		value      string
+	}{
+		{"cursor", &reveald.ResultPagination{NextCursor: "abc", CursorParam: "page"}, "page", "abc"},
+		{"offset", &reveald.ResultPagination{PageSize: 1, OffsetParam: "skip"}, "skip", "1"},
+		{"unnamed offset", &reveald.ResultPagination{PageSize: 1}, "", ""},
+		{"last cursor", &reveald.ResultPagination{CursorParam: "page"}, "", ""},
+		{"unpaginated", nil, "", ""},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			request := reveald.NewRequest()
+			ok := nextPage(request, &reveald.Result{TotalHitCount: 5, Hits: hits, Pagination: tt.pagination})
+			assert.Equal(t, tt.param != "", ok)
+
+			if tt.param != "" {
+				p, err := request.Get(tt.param)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.value, p.Value())
+			}
+		})
+	}
+}
+
+func Test_Server_SearchPages(t *testing.T) {
+	table := []struct {
+		name     string
+		maxPages int32
+		opts     []featureset.PaginationOption
+		expected []int64
+	}{
+		{"all pages", 0, nil, []int64{0, 2, 4}},
+		{"limited", 2, nil, []int64{0, 2}},
+		{"capped offset", 0, []featureset.PaginationOption{featureset.WithMaxOffset(2)}, []int64{0, 2}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := &fakeStream{}
+			err := newTestServer(t, 5, tt.opts...).SearchPages(&SearchPagesRequest{MaxPages: tt.maxPages}, stream)
+			assert.NoError(t, err)
+
+			var offsets []int64
+			for _, page := range stream.pages {
+				offsets = append(offsets, page.Pagination.Offset)
+			}
+			assert.Equal(t, tt.expected, offsets)
+		})
+	}
+}
@@ -0,0 +1,521 @@
+package revealdtest
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// aggregate computes the aggregations of a
+// search, or of a bucket, over its documents
+func aggregate(aggs interface{}, docs []map[string]interface{}) (map[string]interface{}, error) {
+	defs, ok := aggs.(map[string]interface{})
+	if !ok {
+		return nil, badRequest("parsing_exception", fmt.Sprintf("malformed aggregations %v", aggs))
+	}
+
+	result := make(map[string]interface{}, len(defs))
+	for name, def := range defs {
+		agg, err := aggregation(name, def, docs)
+		if err != nil {
+			return nil, err
+		}
+
+		result[name] = agg
+	}
+
+	return result, nil
+}
+
+func aggregation(name string, def interface{}, docs []map[string]interface{}) (map[string]interface{}, error) {
+	body, ok := def.(map[string]interface{})
+	if !ok {
+		return nil, badRequest("parsing_exception", fmt.Sprintf("malformed aggregation [%s]", name))
+	}
+
+	subs := body["aggregations"]
+	if subs == nil {
+		subs = body["aggs"]
+	}
+
+	for typ, params := range body {
+		if typ == "aggregations" || typ == "aggs" || typ == "meta" {
+			continue
+		}
+
+		p, _ := params.(map[string]interface{})
+		switch typ {
+		case "terms":
+			return terms(p, subs, docs)
+		case "histogram":
+			return histogram(p, subs, docs)
+		case "date_histogram":
+			return dateHistogram(p, subs, docs)
+		case "stats":
+			return stats(p, docs), nil
+		case "missing":
+			field, _ := p["field"].(string)
+			var without []map[string]interface{}
+			for _, doc := range docs {
+				if len(values(doc, field)) == 0 {
+					without = append(without, doc)
+				}
+			}
+			return single(subs, without)
+		case "nested":
+			path, _ := p["path"].(string)
+			var nested []map[string]interface{}
+			for _, doc := range docs {
+				nested = append(nested, nestedDocuments(doc, path)...)
+			}
+			return single(subs, nested)
+		case "reverse_nested":
+			var roots []map[string]interface{}
+			seen := make(map[string]bool)
+			for _, doc := range docs {
+				r := root(doc)
+				if id := fmt.Sprintf("%p", r); !seen[id] {
+					seen[id] = true
+					roots = append(roots, r)
+				}
+			}
+			return single(subs, roots)
+		case "filter":
+			var filtered []map[string]interface{}
+			for _, doc := range docs {
+				ok, err := matchQuery(params, doc)
+				if err != nil {
+					return nil, err
+				}
+				if ok {
+					filtered = append(filtered, doc)
+				}
+			}
+			return single(subs, filtered)
+		default:
+			return nil, badRequest("parsing_exception", fmt.Sprintf("unsupported aggregation [%s] of type [%s]", name, typ))
+		}
+	}
+
+	return nil, badRequest("parsing_exception", fmt.Sprintf("missing type of aggregation [%s]", name))
+}
+
+// single returns a single bucket aggregation,
+// such as a nested or filter aggregation
+func single(subs interface{}, docs []map[string]interface{}) (map[string]interface{}, error) {
+	return bucket(map[string]interface{}{}, subs, docs)
+}
+
+func bucket(b map[string]interface{}, subs interface{}, docs []map[string]interface{}) (map[string]interface{}, error) {
+	b["doc_count"] = len(docs)
+	if subs == nil {
+		return b, nil
+	}
+
+	result, err := aggregate(subs, docs)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, agg := range result {
+		b[name] = agg
+	}
+
+	return b, nil
+}
+
+// termsFilter returns the matcher of the include or exclude
+// parameter of a terms aggregation, being a pattern or a list
+// of values, or nil when absent
+func termsFilter(param interface{}) (func(string) bool, error) {
+	switch v := param.(type) {
+	case string:
+		re, err := regexp.Compile("^(?:" + v + ")$")
+		if err != nil {
+			return nil, badRequest("illegal_argument_exception", fmt.Sprintf("invalid terms pattern [%s]", v))
+		}
+		return re.MatchString, nil
+	case []interface{}:
+		set := make(map[string]bool, len(v))
+		for _, value := range v {
+			set[fmt.Sprint(value)] = true
+		}
+		return func(key string) bool { return set[key] }, nil
+	default:
+		return nil, nil
+	}
+}
+
+type termsGroup struct {
+	key  interface{}
+	docs []map[string]interface{}
+}
+
+func terms(p map[string]interface{}, subs interface{}, docs []map[string]interface{}) (map[string]interface{}, error) {
+	field, _ := p["field"].(string)
+	size := intValue(p["size"], 10)
+	minDocCount := intValue(p["min_doc_count"], 1)
+
+	include, err := termsFilter(p["include"])
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := termsFilter(p["exclude"])
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string]*termsGroup)
+	for _, doc := range docs {
+		vals := values(doc, field)
+		if len(vals) == 0 && p["missing"] != nil {
+			vals = []interface{}{p["missing"]}
+		}
+
+		seen := make(map[string]bool)
+		for _, v := range vals {
+			key := fmt.Sprint(v)
+			if seen[key] || (include != nil && !include(key)) || (exclude != nil && exclude(key)) {
+				continue
+			}
+			seen[key] = true
+
+			if _, ok := groups[key]; !ok {
+				groups[key] = &termsGroup{key: v}
+			}
+			groups[key].docs = append(groups[key].docs, doc)
+		}
+	}
+
+	list := make([]*termsGroup, 0, len(groups))
+	for _, g := range groups {
+		if len(g.docs) >= minDocCount {
+			list = append(list, g)
+		}
+	}
+
+	byKey, desc := false, true
+	if order, ok := p["order"].(map[string]interface{}); ok {
+		for k, dir := range order {
+			switch k {
+			case "_count":
+			case "_key", "_term":
+				byKey = true
+			default:
+				return nil, badRequest("parsing_exception", fmt.Sprintf("unsupported terms order [%s]", k))
+			}
+			desc = dir == "desc"
+		}
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		if !byKey && len(list[i].docs) != len(list[j].docs) {
+			return (len(list[i].docs) > len(list[j].docs)) == desc
+		}
+		c := compare(list[i].key, list[j].key)
+		if byKey && desc {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	other := 0
+	if len(list) > size {
+		for _, g := range list[size:] {
+			other += len(g.docs)
+		}
+		list = list[:size]
+	}
+
+	buckets := make([]interface{}, 0, len(list))
+	for _, g := range list {
+		b := map[string]interface{}{"key": g.key}
+		if v, ok := g.key.(bool); ok {
+			b["key"], b["key_as_string"] = 0, "false"
+			if v {
+				b["key"], b["key_as_string"] = 1, "true"
+			}
+		}
+
+		b, err := bucket(b, subs, g.docs)
+		if err != nil {
+			return nil, err
+		}
+
+		buckets = append(buckets, b)
+	}
+
+	return map[string]interface{}{
+		"doc_count_error_upper_bound": 0,
+		"sum_other_doc_count":         other,
+		"buckets":                     buckets,
+	}, nil
+}
+
+func histogram(p map[string]interface{}, subs interface{}, docs []map[string]interface{}) (map[string]interface{}, error) {
+	field, _ := p["field"].(string)
+	interval, ok := number(p["interval"])
+	if !ok || interval <= 0 {
+		return nil, badRequest("parsing_exception", fmt.Sprintf("invalid histogram interval on [%s]", field))
+	}
+
+	groups := make(map[float64][]map[string]interface{})
+	for _, doc := range docs {
+		seen := make(map[float64]bool)
+		for _, v := range values(doc, field) {
+			f, ok := number(v)
+			if !ok {
+				continue
+			}
+
+			key := math.Floor(f/interval) * interval
+			if !seen[key] {
+				seen[key] = true
+				groups[key] = append(groups[key], doc)
+			}
+		}
+	}
+
+	keys := make([]float64, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Float64s(keys)
+
+	minDocCount := intValue(p["min_doc_count"], 0)
+	var buckets []interface{}
+	for i := 0; i < len(keys); i++ {
+		if minDocCount == 0 && i > 0 {
+			for gap := keys[i-1] + interval; gap < keys[i]; gap += interval {
+				buckets = append(buckets, map[string]interface{}{"key": gap, "doc_count": 0})
+			}
+		}
+
+		if len(groups[keys[i]]) < minDocCount {
+			continue
+		}
+
+		b, err := bucket(map[string]interface{}{"key": keys[i]}, subs, groups[keys[i]])
+		if err != nil {
+			return nil, err
+		}
+
+		buckets = append(buckets, b)
+	}
+
+	return map[string]interface{}{"buckets": nonNil(buckets)}, nil
+}
+
+func stats(p map[string]interface{}, docs []map[string]interface{}) map[string]interface{} {
+	field, _ := p["field"].(string)
+
+	var count int
+	var sum float64
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, doc := range docs {
+		for _, v := range values(doc, field) {
+			f, ok := number(v)
+			if !ok {
+				continue
+			}
+
+			count++
+			sum += f
+			min = math.Min(min, f)
+			max = math.Max(max, f)
+		}
+	}
+
+	if count == 0 {
+		return map[string]interface{}{"count": 0, "min": nil, "max": nil, "avg": nil, "sum": 0}
+	}
+
+	return map[string]interface{}{"count": count, "min": min, "max": max, "avg": sum / float64(count), "sum": sum}
+}
+
+var calendarIntervals = map[string]string{
+	"minute": "1m", "1m": "1m",
+	"hour": "1h", "1h": "1h",
+	"day": "1d", "1d": "1d",
+	"week": "1w", "1w": "1w",
+	"month": "1M", "1M": "1M",
+	"quarter": "1q", "1q": "1q",
+	"year": "1y", "1y": "1y",
+}
+
+var fixedInterval = regexp.MustCompile(`^(\d+)(ms|s|m|h|d)$`)
+
+func dateHistogram(p map[string]interface{}, subs interface{}, docs []map[string]interface{}) (map[string]interface{}, error) {
+	field, _ := p["field"].(string)
+	truncate, next, err := dateInterval(p)
+	if err != nil {
+		return nil, err
+	}
+
+	format := javaDateFormat(p["format"])
+
+	groups := make(map[int64][]map[string]interface{})
+	for _, doc := range docs {
+		seen := make(map[int64]bool)
+		for _, v := range values(doc, field) {
+			t, ok := date(v)
+			if !ok {
+				f, isNumber := number(v)
+				if !isNumber {
+					continue
+				}
+				t = time.UnixMilli(int64(f)).UTC()
+			}
+
+			key := truncate(t.UTC()).UnixMilli()
+			if !seen[key] {
+				seen[key] = true
+				groups[key] = append(groups[key], doc)
+			}
+		}
+	}
+
+	keys := make([]int64, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	minDocCount := intValue(p["min_doc_count"], 0)
+	var buckets []interface{}
+	for i, key := range keys {
+		if minDocCount == 0 && i > 0 {
+			for gap := next(time.UnixMilli(keys[i-1]).UTC()); gap.UnixMilli() < key; gap = next(gap) {
+				buckets = append(buckets, map[string]interface{}{
+					"key":           gap.UnixMilli(),
+					"key_as_string": gap.Format(format),
+					"doc_count":     0,
+				})
+			}
+		}
+
+		if len(groups[key]) < minDocCount {
+			continue
+		}
+
+		b, err := bucket(map[string]interface{}{
+			"key":           key,
+			"key_as_string": time.UnixMilli(key).UTC().Format(format),
+		}, subs, groups[key])
+		if err != nil {
+			return nil, err
+		}
+
+		buckets = append(buckets, b)
+	}
+
+	return map[string]interface{}{"buckets": nonNil(buckets)}, nil
+}
+
+// dateInterval returns functions truncating a time to the start
+// of its bucket, and returning the start of the next bucket
+func dateInterval(p map[string]interface{}) (func(time.Time) time.Time, func(time.Time) time.Time, error) {
+	interval, _ := p["calendar_interval"].(string)
+	if interval == "" {
+		interval, _ = p["fixed_interval"].(string)
+	}
+	if interval == "" {
+		interval, _ = p["interval"].(string)
+	}
+
+	if _, fixed := p["fixed_interval"]; !fixed {
+		if calendar, ok := calendarIntervals[interval]; ok {
+			return calendarInterval(calendar)
+		}
+	}
+
+	m := fixedInterval.FindStringSubmatch(interval)
+	if m == nil {
+		return nil, nil, badRequest("parsing_exception", fmt.Sprintf("unsupported date histogram interval [%s]", interval))
+	}
+
+	n, _ := strconv.Atoi(m[1])
+	unit := map[string]time.Duration{
+		"ms": time.Millisecond,
+		"s":  time.Second,
+		"m":  time.Minute,
+		"h":  time.Hour,
+		"d":  24 * time.Hour,
+	}[m[2]]
+	d := time.Duration(n) * unit
+
+	return func(t time.Time) time.Time { return t.Truncate(d) },
+		func(t time.Time) time.Time { return t.Add(d) },
+		nil
+}
+
+func calendarInterval(interval string) (func(time.Time) time.Time, func(time.Time) time.Time, error) {
+	day := func(t time.Time) time.Time { return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC) }
+
+	switch interval {
+	case "1m", "1h":
+		d := time.Minute
+		if interval == "1h" {
+			d = time.Hour
+		}
+		return func(t time.Time) time.Time { return t.Truncate(d) },
+			func(t time.Time) time.Time { return t.Add(d) },
+			nil
+	case "1d":
+		return day, func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }, nil
+	case "1w":
+		return func(t time.Time) time.Time {
+				return day(t).AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+			},
+			func(t time.Time) time.Time { return t.AddDate(0, 0, 7) },
+			nil
+	case "1M":
+		return func(t time.Time) time.Time { return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC) },
+			func(t time.Time) time.Time { return t.AddDate(0, 1, 0) },
+			nil
+	case "1q":
+		return func(t time.Time) time.Time {
+				return time.Date(t.Year(), t.Month()-(t.Month()-1)%3, 1, 0, 0, 0, 0, time.UTC)
+			},
+			func(t time.Time) time.Time { return t.AddDate(0, 3, 0) },
+			nil
+	default:
+		return func(t time.Time) time.Time { return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC) },
+			func(t time.Time) time.Time { return t.AddDate(1, 0, 0) },
+			nil
+	}
+}
+
+var javaDateLayout = strings.NewReplacer(
+	"yyyy", "2006",
+	"SSS", "000",
+	"MM", "01",
+	"dd", "02",
+	"HH", "15",
+	"mm", "04",
+	"ss", "05",
+	"'", "",
+)
+
+// javaDateFormat translates the common patterns of
+// a Java date format to a Go time layout
+func javaDateFormat(format interface{}) string {
+	f, _ := format.(string)
+	if f == "" {
+		return "2006-01-02T15:04:05.000Z"
+	}
+
+	return javaDateLayout.Replace(f)
+}
+
+func nonNil(buckets []interface{}) []interface{} {
+	if buckets == nil {
+		return []interface{}{}
+	}
+
+	return buckets
+}
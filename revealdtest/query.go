@@ -0,0 +1,392 @@
+package revealdtest
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// matchQuery returns whether a document matches a query,
+// where a missing query matches every document
+func matchQuery(query interface{}, doc map[string]interface{}) (bool, error) {
+	if query == nil {
+		return true, nil
+	}
+
+	q, ok := query.(map[string]interface{})
+	if !ok || len(q) != 1 {
+		return false, badRequest("parsing_exception", fmt.Sprintf("malformed query %v", query))
+	}
+
+	for typ, body := range q {
+		params, _ := body.(map[string]interface{})
+		switch typ {
+		case "match_all":
+			return true, nil
+		case "match_none":
+			return false, nil
+		case "bool":
+			return matchBool(params, doc)
+		case "term":
+			field, value := fieldParam(params, "value")
+			for _, v := range values(doc, field) {
+				if equal(v, value) {
+					return true, nil
+				}
+			}
+			return false, nil
+		case "terms":
+			for field, list := range params {
+				if field == "boost" {
+					continue
+				}
+
+				for _, v := range values(doc, field) {
+					for _, value := range asList(list) {
+						if equal(v, value) {
+							return true, nil
+						}
+					}
+				}
+			}
+			return false, nil
+		case "range":
+			return matchRange(params, doc)
+		case "exists":
+			field, _ := params["field"].(string)
+			return len(values(doc, field)) > 0, nil
+		case "nested":
+			p, _ := params["path"].(string)
+			for _, nested := range nestedDocuments(doc, p) {
+				ok, err := matchQuery(params["query"], nested)
+				if err != nil || ok {
+					return ok, err
+				}
+			}
+			return false, nil
+		case "ids":
+			id := root(doc)[idKey]
+			for _, v := range asList(params["values"]) {
+				if equal(v, id) {
+					return true, nil
+				}
+			}
+			return false, nil
+		case "constant_score":
+			return matchQuery(params["filter"], doc)
+		case "function_score":
+			return matchQuery(params["query"], doc)
+		default:
+			return false, badRequest("parsing_exception", fmt.Sprintf("unsupported query [%s]", typ))
+		}
+	}
+
+	return false, nil
+}
+
+func matchBool(params map[string]interface{}, doc map[string]interface{}) (bool, error) {
+	for _, occur := range []string{"must", "filter"} {
+		for _, clause := range asList(params[occur]) {
+			ok, err := matchQuery(clause, doc)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+	}
+
+	for _, clause := range asList(params["must_not"]) {
+		ok, err := matchQuery(clause, doc)
+		if err != nil || ok {
+			return false, err
+		}
+	}
+
+	should := asList(params["should"])
+	if len(should) == 0 {
+		return true, nil
+	}
+
+	required := 0
+	if params["must"] == nil && params["filter"] == nil {
+		required = 1
+	}
+	if msm, ok := params["minimum_should_match"]; ok {
+		required = intValue(msm, required)
+	}
+
+	matched := 0
+	for _, clause := range should {
+		ok, err := matchQuery(clause, doc)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			matched++
+		}
+	}
+
+	return matched >= required, nil
+}
+
+func matchRange(params map[string]interface{}, doc map[string]interface{}) (bool, error) {
+	for field, body := range params {
+		bounds, ok := body.(map[string]interface{})
+		if !ok {
+			return false, badRequest("parsing_exception", fmt.Sprintf("malformed range on [%s]", field))
+		}
+
+		lower, includeLower := bounds["gte"], true
+		if v, ok := bounds["gt"]; ok {
+			lower, includeLower = v, false
+		}
+		if v, ok := bounds["from"]; ok && v != nil {
+			lower, includeLower = v, bounds["include_lower"] != false
+		}
+
+		upper, includeUpper := bounds["lte"], true
+		if v, ok := bounds["lt"]; ok {
+			upper, includeUpper = v, false
+		}
+		if v, ok := bounds["to"]; ok && v != nil {
+			upper, includeUpper = v, bounds["include_upper"] != false
+		}
+
+		for _, v := range values(doc, field) {
+			if lower != nil {
+				c := compare(v, lower)
+				if c < 0 || (c == 0 && !includeLower) {
+					continue
+				}
+			}
+			if upper != nil {
+				c := compare(v, upper)
+				if c > 0 || (c == 0 && !includeUpper) {
+					continue
+				}
+			}
+
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// fieldParam returns the field and value of a single
+// field query, in either its short or long form
+func fieldParam(params map[string]interface{}, key string) (string, interface{}) {
+	for field, body := range params {
+		if field == "boost" {
+			continue
+		}
+
+		if long, ok := body.(map[string]interface{}); ok {
+			return field, long[key]
+		}
+
+		return field, body
+	}
+
+	return "", nil
+}
+
+// values returns the values of a field, following objects and
+// arrays along its path, and falling back on the parent property
+// of keyword sub-fields
+func values(doc map[string]interface{}, field string) []interface{} {
+	found := lookup(doc, strings.Split(field, "."))
+	if len(found) == 0 && strings.HasSuffix(field, ".keyword") {
+		found = lookup(doc, strings.Split(strings.TrimSuffix(field, ".keyword"), "."))
+	}
+
+	return found
+}
+
+func lookup(value interface{}, path []string) []interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		var found []interface{}
+		for _, item := range v {
+			found = append(found, lookup(item, path)...)
+		}
+		return found
+	case map[string]interface{}:
+		if len(path) == 0 {
+			return []interface{}{v}
+		}
+
+		// dotted property names are matched before objects
+		for i := len(path); i > 0; i-- {
+			if child, ok := v[strings.Join(path[:i], ".")]; ok {
+				return lookup(child, path[i:])
+			}
+		}
+		return nil
+	default:
+		if len(path) > 0 {
+			return nil
+		}
+		return []interface{}{v}
+	}
+}
+
+// nestedDocuments returns a copy of the document for each object
+// at the nested path, where the path holds only that object, so
+// that queries and aggregations on the nested fields see one
+// object at a time
+func nestedDocuments(doc map[string]interface{}, path string) []map[string]interface{} {
+	var docs []map[string]interface{}
+	for _, v := range values(doc, path) {
+		if _, ok := v.(map[string]interface{}); !ok {
+			continue
+		}
+
+		nested := replace(doc, strings.Split(path, "."), v)
+		nested[rootKey] = root(doc)
+		docs = append(docs, nested)
+	}
+
+	return docs
+}
+
+// rootKey holds the root document of a nested document,
+// for reverse nested aggregations
+const rootKey = "\x00root"
+
+// idKey holds the id of a document, for ids queries
+const idKey = "\x00id"
+
+// root returns the root document of a nested
+// document, or the document itself
+func root(doc map[string]interface{}) map[string]interface{} {
+	if r, ok := doc[rootKey].(map[string]interface{}); ok {
+		return r
+	}
+
+	return doc
+}
+
+func replace(doc map[string]interface{}, path []string, value interface{}) map[string]interface{} {
+	cp := make(map[string]interface{}, len(doc))
+	for k, v := range doc {
+		cp[k] = v
+	}
+
+	if len(path) == 1 {
+		cp[path[0]] = value
+		return cp
+	}
+
+	child, _ := doc[path[0]].(map[string]interface{})
+	cp[path[0]] = replace(child, path[1:], value)
+	return cp
+}
+
+func equal(a, b interface{}) bool {
+	if af, ok := number(a); ok {
+		if bf, ok := number(b); ok {
+			return af == bf
+		}
+	}
+
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+// compare orders numbers numerically, dates chronologically,
+// and anything else by its string representation
+func compare(a, b interface{}) int {
+	if af, ok := number(a); ok {
+		if bf, ok := number(b); ok {
+			return compareFloat(af, bf)
+		}
+	}
+
+	if at, ok := date(a); ok {
+		if bt, ok := date(b); ok {
+			return at.Compare(bt)
+		}
+	}
+
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func number(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil && !math.IsNaN(f)
+	default:
+		return 0, false
+	}
+}
+
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+func date(v interface{}) (time.Time, bool) {
+	s, ok := v.(string)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+func asList(v interface{}) []interface{} {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return v
+	default:
+		return []interface{}{v}
+	}
+}
+
+func intValue(v interface{}, fallback int) int {
+	switch v := v.(type) {
+	case float64:
+		return int(v)
+	case string:
+		if i, err := strconv.Atoi(v); err == nil {
+			return i
+		}
+	}
+
+	return fallback
+}
@@ -0,0 +1,595 @@
+// Package revealdtest provides an in-process fake of Elasticsearch,
+// understanding the subset of the search API generated by reveald,
+// for fast feature tests without a running cluster
+package revealdtest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/reveald/reveald"
+)
+
+// Server is a fake Elasticsearch, serving searches, multi searches
+// and counts over documents held in memory. It supports:
+//
+//   - bool, term, terms, ids, range, exists, match_all,
+//     nested, constant_score and function_score queries
+//   - terms, histogram, date_histogram, stats, missing,
+//     nested, reverse_nested and filter aggregations
+//   - post filters, sorting on fields and nested fields, pagination, source
+//     filtering of top-level properties, track_total_hits
+//     and terminate_after
+//
+// Unsupported queries and aggregations fail the search, rather
+// than returning misleading results. Documents match keyword
+// sub-fields (e.g. brand.keyword) on their parent property, and
+// every hit is scored 1
+type Server struct {
+	*httptest.Server
+
+	mu      sync.RWMutex
+	indices map[string][]document
+}
+
+type document struct {
+	id     string
+	source map[string]interface{}
+}
+
+// identified returns a copy of the source of a
+// document, holding its id for ids queries
+func (d document) identified() map[string]interface{} {
+	cp := make(map[string]interface{}, len(d.source)+1)
+	for k, v := range d.source {
+		cp[k] = v
+	}
+	cp[idKey] = d.id
+
+	return cp
+}
+
+// NewServer starts a fake Elasticsearch without any indices,
+// which should be closed once the test is done
+func NewServer() *Server {
+	s := &Server{indices: make(map[string][]document)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Index adds documents to an index, creating the index if it
+// doesn't exist. Documents are stored as their JSON encoding,
+// and are identified by their position in the index
+func (s *Server) Index(index string, docs ...interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.indices[index]; !ok {
+		s.indices[index] = nil
+	}
+
+	for _, doc := range docs {
+		data, err := json.Marshal(doc)
+		if err != nil {
+			return fmt.Errorf("failed encoding document: %w", err)
+		}
+
+		var source map[string]interface{}
+		if err := json.Unmarshal(data, &source); err != nil {
+			return fmt.Errorf("failed decoding document: %w", err)
+		}
+
+		id := strconv.Itoa(len(s.indices[index]) + 1)
+		s.indices[index] = append(s.indices[index], document{id, source})
+	}
+
+	return nil
+}
+
+// Backend returns an Elasticsearch backend targeting the
+// fake, with sniffing and healthchecks disabled
+func (s *Server) Backend(opts ...reveald.ElasticBackendOption) (*reveald.ElasticBackend, error) {
+	opts = append([]reveald.ElasticBackendOption{
+		reveald.WithSniff(false),
+		reveald.WithHealthCheck(false),
+	}, opts...)
+
+	return reveald.NewElasticBackend([]string{s.URL}, opts...)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case segments[len(segments)-1] == "_search" && len(segments) <= 2:
+		var indices string
+		if len(segments) == 2 {
+			indices = segments[0]
+		}
+
+		var body map[string]interface{}
+		if err := decode(r.Body, &body); err != nil {
+			writeError(w, badRequest("parsing_exception", err.Error()))
+			return
+		}
+
+		res, err := s.search(indices, body)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, res)
+	case segments[len(segments)-1] == "_count" && len(segments) <= 2:
+		var indices string
+		if len(segments) == 2 {
+			indices = segments[0]
+		}
+
+		var body map[string]interface{}
+		if err := decode(r.Body, &body); err != nil {
+			writeError(w, badRequest("parsing_exception", err.Error()))
+			return
+		}
+
+		res, err := s.search(indices, map[string]interface{}{"query": body["query"], "size": 0, "track_total_hits": true})
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		total := res["hits"].(map[string]interface{})["total"].(map[string]interface{})["value"]
+		writeJSON(w, http.StatusOK, map[string]interface{}{"count": total})
+	case r.URL.Path == "/_msearch":
+		responses, err := s.multiSearch(r.Body)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{"responses": responses})
+	default:
+		writeError(w, badRequest("unsupported_operation_exception",
+			fmt.Sprintf("%s %s is not supported by the fake", r.Method, r.URL.Path)))
+	}
+}
+
+func (s *Server) multiSearch(r io.Reader) ([]interface{}, error) {
+	var responses []interface{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var header map[string]interface{}
+		if err := decode(bytes.NewReader(line), &header); err != nil {
+			return nil, badRequest("parsing_exception", err.Error())
+		}
+
+		if !scanner.Scan() {
+			return nil, badRequest("parsing_exception", "missing search body after header")
+		}
+
+		var body map[string]interface{}
+		if err := decode(bytes.NewReader(scanner.Bytes()), &body); err != nil {
+			return nil, badRequest("parsing_exception", err.Error())
+		}
+
+		indices, _ := header["index"].(string)
+		if list, ok := header["indices"].([]interface{}); ok {
+			var names []string
+			for _, name := range list {
+				names = append(names, fmt.Sprint(name))
+			}
+			indices = strings.Join(names, ",")
+		}
+
+		res, err := s.search(indices, body)
+		if err != nil {
+			responses = append(responses, asFakeError(err).body())
+			continue
+		}
+
+		res["status"] = http.StatusOK
+		responses = append(responses, res)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, badRequest("parsing_exception", err.Error())
+	}
+
+	return responses, nil
+}
+
+// resolve returns the documents of the comma separated
+// indices, which may include wildcard patterns
+func (s *Server) resolve(indices string) ([]hitDocument, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if indices == "" || indices == "_all" {
+		indices = "*"
+	}
+
+	var names []string
+	for _, pattern := range strings.Split(indices, ",") {
+		if !strings.Contains(pattern, "*") {
+			if _, ok := s.indices[pattern]; !ok {
+				return nil, &fakeError{
+					status: http.StatusNotFound,
+					typ:    "index_not_found_exception",
+					reason: fmt.Sprintf("no such index [%s]", pattern),
+				}
+			}
+
+			names = append(names, pattern)
+			continue
+		}
+
+		for name := range s.indices {
+			if ok, _ := path.Match(pattern, name); ok {
+				names = append(names, name)
+			}
+		}
+	}
+
+	sort.Strings(names)
+
+	var docs []hitDocument
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		for _, doc := range s.indices[name] {
+			docs = append(docs, hitDocument{index: name, document: doc})
+		}
+	}
+
+	return docs, nil
+}
+
+type hitDocument struct {
+	index string
+	document
+}
+
+func (s *Server) search(indices string, body map[string]interface{}) (map[string]interface{}, error) {
+	docs, err := s.resolve(indices)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []hitDocument
+	for _, doc := range docs {
+		ok, err := matchQuery(body["query"], doc.identified())
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, doc)
+		}
+	}
+
+	res := map[string]interface{}{
+		"took":      0,
+		"timed_out": false,
+		"_shards":   map[string]interface{}{"total": 1, "successful": 1, "skipped": 0, "failed": 0},
+	}
+
+	if n := intValue(body["terminate_after"], 0); n > 0 && len(matched) > n {
+		matched = matched[:n]
+		res["terminated_early"] = true
+	}
+
+	if aggs, ok := body["aggregations"]; ok {
+		sources := make([]map[string]interface{}, 0, len(matched))
+		for _, doc := range matched {
+			sources = append(sources, doc.identified())
+		}
+
+		result, err := aggregate(aggs, sources)
+		if err != nil {
+			return nil, err
+		}
+
+		res["aggregations"] = result
+	}
+
+	var hits []hitDocument
+	for _, doc := range matched {
+		ok, err := matchQuery(body["post_filter"], doc.identified())
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			hits = append(hits, doc)
+		}
+	}
+
+	if err := sortHits(hits, body["sort"]); err != nil {
+		return nil, err
+	}
+
+	from, size := intValue(body["from"], 0), intValue(body["size"], 10)
+	page := []interface{}{}
+	for i := from; i < len(hits) && i < from+size; i++ {
+		page = append(page, map[string]interface{}{
+			"_index":  hits[i].index,
+			"_id":     hits[i].id,
+			"_score":  1.0,
+			"_source": filterSource(hits[i].source, body["_source"]),
+		})
+	}
+
+	res["hits"] = map[string]interface{}{
+		"max_score": 1.0,
+		"hits":      page,
+	}
+	if total := totalHits(len(hits), body["track_total_hits"]); total != nil {
+		res["hits"].(map[string]interface{})["total"] = total
+	}
+
+	return res, nil
+}
+
+// defaultTrackTotalHits is the number of hits
+// Elasticsearch counts accurately by default
+const defaultTrackTotalHits = 10000
+
+// totalHits returns the total hits of a search, counted
+// accurately up to the track_total_hits setting, or nil
+// when hits aren't tracked
+func totalHits(count int, track interface{}) map[string]interface{} {
+	limit := defaultTrackTotalHits
+	switch track := track.(type) {
+	case bool:
+		if !track {
+			return nil
+		}
+		limit = count
+	case float64:
+		limit = int(track)
+	}
+
+	if count > limit {
+		return map[string]interface{}{"value": limit, "relation": "gte"}
+	}
+
+	return map[string]interface{}{"value": count, "relation": "eq"}
+}
+
+func sortHits(hits []hitDocument, spec interface{}) error {
+	type sorter struct {
+		field  string
+		desc   bool
+		mode   string
+		nested map[string]interface{}
+	}
+
+	var sorters []sorter
+	list, ok := spec.([]interface{})
+	if !ok && spec != nil {
+		list = []interface{}{spec}
+	}
+
+	for _, s := range list {
+		switch s := s.(type) {
+		case string:
+			sorters = append(sorters, sorter{field: s, desc: s == "_score"})
+		case map[string]interface{}:
+			for field, order := range s {
+				st := sorter{field: field}
+				switch order := order.(type) {
+				case string:
+					st.desc = order == "desc"
+				case map[string]interface{}:
+					st.desc = order["order"] == "desc"
+					st.mode, _ = order["mode"].(string)
+					st.nested, _ = order["nested"].(map[string]interface{})
+				}
+
+				sorters = append(sorters, st)
+			}
+		default:
+			return badRequest("parsing_exception", fmt.Sprintf("unsupported sort %v", s))
+		}
+	}
+
+	// sort values are the values of the field, or of the nested
+	// objects matching the nested filter, reduced by the sort mode
+	var err error
+	sortValue := func(doc map[string]interface{}, s sorter) (interface{}, bool) {
+		vals := values(doc, s.field)
+		if s.nested != nil {
+			path, _ := s.nested["path"].(string)
+			vals = nil
+			for _, nested := range nestedDocuments(doc, path) {
+				ok, merr := matchQuery(s.nested["filter"], nested)
+				if merr != nil {
+					err = merr
+				}
+				if ok {
+					vals = append(vals, values(nested, s.field)...)
+				}
+			}
+		}
+		if len(vals) == 0 {
+			return nil, false
+		}
+
+		mode := s.mode
+		if mode == "" && (s.nested != nil || len(vals) > 1) {
+			mode = "min"
+			if s.desc {
+				mode = "max"
+			}
+		}
+
+		v := vals[0]
+		for _, other := range vals[1:] {
+			if c := compare(other, v); (mode == "min" && c < 0) || (mode == "max" && c > 0) {
+				v = other
+			}
+		}
+
+		return v, true
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		for _, s := range sorters {
+			if s.field == "_score" || s.field == "_doc" {
+				continue
+			}
+
+			a, aok := sortValue(hits[i].identified(), s)
+			b, bok := sortValue(hits[j].identified(), s)
+			switch {
+			case !aok && !bok:
+				continue
+			case !aok:
+				return false
+			case !bok:
+				return true
+			}
+
+			c := compare(a, b)
+			if c == 0 {
+				continue
+			}
+
+			return (c < 0) != s.desc
+		}
+
+		return false
+	})
+
+	return err
+}
+
+// filterSource applies source filtering on
+// the top-level properties of a document
+func filterSource(source map[string]interface{}, spec interface{}) interface{} {
+	var includes, excludes []string
+	switch spec := spec.(type) {
+	case nil:
+		return source
+	case bool:
+		if !spec {
+			return nil
+		}
+		return source
+	case string:
+		includes = []string{spec}
+	case []interface{}:
+		for _, v := range spec {
+			includes = append(includes, fmt.Sprint(v))
+		}
+	case map[string]interface{}:
+		for _, v := range asList(spec["includes"]) {
+			includes = append(includes, fmt.Sprint(v))
+		}
+		for _, v := range asList(spec["excludes"]) {
+			excludes = append(excludes, fmt.Sprint(v))
+		}
+	}
+
+	filtered := make(map[string]interface{})
+	for key, value := range source {
+		if len(includes) > 0 && !matchesAny(key, includes) {
+			continue
+		}
+		if matchesAny(key, excludes) {
+			continue
+		}
+
+		filtered[key] = value
+	}
+
+	return filtered
+}
+
+func matchesAny(key string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if root := strings.SplitN(pattern, ".", 2)[0]; root != pattern && root == key {
+			return true
+		}
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+type fakeError struct {
+	status int
+	typ    string
+	reason string
+}
+
+func badRequest(typ, reason string) *fakeError {
+	return &fakeError{status: http.StatusBadRequest, typ: typ, reason: reason}
+}
+
+func (e *fakeError) Error() string {
+	return fmt.Sprintf("%s: %s", e.typ, e.reason)
+}
+
+func (e *fakeError) body() map[string]interface{} {
+	cause := map[string]interface{}{"type": e.typ, "reason": e.reason}
+	return map[string]interface{}{
+		"error": map[string]interface{}{
+			"root_cause": []interface{}{cause},
+			"type":       e.typ,
+			"reason":     e.reason,
+		},
+		"status": e.status,
+	}
+}
+
+func asFakeError(err error) *fakeError {
+	if fe, ok := err.(*fakeError); ok {
+		return fe
+	}
+
+	return &fakeError{status: http.StatusInternalServerError, typ: "exception", reason: err.Error()}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	fe := asFakeError(err)
+	writeJSON(w, fe.status, fe.body())
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func decode(r io.Reader, v interface{}) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,335 @@
+package revealdtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/reveald/reveald"
+	"github.com/reveald/reveald/featureset"
+	"github.com/stretchr/testify/assert"
+)
+
+type product struct {
+	Name     string    `json:"name"`
+	Brand    string    `json:"brand"`
+	Price    float64   `json:"price"`
+	InStock  bool      `json:"inStock"`
+	Released string    `json:"released"`
+	Variants []variant `json:"variants,omitempty"`
+}
+
+type variant struct {
+	Color string `json:"color"`
+	Size  string `json:"size"`
+}
+
+func newServer(t *testing.T) *Server {
+	s := NewServer()
+	t.Cleanup(s.Close)
+
+	assert.NoError(t, s.Index("products",
+		product{"Anvil", "acme", 120, true, "2024-01-15", []variant{{"black", "L"}}},
+		product{"Rocket", "acme", 80, false, "2024-03-02", []variant{{"red", "S"}, {"black", "M"}}},
+		product{"Widget", "globex", 15, true, "2024-03-20", nil},
+		product{"Gadget", "initech", 45, true, "2024-04-01", []variant{{"red", "L"}}},
+	))
+
+	return s
+}
+
+func newEndpoint(t *testing.T, s *Server, opts ...reveald.EndpointOption) *reveald.Endpoint {
+	b, err := s.Backend()
+	assert.NoError(t, err)
+
+	return reveald.NewEndpoint(b, reveald.WithIndices("products"), opts...)
+}
+
+func hitNames(r *reveald.Result) []string {
+	var names []string
+	for _, hit := range r.Hits {
+		names = append(names, hit["name"].(string))
+	}
+
+	return names
+}
+
+func Test_Server_Features(t *testing.T) {
+	s := newServer(t)
+	e := newEndpoint(t, s)
+	assert.NoError(t, e.Register(
+		featureset.NewDynamicFilterFeature("brand"),
+		featureset.NewBooleanFilterFeature("inStock"),
+		featureset.NewHistogramFeature("price", featureset.WithInterval(50), featureset.WithoutZeroBucket()),
+		featureset.NewDateHistogramFeature("released", featureset.WithCalendarInterval(featureset.DateCalendarIntervalMonthly)),
+		featureset.NewSortingFeature("sort",
+			featureset.WithSortOption("price-asc", "price", true),
+			featureset.WithDefaultSortOption("price-asc")),
+		featureset.NewPaginationFeature(featureset.WithPageSize(10)),
+	))
+
+	table := []struct {
+		name   string
+		params []reveald.Parameter
+		hits   []string
+		brands map[interface{}]int64
+	}{
+		{"all", nil,
+			[]string{"Widget", "Gadget", "Rocket", "Anvil"},
+			map[interface{}]int64{"acme": 2, "globex": 1, "initech": 1}},
+		{"term", []reveald.Parameter{reveald.NewParameter("brand", "acme")},
+			[]string{"Rocket", "Anvil"},
+			map[interface{}]int64{"acme": 2}},
+		{"boolean", []reveald.Parameter{reveald.NewParameter("inStock", "true")},
+			[]string{"Widget", "Gadget", "Anvil"},
+			map[interface{}]int64{"acme": 1, "globex": 1, "initech": 1}},
+		{"range", []reveald.Parameter{reveald.NewParameter("price.min", "40"), reveald.NewParameter("price.max", "100")},
+			[]string{"Gadget", "Rocket"},
+			map[interface{}]int64{"acme": 1, "initech": 1}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := e.Execute(context.Background(), reveald.NewRequest(tt.params...))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.hits, hitNames(r))
+			assert.Equal(t, int64(len(tt.hits)), r.TotalHitCount)
+
+			brands := make(map[interface{}]int64)
+			for _, b := range r.Aggregations["brand"] {
+				brands[b.Value] = b.HitCount
+			}
+			assert.Equal(t, tt.brands, brands)
+		})
+	}
+}
+
+func Test_Server_Histograms(t *testing.T) {
+	s := newServer(t)
+	e := newEndpoint(t, s)
+	assert.NoError(t, e.Register(
+		featureset.NewHistogramFeature("price", featureset.WithInterval(50)),
+		featureset.NewDateHistogramFeature("released", featureset.WithCalendarInterval(featureset.DateCalendarIntervalMonthly)),
+	))
+
+	r, err := e.Execute(context.Background(), reveald.NewRequest())
+	assert.NoError(t, err)
+
+	var prices []int64
+	for _, b := range r.Aggregations["price"] {
+		prices = append(prices, b.HitCount)
+	}
+	assert.Equal(t, []int64{2, 1, 1}, prices)
+
+	months := make(map[interface{}]int64)
+	for _, b := range r.Aggregations["released"] {
+		months[b.Value] = b.HitCount
+	}
+	assert.Equal(t, map[interface{}]int64{"2024-01": 1, "2024-02": 0, "2024-03": 2, "2024-04": 1}, months)
+}
+
+func Test_Server_DisjunctiveFacets(t *testing.T) {
+	s := newServer(t)
+	e := newEndpoint(t, s, reveald.WithDisjunctiveFacets("brand"))
+	assert.NoError(t, e.Register(
+		featureset.NewDynamicFilterFeature("brand"),
+		featureset.NewBooleanFilterFeature("inStock"),
+	))
+
+	r, err := e.Execute(context.Background(), reveald.NewRequest(
+		reveald.NewParameter("brand", "acme"),
+		reveald.NewParameter("inStock", "true")))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Anvil"}, hitNames(r))
+
+	brands := make(map[interface{}]int64)
+	for _, b := range r.Aggregations["brand"] {
+		brands[b.Value] = b.HitCount
+	}
+	assert.Equal(t, map[interface{}]int64{"acme": 1, "globex": 1, "initech": 1}, brands)
+}
+
+func bucketCounts(r *reveald.Result, name string) map[interface{}]int64 {
+	counts := make(map[interface{}]int64)
+	for _, b := range r.Aggregations[name] {
+		counts[b.Value] = b.HitCount
+	}
+
+	return counts
+}
+
+func Test_Server_CountingStrategy(t *testing.T) {
+	table := []struct {
+		name     string
+		strategy reveald.CountingStrategy
+		brands   map[interface{}]int64
+		inStock  map[interface{}]int64
+	}{
+		{"conjunctive", reveald.ConjunctiveCounting,
+			map[interface{}]int64{"acme": 1},
+			map[interface{}]int64{"true": 1}},
+		{"disjunctive", reveald.DisjunctiveCounting(),
+			map[interface{}]int64{"acme": 1, "globex": 1, "initech": 1},
+			map[interface{}]int64{"true": 1, "false": 1}},
+		{"mixed", reveald.DisjunctiveCounting("brand"),
+			map[interface{}]int64{"acme": 1, "globex": 1, "initech": 1},
+			map[interface{}]int64{"true": 1}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newServer(t)
+			e := newEndpoint(t, s, reveald.WithCountingStrategy(tt.strategy))
+			assert.NoError(t, e.Register(
+				featureset.NewDynamicFilterFeature("brand"),
+				featureset.NewBooleanFilterFeature("inStock"),
+				featureset.NewHistogramFeature("price", featureset.WithInterval(50), featureset.WithoutZeroBucket()),
+			))
+
+			r, err := e.Execute(context.Background(), reveald.NewRequest(
+				reveald.NewParameter("brand", "acme"),
+				reveald.NewParameter("inStock", "true")))
+			assert.NoError(t, err)
+
+			// hits and the counts of unselected facets are narrowed
+			// by every selection, whatever the strategy
+			assert.Equal(t, []string{"Anvil"}, hitNames(r))
+			assert.Equal(t, int64(1), r.TotalHitCount)
+			assert.Equal(t, map[interface{}]int64{"100": 1}, bucketCounts(r, "price"))
+
+			assert.Equal(t, tt.brands, bucketCounts(r, "brand"))
+			assert.Equal(t, tt.inStock, bucketCounts(r, "inStock"))
+		})
+	}
+}
+
+func Test_Server_Nested(t *testing.T) {
+	s := newServer(t)
+	e := newEndpoint(t, s)
+	assert.NoError(t, e.Register(featureset.NewNestedDocumentFilterFeature("variants.color")))
+
+	r, err := e.Execute(context.Background(), reveald.NewRequest(reveald.NewParameter("variants.color", "red")))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"Rocket", "Gadget"}, hitNames(r))
+
+	colors := make(map[interface{}]int64)
+	for _, b := range r.Aggregations["variants.color"] {
+		colors[b.Value] = b.HitCount
+	}
+	assert.Equal(t, map[interface{}]int64{"red": 2, "black": 1}, colors)
+}
+
+func Test_Server_ExecuteMultiple(t *testing.T) {
+	s := newServer(t)
+	e := newEndpoint(t, s)
+
+	qb := reveald.NewQueryBuilder(reveald.NewRequest(), "products")
+	qb.With(elastic.NewTermQuery("brand.keyword", "acme"))
+
+	b, err := s.Backend()
+	assert.NoError(t, err)
+
+	results, err := b.ExecuteMultiple(context.Background(), []*reveald.QueryBuilder{
+		qb,
+		reveald.NewQueryBuilder(reveald.NewRequest(), "products"),
+	})
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, int64(2), results[0].TotalHitCount)
+	assert.Equal(t, int64(4), results[1].TotalHitCount)
+
+	_, err = e.ExecuteMultiple(context.Background(), []*reveald.Request{reveald.NewRequest()})
+	assert.NoError(t, err)
+}
+
+func Test_Server_Errors(t *testing.T) {
+	s := newServer(t)
+
+	b, err := s.Backend()
+	assert.NoError(t, err)
+
+	missing := reveald.NewQueryBuilder(reveald.NewRequest(), "missing")
+	_, err = b.Execute(context.Background(), missing)
+	var notFound *reveald.IndexNotFoundError
+	assert.True(t, errors.As(err, &notFound))
+
+	unsupported := reveald.NewQueryBuilder(reveald.NewRequest(), "products")
+	unsupported.With(elastic.NewMatchPhraseQuery("name", "anvil"))
+	_, err = b.Execute(context.Background(), unsupported)
+	assert.ErrorContains(t, err, "unsupported query [match_phrase]")
+}
+
+func Test_Server_Count(t *testing.T) {
+	s := newServer(t)
+	e := newEndpoint(t, s)
+	assert.NoError(t, e.Register(featureset.NewDynamicFilterFeature("brand")))
+
+	count, err := e.Count(context.Background(), reveald.NewRequest(reveald.NewParameter("brand", "acme")))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func Test_Server_TrackTotalHits(t *testing.T) {
+	s := newServer(t)
+	b, err := s.Backend()
+	assert.NoError(t, err)
+
+	table := []struct {
+		name     string
+		track    interface{}
+		total    int64
+		relation reveald.TotalHitsRelation
+	}{
+		{"default", nil, 4, reveald.TotalHitsExact},
+		{"exact", true, 4, reveald.TotalHitsExact},
+		{"threshold", 2, 2, reveald.TotalHitsLowerBound},
+		{"untracked", false, 0, reveald.TotalHitsUntracked},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			qb := reveald.NewQueryBuilder(reveald.NewRequest(), "products")
+			if tt.track != nil {
+				qb.Selection().Update(reveald.WithTrackTotalHits(tt.track))
+			}
+
+			r, err := b.Execute(context.Background(), qb)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.total, r.TotalHitCount)
+			assert.Equal(t, tt.relation, r.TotalHitsRelation)
+		})
+	}
+}
+
+func Test_Server_TerminateAfter(t *testing.T) {
+	s := newServer(t)
+	e := newEndpoint(t, s, reveald.WithTerminateAfter(2))
+
+	r, err := e.Execute(context.Background(), reveald.NewRequest())
+	assert.NoError(t, err)
+	assert.True(t, r.TerminatedEarly)
+	assert.False(t, r.TimedOut)
+	assert.Equal(t, int64(2), r.TotalHitCount)
+}
+
+type idsFeature struct {
+	ids []string
+}
+
+func (f *idsFeature) Process(builder *reveald.QueryBuilder, next reveald.FeatureFunc) (*reveald.Result, error) {
+	builder.WithIDs(f.ids...)
+	builder.PreserveIDOrder()
+	return next(builder)
+}
+
+func Test_Server_IDs(t *testing.T) {
+	s := newServer(t)
+	e := newEndpoint(t, s)
+	assert.NoError(t, e.Register(&idsFeature{[]string{"4", "1", "9", "2"}}))
+
+	r, err := e.Execute(context.Background(), reveald.NewRequest())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), r.TotalHitCount)
+	assert.Equal(t, []string{"Gadget", "Anvil", "Rocket"}, hitNames(r))
+}
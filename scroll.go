@@ -0,0 +1,133 @@
+package reveald
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/olivere/elastic/v7"
+)
+
+const defaultScrollKeepAlive = "1m"
+
+// ScrollIterator iterates over all pages of a search
+// using the Elasticsearch scroll API
+type ScrollIterator struct {
+	ctx     context.Context
+	svc     *elastic.ScrollService
+	numbers NumberDecoder
+	stop    func() bool
+	mu      sync.Mutex
+	closed  bool
+}
+
+// ExecuteScroll starts a scrolled search, returning an iterator
+// over result pages of batchSize hits each. The scroll context is
+// cleared when the iterator is exhausted, closed, or when ctx is
+// cancelled
+func (b *ElasticBackend) ExecuteScroll(ctx context.Context, builder *QueryBuilder, batchSize int) (*ScrollIterator, error) {
+	if batchSize <= 0 {
+		return nil, errors.New("scroll batch size must be positive")
+	}
+
+	if builder.PointInTime() != nil {
+		return nil, errors.New("scrolled searches can't use a point in time")
+	}
+
+	if err := b.checkCapabilities(builder); err != nil {
+		return nil, err
+	}
+
+	builder.Selection().Update(WithPageSize(batchSize), WithOffset(0))
+
+	src, err := builder.BuildSource()
+	if err != nil {
+		return nil, fmt.Errorf("failed building elasticsearch request: %w", err)
+	}
+
+	svc := b.client.Scroll(builder.Indices()...).
+		KeepAlive(defaultScrollKeepAlive).
+		Body(src)
+	if id, ok := SearchIDFromContext(ctx); ok {
+		svc = svc.Header(SearchIDHeader, id)
+	}
+	if builder.Preference() != "" {
+		svc = svc.Preference(builder.Preference())
+	}
+	if len(builder.Routing()) > 0 {
+		svc = svc.Routing(builder.Routing()...)
+	}
+	if builder.IgnoreUnavailable() != nil {
+		svc = svc.IgnoreUnavailable(*builder.IgnoreUnavailable())
+	}
+	if builder.AllowNoIndices() != nil {
+		svc = svc.AllowNoIndices(*builder.AllowNoIndices())
+	}
+
+	it := &ScrollIterator{
+		ctx:     ctx,
+		svc:     svc,
+		numbers: b.numbers,
+	}
+
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	it.stop = context.AfterFunc(ctx, func() {
+		_ = it.Close()
+	})
+
+	return it, nil
+}
+
+// Next returns the next page of results, or io.EOF
+// when all pages have been consumed
+func (it *ScrollIterator) Next() (*Result, error) {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	if it.closed {
+		if err := it.ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		return nil, io.EOF
+	}
+
+	res, err := it.svc.Do(it.ctx)
+	if err == io.EOF {
+		it.close()
+		return nil, io.EOF
+	}
+	if err != nil {
+		it.close()
+		return nil, fmt.Errorf("elasticsearch request failed: %w", err)
+	}
+
+	return mapSearchResult(res, it.numbers)
+}
+
+// Close clears the scroll context, releasing the
+// resources held by Elasticsearch
+func (it *ScrollIterator) Close() error {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	return it.close()
+}
+
+func (it *ScrollIterator) close() error {
+	if it.closed {
+		return nil
+	}
+
+	it.closed = true
+	it.stop()
+
+	if err := it.svc.Clear(context.Background()); err != nil {
+		return fmt.Errorf("failed clearing scroll: %w", err)
+	}
+
+	return nil
+}
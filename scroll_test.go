@@ -0,0 +1,117 @@
+package reveald
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+type scrollServer struct {
+	mu      sync.Mutex
+	first   map[string]interface{}
+	query   string
+	pages   int
+	cleared bool
+}
+
+func newScrollBackend(t *testing.T, s *scrollServer) *ElasticBackend {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodDelete:
+			s.cleared = true
+			_, _ = w.Write([]byte(`{"succeeded": true, "num_freed": 1}`))
+			return
+		case strings.HasSuffix(r.URL.Path, "/_search"):
+			s.query = r.URL.RawQuery
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&s.first))
+		}
+
+		s.pages++
+		if s.pages > 2 {
+			_, _ = w.Write([]byte(`{"_scroll_id": "s", "hits": {"total": {"value": 2, "relation": "eq"}, "hits": []}}`))
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"_scroll_id": "s", "hits": {"total": {"value": 2, "relation": "eq"}, "hits": [{"_id": "1", "_source": {"name": "a"}}]}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	b, err := NewElasticBackend([]string{srv.URL}, WithSniff(false), WithHealthCheck(false))
+	assert.NoError(t, err)
+	return b
+}
+
+func Test_ExecuteScroll(t *testing.T) {
+	s := &scrollServer{}
+	b := newScrollBackend(t, s)
+
+	qb := NewQueryBuilder(NewRequest(), "products")
+	qb.With(elastic.NewTermQuery("brand", "acme"))
+	qb.WithSourceField("stats", []string{"scroll"})
+	qb.WithIgnoreUnavailable(true)
+
+	it, err := b.ExecuteScroll(context.Background(), qb, 1)
+	assert.NoError(t, err)
+
+	pages := 0
+	for {
+		r, err := it.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		assert.NoError(t, err)
+		assert.Len(t, r.Hits, 1)
+		pages++
+	}
+
+	assert.Equal(t, 2, pages)
+	assert.True(t, s.cleared)
+
+	// the first page is built like any other search
+	assert.Equal(t, []interface{}{"scroll"}, s.first["stats"])
+	assert.Equal(t, float64(1), s.first["size"])
+	assert.Contains(t, s.first, "query")
+	assert.Contains(t, s.query, "ignore_unavailable=true")
+}
+
+func Test_ExecuteScroll_Capabilities(t *testing.T) {
+	s := &scrollServer{}
+	b := newScrollBackend(t, s)
+	b.cluster = &ClusterInfo{Version: "7.9.3"}
+
+	qb := NewQueryBuilder(NewRequest(), "products")
+	qb.WithKNN(&KNNQuery{Field: "embedding", QueryVector: []float64{1}, K: 1, NumCandidates: 10})
+
+	_, err := b.ExecuteScroll(context.Background(), qb, 10)
+
+	var unsupported *UnsupportedCapabilityError
+	assert.ErrorAs(t, err, &unsupported)
+	assert.Equal(t, 0, s.pages)
+}
+
+func Test_ExecuteScroll_Invalid(t *testing.T) {
+	s := &scrollServer{}
+	b := newScrollBackend(t, s)
+
+	_, err := b.ExecuteScroll(context.Background(), NewQueryBuilder(NewRequest(), "products"), 0)
+	assert.Error(t, err)
+
+	qb := NewQueryBuilder(NewRequest(), "products")
+	qb.WithPointInTime("pit", "1m")
+	_, err = b.ExecuteScroll(context.Background(), qb, 10)
+	assert.Error(t, err)
+	assert.Equal(t, 0, s.pages)
+}
@@ -7,11 +7,14 @@ import "github.com/olivere/elastic/v7"
 // includes information about page sizes, sorting, and
 // field inclusion/exclusion
 type DocumentSelector struct {
-	inclusions []string
-	exclusions []string
-	offset     int
-	pageSize   int
-	sort       *elastic.FieldSort
+	inclusions  []string
+	exclusions  []string
+	offset      int
+	pageSize    int
+	sort        *elastic.FieldSort
+	sorters     []elastic.Sorter
+	searchAfter []interface{}
+	trackTotal  interface{}
 }
 
 const (
@@ -56,6 +59,35 @@ func WithOffset(offset int) Selector {
 func WithSort(sort *elastic.FieldSort) Selector {
 	return func(s *DocumentSelector) {
 		s.sort = sort
+		s.sorters = nil
+	}
+}
+
+// WithSortBy defines an ordered set of sorts for a search
+// result, where later sorts break ties of earlier ones
+func WithSortBy(sorters ...elastic.Sorter) Selector {
+	return func(s *DocumentSelector) {
+		s.sort = nil
+		s.sorters = sorters
+	}
+}
+
+// WithSearchAfter defines the sort values of the last
+// document on the previous page, used for deep pagination
+// past the max result window
+func WithSearchAfter(values ...interface{}) Selector {
+	return func(s *DocumentSelector) {
+		s.searchAfter = values
+	}
+}
+
+// WithTrackTotalHits defines whether the total hits of a
+// search are counted, as true, false, or the number of hits
+// to count accurately up to, after which the total is a lower
+// bound. Elasticsearch counts up to 10,000 hits by default
+func WithTrackTotalHits(track interface{}) Selector {
+	return func(s *DocumentSelector) {
+		s.trackTotal = track
 	}
 }
 
@@ -86,3 +118,31 @@ func (ds *DocumentSelector) Update(selectors ...Selector) {
 func (ds *DocumentSelector) Sort() *elastic.FieldSort {
 	return ds.sort
 }
+
+// Sorters returns all sorts for a search request
+func (ds *DocumentSelector) Sorters() []elastic.Sorter {
+	if len(ds.sorters) > 0 {
+		return ds.sorters
+	}
+	if ds.sort != nil {
+		return []elastic.Sorter{ds.sort}
+	}
+
+	return nil
+}
+
+// PageSize returns the current page size for a search request
+func (ds *DocumentSelector) PageSize() int {
+	return ds.pageSize
+}
+
+// SearchAfter returns the sort values to continue a search from
+func (ds *DocumentSelector) SearchAfter() []interface{} {
+	return ds.searchAfter
+}
+
+// TrackTotalHits returns the track_total_hits setting
+// of the selection, or nil for the cluster default
+func (ds *DocumentSelector) TrackTotalHits() interface{} {
+	return ds.trackTotal
+}
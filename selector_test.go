@@ -31,6 +31,9 @@ func Test_NewDocumentSelector(t *testing.T) {
 		{"set sort", []Selector{WithSort(elastic.NewFieldSort("test"))}, func(ds *DocumentSelector) bool {
 			return assert.Equal(t, elastic.NewFieldSort("test"), ds.sort)
 		}},
+		{"set search after", []Selector{WithSearchAfter(10, "id")}, func(ds *DocumentSelector) bool {
+			return assert.Equal(t, []interface{}{10, "id"}, ds.searchAfter)
+		}},
 	}
 
 	for _, tt := range table {
@@ -0,0 +1,18 @@
+package reveald
+
+import "context"
+
+type sessionKey struct{}
+
+// ContextWithSession returns a copy of the context carrying
+// the specified session (or user) id
+func ContextWithSession(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, id)
+}
+
+// SessionFromContext returns the session id carried by the
+// context, if any
+func SessionFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(sessionKey{}).(string)
+	return id, ok && id != ""
+}
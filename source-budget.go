@@ -0,0 +1,124 @@
+package reveald
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const defaultDetailParam = "detail"
+
+// FieldTypeResolver resolves document fields by their mapping
+// type, implemented by backends able to read index mappings
+type FieldTypeResolver interface {
+	FieldsOfType(ctx context.Context, indices []string, types ...string) ([]string, error)
+}
+
+// sourceBudget excludes large fields from hits,
+// unless a detail projection is requested
+type sourceBudget struct {
+	param  string
+	fields []string
+	types  []string
+
+	mu       sync.Mutex
+	resolved map[string][]string
+}
+
+func (e *Endpoint) sourceBudget() *sourceBudget {
+	if e.budget == nil {
+		e.budget = &sourceBudget{param: defaultDetailParam}
+	}
+
+	return e.budget
+}
+
+// WithExcludedLargeFields excludes the specified fields from
+// hits, unless the request holds a truthy detail parameter
+func WithExcludedLargeFields(fields ...string) EndpointOption {
+	return func(e *Endpoint) {
+		b := e.sourceBudget()
+		b.fields = append(b.fields, fields...)
+	}
+}
+
+// WithExcludedLargeFieldTypes excludes fields of the specified
+// mapping types (e.g. "binary" or "dense_vector") from hits,
+// unless the request holds a truthy detail parameter. Fields
+// are resolved from the index mapping once per set of indices,
+// on first use, and require a backend implementing
+// FieldTypeResolver
+func WithExcludedLargeFieldTypes(types ...string) EndpointOption {
+	return func(e *Endpoint) {
+		b := e.sourceBudget()
+		b.types = append(b.types, types...)
+	}
+}
+
+// WithDetailParam defines the request parameter which
+// includes all fields in hits (default is "detail")
+func WithDetailParam(name string) EndpointOption {
+	return func(e *Endpoint) {
+		e.sourceBudget().param = name
+	}
+}
+
+func (sb *sourceBudget) apply(ctx context.Context, backend Backend, builder *QueryBuilder) error {
+	if p, err := builder.Request().Get(sb.param); err == nil && p.IsTruthy() {
+		return nil
+	}
+
+	fields, err := sb.excluded(ctx, backend, builder.Indices())
+	if err != nil {
+		return err
+	}
+
+	if len(fields) > 0 {
+		builder.Selection().Update(WithoutProperties(fields...))
+	}
+
+	return nil
+}
+
+func (sb *sourceBudget) excluded(ctx context.Context, backend Backend, indices []string) ([]string, error) {
+	if len(sb.types) == 0 {
+		return sb.fields, nil
+	}
+
+	key := indexSetKey(indices)
+
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	if resolved, ok := sb.resolved[key]; ok {
+		return resolved, nil
+	}
+
+	resolver, ok := backend.(FieldTypeResolver)
+	if !ok {
+		return nil, fmt.Errorf("backend %T can't resolve fields by mapping type", backend)
+	}
+
+	fields, err := resolver.FieldsOfType(ctx, indices, sb.types...)
+	if err != nil {
+		return nil, fmt.Errorf("failed resolving large fields: %w", err)
+	}
+
+	if sb.resolved == nil {
+		sb.resolved = make(map[string][]string)
+	}
+
+	resolved := append(append([]string{}, sb.fields...), fields...)
+	sb.resolved[key] = resolved
+	return resolved, nil
+}
+
+// indexSetKey identifies a set of indices,
+// regardless of their order
+func indexSetKey(indices []string) string {
+	sorted := append([]string(nil), indices...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
@@ -0,0 +1,97 @@
+package reveald
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeResolverBackend struct {
+	fakeBackend
+	calls int
+}
+
+func (b *fakeResolverBackend) FieldsOfType(_ context.Context, _ []string, types ...string) ([]string, error) {
+	b.calls++
+	return []string{"thumbnail"}, nil
+}
+
+func Test_SourceBudget_Excludes_Fields(t *testing.T) {
+	table := []struct {
+		name       string
+		req        *Request
+		exclusions []string
+	}{
+		{"without detail", NewRequest(), []string{"body", "thumbnail"}},
+		{"with detail", NewRequest(NewParameter("detail", "true")), nil},
+		{"with false detail", NewRequest(NewParameter("detail", "false")), []string{"body", "thumbnail"}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &fakeResolverBackend{}
+			e := NewEndpoint(b, WithIndices("idx"),
+				WithExcludedLargeFields("body"),
+				WithExcludedLargeFieldTypes("binary"))
+
+			_, err := e.Execute(context.Background(), tt.req)
+			assert.NoError(t, err)
+
+			var exclusions []string
+			if b.builders[0].selection != nil {
+				exclusions = b.builders[0].selection.exclusions
+			}
+			assert.Equal(t, tt.exclusions, exclusions)
+		})
+	}
+}
+
+func Test_SourceBudget_Resolves_Once(t *testing.T) {
+	b := &fakeResolverBackend{}
+	e := NewEndpoint(b, WithIndices("idx"), WithExcludedLargeFieldTypes("binary"))
+
+	for i := 0; i < 3; i++ {
+		_, err := e.Execute(context.Background(), NewRequest())
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, 1, b.calls)
+}
+
+func Test_SourceBudget_Requires_Resolver(t *testing.T) {
+	e := NewEndpoint(&fakeBackend{}, WithIndices("idx"), WithExcludedLargeFieldTypes("binary"))
+
+	_, err := e.Execute(context.Background(), NewRequest())
+	assert.Error(t, err)
+}
+
+func Test_SourceBudget_Resolves_Per_Index_Set(t *testing.T) {
+	b := &fakeResolverBackend{}
+	sb := &sourceBudget{types: []string{"binary"}}
+
+	for _, indices := range [][]string{{"a"}, {"a", "b"}, {"b", "a"}, {"a"}, {"c"}} {
+		_, err := sb.excluded(context.Background(), b, indices)
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, 3, b.calls)
+}
+
+func Test_SourceBudget_Through_CachedBackend(t *testing.T) {
+	b := &fakeResolverBackend{}
+	e := NewEndpoint(NewCachedBackend(b, NewLRUCache(10), time.Minute),
+		WithIndices("idx"), WithExcludedLargeFieldTypes("binary"))
+
+	_, err := e.Execute(context.Background(), NewRequest())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, b.calls)
+	assert.Equal(t, []string{"thumbnail"}, b.builders[0].selection.exclusions)
+
+	e = NewEndpoint(NewCachedBackend(&fakeBackend{}, NewLRUCache(10), time.Minute),
+		WithIndices("idx"), WithExcludedLargeFieldTypes("binary"))
+
+	_, err = e.Execute(context.Background(), NewRequest())
+	assert.Error(t, err)
+}
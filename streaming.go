@@ -0,0 +1,141 @@
+package reveald
+
+import (
+	"context"
+	"errors"
+
+	"github.com/olivere/elastic/v7"
+)
+
+const (
+	streamAggregation     = "stream"
+	streamSource          = "key"
+	defaultStreamPageSize = 1000
+)
+
+// BucketPageFunc handles a page of streamed buckets, where
+// returning an error stops streaming and is returned as is
+type BucketPageFunc func(ctx context.Context, buckets []*ResultBucket) error
+
+type bucketStream struct {
+	pageSize     int
+	missingValue bool
+}
+
+// StreamOption is a functional option for streaming buckets
+type StreamOption func(*bucketStream)
+
+// WithStreamPageSize defines the number of buckets
+// per page, defaulting to 1000
+func WithStreamPageSize(size int) StreamOption {
+	return func(s *bucketStream) {
+		s.pageSize = size
+	}
+}
+
+// WithStreamMissingBucket includes a bucket with a
+// nil value, counting documents without a value
+func WithStreamMissingBucket() StreamOption {
+	return func(s *bucketStream) {
+		s.missingValue = true
+	}
+}
+
+type searchFunc func(ctx context.Context, src interface{}) (*elastic.SearchResult, error)
+
+// StreamBuckets streams every distinct value of a field among the
+// documents matching a query, paging through a composite aggregation.
+// The next page is requested only once fn has handled the previous
+// one, keeping memory bounded regardless of the number of values
+func (b *ElasticBackend) StreamBuckets(ctx context.Context, builder *QueryBuilder, field string, fn BucketPageFunc, opts ...StreamOption) error {
+	if err := b.checkCapabilities(builder); err != nil {
+		return err
+	}
+
+	search := func(ctx context.Context, src interface{}) (*elastic.SearchResult, error) {
+		svc := b.client.Search(searchIndices(builder)...)
+		if id, ok := SearchIDFromContext(ctx); ok {
+			svc = svc.Header(SearchIDHeader, id)
+		}
+		if builder.Preference() != "" {
+			svc = svc.Preference(builder.Preference())
+		}
+		if len(builder.Routing()) > 0 {
+			svc = svc.Routing(builder.Routing()...)
+		}
+		if builder.IgnoreUnavailable() != nil {
+			svc = svc.IgnoreUnavailable(*builder.IgnoreUnavailable())
+		}
+		if builder.AllowNoIndices() != nil {
+			svc = svc.AllowNoIndices(*builder.AllowNoIndices())
+		}
+
+		result, err := svc.Source(src).Do(ctx)
+		if err != nil {
+			return nil, searchError(err)
+		}
+
+		return result, nil
+	}
+
+	return streamBuckets(ctx, search, builder, field, fn, opts...)
+}
+
+func streamBuckets(ctx context.Context, search searchFunc, builder *QueryBuilder, field string, fn BucketPageFunc, opts ...StreamOption) error {
+	s := &bucketStream{pageSize: defaultStreamPageSize}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	terms := elastic.NewCompositeAggregationTermsValuesSource(streamSource).Field(field)
+	if s.missingValue {
+		terms = terms.MissingBucket(true)
+	}
+
+	var after map[string]interface{}
+	for {
+		agg := elastic.NewCompositeAggregation().Sources(terms).Size(s.pageSize)
+		if after != nil {
+			agg = agg.AggregateAfter(after)
+		}
+
+		src := elastic.NewSearchSource().
+			Query(builder.filterQuery()).
+			Size(0).
+			TrackTotalHits(false).
+			Aggregation(streamAggregation, agg)
+		if builder.PointInTime() != nil {
+			src = src.PointInTime(builder.PointInTime())
+		}
+
+		result, err := search(ctx, src)
+		if err != nil {
+			return err
+		}
+
+		page, ok := result.Aggregations.Composite(streamAggregation)
+		if !ok {
+			return errors.New("elasticsearch request failed: missing composite aggregation in response")
+		}
+
+		buckets := make([]*ResultBucket, 0, len(page.Buckets))
+		for _, bucket := range page.Buckets {
+			buckets = append(buckets, &ResultBucket{
+				Value:    bucket.Key[streamSource],
+				HitCount: bucket.DocCount,
+			})
+		}
+
+		if len(buckets) > 0 {
+			if err := fn(ctx, buckets); err != nil {
+				return err
+			}
+		}
+
+		if page.AfterKey == nil || len(page.Buckets) < s.pageSize {
+			return nil
+		}
+
+		after = page.AfterKey
+	}
+}
@@ -0,0 +1,76 @@
+package reveald
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+func fakeCompositePage(t *testing.T, page string) *elastic.SearchResult {
+	var result elastic.SearchResult
+	assert.NoError(t, json.Unmarshal([]byte(`{"aggregations":{"stream":`+page+`}}`), &result))
+	return &result
+}
+
+func Test_StreamBuckets(t *testing.T) {
+	pages := []string{
+		`{"after_key":{"key":"b"},"buckets":[{"key":{"key":"a"},"doc_count":3},{"key":{"key":"b"},"doc_count":2}]}`,
+		`{"after_key":{"key":"c"},"buckets":[{"key":{"key":"c"},"doc_count":1}]}`,
+	}
+
+	var sources []map[string]interface{}
+	search := func(_ context.Context, src interface{}) (*elastic.SearchResult, error) {
+		body, err := src.(*elastic.SearchSource).Source()
+		assert.NoError(t, err)
+
+		data, _ := json.Marshal(body)
+		var m map[string]interface{}
+		assert.NoError(t, json.Unmarshal(data, &m))
+		sources = append(sources, m)
+
+		return fakeCompositePage(t, pages[len(sources)-1]), nil
+	}
+
+	qb := NewQueryBuilder(NewRequest(), "-")
+	qb.With(elastic.NewTermQuery("active", true))
+
+	var values []interface{}
+	err := streamBuckets(context.Background(), search, qb, "seller", func(_ context.Context, buckets []*ResultBucket) error {
+		for _, b := range buckets {
+			values = append(values, b.Value)
+		}
+		return nil
+	}, WithStreamPageSize(2))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"a", "b", "c"}, values)
+	assert.Len(t, sources, 2)
+
+	composite := func(i int) map[string]interface{} {
+		aggs := sources[i]["aggregations"].(map[string]interface{})
+		return aggs["stream"].(map[string]interface{})["composite"].(map[string]interface{})
+	}
+	assert.Nil(t, composite(0)["after"])
+	assert.Equal(t, map[string]interface{}{"key": "b"}, composite(1)["after"])
+	assert.Equal(t, float64(0), sources[0]["size"])
+	assert.NotNil(t, sources[0]["query"])
+}
+
+func Test_StreamBuckets_Stop(t *testing.T) {
+	calls := 0
+	search := func(context.Context, interface{}) (*elastic.SearchResult, error) {
+		calls++
+		return fakeCompositePage(t, `{"after_key":{"key":"a"},"buckets":[{"key":{"key":"a"},"doc_count":1}]}`), nil
+	}
+
+	stop := errors.New("stop")
+	err := streamBuckets(context.Background(), search, NewQueryBuilder(NewRequest(), "-"), "seller",
+		func(context.Context, []*ResultBucket) error { return stop }, WithStreamPageSize(1))
+
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,56 @@
+package reveald
+
+import (
+	"context"
+	"errors"
+)
+
+// errNoSubsearch is returned for subsearches of query
+// builders which weren't built by an endpoint
+var errNoSubsearch = errors.New("query builder can't execute subsearches outside an endpoint")
+
+// Subsearch returns a query builder for a preliminary search of a
+// feature, e.g. looking up the bounds of a property before building
+// its aggregation. It shares the request, context, indices and search
+// options of the builder, but none of its queries or aggregations
+func (qb *QueryBuilder) Subsearch() *QueryBuilder {
+	sub := NewQueryBuilder(qb.request, qb.indices...)
+	sub.ctx = qb.ctx
+	sub.pointInTime = qb.pointInTime
+	sub.preference = qb.preference
+	sub.routing = qb.routing
+	sub.ignoreUnavail = qb.ignoreUnavail
+	sub.allowNoIndices = qb.allowNoIndices
+	sub.queryTimeout = qb.queryTimeout
+	sub.terminateAfter = qb.terminateAfter
+	sub.subsearch = qb.subsearch
+	return sub
+}
+
+// ExecuteSubsearch executes a query builder returned by Subsearch
+// through the endpoint executing the builder, scoped to the tenant
+// of the request and sent to the endpoint's backend, like the
+// search itself
+func (qb *QueryBuilder) ExecuteSubsearch(sub *QueryBuilder) (*Result, error) {
+	if qb.subsearch == nil {
+		return nil, errNoSubsearch
+	}
+
+	return qb.subsearch(qb.Context(), sub)
+}
+
+// subsearch executes the preliminary search of a feature
+func (e *Endpoint) subsearch(ctx context.Context, qb *QueryBuilder) (*Result, error) {
+	if err := e.enforceTenant(ctx, qb); err != nil {
+		return nil, err
+	}
+
+	e.lintBuilder(ctx, qb)
+	r, err := e.backend.Execute(ctx, qb)
+	if err != nil {
+		return nil, err
+	}
+
+	observeBackend(ctx, e.metrics, r)
+	return r, nil
+}
@@ -0,0 +1,47 @@
+package reveald
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type subsearchFeature struct{}
+
+func (f *subsearchFeature) Process(qb *QueryBuilder, next FeatureFunc) (*Result, error) {
+	if _, err := qb.ExecuteSubsearch(qb.Subsearch()); err != nil {
+		return nil, err
+	}
+
+	return next(qb)
+}
+
+func Test_Subsearch_Endpoint(t *testing.T) {
+	b := &fakeBackend{}
+	e := NewEndpoint(b, WithIndices("idx"),
+		WithIgnoreUnavailable(true),
+		WithQueryTimeout(time.Second),
+		WithTenantResolver(TenantResolverFunc(func(context.Context, *Request) (*Tenant, error) {
+			return TermTenantFilter("tenant_id", "a", "tenant-a"), nil
+		})))
+	assert.NoError(t, e.Register(&subsearchFeature{}))
+
+	_, err := e.Execute(context.Background(), NewRequest())
+	assert.NoError(t, err)
+	assert.Len(t, b.builders, 2)
+
+	sub := b.builders[0]
+	assert.Equal(t, []string{"tenant-a"}, sub.Indices())
+	assert.Equal(t, time.Second, sub.QueryTimeout())
+	assert.True(t, *sub.IgnoreUnavailable())
+	assert.Contains(t, sourceJSON(t, sub)["query"].(map[string]interface{})["bool"], "must")
+}
+
+func Test_Subsearch_Without_Endpoint(t *testing.T) {
+	qb := NewQueryBuilder(NewRequest(), "idx")
+
+	_, err := qb.ExecuteSubsearch(qb.Subsearch())
+	assert.ErrorIs(t, err, errNoSubsearch)
+}
@@ -0,0 +1,88 @@
+package reveald
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// SearchIDHeader is the header identifying the Elasticsearch
+// tasks of a search, listed in the task management API
+const SearchIDHeader = "X-Opaque-Id"
+
+const searchTaskActions = "indices:data/read/search*"
+
+type searchIDKey struct{}
+
+// ContextWithSearchID returns a copy of the context carrying an
+// id attached to the Elasticsearch tasks of searches executed with
+// it, so they can be found and cancelled using CancelSearch
+func ContextWithSearchID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, searchIDKey{}, id)
+}
+
+// SearchIDFromContext returns the search id carried
+// by the context, if any
+func SearchIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(searchIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// SearchTasks returns the ids of the running Elasticsearch
+// tasks of searches executed with the specified search id
+func (b *ElasticBackend) SearchTasks(ctx context.Context, searchID string) ([]string, error) {
+	res, err := b.client.TasksList().
+		Actions(searchTaskActions).
+		Detailed(true).
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("elasticsearch request failed: %w", err)
+	}
+
+	var ids []string
+	for nodeID, node := range res.Nodes {
+		for _, task := range node.Tasks {
+			// child tasks on other nodes inherit the header,
+			// but are cancelled along with their parent
+			if task.Headers[SearchIDHeader] != searchID || task.ParentTaskId != "" {
+				continue
+			}
+
+			ids = append(ids, fmt.Sprintf("%s:%d", nodeID, task.Id))
+		}
+	}
+
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// CancelTask cancels an Elasticsearch task, such as a slow
+// search, identified as node:id
+func (b *ElasticBackend) CancelTask(ctx context.Context, taskID string) error {
+	_, err := b.client.TasksCancel().TaskId(taskID).Do(ctx)
+	if err != nil {
+		return fmt.Errorf("elasticsearch request failed: %w", err)
+	}
+
+	return nil
+}
+
+// CancelSearch cancels the running Elasticsearch tasks of
+// searches executed with the specified search id. Searches whose
+// context is cancelled are aborted on the cluster as well, as
+// Elasticsearch cancels searches when their connection is closed,
+// so this is meant for cancelling searches from elsewhere
+func (b *ElasticBackend) CancelSearch(ctx context.Context, searchID string) error {
+	ids, err := b.SearchTasks(ctx, searchID)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if err := b.CancelTask(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
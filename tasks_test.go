@@ -0,0 +1,86 @@
+package reveald
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestBackend(t *testing.T, handler http.Handler) *ElasticBackend {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	b, err := NewElasticBackend([]string{srv.URL}, WithSniff(false), WithHealthCheck(false))
+	assert.NoError(t, err)
+	return b
+}
+
+func Test_ElasticBackend_Execute_Cancellation(t *testing.T) {
+	searchID := make(chan string, 1)
+	aborted := make(chan struct{})
+	b := newTestBackend(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the server only notices disconnects once the body is read
+		_, _ = io.Copy(io.Discard, r.Body)
+		searchID <- r.Header.Get(SearchIDHeader)
+		select {
+		case <-r.Context().Done():
+			close(aborted)
+		case <-time.After(5 * time.Second):
+		}
+	}))
+
+	ctx, cancel := context.WithCancel(ContextWithSearchID(context.Background(), "search-1"))
+	go func() {
+		assert.Equal(t, "search-1", <-searchID)
+		cancel()
+	}()
+
+	_, err := b.Execute(ctx, NewQueryBuilder(NewRequest(), "products"))
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	select {
+	case <-aborted:
+	case <-time.After(time.Second):
+		t.Fatal("search request wasn't aborted")
+	}
+}
+
+func Test_ElasticBackend_CancelSearch(t *testing.T) {
+	var mu sync.Mutex
+	var cancelled []string
+	b := newTestBackend(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			assert.Equal(t, "/_tasks", r.URL.Path)
+			_, _ = w.Write([]byte(`{"nodes": {
+				"n1": {"tasks": {
+					"n1:7": {"id": 7, "action": "indices:data/read/search", "headers": {"X-Opaque-Id": "search-1"}},
+					"n1:8": {"id": 8, "action": "indices:data/read/search", "headers": {"X-Opaque-Id": "search-2"}}
+				}},
+				"n2": {"tasks": {
+					"n2:3": {"id": 3, "action": "indices:data/read/search[phase/query]", "parent_task_id": "n1:7", "headers": {"X-Opaque-Id": "search-1"}}
+				}}
+			}}`))
+			return
+		}
+
+		mu.Lock()
+		cancelled = append(cancelled, r.URL.Path)
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"nodes": {}}`))
+	}))
+
+	ids, err := b.SearchTasks(context.Background(), "search-1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"n1:7"}, ids)
+
+	assert.NoError(t, b.CancelSearch(context.Background(), "search-1"))
+	assert.Equal(t, []string{"/_tasks/n1:7/_cancel"}, cancelled)
+}
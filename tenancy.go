@@ -0,0 +1,92 @@
+package reveald
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// ErrNoTenant is returned by tenant resolvers when
+// a request can't be attributed to a tenant
+var ErrNoTenant = errors.New("request has no tenant")
+
+// ErrTenantPointInTime is returned for searches of a point in time
+// set by a feature when the tenant restricts the searched indices,
+// since the point in time would bypass them. Use a point in time
+// resolver to open it on the tenant indices instead
+var ErrTenantPointInTime = errors.New("point in time bypasses the tenant indices")
+
+// Tenant is the scope of the searches of a tenant, where
+// Indices, when set, replace the indices of the endpoint, and
+// Filter is required to match every document searched
+type Tenant struct {
+	ID      string
+	Indices []string
+	Filter  elastic.Query
+}
+
+// TenantResolver resolves the tenant of a request, e.g.
+// from an authenticated caller carried by the context
+type TenantResolver interface {
+	ResolveTenant(ctx context.Context, request *Request) (*Tenant, error)
+}
+
+// TenantResolverFunc adapts a function to a TenantResolver
+type TenantResolverFunc func(ctx context.Context, request *Request) (*Tenant, error)
+
+// ResolveTenant returns the tenant returned by the function
+func (fn TenantResolverFunc) ResolveTenant(ctx context.Context, request *Request) (*Tenant, error) {
+	return fn(ctx, request)
+}
+
+// WithTenantResolver scopes every search of an endpoint to the
+// tenant of the request. The tenant's indices and filter are
+// enforced after the features have run, just before the search
+// is executed or the builder is prepared, so that neither
+// features nor callers can widen the search. Requests without
+// a tenant fail, rather than searching across tenants
+func WithTenantResolver(resolver TenantResolver) EndpointOption {
+	return func(e *Endpoint) {
+		e.tenants = resolver
+	}
+}
+
+// TermTenantFilter returns a tenant filtering documents
+// on a property holding the tenant id, such as tenant_id
+func TermTenantFilter(property, id string, indices ...string) *Tenant {
+	return &Tenant{
+		ID:      id,
+		Indices: indices,
+		Filter:  elastic.NewTermQuery(property, id),
+	}
+}
+
+// enforceTenant scopes a query builder to the
+// tenant of its request, if tenants are resolved
+func (e *Endpoint) enforceTenant(ctx context.Context, qb *QueryBuilder) error {
+	if e.tenants == nil {
+		return nil
+	}
+
+	tenant, err := e.tenants.ResolveTenant(ctx, qb.Request())
+	if err != nil {
+		return fmt.Errorf("failed resolving tenant: %w", err)
+	}
+	if tenant == nil || (len(tenant.Indices) == 0 && tenant.Filter == nil) {
+		return fmt.Errorf("failed resolving tenant: %w", ErrNoTenant)
+	}
+
+	if len(tenant.Indices) > 0 {
+		if qb.PointInTime() != nil {
+			return fmt.Errorf("failed enforcing tenant: %w", ErrTenantPointInTime)
+		}
+		qb.SetIndices(tenant.Indices...)
+	}
+	if tenant.Filter != nil {
+		qb.With(tenant.Filter)
+	}
+
+	return nil
+}
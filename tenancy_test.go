@@ -0,0 +1,113 @@
+package reveald
+
+import (
+	"context"
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+type tenantKey struct{}
+
+// indicesFeature retargets the search, like a feature
+// accidentally querying other indices would
+type indicesFeature struct{ indices []string }
+
+func (f indicesFeature) Process(qb *QueryBuilder, next FeatureFunc) (*Result, error) {
+	qb.SetIndices(f.indices...)
+	return next(qb)
+}
+
+func tenantFromContext(ctx context.Context, _ *Request) (*Tenant, error) {
+	id, ok := ctx.Value(tenantKey{}).(string)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+
+	return TermTenantFilter("tenant_id", id, "products-"+id), nil
+}
+
+func Test_Endpoint_TenantResolver(t *testing.T) {
+	b := &fakeBackend{}
+	e := NewEndpoint(b, WithIndices("products-*"), WithTenantResolver(TenantResolverFunc(tenantFromContext)))
+	assert.NoError(t, e.Register(indicesFeature{[]string{"products-*"}}, termFeature{}))
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+	_, err := e.Execute(ctx, NewRequest())
+	assert.NoError(t, err)
+
+	assert.Len(t, b.builders, 1)
+	qb := b.builders[0]
+	assert.Equal(t, []string{"products-acme"}, qb.Indices())
+	assert.Equal(t, elastic.NewBoolQuery().Must(
+		elastic.NewTermQuery("brand", "acme"),
+		elastic.NewTermQuery("tenant_id", "acme")), qb.RawQuery())
+
+	prepared, err := e.PrepareBuilder(ctx, NewRequest())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"products-acme"}, prepared.Indices())
+}
+
+func Test_Endpoint_TenantResolver_Without_Tenant(t *testing.T) {
+	b := &fakeBackend{}
+	e := NewEndpoint(b, WithIndices("products-*"), WithTenantResolver(TenantResolverFunc(tenantFromContext)))
+
+	_, err := e.Execute(context.Background(), NewRequest())
+	assert.ErrorIs(t, err, ErrNoTenant)
+	assert.Empty(t, b.builders)
+
+	_, err = e.PrepareBuilder(context.Background(), NewRequest())
+	assert.ErrorIs(t, err, ErrNoTenant)
+
+	_, err = e.Count(context.Background(), NewRequest())
+	assert.ErrorIs(t, err, ErrNoTenant)
+}
+
+// pointInTimeFeature searches a point in time, either set
+// directly or resolved for the indices finally searched
+type pointInTimeFeature struct {
+	deferred bool
+	indices  *[]string
+}
+
+func (f pointInTimeFeature) Process(qb *QueryBuilder, next FeatureFunc) (*Result, error) {
+	if !f.deferred {
+		qb.WithPointInTime("pit", "1m")
+		return next(qb)
+	}
+
+	qb.WithPointInTimeResolver("1m", func(_ context.Context, indices []string) (string, error) {
+		*f.indices = indices
+		return "pit", nil
+	})
+	return next(qb)
+}
+
+func Test_Endpoint_TenantResolver_PointInTime(t *testing.T) {
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+
+	t.Run("set by feature", func(t *testing.T) {
+		b := &fakeBackend{}
+		e := NewEndpoint(b, WithIndices("products-*"), WithTenantResolver(TenantResolverFunc(tenantFromContext)))
+		assert.NoError(t, e.Register(pointInTimeFeature{}))
+
+		_, err := e.Execute(ctx, NewRequest())
+		assert.ErrorIs(t, err, ErrTenantPointInTime)
+		assert.Empty(t, b.builders)
+	})
+
+	t.Run("resolved on tenant indices", func(t *testing.T) {
+		var resolved []string
+		b := &fakeBackend{}
+		e := NewEndpoint(b, WithIndices("products-*"), WithTenantResolver(TenantResolverFunc(tenantFromContext)))
+		assert.NoError(t, e.Register(pointInTimeFeature{deferred: true, indices: &resolved}))
+
+		_, err := e.Execute(ctx, NewRequest())
+		assert.NoError(t, err)
+
+		assert.Equal(t, []string{"products-acme"}, resolved)
+		assert.Len(t, b.builders, 1)
+		assert.Equal(t, "pit", b.builders[0].PointInTime().Id)
+	})
+}